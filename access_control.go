@@ -0,0 +1,149 @@
+package scim
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// AccessControl restricts the clients that can reach the server, e.g. to the egress IP ranges of an identity provider
+// or to clients that present a known certificate. Requests of other clients are rejected with a "403 Forbidden"
+// response before they are handled.
+type AccessControl struct {
+	// AllowedNetworks are the networks from which requests are accepted, see ParseNetworks. If empty, requests from
+	// all networks are accepted. The client address is the remote address of the connection or, if the server trusts
+	// forwarded headers (see Server.TrustForwardedHeaders), the rightmost address in the "X-Forwarded-For" header that
+	// is not one of the TrustedProxies. The addresses on the left are written by the client, so they are never used.
+	AllowedNetworks []*net.IPNet
+	// TrustedProxies are the networks of the reverse proxies in front of the server, see ParseNetworks. The remote
+	// address of the connection is always considered to be a proxy if the server trusts forwarded headers, so it only
+	// needs to list further proxies, e.g. those between a load balancer and an ingress controller.
+	TrustedProxies []*net.IPNet
+
+	// RequireClientCertificate only accepts requests over TLS connections on which the client presented a certificate
+	// that is verified by the TLS configuration of the http.Server, i.e. with a ClientAuth of
+	// tls.VerifyClientCertIfGiven or tls.RequireAndVerifyClientCert and the certificate authorities of the clients in
+	// ClientCAs. It is implied by AllowedSubjects and VerifyClientCertificate.
+	RequireClientCertificate bool
+	// AllowedSubjects are the common names or DNS names of which at least one must match the subject of the client
+	// certificate, case-insensitively. If empty, any verified certificate is accepted.
+	AllowedSubjects []string
+	// VerifyClientCertificate, if set, is called with the verified certificate chains of the client for additional
+	// checks, e.g. of the authority information access or a revocation list. A returned error rejects the request.
+	VerifyClientCertificate func(chains [][]*x509.Certificate) error
+}
+
+// ParseNetworks parses given IP addresses and networks in CIDR notation, e.g. "203.0.113.7" or "20.190.128.0/18".
+func ParseNetworks(networks ...string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", network)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			parsed = append(parsed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+// allow returns an error that describes why given request is rejected, if it is.
+func (c AccessControl) allow(r *http.Request, trustForwardedHeaders bool) *scimError {
+	if len(c.AllowedNetworks) != 0 && !c.allowNetwork(c.clientIP(r, trustForwardedHeaders)) {
+		return &scimError{
+			detail: "Requests from this network are not allowed.",
+			status: http.StatusForbidden,
+		}
+	}
+
+	if !c.RequireClientCertificate && len(c.AllowedSubjects) == 0 && c.VerifyClientCertificate == nil {
+		return nil
+	}
+	certificateErr := &scimError{
+		detail: "A valid client certificate is required.",
+		status: http.StatusForbidden,
+	}
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return certificateErr
+	}
+	if len(c.AllowedSubjects) != 0 && !c.allowSubject(r.TLS.VerifiedChains[0][0]) {
+		return certificateErr
+	}
+	if c.VerifyClientCertificate != nil && c.VerifyClientCertificate(r.TLS.VerifiedChains) != nil {
+		return certificateErr
+	}
+	return nil
+}
+
+func (c AccessControl) allowNetwork(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.AllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c AccessControl) allowSubject(certificate *x509.Certificate) bool {
+	names := append([]string{certificate.Subject.CommonName}, certificate.DNSNames...)
+	for _, subject := range c.AllowedSubjects {
+		for _, name := range names {
+			if name != "" && strings.EqualFold(name, subject) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP address of the client that sent given request. If forwarded headers are trusted, the
+// "X-Forwarded-For" header is read from right to left, skipping the addresses of trusted proxies, since every proxy
+// appends the address it received the request from.
+func (c AccessControl) clientIP(r *http.Request, trustForwardedHeaders bool) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if !trustForwardedHeaders {
+		return ip
+	}
+
+	var forwarded []string
+	for _, header := range r.Header[http.CanonicalHeaderKey("X-Forwarded-For")] {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip = net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil || !c.trustProxy(ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// trustProxy reports whether given IP address is the address of a trusted proxy.
+func (c AccessControl) trustProxy(ip net.IP) bool {
+	for _, network := range c.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
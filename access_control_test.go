@@ -0,0 +1,107 @@
+package scim
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("203.0.113.7", "20.190.128.0/18", "2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	control := AccessControl{AllowedNetworks: networks}
+	for ip, expected := range map[string]bool{
+		"203.0.113.7":  true,
+		"203.0.113.8":  false,
+		"20.190.130.1": true,
+		"2001:db8::1":  true,
+		"2001:db9::1":  false,
+		"192.168.0.1":  false,
+		"not-an-ip":    false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+		req.RemoteAddr = remoteAddr(ip)
+		if allowed := control.allow(req, false) == nil; allowed != expected {
+			t.Errorf("%s: expected allowed to be %v", ip, expected)
+		}
+	}
+
+	if _, err := ParseNetworks("20.190.128.0/33"); err == nil {
+		t.Error("expected an error for an invalid network")
+	}
+}
+
+// remoteAddr returns the remote address of a connection from given IP address.
+func remoteAddr(ip string) string {
+	return "[" + ip + "]:1234"
+}
+
+func TestServerAccessControl(t *testing.T) {
+	networks, _ := ParseNetworks("203.0.113.0/24")
+	proxies, _ := ParseNetworks("10.0.0.0/8")
+	server := newTestServer()
+	server.AccessControl = &AccessControl{AllowedNetworks: networks, TrustedProxies: proxies}
+
+	for _, test := range []struct {
+		remoteAddr string
+		forwarded  string
+		trust      bool
+		expected   int
+	}{
+		{"203.0.113.7:1234", "", false, http.StatusOK},
+		{"192.0.2.1:1234", "", false, http.StatusForbidden},
+		{"192.0.2.1:1234", "203.0.113.7", false, http.StatusForbidden},
+		{"192.0.2.1:1234", "192.0.2.1, 203.0.113.7", true, http.StatusOK},
+		// The client can prepend any address, only the rightmost addresses are written by proxies.
+		{"192.0.2.1:1234", "203.0.113.7, 192.0.2.1", true, http.StatusForbidden},
+		{"10.0.0.1:1234", "203.0.113.7, 10.0.0.2", true, http.StatusOK},
+		{"10.0.0.1:1234", "203.0.113.7, 192.0.2.1, 10.0.0.2", true, http.StatusForbidden},
+		{"10.0.0.1:1234", "10.0.0.3, 10.0.0.2", true, http.StatusForbidden},
+	} {
+		server.TrustForwardedHeaders = test.trust
+		req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+		req.RemoteAddr = test.remoteAddr
+		if test.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s (%s): expected status %d, got %d", test.remoteAddr, test.forwarded, test.expected, rr.Code)
+		}
+	}
+}
+
+func TestServerAccessControlClientCertificate(t *testing.T) {
+	server := newTestServer()
+	server.AccessControl = &AccessControl{AllowedSubjects: []string{"idp.example.com"}}
+
+	certificate := func(commonName string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+			Subject: pkix.Name{CommonName: commonName},
+		}}}}
+	}
+	for _, test := range []struct {
+		name     string
+		tls      *tls.ConnectionState
+		expected int
+	}{
+		{"allowed", certificate("IdP.example.com"), http.StatusOK},
+		{"other subject", certificate("evil.example.com"), http.StatusForbidden},
+		{"unverified", &tls.ConnectionState{}, http.StatusForbidden},
+		{"plain", nil, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+		req.TLS = test.tls
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.expected, rr.Code)
+		}
+	}
+}
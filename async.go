@@ -0,0 +1,20 @@
+package scim
+
+import (
+	"net/http"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// acceptedHandler writes a "202 Accepted" response if given error of a callback method signals that the operation was
+// queued for asynchronous processing, e.g. errors.PostErrorAccepted. It reports whether it did so.
+func acceptedHandler(w http.ResponseWriter, err errors.ScimError) bool {
+	if err.Status != http.StatusAccepted {
+		return false
+	}
+	if err.Location != "" {
+		w.Header().Set("Location", err.Location)
+	}
+	w.WriteHeader(http.StatusAccepted)
+	return true
+}
@@ -0,0 +1,99 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// queuedResourceHandler queues all changes for asynchronous processing.
+type queuedResourceHandler struct {
+	testResourceHandler
+}
+
+func (h queuedResourceHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	return Resource{}, errors.PostErrorAccepted("/Tasks/1")
+}
+
+func (h queuedResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	return Resource{}, errors.PutErrorAccepted("/Tasks/2")
+}
+
+func (h queuedResourceHandler) Patch(r *http.Request, id string, req PatchRequest) (Resource, errors.PatchError) {
+	return Resource{}, errors.PatchErrorAccepted("/Tasks/3")
+}
+
+func (h queuedResourceHandler) Delete(r *http.Request, id string) errors.DeleteError {
+	return errors.DeleteErrorAccepted("/Tasks/4")
+}
+
+func newQueuedTestServer() Server {
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = queuedResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+	}
+	return server
+}
+
+func TestServerAccepted(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		location string
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test"}`, "/Tasks/1"},
+		{http.MethodPut, "/Users/0001", `{"userName": "test"}`, "/Tasks/2"},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "userName", "value": "test"}]
+		}`, "/Tasks/3"},
+		{http.MethodDelete, "/Users/0001", "", "/Tasks/4"},
+	} {
+		rr := httptest.NewRecorder()
+		newQueuedTestServer().ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != http.StatusAccepted {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", test.method, rr.Code, http.StatusAccepted)
+		}
+		if location := rr.Header().Get("Location"); location != test.location {
+			t.Errorf("%s: unexpected location: got %q want %q", test.method, location, test.location)
+		}
+	}
+}
+
+func TestServerBulkHandlerAccepted(t *testing.T) {
+	server := newQueuedTestServer()
+	server.Config.SupportBulk = true
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{"method": "POST", "bulkId": "a", "path": "/Users", "data": {"userName": "test"}},
+			{"method": "DELETE", "path": "/Users/bulkId:a"}
+		]
+	}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response bulkResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Operations) != 2 {
+		t.Fatalf("unexpected number of operations: %d", len(response.Operations))
+	}
+	if op := response.Operations[0]; op.Status != "202" || op.Location != "/Tasks/1" {
+		t.Errorf("unexpected result of the queued operation: %+v", op)
+	}
+	// The queued resource does not exist yet, so it can not be referenced.
+	if op := response.Operations[1]; op.Status != "409" {
+		t.Errorf("unexpected result of the referencing operation: %+v", op)
+	}
+}
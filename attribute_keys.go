@@ -0,0 +1,135 @@
+package scim
+
+import (
+	"strings"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/schema"
+)
+
+// canonicalKeys returns a copy of given attributes whose keys are the names of the attributes and the URIs of the
+// schema extensions as defined by the resource type, regardless of the casing that is used by the client or the
+// resource handler. Keys of unknown attributes are kept as is.
+func (t ResourceType) canonicalKeys(attributes ResourceAttributes) ResourceAttributes {
+	if attributes == nil {
+		return nil
+	}
+	canonical := ResourceAttributes(t.Schema.CanonicalKeys(attributes))
+	for _, extension := range t.SchemaExtensions {
+		key := keyFold(canonical, extension.Schema.ID)
+		values, ok := canonical[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delete(canonical, key)
+		canonical[extension.Schema.ID] = extension.Schema.CanonicalKeys(values)
+	}
+	return canonical
+}
+
+// canonicalOperation returns given validated PATCH operation with the attribute names in its path and the keys of its
+// value replaced by the names as defined by the resource type.
+func (t ResourceType) canonicalOperation(op PatchOperation) PatchOperation {
+	if op.Path == "" {
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return op
+		}
+		canonical := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			k, v = t.canonicalEntry(k, v)
+			canonical[k] = v
+		}
+		op.Value = canonical
+		return op
+	}
+
+	path, err := op.ParsePath()
+	if err != nil {
+		return op
+	}
+	attribute, ok := t.SchemaSet().attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName})
+	if !ok {
+		return op
+	}
+	if canonical := t.canonicalPath(path); canonical.String() != path.String() {
+		op.Path = canonical.String()
+	}
+	if path.SubAttribute == "" {
+		op.Value = attribute.CanonicalKeys(op.Value)
+	}
+	return op
+}
+
+// canonicalEntry returns the canonical key and value of an entry of the value of a PATCH operation without path, whose
+// key is the path of an attribute.
+func (t ResourceType) canonicalEntry(k string, v interface{}) (string, interface{}) {
+	path, err := ParseAttributePath(k)
+	if err != nil {
+		return k, v
+	}
+	attribute, ok := t.SchemaSet().attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName})
+	if !ok {
+		return k, v
+	}
+	if path.SubAttribute == "" {
+		v = attribute.CanonicalKeys(v)
+	}
+	return t.canonicalPath(path).String(), v
+}
+
+// canonicalPath returns given attribute path with the URI, attribute names and the attribute names within its value
+// filter as defined by the resource type. Unknown names are kept as is.
+func (t ResourceType) canonicalPath(path AttributePath) AttributePath {
+	schemaSet := t.SchemaSet()
+	attribute, ok := schemaSet.attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName})
+	if !ok {
+		return path
+	}
+	path.AttributeName = attribute.Name()
+	if path.URI != "" {
+		for _, s := range append([]schema.Schema{t.Schema}, t.extensionSchemas()...) {
+			if strings.EqualFold(s.ID, path.URI) {
+				path.URI = s.ID
+			}
+		}
+	}
+	if path.SubAttribute != "" {
+		if sub, ok := schemaSet.attribute(path); ok {
+			path.SubAttribute = sub.Name()
+		}
+	}
+	if path.ValueFilter != nil {
+		path.ValueFilter = canonicalValueFilter(attribute, path.ValueFilter)
+	}
+	return path
+}
+
+func (t ResourceType) extensionSchemas() []schema.Schema {
+	schemas := make([]schema.Schema, 0, len(t.SchemaExtensions))
+	for _, extension := range t.SchemaExtensions {
+		schemas = append(schemas, extension.Schema)
+	}
+	return schemas
+}
+
+// canonicalValueFilter replaces the names of the sub-attributes of given attribute within given value filter by their
+// names as defined by the schema.
+func canonicalValueFilter(attribute schema.CoreAttribute, expression filter.Expression) filter.Expression {
+	switch e := expression.(type) {
+	case filter.AttributeExpression:
+		if sub, ok := attribute.SubAttribute(e.AttributePath.AttributeName); ok && e.AttributePath.URIPrefix == "" {
+			e.AttributePath.AttributeName = sub.Name()
+		}
+		return e
+	case filter.BinaryExpression:
+		e.X = canonicalValueFilter(attribute, e.X)
+		e.Y = canonicalValueFilter(attribute, e.Y)
+		return e
+	case filter.UnaryExpression:
+		e.X = canonicalValueFilter(attribute, e.X)
+		return e
+	default:
+		return expression
+	}
+}
@@ -0,0 +1,143 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// patchRecordingResourceHandler records the PATCH requests it receives.
+type patchRecordingResourceHandler struct {
+	testResourceHandler
+	requests *[]PatchRequest
+}
+
+func (h patchRecordingResourceHandler) Patch(r *http.Request, id string, req PatchRequest) (Resource, errors.PatchError) {
+	*h.requests = append(*h.requests, req)
+	return h.testResourceHandler.Patch(r, id, req)
+}
+
+func TestServerCanonicalKeys(t *testing.T) {
+	server := newTestServer()
+	handler := server.ResourceTypes[1].Handler.(testResourceHandler)
+
+	for _, test := range []struct {
+		method, target string
+		expected       int
+	}{
+		{http.MethodPost, "/EnterpriseUser", http.StatusCreated},
+		{http.MethodPut, "/EnterpriseUser/0001", http.StatusOK},
+	} {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(`{
+			"USERNAME": "bjensen",
+			"name": {"GIVENNAME": "Barbara"},
+			"Emails": [{"VALUE": "bjensen@example.com", "Type": "work"}],
+			"URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER": {"EMPLOYEENUMBER": "701984"}
+		}`))
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Fatalf("%s: expected %d, got %d: %s", test.method, test.expected, rr.Code, rr.Body.String())
+		}
+
+		var resource struct{ ID string }
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		stored := handler.data[resource.ID]
+		if stored["userName"] != "bjensen" {
+			t.Errorf("%s: expected the canonical key userName, got %v", test.method, stored)
+		}
+		if name, _ := stored["Name"].(map[string]interface{}); name["givenName"] != "Barbara" {
+			t.Errorf("%s: expected the canonical key Name.givenName, got %v", test.method, stored["Name"])
+		}
+		if emails, _ := stored["emails"].([]interface{}); len(emails) != 1 || emails[0].(map[string]interface{})["value"] != "bjensen@example.com" {
+			t.Errorf("%s: expected the canonical key emails.value, got %v", test.method, stored["emails"])
+		}
+		extension, _ := stored["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"].(map[string]interface{})
+		if extension["employeeNumber"] != "701984" {
+			t.Errorf("%s: expected the canonical keys of the extension, got %v", test.method, stored)
+		}
+	}
+}
+
+func TestServerPatchCanonicalKeys(t *testing.T) {
+	server := newTestServer()
+	var requests []PatchRequest
+	server.ResourceTypes[1].Handler = patchRecordingResourceHandler{
+		testResourceHandler: server.ResourceTypes[1].Handler.(testResourceHandler),
+		requests:            &requests,
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/EnterpriseUser/0001", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "replace", "path": "DISPLAYNAME", "value": "Babs"},
+			{"op": "replace", "path": "NAME.GIVENNAME", "value": "Barbara"},
+			{"op": "add", "path": "EMAILS", "value": [{"VALUE": "babs@example.com"}]},
+			{"op": "replace", "path": "emails[TYPE eq \"work\"].Value", "value": "bjensen@example.com"},
+			{"op": "replace", "path": "URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER:EMPLOYEENUMBER", "value": "701984"},
+			{"op": "add", "value": {"ACTIVE": true, "name.FAMILYNAME": "Jensen"}}
+		]
+	}`))
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected a single PATCH request, got %d", len(requests))
+	}
+
+	expected := []PatchOperation{
+		{Op: PatchOperationReplace, Path: "displayName", Value: "Babs"},
+		{Op: PatchOperationReplace, Path: "Name.givenName", Value: "Barbara"},
+		{Op: PatchOperationAdd, Path: "emails", Value: []interface{}{map[string]interface{}{"value": "babs@example.com"}}},
+		{Op: PatchOperationReplace, Path: `emails[type eq "work"].value`, Value: "bjensen@example.com"},
+		{Op: PatchOperationReplace, Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", Value: "701984"},
+		{Op: PatchOperationAdd, Value: map[string]interface{}{
+			"active":          true,
+			"Name.familyName": "Jensen",
+		}},
+	}
+	if !reflect.DeepEqual(requests[0].Operations, expected) {
+		t.Errorf("expected operations\n%v\ngot\n%v", expected, requests[0].Operations)
+	}
+}
+
+func TestServerCanonicalResponseKeys(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[1].CanonicalResponseKeys = true
+	handler := server.ResourceTypes[1].Handler.(testResourceHandler)
+	handler.data["0001"] = ResourceAttributes{
+		"username": "bjensen",
+		"name":     map[string]interface{}{"givenname": "Barbara"},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:user": map[string]interface{}{"employeenumber": "701984"},
+		"unknown": "kept",
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/EnterpriseUser/0001", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if resource["userName"] != "bjensen" || resource["unknown"] != "kept" {
+		t.Errorf("expected the canonical key userName, got %v", resource)
+	}
+	if name, _ := resource["Name"].(map[string]interface{}); name["givenName"] != "Barbara" {
+		t.Errorf("expected the canonical key Name.givenName, got %v", resource)
+	}
+	extension, _ := resource["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"].(map[string]interface{})
+	if extension["employeeNumber"] != "701984" {
+		t.Errorf("expected the canonical keys of the extension, got %v", resource)
+	}
+}
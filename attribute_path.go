@@ -0,0 +1,233 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	filter "github.com/di-wu/scim-filter-parser"
+)
+
+// AttributePath is a parsed attribute path (RFC 7644, section 3.10), which refers to an attribute, e.g. "userName", a
+// sub-attribute, e.g. "name.givenName", or (a sub-attribute of) the values of a multi-valued attribute that match a
+// value filter, e.g. `members[value eq "2819c223"]` or `emails[type eq "work"].value`. Attributes of a specific schema
+// are prefixed with its URI, e.g. "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value".
+type AttributePath struct {
+	// URI is the URI of the schema of the attribute, e.g. of a schema extension. It is empty if the path is not prefixed
+	// with a URI.
+	URI string
+	// AttributeName is the name of the attribute, e.g. "emails".
+	AttributeName string
+	// ValueFilter is the filter that selects the values of the multi-valued attribute, e.g. `type eq "work"`. It is nil
+	// if the path has no value filter.
+	ValueFilter filter.Expression
+	// SubAttribute is the name of the sub-attribute, e.g. "value". It is empty if the path refers to the attribute
+	// itself.
+	SubAttribute string
+}
+
+// ParseAttributePath parses given attribute path. The URI prefix is separated from the attribute name by the last colon
+// outside of the value filter, so a path that only consists of a URI can not be parsed.
+func ParseAttributePath(path string) (AttributePath, error) {
+	attributePath, valueFilter, subAttribute := path, "", ""
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		end := closingBracket(path, i)
+		if end < 0 {
+			return AttributePath{}, fmt.Errorf("invalid path %q: unterminated value filter", path)
+		}
+		attributePath, valueFilter = path[:i], path[i+1:end]
+		if rest := path[end+1:]; rest != "" {
+			if rest[0] != '.' {
+				return AttributePath{}, fmt.Errorf("invalid path %q: unexpected %q after value filter", path, rest)
+			}
+			subAttribute = rest[1:]
+			if !validAttributeName(subAttribute) {
+				return AttributePath{}, fmt.Errorf("invalid path %q: invalid sub-attribute name %q", path, subAttribute)
+			}
+		}
+	}
+
+	var p AttributePath
+	name := attributePath
+	if i := strings.LastIndexByte(attributePath, ':'); i >= 0 {
+		p.URI, name = attributePath[:i], attributePath[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		if valueFilter != "" {
+			return AttributePath{}, fmt.Errorf("invalid path %q: a value filter can not follow a sub-attribute", path)
+		}
+		name, subAttribute = name[:i], name[i+1:]
+		if !validAttributeName(subAttribute) {
+			return AttributePath{}, fmt.Errorf("invalid path %q: invalid sub-attribute name %q", path, subAttribute)
+		}
+	}
+	if !validAttributeName(name) {
+		return AttributePath{}, fmt.Errorf("invalid path %q: invalid attribute name %q", path, name)
+	}
+	p.AttributeName, p.SubAttribute = name, subAttribute
+
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		if strings.TrimSpace(valueFilter) == "" {
+			return AttributePath{}, fmt.Errorf("invalid path %q: empty value filter", path)
+		}
+		expression, err := filter.NewParser(strings.NewReader(valueFilter)).Parse()
+		if err != nil {
+			return AttributePath{}, fmt.Errorf("invalid path %q: %v", path, err)
+		}
+		p.ValueFilter = expression
+	}
+	return p, nil
+}
+
+// String returns the canonical form of the path, which ParseAttributePath parses to an equal path. Operators of the
+// value filter are written in lower case, separated by single spaces.
+func (p AttributePath) String() string {
+	path := p.AttributeName
+	if p.URI != "" {
+		path = p.URI + ":" + path
+	}
+	if p.ValueFilter != nil {
+		path += "[" + formatFilter(p.ValueFilter) + "]"
+	}
+	if p.SubAttribute != "" {
+		path += "." + p.SubAttribute
+	}
+	return path
+}
+
+// Equal reports whether given path refers to the same (sub-)attribute with the same value filter. URIs and attribute
+// names are compared case-insensitively.
+func (p AttributePath) Equal(other AttributePath) bool {
+	if !strings.EqualFold(p.URI, other.URI) ||
+		!strings.EqualFold(p.AttributeName, other.AttributeName) ||
+		!strings.EqualFold(p.SubAttribute, other.SubAttribute) ||
+		(p.ValueFilter == nil) != (other.ValueFilter == nil) {
+		return false
+	}
+	return p.ValueFilter == nil || formatFilter(p.ValueFilter) == formatFilter(other.ValueFilter)
+}
+
+// attributePath returns the path of the (sub-)attribute without the value filter, e.g. "emails.value" for
+// `emails[type eq "work"].value`, prefixed with the URI if any.
+func (p AttributePath) attributePath() string {
+	p.ValueFilter = nil
+	return p.String()
+}
+
+// formatFilter returns the canonical form of given filter expression.
+func formatFilter(expression filter.Expression) string {
+	switch e := expression.(type) {
+	case filter.AttributeExpression:
+		path := e.AttributePath.AttributeName
+		if e.AttributePath.URIPrefix != "" {
+			path = e.AttributePath.URIPrefix + ":" + path
+		}
+		if e.AttributePath.SubAttribute != "" {
+			path += "." + e.AttributePath.SubAttribute
+		}
+		if e.CompareOperator == filter.PR {
+			return path + " pr"
+		}
+		return path + " " + formatOperator(e.CompareOperator) + " " + formatCompareValue(e.CompareValue)
+	case filter.BinaryExpression:
+		return formatOperand(e.X, e.CompareOperator) + " " + formatOperator(e.CompareOperator) + " " + formatOperand(e.Y, e.CompareOperator)
+	case filter.UnaryExpression:
+		return "not (" + formatFilter(e.X) + ")"
+	case filter.ValuePath:
+		return e.AttributeName + "[" + formatFilter(e.ValueExpression) + "]"
+	default:
+		return fmt.Sprint(expression)
+	}
+}
+
+// formatOperand returns the canonical form of an operand of a logical expression with given operator. Logical
+// expressions with another operator are grouped.
+func formatOperand(expression filter.Expression, operator filter.Token) string {
+	if e, ok := expression.(filter.BinaryExpression); ok && e.CompareOperator != operator {
+		return "(" + formatFilter(e) + ")"
+	}
+	return formatFilter(expression)
+}
+
+func formatOperator(operator filter.Token) string {
+	switch operator {
+	case filter.EQ:
+		return "eq"
+	case filter.NE:
+		return "ne"
+	case filter.CO:
+		return "co"
+	case filter.SW:
+		return "sw"
+	case filter.EW:
+		return "ew"
+	case filter.GT:
+		return "gt"
+	case filter.LT:
+		return "lt"
+	case filter.GE:
+		return "ge"
+	case filter.LE:
+		return "le"
+	case filter.AND:
+		return "and"
+	case filter.OR:
+		return "or"
+	case filter.PR:
+		return "pr"
+	default:
+		return strings.ToLower(fmt.Sprint(operator))
+	}
+}
+
+// formatCompareValue returns the JSON representation of a comparison value. Booleans, null and numbers are written as
+// is, other values are quoted.
+func formatCompareValue(value string) string {
+	switch value {
+	case "true", "false", "null":
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+// closingBracket returns the index of the bracket that closes the value filter that is opened at given index, skipping
+// brackets within quoted strings. It returns -1 if the value filter is not closed.
+func closingBracket(path string, open int) int {
+	quoted := false
+	for i := open + 1; i < len(path); i++ {
+		switch c := path[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// validAttributeName reports whether given name is a valid attribute name (RFC 7643, section 2.1), i.e. a letter
+// followed by letters, digits, hyphens and underscores. The "$ref" sub-attribute of references is allowed as well.
+func validAttributeName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if strings.EqualFold(name, "$ref") {
+		return true
+	}
+	for i, c := range name {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && ('0' <= c && c <= '9' || c == '-' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
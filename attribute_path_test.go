@@ -0,0 +1,74 @@
+package scim
+
+import (
+	"testing"
+)
+
+func TestAttributePathString(t *testing.T) {
+	for _, test := range []struct {
+		path, expected string
+	}{
+		{"userName", "userName"},
+		{"name.givenName", "name.givenName"},
+		{
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value",
+		},
+		{`members[value eq "2819c223"]`, `members[value eq "2819c223"]`},
+		{`emails[type EQ "work"].value`, `emails[type eq "work"].value`},
+		{`emails[type eq "work" and primary eq true]`, `emails[type eq "work" and primary eq true]`},
+		{`emails[type eq "work" or (primary eq true and value pr)]`, `emails[type eq "work" or (primary eq true and value pr)]`},
+		{`emails[value eq "a]b"]`, `emails[value eq "a]b"]`},
+	} {
+		p, err := ParseAttributePath(test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if s := p.String(); s != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.path, test.expected, s)
+		}
+
+		parsed, err := ParseAttributePath(p.String())
+		if err != nil {
+			t.Errorf("%s: %v", p.String(), err)
+			continue
+		}
+		if !parsed.Equal(p) {
+			t.Errorf("%s: expected the parsed canonical form to equal the path, got %#v", test.path, parsed)
+		}
+	}
+}
+
+func TestAttributePathEqual(t *testing.T) {
+	for _, test := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{"userName", "USERNAME", true},
+		{"name.givenName", "Name.GivenName", true},
+		{"name.givenName", "name.familyName", false},
+		{"name", "name.givenName", false},
+		{
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:user:Manager",
+			true,
+		},
+		{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager", "manager", false},
+		{`emails[type eq "work"]`, `emails[type  eq  "work"]`, true},
+		{`emails[type eq "work"]`, `emails[type eq "home"]`, false},
+		{`emails[type eq "work"]`, "emails", false},
+	} {
+		a, err := ParseAttributePath(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseAttributePath(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equal := a.Equal(b); equal != test.equal {
+			t.Errorf("%s, %s: expected %v, got %v", test.a, test.b, test.equal, equal)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package scim
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// RedactedValue replaces the values of sensitive attributes in audit events.
+const RedactedValue = "[REDACTED]"
+
+// AuditOperation is the kind of change that was made to a resource.
+type AuditOperation string
+
+const (
+	// AuditOperationCreate indicates that a resource was created.
+	AuditOperationCreate AuditOperation = "create"
+	// AuditOperationReplace indicates that a resource was replaced.
+	AuditOperationReplace AuditOperation = "replace"
+	// AuditOperationPatch indicates that a resource was patched.
+	AuditOperationPatch AuditOperation = "patch"
+	// AuditOperationDelete indicates that a resource was deleted.
+	AuditOperationDelete AuditOperation = "delete"
+)
+
+// AuditEvent describes a successful change that was made to a resource.
+type AuditEvent struct {
+	// Operation is the kind of change that was made.
+	Operation AuditOperation
+	// ResourceType is the name of the resource type of the changed resource.
+	ResourceType string
+	// ID is the identifier of the changed resource.
+	ID string
+	// Time is the time of the change, according to the clock of the server.
+	Time time.Time
+	// Changes are the attribute values that were changed, sorted by their path. Values of sensitive attributes, i.e.
+	// attributes that are write-only or never returned, are replaced by RedactedValue.
+	Changes []AttributeChange
+}
+
+// Auditor receives an audit event for every resource that is changed through the server. For replace and patch
+// operations, the server retrieves the resource before forwarding the request to the callback method to be able to
+// compute the changes.
+type Auditor interface {
+	Audit(r *http.Request, event AuditEvent)
+}
+
+// AttributeChange represents a change of the value of a single attribute.
+type AttributeChange struct {
+	// Path is the path of the changed attribute, sub-attributes of complex attributes are separated by a dot, e.g.
+	// "name.givenName". Multi-valued attributes are compared as a whole.
+	Path string
+	// Before is the value before the change, nil if the attribute was added.
+	Before interface{}
+	// After is the value after the change, nil if the attribute was removed.
+	After interface{}
+}
+
+// DiffResources returns the attributes that differ between given resource attributes. Attribute names are compared
+// case-insensitively and attributes with a nil value are considered to be absent. Values are compared deeply, use
+// SchemaSet.DiffResources to compare them according to the schemas of a resource type.
+func DiffResources(before, after ResourceAttributes) []AttributeChange {
+	changes := diffAttributes("", before, after)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffAttributes(prefix string, before, after map[string]interface{}) []AttributeChange {
+	changes := make([]AttributeChange, 0)
+
+	afterKeys := make(map[string]string, len(after))
+	for k := range after {
+		afterKeys[strings.ToLower(k)] = k
+	}
+
+	for k, b := range before {
+		var a interface{}
+		if key, ok := afterKeys[strings.ToLower(k)]; ok {
+			a = after[key]
+			delete(afterKeys, strings.ToLower(k))
+		}
+		changes = append(changes, diffValues(prefix+k, b, a)...)
+	}
+	for _, k := range afterKeys {
+		changes = append(changes, diffValues(prefix+k, nil, after[k])...)
+	}
+	return changes
+}
+
+func diffValues(path string, before, after interface{}) []AttributeChange {
+	if before == nil && after == nil {
+		return nil
+	}
+
+	b, bIsMap := toAttributeMap(before)
+	a, aIsMap := toAttributeMap(after)
+	if bIsMap && aIsMap {
+		return diffAttributes(path+".", b, a)
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return []AttributeChange{{
+		Path:   path,
+		Before: before,
+		After:  after,
+	}}
+}
+
+func toAttributeMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case ResourceAttributes:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// redactChanges replaces the values of changes to sensitive attributes of given resource type, including sensitive
+// sub-attributes, attributes of schema extensions and the sensitive sub-attributes within changed complex values.
+func (t ResourceType) redactChanges(changes []AttributeChange) []AttributeChange {
+	for i, change := range changes {
+		changes[i].Before = t.redactChange(change.Path, change.Before)
+		changes[i].After = t.redactChange(change.Path, change.After)
+	}
+	return changes
+}
+
+// redactChange returns given value of the change with given path, as returned by DiffResources, of which the values of
+// sensitive (sub-)attributes are replaced by RedactedValue.
+func (t ResourceType) redactChange(path string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	s := t.Schema
+	for _, extension := range t.SchemaExtensions {
+		id := extension.Schema.ID
+		if strings.EqualFold(path, id) {
+			// All attributes of the extension were added or removed.
+			return redactAttributes(extension.Schema.Attributes, value)
+		}
+		// DiffResources separates the attributes of extensions from their URI by a dot.
+		if len(path) > len(id) && strings.EqualFold(path[:len(id)+1], id+".") {
+			s, path = extension.Schema, path[len(id)+1:]
+			break
+		}
+	}
+
+	names := strings.SplitN(path, ".", 2)
+	attribute, ok := s.Attribute(names[0])
+	if !ok {
+		return value
+	}
+	if isSensitive(*attribute) {
+		return RedactedValue
+	}
+	if len(names) == 2 {
+		if attribute, ok = attribute.SubAttribute(names[1]); !ok {
+			return value
+		}
+	}
+	return redactValue(*attribute, value)
+}
+
+// redactValue returns RedactedValue if given attribute is sensitive, otherwise given value of the attribute of which
+// the values of sensitive sub-attributes are redacted.
+func redactValue(attribute schema.CoreAttribute, value interface{}) interface{} {
+	if isSensitive(attribute) {
+		return RedactedValue
+	}
+	if len(attribute.SubAttributes()) == 0 {
+		return value
+	}
+	return redactAttributes(attribute.SubAttributes(), value)
+}
+
+// redactAttributes returns a copy of given complex value, or multi-valued complex value, of which the values of
+// sensitive attributes are redacted.
+func redactAttributes(attributes []schema.CoreAttribute, value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, v := range value {
+			values[i] = redactAttributes(attributes, v)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(value))
+	outer:
+		for k, v := range value {
+			for _, attribute := range attributes {
+				if strings.EqualFold(attribute.Name(), k) {
+					values[k] = redactValue(attribute, v)
+					continue outer
+				}
+			}
+			values[k] = v
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+func isSensitive(attribute schema.CoreAttribute) bool {
+	return attribute.Mutability() == schema.AttributeMutabilityWriteOnly() ||
+		attribute.Returned() == schema.AttributeReturnedNever()
+}
+
+// audit sends an audit event for the change of given resource to the auditor of the server, if any.
+func (s Server) audit(r *http.Request, operation AuditOperation, resourceType ResourceType, id string, before, after ResourceAttributes) {
+	if s.Auditor == nil {
+		return
+	}
+	s.Auditor.Audit(r, AuditEvent{
+		Operation:    operation,
+		ResourceType: resourceType.Name,
+		ID:           id,
+		Time:         ClockFromContext(r.Context()).Now(),
+		Changes: resourceType.redactChanges(resourceType.SchemaSet().DiffResources(
+			withoutCommonAttributes(before),
+			withoutCommonAttributes(after),
+		)),
+	})
+}
+
+// auditSnapshot retrieves the current attributes of the resource to compute the changes of an audit event. It returns
+// nil if there is no auditor or the resource could not be retrieved.
+func (s Server) auditSnapshot(r *http.Request, resourceType ResourceType, id string) ResourceAttributes {
+	if s.Auditor == nil {
+		return nil
+	}
+	return snapshot(r, resourceType, id)
+}
+
+// snapshot retrieves a copy of the current attributes of the resource with given identifier. It returns nil if the
+// resource could not be retrieved.
+func snapshot(r *http.Request, resourceType ResourceType, id string) ResourceAttributes {
+	resource, getErr := resourceType.Handler.Get(r, id)
+	if getErr != errors.GetErrorNil {
+		return nil
+	}
+
+	// Copy the attributes, since the callback method might modify the same map during the change.
+	return copyValue(map[string]interface{}(resource.Attributes)).(map[string]interface{})
+}
+
+// copyValue returns a deep copy of given (JSON) value.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyValue(e)
+		}
+		return m
+	case ResourceAttributes:
+		return copyValue(map[string]interface{}(v))
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = copyValue(e)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// withoutCommonAttributes returns the attributes without the common attributes that are assigned by the server.
+func withoutCommonAttributes(attributes ResourceAttributes) ResourceAttributes {
+	m := make(ResourceAttributes, len(attributes))
+	for k, v := range attributes {
+		switch strings.ToLower(k) {
+		case "id", "schemas", "meta":
+		default:
+			m[k] = v
+		}
+	}
+	return m
+}
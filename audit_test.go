@@ -0,0 +1,129 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+type testAuditor struct {
+	events *[]AuditEvent
+}
+
+func (a testAuditor) Audit(_ *http.Request, event AuditEvent) {
+	*a.events = append(*a.events, event)
+}
+
+func TestDiffResources(t *testing.T) {
+	changes := DiffResources(ResourceAttributes{
+		"userName": "bjensen",
+		"name": map[string]interface{}{
+			"givenName":  "Barbara",
+			"familyName": "Jensen",
+		},
+		"emails": []interface{}{"a"},
+		"title":  nil,
+	}, ResourceAttributes{
+		"UserName": "bjensen",
+		"name": map[string]interface{}{
+			"givenName":  "Babs",
+			"familyName": "Jensen",
+		},
+		"emails": []interface{}{"a", "b"},
+		"active": true,
+	})
+
+	expected := []AttributeChange{
+		{Path: "active", After: true},
+		{Path: "emails", Before: []interface{}{"a"}, After: []interface{}{"a", "b"}},
+		{Path: "name.givenName", Before: "Barbara", After: "Babs"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes: got %v want %v", changes, expected)
+	}
+}
+
+func TestRedactChanges(t *testing.T) {
+	extensionID := "urn:ietf:params:scim:schemas:extension:test:2.0:User"
+	resourceType := ResourceType{
+		Schema: schema.Schema{
+			ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+			Attributes: []schema.CoreAttribute{
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "userName"})),
+				schema.ComplexCoreAttribute(schema.ComplexParams{
+					Name:        "certificates",
+					MultiValued: true,
+					SubAttributes: []schema.SimpleParams{
+						schema.SimpleStringParams(schema.StringParams{Name: "display"}),
+						schema.SimpleStringParams(schema.StringParams{Name: "value", Returned: schema.AttributeReturnedNever()}),
+					},
+				}),
+				schema.ComplexCoreAttribute(schema.ComplexParams{
+					Name: "credentials",
+					SubAttributes: []schema.SimpleParams{
+						schema.SimpleStringParams(schema.StringParams{Name: "secret", Mutability: schema.AttributeMutabilityWriteOnly()}),
+					},
+				}),
+			},
+		},
+		SchemaExtensions: []SchemaExtension{{Schema: schema.Schema{
+			ID: extensionID,
+			Attributes: []schema.CoreAttribute{
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "pin", Returned: schema.AttributeReturnedNever()})),
+			},
+		}}},
+	}
+
+	changes := resourceType.redactChanges([]AttributeChange{
+		{Path: "userName", Before: "a", After: "b"},
+		{Path: "certificates", After: []interface{}{map[string]interface{}{"display": "a", "Value": "secret"}}},
+		{Path: "credentials.secret", Before: "a", After: "b"},
+		{Path: extensionID + ".pin", Before: "1234"},
+		{Path: extensionID, After: map[string]interface{}{"pin": "1234"}},
+	})
+	expected := []AttributeChange{
+		{Path: "userName", Before: "a", After: "b"},
+		{Path: "certificates", After: []interface{}{map[string]interface{}{"display": "a", "Value": RedactedValue}}},
+		{Path: "credentials.secret", Before: RedactedValue, After: RedactedValue},
+		{Path: extensionID + ".pin", Before: RedactedValue},
+		{Path: extensionID, After: map[string]interface{}{"pin": RedactedValue}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes: got %v want %v", changes, expected)
+	}
+}
+
+func TestServerAuditPatch(t *testing.T) {
+	var events []AuditEvent
+	server := newTestServer()
+	server.Auditor = testAuditor{events: &events}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server.Clock = ClockFunc(func() time.Time { return now })
+
+	req := httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations":[{"op":"replace","path":"active","value":false}]
+	}`))
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := []AuditEvent{{
+		Operation:    AuditOperationPatch,
+		ResourceType: "User",
+		ID:           "0001",
+		Time:         now,
+		Changes:      []AttributeChange{{Path: "active", After: false}},
+	}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("unexpected audit events: got %v want %v", events, expected)
+	}
+}
@@ -0,0 +1,46 @@
+package scim
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// Authenticator authenticates the clients of the server, e.g. by verifying their bearer tokens.
+type Authenticator interface {
+	// Authenticate authenticates the client that sent given request. It returns the request to handle, e.g. a shallow
+	// copy with the identity of the client added to its context, so that the callback methods can authorize it. If it
+	// returns an error, the request is rejected with a "401 Unauthorized" response, unless the error is (or wraps) an
+	// Error with another status code, e.g. "403 Forbidden".
+	Authenticate(r *http.Request) (*http.Request, error)
+}
+
+// AuthenticatorFunc is an adapter to use an ordinary function as an authenticator.
+type AuthenticatorFunc func(r *http.Request) (*http.Request, error)
+
+// Authenticate returns f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*http.Request, error) {
+	return f(r)
+}
+
+// authenticate authenticates the client that sent given request with the authenticator of the server, if any.
+func (s Server) authenticate(r *http.Request) (*http.Request, *scimError) {
+	if s.Authenticator == nil {
+		return r, nil
+	}
+	authenticated, err := s.Authenticator.Authenticate(r)
+	if err == nil {
+		if authenticated == nil {
+			return r, nil
+		}
+		return authenticated, nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		scimErr := scimCustomError(scimErrorFromError(r.Context(), err))
+		return r, &scimErr
+	}
+	return r, &scimError{
+		detail: "The client could not be authenticated.",
+		status: http.StatusUnauthorized,
+	}
+}
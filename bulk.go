@@ -0,0 +1,290 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	bulkRequestSchema  = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	bulkResponseSchema = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+)
+
+// bulkRequest is the body of a request to the "/Bulk" endpoint, as described in RFC 7644 section 3.7.
+type bulkRequest struct {
+	Schemas []string `json:"schemas"`
+	// FailOnErrors is the number of errors that the service provider will accept before the operation is terminated
+	// and an error response is returned. Zero means that all operations are processed.
+	FailOnErrors int             `json:"failOnErrors"`
+	Operations   []bulkOperation `json:"Operations"`
+}
+
+// bulkOperation is a single operation of a bulk request.
+type bulkOperation struct {
+	Method  string          `json:"method"`
+	BulkID  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// bulkResponse is the body of the response to a bulk request.
+type bulkResponse struct {
+	Schemas    []string                `json:"schemas"`
+	Operations []bulkOperationResponse `json:"Operations"`
+}
+
+// bulkOperationResponse is the result of a single operation of a bulk request.
+type bulkOperationResponse struct {
+	Location string          `json:"location,omitempty"`
+	Method   string          `json:"method"`
+	BulkID   string          `json:"bulkId,omitempty"`
+	Version  string          `json:"version,omitempty"`
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// bulkHandler receives an HTTP POST to the "/Bulk" endpoint, which is used to apply multiple operations to resources
+// in a single request. The operations are processed in order. Once the number of failed operations reaches the
+// "failOnErrors" value of the request, the remaining operations are skipped and the results of the processed
+// operations are returned.
+func (s Server) bulkHandler(w http.ResponseWriter, r *http.Request) {
+	req, scimErr := s.readBulkRequest(r)
+	if scimErr != nil {
+		errorHandler(w, r, *scimErr)
+		return
+	}
+	if len(req.Schemas) != 1 || req.Schemas[0] != bulkRequestSchema {
+		errorHandler(w, r, scimErrorInvalidValue)
+		return
+	}
+
+	response := bulkResponse{
+		Schemas:    []string{bulkResponseSchema},
+		Operations: make([]bulkOperationResponse, 0, len(req.Operations)),
+	}
+	r = s.prefetchVersions(r, req.Operations)
+	ids := make(map[string]string)
+	var failures int
+	for _, op := range req.Operations {
+		result := s.bulkOperation(r, op, ids)
+		response.Operations = append(response.Operations, result)
+
+		if status, _ := strconv.Atoi(result.Status); status >= http.StatusBadRequest {
+			failures++
+			if req.FailOnErrors > 0 && failures >= req.FailOnErrors {
+				break
+			}
+		}
+	}
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		errorHandler(w, r, scimErrorInternalServer)
+		log.Fatalf("failed marshaling bulk response: %v", err)
+		return
+	}
+	_, err = w.Write(raw)
+	if err != nil {
+		logf(r, "failed writing response: %v", err)
+	}
+}
+
+// readBulkRequest reads the bulk request in the body of given request. Large bodies are decoded while they are read,
+// see Server.StreamingThreshold.
+func (s Server) readBulkRequest(r *http.Request) (bulkRequest, *scimError) {
+	maxPayload := s.Config.features().Bulk.MaxPayloadSize
+	maxOpts := s.Config.features().Bulk.MaxOperations
+	payloadErr := scimErrorPayloadTooLarge(fmt.Sprintf(
+		"The size of the bulk operation exceeds the maxPayloadSize (%d).", maxPayload,
+	))
+	optsErr := scimErrorPayloadTooLarge(fmt.Sprintf(
+		"The number of operations exceeds the maxOperations (%d).", maxOpts,
+	))
+
+	var req bulkRequest
+	if s.streamBody(r) {
+		body := &payloadReader{r: r.Body, limit: int64(maxPayload)}
+		var err error
+		req, err = decodeBulkRequest(json.NewDecoder(body), maxOpts)
+		switch {
+		case err == errPayloadTooLarge:
+			return req, &payloadErr
+		case err == errTooManyOperations:
+			return req, &optsErr
+		case err != nil:
+			return req, &scimErrorInvalidSyntax
+		}
+	} else {
+		data, _ := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxPayload)+1))
+		if len(data) > maxPayload {
+			return req, &payloadErr
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, &scimErrorInvalidSyntax
+		}
+		if len(req.Operations) > maxOpts {
+			return req, &optsErr
+		}
+	}
+
+	if req.FailOnErrors < 0 {
+		return req, &scimErrorInvalidSyntax
+	}
+	return req, nil
+}
+
+// bulkOperation performs a single operation of a bulk request. The identifiers of the resources that are created by
+// previous operations are used to resolve "bulkId:" references in the path and data of the operation.
+func (s Server) bulkOperation(r *http.Request, op bulkOperation, ids map[string]string) bulkOperationResponse {
+	result := bulkOperationResponse{
+		Method:  op.Method,
+		BulkID:  op.BulkID,
+		Version: op.Version,
+	}
+
+	method := strings.ToUpper(op.Method)
+	path, pathOK := resolveBulkIDs(op.Path, ids, false)
+	data, dataOK := resolveBulkIDs(string(op.Data), ids, true)
+	switch {
+	case !pathOK || !dataOK:
+		return result.withError(scimError{
+			scimType: scimErrorInvalidValue.scimType,
+			detail:   "The operation references a bulkId of an operation that did not succeed.",
+			status:   http.StatusConflict,
+		})
+	case method == http.MethodPost && op.BulkID == "",
+		method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete:
+		return result.withError(scimErrorInvalidSyntax)
+	}
+
+	opRequest, err := http.NewRequest(method, path, strings.NewReader(data))
+	if err != nil {
+		return result.withError(scimErrorInvalidSyntax)
+	}
+	opRequest = opRequest.WithContext(r.Context())
+	opRequest.Header = r.Header.Clone()
+	if op.Version != "" && method != http.MethodPost {
+		// The version of an operation is its precondition, so stale operations fail with "412 Precondition Failed"
+		// while the others proceed.
+		opRequest.Header.Set("If-Match", formatETag(op.Version))
+		opRequest.Header.Del("If-None-Match")
+	}
+	opRequest.Host, opRequest.TLS = r.Host, r.TLS
+	if strings.HasPrefix(r.URL.Path, "/v2/") {
+		opRequest.URL.Path = "/v2" + opRequest.URL.Path
+	}
+
+	rw := &bulkResponseWriter{header: make(http.Header), status: http.StatusOK}
+	if !s.serveResource(rw, opRequest, strings.TrimPrefix(opRequest.URL.Path, "/v2")) {
+		return result.withError(scimError{
+			detail: "Specified endpoint does not exist.",
+			status: http.StatusNotFound,
+		})
+	}
+
+	result.Status = strconv.Itoa(rw.status)
+	var resource struct {
+		ID   string `json:"id"`
+		Meta struct {
+			Location string `json:"location"`
+			Version  string `json:"version"`
+		} `json:"meta"`
+	}
+	if rw.status >= http.StatusBadRequest {
+		result.Response = rw.body.Bytes()
+		return result
+	}
+	if rw.status == http.StatusAccepted {
+		// The operation is queued, so there is no resource yet that can be referenced by later operations.
+		result.Location = rw.header.Get("Location")
+		return result
+	}
+	if err := json.Unmarshal(rw.body.Bytes(), &resource); err == nil {
+		result.Location = resource.Meta.Location
+		if resource.Meta.Version != "" {
+			result.Version = resource.Meta.Version
+		}
+	}
+	if method == http.MethodPost {
+		ids[op.BulkID] = resource.ID
+	}
+	if result.Location == "" && method != http.MethodPost {
+		result.Location = op.Path
+	}
+	return result
+}
+
+func (r bulkOperationResponse) withError(scimErr scimError) bulkOperationResponse {
+	raw, _ := json.Marshal(scimErr)
+	r.Status = strconv.Itoa(scimErr.status)
+	r.Response = raw
+	return r
+}
+
+// resolveBulkIDs replaces the "bulkId:" references in given value with the identifiers of the created resources. In
+// JSON data only complete string values are replaced. It returns false if a reference can not be resolved.
+func resolveBulkIDs(value string, ids map[string]string, quoted bool) (string, bool) {
+	const prefix = "bulkId:"
+	var resolved strings.Builder
+	for {
+		i := strings.Index(value, prefix)
+		if i < 0 || quoted && (i == 0 || value[i-1] != '"') {
+			if i < 0 {
+				resolved.WriteString(value)
+				return resolved.String(), true
+			}
+			resolved.WriteString(value[:i+len(prefix)])
+			value = value[i+len(prefix):]
+			continue
+		}
+
+		end := len(value)
+		if quoted {
+			end = i + strings.IndexByte(value[i:], '"')
+			if end < i {
+				end = len(value)
+			}
+		} else if j := strings.IndexAny(value[i:], "/?"); j >= 0 {
+			end = i + j
+		}
+
+		id, ok := ids[value[i+len(prefix):end]]
+		if !ok || id == "" {
+			return "", false
+		}
+		if quoted {
+			raw, _ := json.Marshal(id)
+			id = string(raw[1 : len(raw)-1])
+		}
+		resolved.WriteString(value[:i])
+		resolved.WriteString(id)
+		value = value[end:]
+	}
+}
+
+// bulkResponseWriter records the response to a single operation of a bulk request.
+type bulkResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bulkResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bulkResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bulkResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
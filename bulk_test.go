@@ -0,0 +1,97 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerBulkHandler(t *testing.T) {
+	server := newTestServer()
+	server.Config.SupportBulk = true
+
+	body := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"failOnErrors": %d,
+		"Operations": [
+			{"method": "POST", "path": "/Users", "bulkId": "qwerty", "data": {"userName": "alice"}},
+			{"method": "PUT", "path": "/Users/bulkId:qwerty", "data": {"userName": "bob"}},
+			{"method": "POST", "path": "/Users", "bulkId": "invalid", "data": {"userName": 1}},
+			{"method": "DELETE", "path": "/Users/bulkId:invalid"},
+			{"method": "DELETE", "path": "/Users/0001"}
+		]
+	}`
+	for _, test := range []struct {
+		failOnErrors int
+		statuses     []string
+	}{
+		{0, []string{"201", "200", "400", "409", "204"}},
+		{1, []string{"201", "200", "400"}},
+		{2, []string{"201", "200", "400", "409"}},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(fmt.Sprintf(body, test.failOnErrors))))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var response bulkResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		var statuses []string
+		for _, op := range response.Operations {
+			statuses = append(statuses, op.Status)
+		}
+		if strings.Join(statuses, ",") != strings.Join(test.statuses, ",") {
+			t.Errorf("failOnErrors %d: got statuses %v want %v", test.failOnErrors, statuses, test.statuses)
+		}
+		if location := response.Operations[1].Location; location == "" || strings.Contains(location, "bulkId") {
+			t.Errorf("expected the bulkId to be resolved, got location %q", location)
+		}
+	}
+}
+
+func TestServerBulkHandlerNotSupported(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": []
+	}`)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestServerBulkHandlerLimits(t *testing.T) {
+	body := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{"method": "DELETE", "path": "/Users/0001"},
+			{"method": "DELETE", "path": "/Users/0002"}
+		]
+	}`
+	for _, test := range []struct {
+		config   ServiceProviderConfig
+		expected int
+	}{
+		{ServiceProviderConfig{SupportBulk: true}, http.StatusOK},
+		{ServiceProviderConfig{SupportBulk: true, BulkMaxOpts: 1}, http.StatusRequestEntityTooLarge},
+		{ServiceProviderConfig{SupportBulk: true, BulkMaxPayload: 64}, http.StatusRequestEntityTooLarge},
+	} {
+		server := newTestServer()
+		server.Config = test.config
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(body)))
+		if rr.Code != test.expected {
+			t.Errorf("%+v: handler returned wrong status code: got %v want %v", test.config, rr.Code, test.expected)
+		}
+		if test.expected == http.StatusRequestEntityTooLarge && !strings.Contains(rr.Body.String(), `"status":"413"`) {
+			t.Errorf("expected a SCIM error, got %s", rr.Body.String())
+		}
+	}
+}
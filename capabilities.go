@@ -0,0 +1,53 @@
+package scim
+
+// CapabilitiesExtensionID is the URI of the vendor extension of the service provider configuration that exposes the
+// version of the library, the enabled compatibility profiles and the enabled features of the server.
+const CapabilitiesExtensionID = "urn:elimity:params:scim:schemas:extension:capabilities:2.0"
+
+// Version is the version of the library that is reported in the capabilities extension. It can be overridden at build
+// time, e.g. with `-ldflags "-X github.com/elimity-com/scim.Version=v1.2.3"`.
+var Version = "devel"
+
+// Capabilities configures the capabilities extension of the service provider configuration, which operators and
+// support engineers can use to verify a deployment remotely.
+type Capabilities struct {
+	// Profiles are the names of the compatibility profiles that are enabled, e.g. "azure", in addition to the profiles
+	// that are enabled with WithCompatibilityProfile.
+	Profiles []string
+	// Features are additional (application-level) feature flags that are reported next to the features of the server.
+	Features map[string]bool
+}
+
+// getRaw returns the capabilities extension of given server.
+func (c Capabilities) getRaw(s Server) map[string]interface{} {
+	config := s.Config.features()
+	features := map[string]bool{
+		"audit":                   s.Auditor != nil,
+		"bulk":                    config.Bulk.Supported,
+		"changePassword":          config.ChangePassword.Supported,
+		"coercePatchValues":       s.CoercePatchValues,
+		"filter":                  config.Filter.Supported,
+		"loadShedding":            s.LoadShedder != nil,
+		"paginationDeadline":      s.PaginationDeadlineMargin > 0,
+		"patch":                   config.Patch.Supported,
+		"patchStatistics":         s.PatchStatistics,
+		"rejectControlCharacters": s.TextValidation.RejectControlCharacters,
+		"rejectInvalidUTF8":       s.TextValidation.RejectInvalidUTF8,
+		"sort":                    config.Sort.Supported,
+	}
+	for name, enabled := range c.Features {
+		features[name] = enabled
+	}
+
+	profiles := append([]string{}, c.Profiles...)
+	for _, profile := range s.profiles {
+		if !contains(profiles, profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return map[string]interface{}{
+		"version":  Version,
+		"profiles": profiles,
+		"features": features,
+	}
+}
@@ -0,0 +1,39 @@
+package scim
+
+// passwordAttribute is the name of the attribute that holds the password of a user, see RFC 7643, section 4.1.1.
+const passwordAttribute = "password"
+
+// checkChangePassword rejects given validated attributes of a PUT request if they contain a password, while the
+// service provider does not support changing passwords.
+func (s Server) checkChangePassword(attributes ResourceAttributes) *scimError {
+	if s.Config.features().ChangePassword.Supported || lookupFold(attributes, passwordAttribute) == nil {
+		return nil
+	}
+	return &scimErrorChangePasswordNotSupported
+}
+
+// checkPatchChangePassword rejects given PATCH request if one of its operations sets or removes the password, while the
+// service provider does not support changing passwords.
+func (s Server) checkPatchChangePassword(resourceType ResourceType, req PatchRequest) *scimError {
+	if s.Config.features().ChangePassword.Supported {
+		return nil
+	}
+	password := AttributePath{AttributeName: passwordAttribute}
+	for _, op := range req.Operations {
+		if op.Path != "" {
+			path, err := ParseAttributePath(op.Path)
+			if err == nil && resourceType.samePath(path, password) {
+				return &scimErrorChangePasswordNotSupported
+			}
+			continue
+		}
+		values, _ := op.Value.(map[string]interface{})
+		for k := range values {
+			path, err := ParseAttributePath(k)
+			if err == nil && resourceType.samePath(path, password) {
+				return &scimErrorChangePasswordNotSupported
+			}
+		}
+	}
+	return nil
+}
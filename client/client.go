@@ -0,0 +1,193 @@
+// Package client implements a SCIM client which talks to a service provider over the HTTP-based SCIM protocol.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/elimity-com/scim"
+)
+
+// Client represents a SCIM client which sends requests to the service provider located at the base URL.
+type Client struct {
+	// BaseURL is the base URL of the service provider, e.g. "https://example.com/scim/v2".
+	BaseURL string
+	// HTTPClient is the client used to send the requests, e.g. one created with NewHTTPClient. It defaults to a client
+	// created with the default TransportOptions, which is shared by all clients.
+	HTTPClient *http.Client
+}
+
+// ListParams are the query parameters sent to the service provider when listing resources.
+type ListParams struct {
+	// Count specifies the desired maximum number of query results per page. The service provider may return less
+	// results than requested. If zero, the parameter is omitted and the service provider's default is used.
+	Count int
+	// Filter is the raw filter query parameter, e.g. `userName eq "bjensen"`. It is omitted when empty.
+	Filter string
+	// StartIndex is the 1-based index of the first query result. A value less than 1 is interpreted as 1.
+	StartIndex int
+}
+
+// ListResponse represents a page of resources returned by the service provider.
+type ListResponse struct {
+	// TotalResults is the total number of results matching the list or query operation. It is
+	// scim.UnknownTotalResults if the service provider left it out.
+	TotalResults int
+	// Estimated reports whether TotalResults is an estimate or unknown, see scim.TotalResultsExtensionID.
+	Estimated bool
+	// ItemsPerPage is the number of resources returned in the page.
+	ItemsPerPage int
+	// StartIndex is the 1-based index of the first result in the page.
+	StartIndex int
+	// Resources is the list of resources in the page.
+	Resources []scim.ResourceAttributes
+}
+
+// List retrieves a single page of resources from given endpoint, e.g., "/Users". Unsuccessful responses are returned
+// as an *Error.
+func (c Client) List(ctx context.Context, endpoint string, params ListParams) (ListResponse, error) {
+	query := url.Values{}
+	if params.Count > 0 {
+		query.Set("count", strconv.Itoa(params.Count))
+	}
+	if params.StartIndex > 1 {
+		query.Set("startIndex", strconv.Itoa(params.StartIndex))
+	}
+	if params.Filter != "" {
+		query.Set("filter", params.Filter)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, query, nil)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	var raw struct {
+		Schemas      []string
+		TotalResults *int
+		ItemsPerPage int
+		StartIndex   int
+		Resources    []scim.ResourceAttributes
+	}
+	if err := c.do(req, &raw); err != nil {
+		return ListResponse{}, err
+	}
+
+	resp := ListResponse{
+		TotalResults: scim.UnknownTotalResults,
+		Estimated:    true,
+		ItemsPerPage: raw.ItemsPerPage,
+		StartIndex:   raw.StartIndex,
+		Resources:    raw.Resources,
+	}
+	if raw.TotalResults != nil && *raw.TotalResults >= 0 {
+		resp.TotalResults = *raw.TotalResults
+		resp.Estimated = false
+		for _, schema := range raw.Schemas {
+			if schema == scim.TotalResultsExtensionID {
+				resp.Estimated = true
+			}
+		}
+	}
+	return resp, nil
+}
+
+// Get retrieves the resource with given identifier from given endpoint, e.g., "/Users". Unsuccessful responses are
+// returned as an *Error.
+func (c Client) Get(ctx context.Context, endpoint, id string) (scim.ResourceAttributes, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint+"/"+url.PathEscape(id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resource scim.ResourceAttributes
+	if err := c.do(req, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// Create creates a resource with given attributes at given endpoint, e.g., "/Users", and returns the created resource.
+// Unsuccessful responses are returned as an *Error.
+func (c Client) Create(ctx context.Context, endpoint string, attributes scim.ResourceAttributes) (scim.ResourceAttributes, error) {
+	return c.send(ctx, http.MethodPost, endpoint, attributes)
+}
+
+// Replace replaces the attributes of the resource with given identifier at given endpoint, e.g., "/Users", and returns
+// the replaced resource. Unsuccessful responses are returned as an *Error.
+func (c Client) Replace(ctx context.Context, endpoint, id string, attributes scim.ResourceAttributes) (scim.ResourceAttributes, error) {
+	return c.send(ctx, http.MethodPut, endpoint+"/"+url.PathEscape(id), attributes)
+}
+
+// send sends given attributes with given method to given endpoint and returns the resource in the response.
+func (c Client) send(ctx context.Context, method, endpoint string, attributes scim.ResourceAttributes) (scim.ResourceAttributes, error) {
+	body, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, method, endpoint, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var resource scim.ResourceAttributes
+	if err := c.do(req, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+func (c Client) newRequest(ctx context.Context, method, endpoint string, query url.Values, body []byte) (*http.Request, error) {
+	u := strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(endpoint, "/")
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	return req, nil
+}
+
+func (c Client) do(req *http.Request, v interface{}) error {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp.StatusCode, data)
+	}
+
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// Error represents a SCIM error response returned by the service provider.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// ScimType is the SCIM detail error keyword, e.g. errors.ScimTypeUniqueness. It is empty if the service provider
+	// did not specify one.
+	ScimType errors.ScimType
+	// Detail is the detailed human-readable message returned by the service provider.
+	Detail string
+}
+
+// Error returns a human readable representation of the SCIM error.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("scim: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.ScimType != "" {
+		msg += fmt.Sprintf(" (%s)", e.ScimType)
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// IsRetryable reports whether the request that caused the error is likely to succeed when retried later, e.g. when
+// the service provider is temporarily overloaded or unavailable. Client errors such as uniqueness conflicts or invalid
+// values are never retryable.
+func (e *Error) IsRetryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// newError decodes the body of an unsuccessful response into an error. Bodies that are not SCIM errors are used as
+// the detail of the error.
+func newError(statusCode int, body []byte) *Error {
+	var raw struct {
+		ScimType errors.ScimType
+		Detail   string
+		Status   json.RawMessage
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return &Error{
+			StatusCode: statusCode,
+			Detail:     strings.TrimSpace(string(body)),
+		}
+	}
+
+	// The status is expressed as a JSON string, but some service providers return a number instead.
+	if status, err := strconv.Atoi(strings.Trim(string(raw.Status), `"`)); err == nil && status != 0 {
+		statusCode = status
+	}
+
+	return &Error{
+		StatusCode: statusCode,
+		ScimType:   raw.ScimType,
+		Detail:     raw.Detail,
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	scimErrors "github.com/elimity-com/scim/errors"
+)
+
+func TestListError(t *testing.T) {
+	for _, test := range []struct {
+		status    int
+		body      string
+		scimType  scimErrors.ScimType
+		detail    string
+		retryable bool
+	}{
+		{
+			status:   http.StatusConflict,
+			body:     `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"scimType":"uniqueness","detail":"taken","status":"409"}`,
+			scimType: scimErrors.ScimTypeUniqueness,
+			detail:   "taken",
+		},
+		{
+			status:    http.StatusTooManyRequests,
+			body:      `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":429}`,
+			retryable: true,
+		},
+		{
+			status:    http.StatusServiceUnavailable,
+			body:      "upstream unavailable",
+			detail:    "upstream unavailable",
+			retryable: true,
+		},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			_, _ = w.Write([]byte(test.body))
+		}))
+
+		_, err := Client{BaseURL: server.URL}.List(context.Background(), "/Users", ListParams{})
+		server.Close()
+
+		var scimErr *Error
+		if !errors.As(err, &scimErr) {
+			t.Fatalf("expected a scim error, got %v", err)
+		}
+		if scimErr.StatusCode != test.status {
+			t.Errorf("expected status %d, got %d", test.status, scimErr.StatusCode)
+		}
+		if scimErr.ScimType != test.scimType {
+			t.Errorf("expected scim type %q, got %q", test.scimType, scimErr.ScimType)
+		}
+		if scimErr.Detail != test.detail {
+			t.Errorf("expected detail %q, got %q", test.detail, scimErr.Detail)
+		}
+		if scimErr.IsRetryable() != test.retryable {
+			t.Errorf("expected retryable to be %v for status %d", test.retryable, test.status)
+		}
+	}
+}
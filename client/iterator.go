@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+
+	"github.com/elimity-com/scim"
+)
+
+// ListIterator lazily pages through the resources of an endpoint. Pages are only requested once all resources of the
+// previous page are consumed.
+//
+//	it := c.ListIterator("/Users", client.ListParams{})
+//	for it.Next(ctx) {
+//		resource := it.Resource()
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+//
+// The start index of the next page is based on the number of resources actually returned, so service providers that
+// clamp the requested count to a lower page size are handled correctly. The iterator stops when the service provider
+// returns an empty page or when the start index exceeds the total number of results of the most recent page, which
+// makes it tolerant to resources being added or removed during the iteration. If the total number of results is
+// estimated or unknown, the iterator only stops at an empty page.
+type ListIterator struct {
+	client   Client
+	endpoint string
+	params   ListParams
+
+	page         []scim.ResourceAttributes
+	index        int
+	totalResults int
+	done         bool
+	err          error
+}
+
+// ListIterator returns an iterator over all resources of the given endpoint, e.g., "/Users".
+func (c Client) ListIterator(endpoint string, params ListParams) *ListIterator {
+	if params.StartIndex < 1 {
+		params.StartIndex = 1
+	}
+	return &ListIterator{
+		client:   c,
+		endpoint: endpoint,
+		params:   params,
+		index:    -1,
+	}
+}
+
+// Next advances the iterator to the next resource, which will then be available through the Resource method. It
+// returns false when the iteration stops, either by reaching the end or an error. After Next returns false, the Err
+// method will return any error that occurred during iteration.
+func (it *ListIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	if !it.fetch(ctx) {
+		return false
+	}
+	it.index = 0
+	return true
+}
+
+// fetch requests the next page and reports whether it contains any resources.
+func (it *ListIterator) fetch(ctx context.Context) bool {
+	resp, err := it.client.List(ctx, it.endpoint, it.params)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = resp.Resources
+	it.totalResults = resp.TotalResults
+	it.params.StartIndex += len(resp.Resources)
+
+	if len(resp.Resources) == 0 || !resp.Estimated && it.params.StartIndex > resp.TotalResults {
+		it.done = true
+	}
+	return len(resp.Resources) != 0
+}
+
+// Resource returns the current resource. It should only be called after a call to Next returned true.
+func (it *ListIterator) Resource() scim.ResourceAttributes {
+	if it.index < 0 || it.index >= len(it.page) {
+		return nil
+	}
+	return it.page[it.index]
+}
+
+// TotalResults returns the total number of results as reported by the most recently requested page.
+func (it *ListIterator) TotalResults() int {
+	return it.totalResults
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (it *ListIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/elimity-com/scim"
+)
+
+// newTestListServer returns a server with given number of resources that never returns more than pageSize resources.
+func newTestListServer(total *int, pageSize int) *httptest.Server {
+	return newTestListServerWithTotal(total, pageSize, true)
+}
+
+// newTestListServerWithTotal returns a test list server that leaves out the total number of results unless withTotal
+// is set.
+func newTestListServerWithTotal(total *int, pageSize int, withTotal bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+		if err != nil {
+			startIndex = 1
+		}
+
+		resources := make([]map[string]interface{}, 0)
+		for i := startIndex; i <= *total && len(resources) < pageSize; i++ {
+			resources = append(resources, map[string]interface{}{
+				"id": fmt.Sprintf("%04d", i),
+			})
+		}
+
+		response := map[string]interface{}{
+			"schemas":      []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"},
+			"totalResults": *total,
+			"itemsPerPage": len(resources),
+			"startIndex":   startIndex,
+			"Resources":    resources,
+		}
+		if !withTotal {
+			delete(response, "totalResults")
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestListIteratorClampedPageSize(t *testing.T) {
+	total := 10
+	server := newTestListServer(&total, 3)
+	defer server.Close()
+
+	c := Client{BaseURL: server.URL}
+	it := c.ListIterator("/Users", ListParams{Count: 5})
+
+	var ids []string
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Resource()["id"].(string))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != total {
+		t.Fatalf("expected %d resources, got %d", total, len(ids))
+	}
+	for i, id := range ids {
+		if id != fmt.Sprintf("%04d", i+1) {
+			t.Errorf("unexpected resource at index %d: %s", i, id)
+		}
+	}
+}
+
+func TestListIteratorTotalResultsShrinks(t *testing.T) {
+	total := 10
+	server := newTestListServer(&total, 4)
+	defer server.Close()
+
+	c := Client{BaseURL: server.URL}
+	it := c.ListIterator("/Users", ListParams{})
+
+	var n int
+	for it.Next(context.Background()) {
+		n++
+		if n == 1 {
+			total = 6
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 6 {
+		t.Errorf("expected 6 resources, got %d", n)
+	}
+}
+
+func TestListIteratorUnknownTotalResults(t *testing.T) {
+	total := 10
+	server := newTestListServerWithTotal(&total, 3, false)
+	defer server.Close()
+
+	c := Client{BaseURL: server.URL}
+	it := c.ListIterator("/Users", ListParams{})
+
+	var n int
+	for it.Next(context.Background()) {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != total {
+		t.Errorf("expected %d resources, got %d", total, n)
+	}
+	if it.TotalResults() != scim.UnknownTotalResults {
+		t.Errorf("expected an unknown total, got %d", it.TotalResults())
+	}
+}
+
+func TestListIteratorContextCancelled(t *testing.T) {
+	total := 10
+	server := newTestListServer(&total, 2)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := Client{BaseURL: server.URL}
+	it := c.ListIterator("/Users", ListParams{})
+
+	var n int
+	for it.Next(ctx) {
+		n++
+		if n == 3 {
+			cancel()
+		}
+	}
+
+	if n != 3 {
+		t.Errorf("expected iteration to stop after 3 resources, got %d", n)
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("expected context canceled error, got %v", it.Err())
+	}
+}
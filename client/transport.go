@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultMaxConnsPerHost     = 64
+	defaultMaxIdleConnsPerHost = 32
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultDialTimeout         = 10 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultRequestTimeout      = time.Minute
+)
+
+// defaultHTTPClient is the client that is used when Client.HTTPClient is nil.
+var defaultHTTPClient = NewHTTPClient(TransportOptions{})
+
+// TransportOptions tune the connections of the HTTP client that sends the requests to the service provider. The
+// defaults suit long-running synchronization jobs that send many requests to a single service provider: unlike the
+// transport of http.DefaultClient, which only keeps two idle connections per host, they keep enough connections alive
+// to send requests concurrently without reconnecting.
+type TransportOptions struct {
+	// MaxConnsPerHost limits the total number of connections to the service provider. It defaults to 64, a negative
+	// value means no limit.
+	MaxConnsPerHost int
+	// MaxIdleConnsPerHost is the number of idle connections that are kept alive for reuse. It defaults to 32.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the time after which idle connections are closed. It defaults to 90 seconds.
+	IdleConnTimeout time.Duration
+	// Timeout limits the time of a single request, including reading the response body. It defaults to one minute, a
+	// negative value means no limit. Requests can still be cancelled earlier through their context.
+	Timeout time.Duration
+	// DisableHTTP2 disables HTTP/2, which is otherwise negotiated with service providers that support it.
+	DisableHTTP2 bool
+	// Proxy returns the proxy to use for a request, see http.Transport. It defaults to http.ProxyFromEnvironment,
+	// which uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy func(*http.Request) (*url.URL, error)
+	// TLSConfig is the TLS configuration to use, e.g. to trust a private certificate authority or to present a client
+	// certificate. It is optional.
+	TLSConfig *tls.Config
+}
+
+// NewHTTPClient returns an HTTP client with a transport that is tuned with given options. It can be assigned to
+// Client.HTTPClient, or be wrapped first, e.g. to add authentication.
+func NewHTTPClient(opts TransportOptions) *http.Client {
+	transport := &http.Transport{
+		Proxy: opts.Proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   defaultDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   !opts.DisableHTTP2,
+		MaxConnsPerHost:     opts.MaxConnsPerHost,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		TLSClientConfig:     opts.TLSConfig,
+		TLSHandshakeTimeout: defaultTLSHandshakeTimeout,
+	}
+	if transport.Proxy == nil {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	switch {
+	case transport.MaxConnsPerHost == 0:
+		transport.MaxConnsPerHost = defaultMaxConnsPerHost
+	case transport.MaxConnsPerHost < 0:
+		transport.MaxConnsPerHost = 0
+	}
+	if transport.MaxIdleConnsPerHost <= 0 {
+		transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if transport.IdleConnTimeout <= 0 {
+		transport.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if opts.DisableHTTP2 {
+		// A non-nil, empty map disables the automatic upgrade to HTTP/2.
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	timeout := opts.Timeout
+	switch {
+	case timeout == 0:
+		timeout = defaultRequestTimeout
+	case timeout < 0:
+		timeout = 0
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
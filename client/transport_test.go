@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	c := NewHTTPClient(TransportOptions{})
+	transport := c.Transport.(*http.Transport)
+	if transport.MaxConnsPerHost != defaultMaxConnsPerHost || transport.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("unexpected connection limits: %d, %d", transport.MaxConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+	if !transport.ForceAttemptHTTP2 || transport.Proxy == nil || c.Timeout != defaultRequestTimeout {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+
+	c = NewHTTPClient(TransportOptions{
+		MaxConnsPerHost: -1,
+		Timeout:         -1,
+		DisableHTTP2:    true,
+	})
+	transport = c.Transport.(*http.Transport)
+	if transport.MaxConnsPerHost != 0 || c.Timeout != 0 {
+		t.Errorf("expected no limits, got %d, %v", transport.MaxConnsPerHost, c.Timeout)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("expected HTTP/2 to be disabled")
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	c := Client{
+		BaseURL:    server.URL,
+		HTTPClient: NewHTTPClient(TransportOptions{Timeout: 10 * time.Millisecond}),
+	}
+	if _, err := c.List(context.Background(), "/Users", ListParams{}); err == nil {
+		t.Errorf("expected the request to time out")
+	}
+}
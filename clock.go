@@ -0,0 +1,105 @@
+package scim
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides the current time to the server, e.g. for the rate limiting of the load shedder and the time of audit
+// events. Callback methods can get the clock of the server with ClockFromContext, e.g. to set the "created" and
+// "lastModified" meta attributes. Replacing the system clock makes tests and golden files deterministic.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to use an ordinary function as a clock, e.g. one that returns a fixed time.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock is the default clock, which returns the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IDGenerator generates the identifiers of created resources. Callback methods can get the ID generator of the server
+// with IDGeneratorFromContext.
+type IDGenerator interface {
+	// NewID returns a new unique identifier.
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter to use an ordinary function as an ID generator.
+type IDGeneratorFunc func() string
+
+// NewID returns f().
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// SequentialIDGenerator returns an ID generator that formats the sequence 1, 2, 3, ... with given format, e.g. "%04d".
+// It is safe for concurrent use.
+func SequentialIDGenerator(format string) IDGenerator {
+	var last uint64
+	return IDGeneratorFunc(func() string {
+		return fmt.Sprintf(format, atomic.AddUint64(&last, 1))
+	})
+}
+
+// randomIDGenerator is the default ID generator, which generates random (version 4) UUIDs.
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed generating random identifier: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+type clockContextKey struct{}
+
+// ClockFromContext returns the clock of the server that handles the request. It returns the system clock if the
+// context does not originate from a request to the server.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return systemClock{}
+}
+
+type idGeneratorContextKey struct{}
+
+// IDGeneratorFromContext returns the ID generator of the server that handles the request. It returns a generator of
+// random UUIDs if the context does not originate from a request to the server.
+func IDGeneratorFromContext(ctx context.Context) IDGenerator {
+	if generator, ok := ctx.Value(idGeneratorContextKey{}).(IDGenerator); ok {
+		return generator
+	}
+	return randomIDGenerator{}
+}
+
+// withClock returns a shallow copy of given request with the clock and ID generator of the server added to its
+// context.
+func (s Server) withClock(r *http.Request) *http.Request {
+	ctx := r.Context()
+	if s.Clock != nil {
+		ctx = context.WithValue(ctx, clockContextKey{}, s.Clock)
+	}
+	if s.IDGenerator != nil {
+		ctx = context.WithValue(ctx, idGeneratorContextKey{}, s.IDGenerator)
+	}
+	return r.WithContext(ctx)
+}
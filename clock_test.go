@@ -0,0 +1,58 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerClockRateLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := newTestServer()
+	server.Clock = ClockFunc(func() time.Time { return now })
+	server.LoadShedder = &LoadShedder{
+		Limiter: NewRateLimiter(1, 1),
+	}
+
+	for i, test := range []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{0, http.StatusOK},
+		{0, http.StatusTooManyRequests},
+		{500 * time.Millisecond, http.StatusTooManyRequests},
+		{500 * time.Millisecond, http.StatusOK},
+	} {
+		now = now.Add(test.elapsed)
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+		if rr.Code != test.expected {
+			t.Errorf("request %d returned wrong status code: got %v want %v", i, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestServerIDGenerator(t *testing.T) {
+	server := newTestServer()
+	server.IDGenerator = SequentialIDGenerator("user-%d")
+
+	for _, expected := range []string{"user-1", "user-2"} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{"userName": "test"}`)))
+		if !strings.Contains(rr.Body.String(), `"id":"`+expected+`"`) {
+			t.Errorf("expected identifier %q, got %s", expected, rr.Body.String())
+		}
+	}
+}
+
+func TestRandomIDGenerator(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	generator := IDGeneratorFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	if a, b := generator.NewID(), generator.NewID(); !uuid.MatchString(a) || a == b {
+		t.Errorf("expected distinct random UUIDs, got %q and %q", a, b)
+	}
+}
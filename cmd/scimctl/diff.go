@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/client"
+)
+
+// errDifferences is returned by the diff command if the file and the endpoint differ.
+var errDifferences = errors.New("differences found")
+
+// diffCommand compares the resources of a file with the resources of an endpoint. Resources are paired by the match
+// attribute. Resources that only exist in the file are prefixed with "+", resources that only exist at the endpoint
+// with "-" and resources that differ with "~", followed by their changed attributes.
+func diffCommand(ctx context.Context, c client.Client, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	endpoint := flags.String("endpoint", "", "endpoint of the resources, e.g. /Users")
+	match := flags.String("match", "id", "attribute that pairs the resources, e.g. userName or externalId")
+	filter := flags.String("filter", "", "filter of the resources at the endpoint to compare, e.g. 'active eq true'")
+	input := flags.String("i", "-", "file to read from, - for the standard input")
+	if err := parseCommandFlags(flags, args, endpoint); err != nil {
+		return err
+	}
+
+	resources, err := readResources(*input, stdin)
+	if err != nil {
+		return err
+	}
+	local, err := indexResources(resources, *match)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *input, err)
+	}
+
+	var remoteResources []scim.ResourceAttributes
+	it := c.ListIterator(*endpoint, client.ListParams{Filter: *filter})
+	for it.Next(ctx) {
+		remoteResources = append(remoteResources, it.Resource())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	remote, err := indexResources(remoteResources, *match)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *endpoint, err)
+	}
+
+	keys := make([]string, 0, len(local)+len(remote))
+	for key := range local {
+		keys = append(keys, key)
+	}
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var differences int
+	for _, key := range keys {
+		l, inFile := local[key]
+		r, atEndpoint := remote[key]
+		switch {
+		case !atEndpoint:
+			fmt.Fprintf(stdout, "+ %s\n", key)
+		case !inFile:
+			fmt.Fprintf(stdout, "- %s\n", key)
+		default:
+			changes := scim.DiffResources(comparedAttributes(r), comparedAttributes(l))
+			if len(changes) == 0 {
+				continue
+			}
+			fmt.Fprintf(stdout, "~ %s\n", key)
+			for _, change := range changes {
+				fmt.Fprintf(stdout, "    %s: %s -> %s\n", change.Path, formatValue(change.Before), formatValue(change.After))
+			}
+		}
+		differences++
+	}
+
+	if differences != 0 {
+		return errDifferences
+	}
+	return nil
+}
+
+// indexResources indexes given resources by the value of the match attribute.
+func indexResources(resources []scim.ResourceAttributes, match string) (map[string]scim.ResourceAttributes, error) {
+	index := make(map[string]scim.ResourceAttributes, len(resources))
+	for i, resource := range resources {
+		key := stringValue(resource, match)
+		if key == "" {
+			return nil, fmt.Errorf("resource %d: missing %q attribute", i+1, match)
+		}
+		if _, ok := index[key]; ok {
+			return nil, fmt.Errorf("multiple resources with %s %q", match, key)
+		}
+		index[key] = resource
+	}
+	return index, nil
+}
+
+// comparedAttributes returns the attributes of given resource that are compared, leaving out the attributes that are
+// assigned by the service provider and the schemas.
+func comparedAttributes(resource scim.ResourceAttributes) scim.ResourceAttributes {
+	attributes := payload(resource)
+	for k := range attributes {
+		if strings.EqualFold(k, "schemas") {
+			delete(attributes, k)
+		}
+	}
+	return attributes
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
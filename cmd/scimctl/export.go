@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elimity-com/scim/client"
+)
+
+// exportCommand writes the resources of an endpoint to a file.
+func exportCommand(ctx context.Context, c client.Client, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	endpoint := flags.String("endpoint", "", "endpoint of the resources, e.g. /Users")
+	filter := flags.String("filter", "", "filter of the resources to export, e.g. 'active eq true'")
+	format := flags.String("format", formatJSON, "format of the file, json or ndjson")
+	output := flags.String("o", "-", "file to write to, - for the standard output")
+	if err := parseCommandFlags(flags, args, endpoint); err != nil {
+		return err
+	}
+	if *format != formatJSON && *format != formatNDJSON {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	w := stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		w = f
+	}
+
+	writer := &resourceWriter{w: w, format: *format}
+	it := c.ListIterator(*endpoint, client.ListParams{Filter: *filter})
+	for it.Next(ctx) {
+		if err := writer.write(it.Resource()); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if err := writer.close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "exported %d resources\n", writer.count)
+	return nil
+}
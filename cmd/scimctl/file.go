@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elimity-com/scim"
+)
+
+// errUsage is returned by commands that are called with invalid flags, after printing their usage.
+var errUsage = errors.New("invalid usage")
+
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// readResources reads the resources in given file, which contains either a JSON array of resources or NDJSON. The file
+// "-" is the standard input.
+func readResources(path string, stdin io.Reader) ([]scim.ResourceAttributes, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	decoder := json.NewDecoder(br)
+	if first, err := peekNonSpace(br); err == nil && first == '[' {
+		var resources []scim.ResourceAttributes
+		if err := decoder.Decode(&resources); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return resources, nil
+	}
+
+	var resources []scim.ResourceAttributes
+	for {
+		var resource scim.ResourceAttributes
+		err := decoder.Decode(&resource)
+		if err == io.EOF {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: resource %d: %v", path, len(resources)+1, err)
+		}
+		resources = append(resources, resource)
+	}
+}
+
+// peekNonSpace returns the first byte of given reader that is not white space, without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// resourceWriter writes resources in the JSON or NDJSON format.
+type resourceWriter struct {
+	w      io.Writer
+	format string
+	count  int
+}
+
+func (w *resourceWriter) write(resource scim.ResourceAttributes) error {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	switch {
+	case w.format == formatNDJSON:
+		raw = append(raw, '\n')
+	case w.count == 0:
+		raw = append([]byte("[\n"), raw...)
+	default:
+		raw = append([]byte(",\n"), raw...)
+	}
+	w.count++
+	_, err = w.w.Write(raw)
+	return err
+}
+
+func (w *resourceWriter) close() error {
+	if w.format == formatNDJSON {
+		return nil
+	}
+	closing := "\n]\n"
+	if w.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(w.w, closing)
+	return err
+}
+
+// parseCommandFlags parses the flags of a command and checks that the endpoint is set.
+func parseCommandFlags(flags *flag.FlagSet, args []string, endpoint *string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if *endpoint == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
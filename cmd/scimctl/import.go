@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/client"
+)
+
+// importCommand creates the resources of a file at an endpoint, or replaces them if they already exist.
+func importCommand(ctx context.Context, c client.Client, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	endpoint := flags.String("endpoint", "", "endpoint of the resources, e.g. /Users")
+	match := flags.String("match", "id", "attribute that identifies existing resources, e.g. userName or externalId")
+	input := flags.String("i", "-", "file to read from, - for the standard input")
+	if err := parseCommandFlags(flags, args, endpoint); err != nil {
+		return err
+	}
+
+	resources, err := readResources(*input, stdin)
+	if err != nil {
+		return err
+	}
+
+	var created, replaced, failed int
+	for i, resource := range resources {
+		existing, err := findExisting(ctx, c, *endpoint, *match, resource)
+		switch {
+		case err != nil:
+		case existing == nil:
+			if _, err = c.Create(ctx, *endpoint, payload(resource)); err == nil {
+				created++
+			}
+		default:
+			if _, err = c.Replace(ctx, *endpoint, stringValue(existing, "id"), payload(resource)); err == nil {
+				replaced++
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "resource %d: %v\n", i+1, err)
+			failed++
+		}
+	}
+
+	fmt.Fprintf(stdout, "created %d, replaced %d, failed %d\n", created, replaced, failed)
+	if failed != 0 {
+		return fmt.Errorf("%d of %d resources failed", failed, len(resources))
+	}
+	return nil
+}
+
+// findExisting returns the resource at the endpoint that has the same value for the match attribute as given resource,
+// or nil if there is none.
+func findExisting(ctx context.Context, c client.Client, endpoint, match string, resource scim.ResourceAttributes) (scim.ResourceAttributes, error) {
+	value := stringValue(resource, match)
+	if value == "" {
+		if strings.EqualFold(match, "id") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("missing %q attribute", match)
+	}
+
+	if strings.EqualFold(match, "id") {
+		existing, err := c.Get(ctx, endpoint, value)
+		var scimErr *client.Error
+		if errors.As(err, &scimErr) && scimErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return existing, err
+	}
+
+	list, err := c.List(ctx, endpoint, client.ListParams{
+		Count:  2,
+		Filter: fmt.Sprintf("%s eq %q", match, value),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case list.TotalResults > 1 || len(list.Resources) > 1:
+		return nil, fmt.Errorf("multiple resources with %s %q", match, value)
+	case len(list.Resources) == 0:
+		return nil, nil
+	default:
+		return list.Resources[0], nil
+	}
+}
+
+// payload returns the attributes of given resource without the attributes that are assigned by the service provider.
+func payload(resource scim.ResourceAttributes) scim.ResourceAttributes {
+	attributes := make(scim.ResourceAttributes, len(resource))
+	for k, v := range resource {
+		switch strings.ToLower(k) {
+		case "id", "meta":
+			continue
+		}
+		attributes[k] = v
+	}
+	return attributes
+}
+
+// stringValue returns the string value of the (top-level) attribute with given name, compared case-insensitively.
+func stringValue(resource scim.ResourceAttributes, name string) string {
+	for k, v := range resource {
+		if strings.EqualFold(k, name) {
+			s, _ := v.(string)
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+// Command scimctl exports, imports and compares the resources of a SCIM service provider, e.g. to migrate users and
+// groups between identity systems.
+//
+//	scimctl -url https://example.com/scim/v2 export -endpoint /Users -format ndjson -o users.ndjson
+//	scimctl -url https://example.com/scim/v2 import -endpoint /Users -match userName -i users.ndjson
+//	scimctl -url https://example.com/scim/v2 diff -endpoint /Users -match userName -i users.ndjson
+//
+// Files contain either a JSON array of resources or newline-delimited JSON (NDJSON) with one resource per line. The
+// bearer token to authenticate with is read from the SCIM_TOKEN environment variable, unless the -token flag is set.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/elimity-com/scim/client"
+)
+
+const usage = `Usage: scimctl [flags] <command> [command flags]
+
+Commands:
+  export    write the resources of an endpoint to a file
+  import    create or update the resources of a file at an endpoint
+  diff      compare the resources of a file with the resources of an endpoint
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with given arguments and returns its exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scimctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	baseURL := flags.String("url", "", "base URL of the service provider, e.g. https://example.com/scim/v2")
+	token := flags.String("token", os.Getenv("SCIM_TOKEN"), "bearer token (defaults to $SCIM_TOKEN)")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *baseURL == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	httpClient := client.NewHTTPClient(client.TransportOptions{})
+	if *token != "" {
+		httpClient.Transport = bearerTransport{token: *token, next: httpClient.Transport}
+	}
+	c := client.Client{BaseURL: *baseURL, HTTPClient: httpClient}
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	var err error
+	switch command {
+	case "export":
+		err = exportCommand(ctx, c, commandArgs, stdout, stderr)
+	case "import":
+		err = importCommand(ctx, c, commandArgs, stdin, stdout, stderr)
+	case "diff":
+		err = diffCommand(ctx, c, commandArgs, stdin, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "scimctl: unknown command %q\n", command)
+		flags.Usage()
+		return 2
+	}
+
+	switch err {
+	case nil:
+		return 0
+	case errUsage:
+		return 2
+	case errDifferences:
+		return 1
+	default:
+		fmt.Fprintf(stderr, "scimctl %s: %v\n", command, err)
+		return 1
+	}
+}
+
+// bearerTransport adds a bearer token to every request.
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(r)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/examples/memstore"
+	"github.com/elimity-com/scim/schema"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(scim.Server{
+		Config: scim.ServiceProviderConfig{
+			Features: scim.Features{Filter: scim.FilterFeature{Supported: true}},
+		},
+		ResourceTypes: []scim.ResourceType{{
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   schema.CoreUserSchema(),
+			Handler:  scim.ContextHandler(memstore.New(schema.CoreUserSchema())),
+		}},
+	})
+}
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	if code == 2 {
+		t.Logf("%s", stderr.String())
+	}
+	return code, stdout.String()
+}
+
+func TestImportExportDiff(t *testing.T) {
+	source, target := newTestServer(), newTestServer()
+	defer source.Close()
+	defer target.Close()
+	users := `{"userName": "bjensen", "displayName": "Babs Jensen"}
+{"userName": "jsmith", "displayName": "John Smith"}`
+	if code, out := runCommand(t, users, "-url", source.URL, "import", "-endpoint", "/Users"); code != 0 || out != "created 2, replaced 0, failed 0\n" {
+		t.Fatalf("unexpected import result %d: %s", code, out)
+	}
+
+	dir, err := ioutil.TempDir("", "scimctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "users.json")
+	if code, _ := runCommand(t, "", "-url", source.URL, "export", "-endpoint", "/Users", "-o", file); code != 0 {
+		t.Fatalf("unexpected export exit code %d", code)
+	}
+	exported, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(exported, []byte("[\n")) || !bytes.Contains(exported, []byte(`"userName":"jsmith"`)) {
+		t.Fatalf("unexpected export: %s", exported)
+	}
+
+	// Import the exported users in the target twice, the second import replaces the users created by the first one.
+	for _, expected := range []string{"created 2, replaced 0, failed 0\n", "created 0, replaced 2, failed 0\n"} {
+		code, out := runCommand(t, "", "-url", target.URL, "import", "-endpoint", "/Users", "-match", "userName", "-i", file)
+		if code != 0 || out != expected {
+			t.Fatalf("unexpected import result %d: %s", code, out)
+		}
+	}
+	if code, out := runCommand(t, "", "-url", target.URL, "diff", "-endpoint", "/Users", "-match", "userName", "-i", file); code != 0 || out != "" {
+		t.Errorf("expected no differences, got %d: %s", code, out)
+	}
+
+	changed := `{"userName": "bjensen", "displayName": "Barbara Jensen"}
+{"userName": "mdoe", "displayName": "Mary Doe"}`
+	code, out := runCommand(t, changed, "-url", target.URL, "diff", "-endpoint", "/Users", "-match", "userName", "-format", "ndjson")
+	if code != 2 {
+		t.Errorf("expected a usage error for an unknown flag, got %d", code)
+	}
+	code, out = runCommand(t, changed, "-url", target.URL, "diff", "-endpoint", "/Users", "-match", "userName")
+	expected := `~ bjensen
+    displayName: "Babs Jensen" -> "Barbara Jensen"
+- jsmith
++ mdoe
+`
+	if code != 1 || out != expected {
+		t.Errorf("unexpected diff %d:\n%s", code, out)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"totalResults": 0, "Resources": []}`))
+	}))
+	defer server.Close()
+
+	if code, out := runCommand(t, "", "-url", server.URL, "-token", "secret", "export", "-endpoint", "/Users", "-format", "ndjson"); code != 0 || out != "" {
+		t.Errorf("unexpected export result %d: %s", code, out)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("expected the bearer token to be sent, got %q", authorization)
+	}
+}
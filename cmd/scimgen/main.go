@@ -0,0 +1,119 @@
+// Command scimgen generates Go types from SCIM schemas, e.g. with go generate:
+//
+//	//go:generate go run github.com/elimity-com/scim/cmd/scimgen -package models -core user,group -o core_gen.go
+//	//go:generate go run github.com/elimity-com/scim/cmd/scimgen -package models -o device_gen.go device.json
+//
+// Input files contain the JSON representation of a single schema, an array of schemas or a list response of the
+// "/Schemas" endpoint. The core schemas of the schema package are generated with the -core flag instead, in a separate
+// file. See package codegen for the generated code.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/elimity-com/scim/codegen"
+	"github.com/elimity-com/scim/schema"
+)
+
+const usage = `Usage: scimgen [flags] [schema.json ...]
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with given arguments and returns its exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scimgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pkg := flags.String("package", "models", "name of the package of the generated code")
+	out := flags.String("o", "-", "file to write the generated code to (defaults to the standard output)")
+	core := flags.String("core", "", "comma-separated core schemas to generate types for: user, group")
+	typeNames := typeNamesFlag{}
+	flags.Var(typeNames, "type", "type name of a schema as id=Name, e.g. urn:example:Device=Device (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *core == "" && flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	opts := codegen.Options{Package: *pkg, TypeNames: typeNames, Generator: "scimgen"}
+	source, err := generate(opts, *core, flags.Args())
+	if err == nil {
+		err = write(*out, source, stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "scimgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// generate returns the generated code of given core schemas or the schemas in given files.
+func generate(opts codegen.Options, core string, paths []string) ([]byte, error) {
+	if core != "" {
+		if len(paths) != 0 {
+			return nil, fmt.Errorf("core schemas cannot be combined with schema files, generate them in separate files")
+		}
+		var schemas []schema.Schema
+		for _, name := range strings.Split(core, ",") {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "user":
+				schemas = append(schemas, schema.CoreUserSchema())
+			case "group":
+				schemas = append(schemas, schema.CoreGroupSchema())
+			default:
+				return nil, fmt.Errorf("unknown core schema %q", name)
+			}
+		}
+		return codegen.Generate(opts, schemas...)
+	}
+
+	documents := make([][]byte, len(paths))
+	for i, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		documents[i] = data
+	}
+	return codegen.GenerateFromJSON(opts, documents...)
+}
+
+// write writes given source code to given file. The file "-" is the standard output.
+func write(path string, source []byte, stdout io.Writer) error {
+	if path == "-" {
+		_, err := stdout.Write(source)
+		return err
+	}
+	return ioutil.WriteFile(path, source, 0644)
+}
+
+// typeNamesFlag collects the type names of schemas, keyed by the IDs of the schemas.
+type typeNamesFlag map[string]string
+
+func (f typeNamesFlag) String() string {
+	return ""
+}
+
+func (f typeNamesFlag) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("expected id=Name, got %q", value)
+	}
+	f[value[:i]] = value[i+1:]
+	return nil
+}
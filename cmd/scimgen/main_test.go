@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scimgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "device.json")
+	device := `{"id": "urn:example:params:scim:schemas:Device", "name": "Device", "attributes": [
+		{"name": "serialNumber", "type": "string", "required": true}
+	]}`
+	if err := ioutil.WriteFile(in, []byte(device), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "device_gen.go")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-package", "devices", "-type", "urn:example:params:scim:schemas:Device=Gadget", "-o", out, in}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	source, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snippet := range []string{"package devices", "type Gadget struct", "func GadgetFromAttributes"} {
+		if !strings.Contains(string(source), snippet) {
+			t.Errorf("expected %q in:\n%s", snippet, source)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-core", "user,group"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "type Group struct") {
+		t.Errorf("expected the core schemas on the standard output:\n%s", stdout.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		code int
+	}{
+		{nil, 2},
+		{[]string{"-type", "Device", "device.json"}, 2},
+		{[]string{"-core", "device"}, 1},
+		{[]string{"-core", "user", "device.json"}, 1},
+		{[]string{"missing.json"}, 1},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(test.args, &stdout, &stderr); code != test.code {
+			t.Errorf("expected exit code %d for %v, got %d: %s", test.code, test.args, code, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,369 @@
+// Package codegen generates Go types from SCIM schemas, so that handlers can work with typed models that are kept in
+// sync with the schemas of the server. For every schema it generates a struct whose fields are tagged for
+// scim.MarshalAttributes and scim.UnmarshalAttributes, nested structs for its complex attributes and functions to
+// convert the struct from and to resource attributes, e.g. for the core user schema:
+//
+//	type User struct {
+//		UserName string       `scim:"userName"`
+//		Name     *UserName    `scim:"name"`
+//		Emails   []UserEmail  `scim:"emails"`
+//		...
+//	}
+//
+//	func UserFromAttributes(attributes scim.ResourceAttributes) (User, error)
+//	func (v User) Attributes() (scim.ResourceAttributes, error)
+//
+// The scimgen command wraps the generator for use with go generate.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// Options configure the generated code.
+type Options struct {
+	// Package is the name of the package of the generated code. It defaults to "models".
+	Package string
+	// TypeNames maps the IDs of schemas onto the names of their generated types. By default, a type is named after the
+	// name of its schema, e.g. "User" or "EnterpriseUser".
+	TypeNames map[string]string
+	// Generator is the name of the generator in the header of the generated code. It defaults to "codegen".
+	Generator string
+}
+
+// Generate returns the formatted Go source code of the types of given schemas.
+func Generate(opts Options, schemas ...schema.Schema) ([]byte, error) {
+	definitions := make([]schemaDefinition, len(schemas))
+	for i, s := range schemas {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		var raw struct {
+			Attributes []attributeDefinition
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		definitions[i] = schemaDefinition{
+			ID:          s.ID,
+			Name:        s.Name.Value(),
+			Description: s.Description.Value(),
+			Attributes:  raw.Attributes,
+		}
+	}
+	return generate(opts, definitions)
+}
+
+// GenerateFromJSON is the counterpart of Generate for schemas in their JSON representation, i.e. as returned by the
+// "/Schemas" endpoint of a service provider. Every given document contains either a single schema, an array of schemas
+// or a list response of schemas. The types of all schemas are generated in a single file.
+func GenerateFromJSON(opts Options, documents ...[]byte) ([]byte, error) {
+	var definitions []schemaDefinition
+	for i, data := range documents {
+		d, err := parseDefinitions(data)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		definitions = append(definitions, d...)
+	}
+	return generate(opts, definitions)
+}
+
+// parseDefinitions parses the schemas in given document.
+func parseDefinitions(data []byte) ([]schemaDefinition, error) {
+	var list struct {
+		Resources []schemaDefinition
+	}
+	if err := json.Unmarshal(data, &list); err == nil && len(list.Resources) != 0 {
+		return list.Resources, nil
+	}
+	var definitions []schemaDefinition
+	if err := json.Unmarshal(data, &definitions); err == nil {
+		return definitions, nil
+	}
+	var definition schemaDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return []schemaDefinition{definition}, nil
+}
+
+// schemaDefinition is the JSON representation of a schema.
+type schemaDefinition struct {
+	ID          string
+	Name        string
+	Description string
+	Attributes  []attributeDefinition
+}
+
+// attributeDefinition is the JSON representation of an attribute.
+type attributeDefinition struct {
+	Name          string
+	Type          string
+	Description   string
+	MultiValued   bool
+	Required      bool
+	SubAttributes []attributeDefinition
+}
+
+// generator collects the declarations of the generated code.
+type generator struct {
+	buf   bytes.Buffer
+	types map[string]bool
+	time  bool
+}
+
+func generate(opts Options, definitions []schemaDefinition) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+	if opts.Generator == "" {
+		opts.Generator = "codegen"
+	}
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("no schemas to generate types for")
+	}
+
+	g := generator{types: make(map[string]bool)}
+	for _, definition := range definitions {
+		if definition.ID == "" {
+			return nil, fmt.Errorf("schema %q has no id", definition.Name)
+		}
+		name := opts.TypeNames[definition.ID]
+		if name == "" {
+			name = identifier(definition.Name)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("schema %q has no name to derive a type name from", definition.ID)
+		}
+		if err := g.schema(name, definition); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by %s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", opts.Generator, opts.Package)
+	if g.time {
+		out.WriteString("\"time\"\n\n")
+	}
+	out.WriteString("\"github.com/elimity-com/scim\"\n)\n")
+	out.Write(g.buf.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// schema generates the type of given schema and its conversion functions.
+func (g *generator) schema(name string, definition schemaDefinition) error {
+	fmt.Fprintf(&g.buf, "\n// %sSchemaID is the ID of the schema of %s.\nconst %sSchemaID = %q\n", name, name, name, definition.ID)
+	doc := fmt.Sprintf("%s is a resource of schema %q.", name, definition.ID)
+	if definition.Description != "" && definition.Description != definition.Name {
+		doc += " " + definition.Description
+	}
+	if err := g.complex(name, doc, definition.Attributes); err != nil {
+		return fmt.Errorf("schema %q: %v", definition.ID, err)
+	}
+	fmt.Fprintf(&g.buf, `
+// %[1]sFromAttributes decodes given resource attributes into a %[1]s.
+func %[1]sFromAttributes(attributes scim.ResourceAttributes) (%[1]s, error) {
+	var v %[1]s
+	err := scim.UnmarshalAttributes(attributes, &v)
+	return v, err
+}
+
+// Attributes encodes the %[1]s into resource attributes.
+func (v %[1]s) Attributes() (scim.ResourceAttributes, error) {
+	return scim.MarshalAttributes(v)
+}
+`, name)
+	return nil
+}
+
+// complex generates a struct type with given name for given attributes, followed by the types of its complex
+// attributes.
+func (g *generator) complex(name, doc string, attributes []attributeDefinition) error {
+	if g.types[name] {
+		return fmt.Errorf("type %s is generated more than once", name)
+	}
+	g.types[name] = true
+
+	type nested struct {
+		name       string
+		doc        string
+		attributes []attributeDefinition
+	}
+	var (
+		fields  bytes.Buffer
+		nesteds []nested
+		names   = make(map[string]string)
+	)
+	for _, attribute := range attributes {
+		field := identifier(attribute.Name)
+		if field == "" {
+			return fmt.Errorf("attribute %q has no valid Go identifier", attribute.Name)
+		}
+		if other, ok := names[field]; ok {
+			return fmt.Errorf("attributes %q and %q map onto the same field %s", other, attribute.Name, field)
+		}
+		names[field] = attribute.Name
+
+		var typ string
+		if attribute.Type == "complex" {
+			typ = name + field
+			if attribute.MultiValued {
+				typ = name + singular(field)
+			}
+			nesteds = append(nesteds, nested{
+				name:       typ,
+				doc:        fmt.Sprintf("%s is the value of attribute %q of %s.", typ, attribute.Name, name),
+				attributes: attribute.SubAttributes,
+			})
+		} else {
+			var err error
+			if typ, err = g.simpleType(attribute.Type); err != nil {
+				return fmt.Errorf("attribute %q: %v", attribute.Name, err)
+			}
+		}
+
+		tag := attribute.Name
+		switch {
+		case attribute.MultiValued:
+			typ = "[]" + typ
+		case attribute.Required || typ == "[]byte":
+		case typ == "string":
+			tag += ",omitempty"
+		default:
+			typ = "*" + typ
+		}
+
+		if attribute.Description != "" {
+			fields.WriteString(comment(attribute.Description))
+		}
+		fmt.Fprintf(&fields, "%s %s `scim:%q`\n", field, typ, tag)
+	}
+
+	fmt.Fprintf(&g.buf, "\n%stype %s struct {\n%s}\n", comment(doc), name, fields.String())
+	for _, n := range nesteds {
+		if err := g.complex(n.name, n.doc, n.attributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simpleType returns the Go type of the values of given SCIM data type.
+func (g *generator) simpleType(typ string) (string, error) {
+	switch typ {
+	case "string", "reference":
+		return "string", nil
+	case "binary":
+		return "[]byte", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		return "int", nil
+	case "decimal":
+		return "float64", nil
+	case "dateTime":
+		g.time = true
+		return "time.Time", nil
+	default:
+		return "", fmt.Errorf("unsupported data type %q", typ)
+	}
+}
+
+// initialisms are the words that are written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "URI": true,
+	"URL": true, "URN": true, "UTF8": true, "XML": true,
+}
+
+// identifier converts given attribute or schema name into an exported Go identifier, e.g. "externalId" into
+// "ExternalID" and "$ref" into "Ref".
+func identifier(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) != 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(word) != 0 && !unicode.IsUpper(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	id := b.String()
+	if id != "" && !unicode.IsLetter([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// singular returns the singular form of given plural identifier, e.g. "Email" for "Emails" and "Address" for
+// "Addresses". Identifiers that do not look plural are returned as is.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return strings.TrimSuffix(name, "s")
+	default:
+		return name
+	}
+}
+
+// commentWidth is the maximum width of the text of the lines of generated comments.
+const commentWidth = 100
+
+// comment formats given description as a comment, wrapped at commentWidth.
+func comment(description string) string {
+	var b strings.Builder
+	var line []string
+	width := 0
+	flush := func() {
+		fmt.Fprintf(&b, "// %s\n", strings.Join(line, " "))
+		line, width = nil, 0
+	}
+	for _, word := range strings.Fields(description) {
+		if width != 0 && width+1+len(word) > commentWidth {
+			flush()
+		}
+		if width != 0 {
+			width++
+		}
+		line = append(line, word)
+		width += len(word)
+	}
+	if len(line) != 0 {
+		flush()
+	}
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// declarations returns the names of the top-level declarations of given source code.
+func declarations(t *testing.T, source []byte) map[string]bool {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "generated.go", source, 0)
+	if err != nil {
+		t.Fatalf("invalid generated code: %v\n%s", err, source)
+	}
+	names := make(map[string]bool)
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			names[d.Name.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names[s.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names[n.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestGenerate(t *testing.T) {
+	source, err := Generate(Options{Package: "users"}, schema.CoreUserSchema(), schema.CoreGroupSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(source), "// Code generated by codegen. DO NOT EDIT.\n\npackage users\n") {
+		t.Errorf("unexpected header:\n%s", source)
+	}
+	names := declarations(t, source)
+	for _, name := range []string{
+		"User", "UserSchemaID", "UserFromAttributes", "Attributes", "UserName", "UserEmail", "UserAddress",
+		"UserX509Certificate", "Group", "GroupMember", "GroupFromAttributes",
+	} {
+		if !names[name] {
+			t.Errorf("expected a declaration of %s", name)
+		}
+	}
+	for _, field := range []string{
+		"UserName string `scim:\"userName\"`",
+		"Name *UserName `scim:\"name\"`",
+		"DisplayName string `scim:\"displayName,omitempty\"`",
+		"Active *bool `scim:\"active\"`",
+		"Emails []UserEmail `scim:\"emails\"`",
+		"ProfileURL string `scim:\"profileUrl,omitempty\"`",
+		"Ref string `scim:\"$ref,omitempty\"`",
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(string(source)), " "), field) {
+			t.Errorf("expected field %s", field)
+		}
+	}
+}
+
+func TestGenerateFromJSON(t *testing.T) {
+	device := []byte(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"],
+		"Resources": [{
+			"id": "urn:example:params:scim:schemas:Device",
+			"name": "Device",
+			"attributes": [
+				{"name": "serialNumber", "type": "string", "required": true},
+				{"name": "lastSeen", "type": "dateTime"},
+				{"name": "cores", "type": "integer"},
+				{"name": "firmware", "type": "binary"},
+				{"name": "owners", "type": "complex", "multiValued": true, "subAttributes": [
+					{"name": "value", "type": "string"}
+				]}
+			]
+		}]
+	}`)
+	sensor := []byte(`{"id": "urn:example:params:scim:schemas:Sensor", "name": "Sensor", "attributes": []}`)
+	source, err := GenerateFromJSON(Options{
+		TypeNames: map[string]string{"urn:example:params:scim:schemas:Sensor": "Probe"},
+	}, device, sensor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := declarations(t, source)
+	for _, name := range []string{"Device", "DeviceOwner", "Probe", "ProbeFromAttributes"} {
+		if !names[name] {
+			t.Errorf("expected a declaration of %s", name)
+		}
+	}
+	flat := strings.Join(strings.Fields(string(source)), " ")
+	for _, snippet := range []string{
+		`import ( "time" "github.com/elimity-com/scim" )`,
+		"SerialNumber string `scim:\"serialNumber\"`",
+		"LastSeen *time.Time `scim:\"lastSeen\"`",
+		"Cores *int `scim:\"cores\"`",
+		"Firmware []byte `scim:\"firmware\"`",
+		"Owners []DeviceOwner `scim:\"owners\"`",
+	} {
+		if !strings.Contains(flat, snippet) {
+			t.Errorf("expected %s in:\n%s", snippet, source)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{"invalid", `"User"`, "invalid schema"},
+		{"no id", `{"name": "User"}`, "no id"},
+		{"data type", `{"id": "urn:x", "name": "X", "attributes": [{"name": "a", "type": "float"}]}`, "unsupported data type"},
+		{"duplicate field", `{"id": "urn:x", "name": "X", "attributes": [
+			{"name": "userName", "type": "string"}, {"name": "user_name", "type": "string"}
+		]}`, "same field"},
+		{"duplicate type", `[{"id": "urn:x", "name": "X"}, {"id": "urn:y", "name": "X"}]`, "more than once"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := GenerateFromJSON(Options{}, []byte(test.document))
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error containing %q, got: %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	for name, expected := range map[string]string{
+		"userName":         "UserName",
+		"externalId":       "ExternalID",
+		"$ref":             "Ref",
+		"x509Certificates": "X509Certificates",
+		"Enterprise User":  "EnterpriseUser",
+		"2fa":              "X2fa",
+	} {
+		if id := identifier(name); id != expected {
+			t.Errorf("expected identifier %s for %q, got %s", expected, name, id)
+		}
+	}
+}
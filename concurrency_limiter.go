@@ -0,0 +1,122 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultConcurrencyRetryAfter = time.Second
+
+// ConcurrencyLimiter limits the number of requests of a resource type that are handled at the same time, e.g. to
+// protect a fragile backend such as a legacy HR API against the parallel synchronization workers of an identity
+// provider. Requests that exceed a limit are not queued, but rejected immediately with a "503 Service Unavailable"
+// response that includes a "Retry-After" header. The operations of a bulk request are limited one by one.
+//
+// A concurrency limiter keeps track of the requests in flight, so every resource type needs its own limiter.
+type ConcurrencyLimiter struct {
+	// MaxInFlight is the maximum number of requests of the resource type that are handled at the same time. Zero
+	// means that there is no limit.
+	MaxInFlight int
+	// MaxInFlightByOperation are the maximum numbers of requests that are handled at the same time by operation, e.g.
+	// a limit for OperationWrite only. Operations without a limit are only limited by MaxInFlight.
+	MaxInFlightByOperation map[Operation]int
+	// RetryAfter is the delay suggested to clients whose requests are rejected. It defaults to one second.
+	RetryAfter time.Duration
+
+	once       sync.Once
+	total      chan struct{}
+	operations map[Operation]chan struct{}
+	inFlight   int64
+	rejected   uint64
+}
+
+// ConcurrencyStats contains the counters of a concurrency limiter, which can be used to tune its limits.
+type ConcurrencyStats struct {
+	// InFlight is the number of requests that are being handled.
+	InFlight int
+	// Rejected is the number of requests that were rejected because a limit was reached.
+	Rejected uint64
+}
+
+// Stats returns a snapshot of the counters of the concurrency limiter.
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: int(atomic.LoadInt64(&l.inFlight)),
+		Rejected: atomic.LoadUint64(&l.rejected),
+	}
+}
+
+func (l *ConcurrencyLimiter) init() {
+	l.once.Do(func() {
+		if l.MaxInFlight > 0 {
+			l.total = make(chan struct{}, l.MaxInFlight)
+		}
+		l.operations = make(map[Operation]chan struct{}, len(l.MaxInFlightByOperation))
+		for operation, limit := range l.MaxInFlightByOperation {
+			if limit > 0 {
+				l.operations[operation] = make(chan struct{}, limit)
+			}
+		}
+	})
+}
+
+// acquire reserves a slot for a request with given operation. If a limit is reached, it returns false and the delay
+// after which the request may be retried. Otherwise the returned function releases the slot.
+func (l *ConcurrencyLimiter) acquire(operation Operation) (func(), bool, time.Duration) {
+	l.init()
+	var acquired []chan struct{}
+	release := func() {
+		for _, semaphore := range acquired {
+			<-semaphore
+		}
+	}
+	for _, semaphore := range []chan struct{}{l.operations[operation], l.total} {
+		if semaphore == nil {
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}:
+			acquired = append(acquired, semaphore)
+		default:
+			release()
+			atomic.AddUint64(&l.rejected, 1)
+			return nil, false, l.getRetryAfter()
+		}
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	return func() {
+		atomic.AddInt64(&l.inFlight, -1)
+		release()
+	}, true, 0
+}
+
+func (l *ConcurrencyLimiter) getRetryAfter() time.Duration {
+	if l.RetryAfter <= 0 {
+		return defaultConcurrencyRetryAfter
+	}
+	return l.RetryAfter
+}
+
+// resourceOperation classifies the request to the resource endpoint with given path, in the same way serveResource
+// routes it. It returns false if the request is not routed to the resource type with given endpoint.
+func resourceOperation(r *http.Request, path, endpoint string) (Operation, bool) {
+	switch {
+	case (path == endpoint+"/.search" || path == endpoint+"/.getMany") && r.Method == http.MethodPost:
+		return OperationList, true
+	case path == endpoint && r.Method == http.MethodPost:
+		return OperationWrite, true
+	case path == endpoint && r.Method == http.MethodGet:
+		return OperationList, true
+	case strings.HasPrefix(path, endpoint+"/") && r.Method == http.MethodGet:
+		return OperationRead, true
+	case strings.HasPrefix(path, endpoint+"/"):
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			return OperationWrite, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// blockingResourceHandler blocks every retrieval until it is released.
+type blockingResourceHandler struct {
+	testResourceHandler
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (h blockingResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	h.entered <- struct{}{}
+	<-h.release
+	return h.testResourceHandler.Get(r, id)
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	server := newTestServer()
+	handler := blockingResourceHandler{
+		testResourceHandler: server.ResourceTypes[0].Handler.(testResourceHandler),
+		entered:             make(chan struct{}),
+		release:             make(chan struct{}),
+	}
+	limiter := &ConcurrencyLimiter{MaxInFlightByOperation: map[Operation]int{OperationRead: 1}}
+	server.ResourceTypes[0].Handler = handler
+	server.ResourceTypes[0].ConcurrencyLimiter = limiter
+
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+		done <- rr.Code
+	}()
+	<-handler.entered
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0002", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("unexpected Retry-After header: %q", retryAfter)
+	}
+
+	// Other operations are not limited.
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, rr.Code)
+	}
+	if stats := limiter.Stats(); stats.InFlight != 1 || stats.Rejected != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	handler.release <- struct{}{}
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, code)
+	}
+
+	go func() {
+		<-handler.entered
+		handler.release <- struct{}{}
+	}()
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0002", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected %d once the slot is released, got %d", http.StatusOK, rr.Code)
+	}
+	if stats := limiter.Stats(); stats.InFlight != 0 {
+		t.Errorf("expected no requests in flight, got %d", stats.InFlight)
+	}
+}
+
+func TestConcurrencyLimiterTotal(t *testing.T) {
+	limiter := &ConcurrencyLimiter{MaxInFlight: 2, MaxInFlightByOperation: map[Operation]int{OperationWrite: 1}}
+	releaseWrite, ok, _ := limiter.acquire(OperationWrite)
+	if !ok {
+		t.Fatal("expected the first write to be allowed")
+	}
+	if _, ok, _ := limiter.acquire(OperationWrite); ok {
+		t.Error("expected the second write to be rejected")
+	}
+	releaseRead, ok, _ := limiter.acquire(OperationRead)
+	if !ok {
+		t.Fatal("expected a read to be allowed")
+	}
+	if _, ok, _ := limiter.acquire(OperationList); ok {
+		t.Error("expected the total limit to reject a third request")
+	}
+	releaseWrite()
+	releaseRead()
+	if _, ok, _ := limiter.acquire(OperationWrite); !ok {
+		t.Error("expected a write to be allowed once the slots are released")
+	}
+}
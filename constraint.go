@@ -0,0 +1,68 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// checkConstraints checks given validated attributes against the constraints of the schema and schema extensions of
+// the resource type, see schema.Schema.Constraints.
+func (s Server) checkConstraints(r *http.Request, resourceType ResourceType, attributes ResourceAttributes) *scimError {
+	if validationSkipped(r) {
+		return nil
+	}
+	err := resourceType.Schema.CheckConstraints(attributes)
+	for _, extension := range resourceType.SchemaExtensions {
+		if err != nil {
+			break
+		}
+		extensionAttributes, _ := attributes[extension.Schema.ID].(map[string]interface{})
+		err = extension.Schema.CheckConstraints(extensionAttributes)
+	}
+	if err == nil {
+		return nil
+	}
+	return &scimError{
+		scimType: errors.ScimTypeInvalidValue,
+		detail:   fmt.Sprintf("The resource violates a constraint: %v.", err),
+		status:   http.StatusBadRequest,
+	}
+}
+
+// checkPatchedResource checks the resource with given identifier, as it would be after applying given validated PATCH
+// request, against the constraints of the schema and schema extensions of the resource type, and checks that its
+// immutable attributes are not changed, see checkImmutable. The resource is only retrieved if the resource type has
+// constraints or one of the operations targets an immutable attribute. Errors of retrieving the resource or applying
+// the operations are left to the Patch callback method.
+func (s Server) checkPatchedResource(r *http.Request, resourceType ResourceType, id string, patch PatchRequest) *scimError {
+	if validationSkipped(r) || !resourceType.hasConstraints() && !resourceType.patchesImmutable(patch) {
+		return nil
+	}
+	resource, getErr := resourceType.Handler.Get(r, id)
+	if getErr != errors.GetErrorNil {
+		return nil
+	}
+	attributes, err := resourceType.ApplyPatch(resource.Attributes, patch)
+	if err != nil {
+		return nil
+	}
+	if immutableErr := resourceType.checkImmutable(resource.Attributes, attributes, false); immutableErr != nil {
+		return immutableErr
+	}
+	return s.checkConstraints(r, resourceType, attributes)
+}
+
+// hasConstraints reports whether the schema or any of the schema extensions of the resource type has constraints.
+func (t ResourceType) hasConstraints() bool {
+	if len(t.Schema.Constraints) != 0 {
+		return true
+	}
+	for _, extension := range t.SchemaExtensions {
+		if len(extension.Schema.Constraints) != 0 {
+			return true
+		}
+	}
+	return false
+}
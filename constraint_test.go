@@ -0,0 +1,54 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestServerConstraints(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test", "displayName": "Test"}`, http.StatusCreated},
+		{http.MethodPost, "/Users", `{"userName": "test", "displayName": "Test", "Name": {"givenName": "Test"}}`, http.StatusBadRequest},
+		{http.MethodPut, "/Users/0001", `{"userName": "test", "active": true}`, http.StatusBadRequest},
+		{http.MethodPut, "/Users/0001", `{"userName": "test", "active": true, "displayName": "Test"}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", patchBody(`{"op": "replace", "path": "active", "value": true}`), http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", patchBody(`{"op": "add", "value": {"active": true, "displayName": "Test"}}`), http.StatusOK},
+		{http.MethodPatch, "/Users/0001", patchBody(
+			`{"op": "add", "path": "displayName", "value": "Test"}`,
+			`{"op": "add", "path": "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName", "value": "Test"}`,
+		), http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0002", patchBody(
+			`{"op": "add", "value": {"active": true, "displayName": "Test"}}`,
+			`{"op": "remove", "path": "displayName"}`,
+		), http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.ResourceTypes[0].Schema.Constraints = []schema.Constraint{
+			schema.MutuallyExclusive("displayName", "name.givenName"),
+			schema.RequiredIf("displayName", "active", true),
+		}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: handler returned wrong status code: got %v want %v", test.method, test.body, rr.Code, test.expected)
+		}
+		if rr.Code == http.StatusBadRequest && !strings.Contains(rr.Body.String(), "constraint") {
+			t.Errorf("expected the violated constraint in the error detail, got %s", rr.Body.String())
+		}
+	}
+}
+
+// patchBody returns the body of a PATCH request with given operations.
+func patchBody(operations ...string) string {
+	return `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [` + strings.Join(operations, ", ") + `]}`
+}
@@ -0,0 +1,114 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// ContextResourceHandler is the counterpart of ResourceHandler whose callback methods receive the context of the
+// request instead of the request itself, so that cancellation and deadlines can be propagated to databases and other
+// backends, and return Go errors instead of the error types of the errors package, see Error. The context carries
+// everything the server adds to the request, e.g. its clock (see ClockFromContext) and the classification of the
+// operation (see OperationFromContext). Use ContextHandler to assign it to a resource type.
+//
+// It is the preferred way to implement a resource handler: ResourceHandler and the error types of the errors package
+// are kept for compatibility.
+type ContextResourceHandler interface {
+	// Create stores given attributes and the optional external identifier that the client assigned to the resource.
+	// Returns a resource with the attributes that are stored and a (new) unique identifier.
+	Create(ctx context.Context, attributes ResourceAttributes, externalID optional.String) (Resource, error)
+	// Get returns the resource corresponding with the given identifier.
+	Get(ctx context.Context, id string) (Resource, error)
+	// GetAll returns a paginated list of resources.
+	GetAll(ctx context.Context, params ListRequestParams) (Page, error)
+	// Replace replaces ALL existing attributes of the resource with given identifier. Given attributes that are empty
+	// are to be deleted, as is the external identifier if it is not present. Returns a resource with the attributes that
+	// are stored.
+	Replace(ctx context.Context, id string, attributes ResourceAttributes, externalID optional.String) (Resource, error)
+	// Delete removes the resource with corresponding ID.
+	Delete(ctx context.Context, id string) error
+	// Patch update one or more attributes of a SCIM resource using a sequence of
+	// operations to "add", "remove", or "replace" values.
+	Patch(ctx context.Context, id string, request PatchRequest) (Resource, error)
+}
+
+// ContextManyGetter is the counterpart of ManyGetter for context resource handlers.
+type ContextManyGetter interface {
+	// GetMany returns the resources with given identifiers. Identifiers of resources that do not exist (or that the
+	// client is not allowed to access) are skipped.
+	GetMany(ctx context.Context, ids []string) ([]Resource, error)
+}
+
+// ContextHandler adapts given context resource handler to a resource handler, which can be assigned to the handler of
+// a resource type. The adapter forwards the optional interfaces that the given handler implements: Sorter,
+// ContextManyGetter (as ManyGetter) and VersionGetter.
+func ContextHandler(handler ContextResourceHandler) ResourceHandler {
+	adapter := contextHandler{handler: handler}
+	if getter, ok := handler.(VersionGetter); ok {
+		return versionedContextHandler{contextHandler: adapter, VersionGetter: getter}
+	}
+	return adapter
+}
+
+// contextHandler adapts a context resource handler to a resource handler.
+type contextHandler struct {
+	handler ContextResourceHandler
+}
+
+// versionedContextHandler adapts a context resource handler that implements VersionGetter. Unlike the other optional
+// interfaces, the server behaves differently for handlers that implement VersionGetter, so the adapter only implements
+// it if the adapted handler does.
+type versionedContextHandler struct {
+	contextHandler
+	VersionGetter
+}
+
+func (h contextHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	resource, err := h.handler.Create(r.Context(), attributes, externalID)
+	return resource, errors.PostError(scimErrorFromError(r.Context(), err))
+}
+
+func (h contextHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	resource, err := h.handler.Get(r.Context(), id)
+	return resource, errors.GetError(scimErrorFromError(r.Context(), err))
+}
+
+func (h contextHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	page, err := h.handler.GetAll(r.Context(), params)
+	return page, errors.GetError(scimErrorFromError(r.Context(), err))
+}
+
+func (h contextHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	resource, err := h.handler.Replace(r.Context(), id, attributes, externalID)
+	return resource, errors.PutError(scimErrorFromError(r.Context(), err))
+}
+
+func (h contextHandler) Delete(r *http.Request, id string) errors.DeleteError {
+	return errors.DeleteError(scimErrorFromError(r.Context(), h.handler.Delete(r.Context(), id)))
+}
+
+func (h contextHandler) Patch(r *http.Request, id string, request PatchRequest) (Resource, errors.PatchError) {
+	resource, err := h.handler.Patch(r.Context(), id, request)
+	return resource, errors.PatchError(scimErrorFromError(r.Context(), err))
+}
+
+// SupportsSort forwards to the adapted handler. Handlers that do not implement Sorter are assumed to sort.
+func (h contextHandler) SupportsSort() bool {
+	if sorter, ok := h.handler.(Sorter); ok {
+		return sorter.SupportsSort()
+	}
+	return true
+}
+
+// GetMany forwards to the adapted handler if it implements ContextManyGetter. Otherwise the resources are retrieved
+// one by one, as for handlers that do not implement ManyGetter.
+func (h contextHandler) GetMany(r *http.Request, ids []string) ([]Resource, errors.GetError) {
+	if getter, ok := h.handler.(ContextManyGetter); ok {
+		resources, err := getter.GetMany(r.Context(), ids)
+		return resources, errors.GetError(scimErrorFromError(r.Context(), err))
+	}
+	return getEach(r, h, ids)
+}
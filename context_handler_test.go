@@ -0,0 +1,171 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// contextResourceHandler records the contexts it receives and delegates to a test resource handler.
+type contextResourceHandler struct {
+	testResourceHandler
+	contexts *[]context.Context
+}
+
+func (h contextResourceHandler) request(ctx context.Context) *http.Request {
+	*h.contexts = append(*h.contexts, ctx)
+	return httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+}
+
+func (h contextResourceHandler) Create(ctx context.Context, attributes ResourceAttributes, externalID optional.String) (Resource, error) {
+	resource, err := h.testResourceHandler.Create(h.request(ctx), attributes, externalID)
+	return resource, toError(errors.ScimError(err))
+}
+
+func (h contextResourceHandler) Get(ctx context.Context, id string) (Resource, error) {
+	resource, err := h.testResourceHandler.Get(h.request(ctx), id)
+	return resource, toError(errors.ScimError(err))
+}
+
+func (h contextResourceHandler) GetAll(ctx context.Context, params ListRequestParams) (Page, error) {
+	page, err := h.testResourceHandler.GetAll(h.request(ctx), params)
+	return page, toError(errors.ScimError(err))
+}
+
+func (h contextResourceHandler) Replace(ctx context.Context, id string, attributes ResourceAttributes, externalID optional.String) (Resource, error) {
+	resource, err := h.testResourceHandler.Replace(h.request(ctx), id, attributes, externalID)
+	return resource, toError(errors.ScimError(err))
+}
+
+func (h contextResourceHandler) Delete(ctx context.Context, id string) error {
+	return toError(errors.ScimError(h.testResourceHandler.Delete(h.request(ctx), id)))
+}
+
+func (h contextResourceHandler) Patch(ctx context.Context, id string, req PatchRequest) (Resource, error) {
+	resource, err := h.testResourceHandler.Patch(h.request(ctx), id, req)
+	return resource, toError(errors.ScimError(err))
+}
+
+// toError converts given error of a resource handler to an error of a context resource handler.
+func toError(err errors.ScimError) error {
+	if err == (errors.ScimError{}) {
+		return nil
+	}
+	return &Error{Status: err.Status, ScimType: err.ScimType, Detail: err.Detail}
+}
+
+func TestContextHandler(t *testing.T) {
+	var contexts []context.Context
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := newTestServer()
+	server.Clock = ClockFunc(func() time.Time { return now })
+	server.ResourceTypes[0].Handler = ContextHandler(contextResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		contexts:            &contexts,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test"}`, http.StatusCreated},
+		{http.MethodGet, "/Users/0001", "", http.StatusOK},
+		{http.MethodGet, "/Users", "", http.StatusOK},
+		{http.MethodPut, "/Users/0001", `{"userName": "test"}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "displayName", "value": "Test"}]
+		}`, http.StatusOK},
+		{http.MethodDelete, "/Users/0001", "", http.StatusNoContent},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)).WithContext(ctx))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: expected status %d, got %d", test.method, test.target, test.expected, rr.Code)
+		}
+	}
+
+	if len(contexts) != 6 {
+		t.Fatalf("expected 6 calls, got %d", len(contexts))
+	}
+	for _, c := range contexts {
+		if _, ok := c.Deadline(); !ok {
+			t.Error("expected the deadline of the request to be propagated")
+		}
+		if !ClockFromContext(c).Now().Equal(now) {
+			t.Error("expected the clock of the server in the context")
+		}
+	}
+}
+
+// optionalContextResourceHandler implements the optional interfaces of context resource handlers and counts the calls of
+// GetMany and Versions.
+type optionalContextResourceHandler struct {
+	contextResourceHandler
+	getMany, versions *int
+}
+
+func (h optionalContextResourceHandler) SupportsSort() bool {
+	return false
+}
+
+func (h optionalContextResourceHandler) GetMany(ctx context.Context, ids []string) ([]Resource, error) {
+	*h.getMany++
+	var resources []Resource
+	for _, id := range ids {
+		if resource, err := h.Get(ctx, id); err == nil {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func (h optionalContextResourceHandler) Versions(ctx context.Context, ids []string) (map[string]string, error) {
+	*h.versions++
+	return map[string]string{}, nil
+}
+
+func TestContextHandlerOptionalInterfaces(t *testing.T) {
+	var contexts []context.Context
+	plain := contextResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		contexts:            &contexts,
+	}
+	adapter := ContextHandler(plain)
+	if sorter, ok := adapter.(Sorter); !ok || !sorter.SupportsSort() {
+		t.Error("expected the adapter of a handler without Sorter to sort")
+	}
+	if _, ok := adapter.(VersionGetter); ok {
+		t.Error("expected the adapter of a handler without VersionGetter not to implement it")
+	}
+	resources, getErr := adapter.(ManyGetter).GetMany(httptest.NewRequest(http.MethodGet, "/", nil), []string{"0001", "9999"})
+	if getErr != errors.GetErrorNil || len(resources) != 1 || resources[0].ID != "0001" {
+		t.Errorf("expected the resources to be retrieved one by one, got %v (%v)", resources, getErr)
+	}
+
+	var getMany, versions int
+	adapter = ContextHandler(optionalContextResourceHandler{contextResourceHandler: plain, getMany: &getMany, versions: &versions})
+	if sorter, ok := adapter.(Sorter); !ok || sorter.SupportsSort() {
+		t.Error("expected the adapter to forward SupportsSort")
+	}
+	getter, ok := adapter.(VersionGetter)
+	if !ok {
+		t.Fatal("expected the adapter to implement VersionGetter")
+	}
+	if _, err := getter.Versions(context.Background(), []string{"0001"}); err != nil || versions != 1 {
+		t.Errorf("expected the adapter to forward Versions, got %d calls (%v)", versions, err)
+	}
+	if _, getErr := adapter.(ManyGetter).GetMany(httptest.NewRequest(http.MethodGet, "/", nil), []string{"0001"}); getErr != errors.GetErrorNil || getMany != 1 {
+		t.Errorf("expected the adapter to forward GetMany, got %d calls (%v)", getMany, getErr)
+	}
+}
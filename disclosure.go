@@ -0,0 +1,23 @@
+package scim
+
+import "net/http"
+
+// DisclosurePolicy controls how the server responds when a client is not allowed to access an existing resource.
+type DisclosurePolicy int
+
+const (
+	// DisclosurePolicyForbidden returns a "403 Forbidden" when access to a resource is denied. This is the default.
+	DisclosurePolicyForbidden DisclosurePolicy = iota
+	// DisclosurePolicyNotFound returns a "404 Not Found" when access to a resource is denied, so that clients can not
+	// find out which resources exist by enumerating identifiers.
+	DisclosurePolicyNotFound
+)
+
+// disclose applies the disclosure policy of the server to given error that resulted from accessing the resource with
+// given identifier.
+func (s Server) disclose(scimErr scimError, id string) scimError {
+	if s.DisclosurePolicy == DisclosurePolicyNotFound && scimErr.status == http.StatusForbidden {
+		return scimErrorResourceNotFound(id)
+	}
+	return scimErr
+}
@@ -0,0 +1,82 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// forbiddingResourceHandler denies access to the resource with identifier "0001".
+type forbiddingResourceHandler struct {
+	testResourceHandler
+}
+
+func (h forbiddingResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	if id == "0001" {
+		return Resource{}, errors.GetErrorForbidden
+	}
+	return h.testResourceHandler.Get(r, id)
+}
+
+func (h forbiddingResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	if id == "0001" {
+		return Resource{}, errors.PutErrorForbidden
+	}
+	return h.testResourceHandler.Replace(r, id, attributes, externalID)
+}
+
+func (h forbiddingResourceHandler) Delete(r *http.Request, id string) errors.DeleteError {
+	if id == "0001" {
+		return errors.DeleteErrorForbidden
+	}
+	return h.testResourceHandler.Delete(r, id)
+}
+
+func (h forbiddingResourceHandler) Patch(r *http.Request, id string, request PatchRequest) (Resource, errors.PatchError) {
+	if id == "0001" {
+		return Resource{}, errors.PatchErrorForbidden
+	}
+	return h.testResourceHandler.Patch(r, id, request)
+}
+
+func TestServerDisclosurePolicy(t *testing.T) {
+	for _, policy := range []struct {
+		policy   DisclosurePolicy
+		expected int
+	}{
+		{DisclosurePolicyForbidden, http.StatusForbidden},
+		{DisclosurePolicyNotFound, http.StatusNotFound},
+	} {
+		server := newTestServer()
+		server.DisclosurePolicy = policy.policy
+		server.ResourceTypes[0].Handler = forbiddingResourceHandler{
+			testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		}
+
+		for _, test := range []struct {
+			method string
+			body   string
+		}{
+			{http.MethodGet, ""},
+			{http.MethodPut, `{"userName": "test"}`},
+			{http.MethodPatch, `{
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [{"op": "replace", "path": "userName", "value": "test"}]
+			}`},
+			{http.MethodDelete, ""},
+		} {
+			rr := httptest.NewRecorder()
+			server.ServeHTTP(rr, httptest.NewRequest(test.method, "/Users/0001", strings.NewReader(test.body)))
+			if rr.Code != policy.expected {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", test.method, rr.Code, policy.expected)
+			}
+			if !strings.Contains(rr.Body.String(), `"status":"`) {
+				t.Errorf("%s: expected a SCIM error, got %s", test.method, rr.Body.String())
+			}
+		}
+	}
+}
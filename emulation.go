@@ -0,0 +1,122 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/errors"
+)
+
+const (
+	defaultEmulatorPageSize   = 100
+	defaultEmulatorMaxScanned = 10000
+)
+
+// ListEmulator wraps a resource handler whose "GetAll" callback method can only paginate, e.g. one that is backed by a
+// store without query capabilities. It retrieves all resources from the wrapped handler page by page, without filter
+// and sort parameters, and filters, sorts and paginates them in memory according to the parameters of the request.
+// The server applies the "attributes" and "excludedAttributes" parameters to the resulting page as usual.
+//
+// Since every list request scans all resources, it only suits small deployments. Requests that would scan more than
+// the maximum number of resources are rejected with a "400 Bad Request" response with scimType "tooMany".
+type ListEmulator struct {
+	ResourceHandler
+	// PageSize is the number of resources that are requested from the wrapped handler at once. It defaults to 100.
+	PageSize int
+	// MaxScanned is the maximum number of resources that are scanned for a single list request. It defaults to 10000.
+	MaxScanned int
+}
+
+// GetAll retrieves the resources from the wrapped handler and returns the requested page of the resources that match
+// the filter, in the requested order. Requests without a filter and sort parameters are passed on as is.
+func (h ListEmulator) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	if params.Filter == nil && params.SortBy == "" {
+		return h.ResourceHandler.GetAll(r, params)
+	}
+	schemaSet, ok := SchemaSetFromContext(r.Context())
+	if !ok {
+		return h.ResourceHandler.GetAll(r, params)
+	}
+
+	resources, getErr := h.scan(r)
+	if getErr != errors.GetErrorNil {
+		return Page{}, getErr
+	}
+
+	if params.Filter != nil {
+		matched := resources[:0]
+		for _, resource := range resources {
+			if schemaSet.matchesFilter(params.Filter, resource.Attributes) {
+				matched = append(matched, resource)
+			}
+		}
+		resources = matched
+	}
+	schemaSet.sortResources(resources, params)
+
+	page := Page{TotalResults: len(resources)}
+	start := params.StartIndex - 1
+	if start < 0 {
+		start = 0
+	}
+	if start < len(resources) {
+		end := len(resources)
+		if params.Count >= 0 && start+params.Count < end {
+			end = start + params.Count
+		}
+		page.Resources = resources[start:end]
+	}
+	return page, errors.GetErrorNil
+}
+
+// SupportsSort reports that the pages are sorted, so the server does not sort them again.
+func (h ListEmulator) SupportsSort() bool {
+	return true
+}
+
+// scan retrieves all resources from the wrapped handler, page by page.
+func (h ListEmulator) scan(r *http.Request) ([]Resource, errors.GetError) {
+	pageSize := h.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultEmulatorPageSize
+	}
+	maxScanned := h.MaxScanned
+	if maxScanned <= 0 {
+		maxScanned = defaultEmulatorMaxScanned
+	}
+	tooMany := errors.GetError{
+		ScimType: errors.ScimTypeTooMany,
+		Detail:   fmt.Sprintf("The number of resources to filter or sort exceeds the maximum (%d).", maxScanned),
+		Status:   http.StatusBadRequest,
+	}
+
+	var resources []Resource
+	for {
+		if r.Context().Err() != nil {
+			return nil, errors.GetErrorServiceUnavailable.WithDetail("The resources could not be scanned in time.")
+		}
+		page, getErr := h.ResourceHandler.GetAll(r, ListRequestParams{
+			Count:      pageSize,
+			StartIndex: len(resources) + 1,
+		})
+		if getErr != errors.GetErrorNil {
+			return nil, getErr
+		}
+		if page.TotalResults > maxScanned || len(resources)+len(page.Resources) > maxScanned {
+			return nil, tooMany
+		}
+		resources = append(resources, page.Resources...)
+		if len(page.Resources) == 0 || page.TotalResults != UnknownTotalResults && len(resources) >= page.TotalResults {
+			return resources, errors.GetErrorNil
+		}
+	}
+}
+
+// matchesFilter reports whether given attributes match given filter expression.
+func (s SchemaSet) matchesFilter(expression filter.Expression, attributes ResourceAttributes) bool {
+	return filterMatcher{
+		attributes: s.schema.Attributes,
+		extensions: s.extensions,
+	}.matches(expression, attributes)
+}
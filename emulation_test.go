@@ -0,0 +1,107 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// paginatingResourceHandler returns the resources in a stable order, but ignores the filter and sort parameters.
+type paginatingResourceHandler struct {
+	testResourceHandler
+	calls *int
+}
+
+func (h paginatingResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	*h.calls++
+	ids := make([]string, 0, len(h.data))
+	for id := range h.data {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var resources []Resource
+	for i := params.StartIndex - 1; i >= 0 && i < len(ids) && len(resources) < params.Count; i++ {
+		resources = append(resources, Resource{ID: ids[i], Attributes: h.data[ids[i]]})
+	}
+	return Page{TotalResults: len(ids), Resources: resources}, errors.GetErrorNil
+}
+
+func TestListEmulator(t *testing.T) {
+	var calls int
+	server := newTestServer()
+	server.Config.Features.Sort.Supported = true
+	server.ResourceTypes[0].Handler = ListEmulator{
+		ResourceHandler: paginatingResourceHandler{
+			testResourceHandler: newTestResourceHandler().(testResourceHandler),
+			calls:               &calls,
+		},
+		PageSize: 6,
+	}
+
+	query := url.Values{
+		"filter":     {`userName sw "test1"`},
+		"sortBy":     {"userName"},
+		"sortOrder":  {"descending"},
+		"startIndex": {"2"},
+		"count":      {"3"},
+	}
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?"+query.Encode(), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		TotalResults int
+		Resources    []map[string]interface{}
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.TotalResults != 11 {
+		t.Errorf("expected 11 matching resources, got %d", response.TotalResults)
+	}
+	var userNames []string
+	for _, resource := range response.Resources {
+		userNames = append(userNames, fmt.Sprint(resource["userName"]))
+	}
+	if expected := []string{"test18", "test17", "test16"}; !reflect.DeepEqual(userNames, expected) {
+		t.Errorf("expected %v, got %v", expected, userNames)
+	}
+	if calls != 4 {
+		t.Errorf("expected the resources to be scanned in 4 pages, got %d", calls)
+	}
+}
+
+func TestListEmulatorMaxScanned(t *testing.T) {
+	var calls int
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = ListEmulator{
+		ResourceHandler: paginatingResourceHandler{
+			testResourceHandler: newTestResourceHandler().(testResourceHandler),
+			calls:               &calls,
+		},
+		MaxScanned: 10,
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?filter="+url.QueryEscape(`userName eq "test1"`), nil))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"scimType":"tooMany"`) {
+		t.Errorf("expected too many resources to scan, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Requests without filter and sort parameters are passed on to the handler.
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?count=5", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"totalResults":20`) {
+		t.Errorf("expected the unfiltered page, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
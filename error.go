@@ -6,27 +6,11 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/elimity-com/scim/errors"
 )
 
-type scimType string
-
-const (
-	// One or more of the attribute values are already in use or are reserved.
-	scimTypeUniqueness = "uniqueness"
-	// The attempted modification is not compatible with the target attribute's mutability or current state (e.g.,
-	// modification of an "immutable" attribute with an existing value).
-	scimTypeMutability = "mutability"
-	// The request body message structure was invalid or did not conform to the request schema.
-	scimTypeInvalidSyntax = "invalidSyntax"
-	// A required value was missing or the value specified was not compatible with the operation, attribute type
-	// or resource schema.
-	scimTypeInvalidValue = "invalidValue"
-	// Endpoint not implemented
-	scimTypeNotImplemented = "notImplemented"
-)
-
 func scimErrorResourceNotFound(id string) scimError {
 	return scimError{
 		detail: fmt.Sprintf("Resource %s not found.", id),
@@ -34,6 +18,13 @@ func scimErrorResourceNotFound(id string) scimError {
 	}
 }
 
+func scimErrorForbidden(id string) scimError {
+	return scimError{
+		detail: fmt.Sprintf("Access to resource %s is forbidden.", id),
+		status: http.StatusForbidden,
+	}
+}
+
 func scimErrorBadParams(invalidParams []string) scimError {
 	var suffix string
 
@@ -48,6 +39,29 @@ func scimErrorBadParams(invalidParams []string) scimError {
 	))
 }
 
+func scimErrorTooManyRequests(retryAfter time.Duration) scimError {
+	return scimError{
+		detail:     "Too many requests, the request rate limit of the service provider has been exceeded.",
+		status:     http.StatusTooManyRequests,
+		retryAfter: retryAfter,
+	}
+}
+
+func scimErrorConcurrencyLimit(retryAfter time.Duration) scimError {
+	return scimError{
+		detail:     "Too many concurrent requests, the service provider is temporarily unable to handle the request.",
+		status:     http.StatusServiceUnavailable,
+		retryAfter: retryAfter,
+	}
+}
+
+func scimErrorPayloadTooLarge(msg string) scimError {
+	return scimError{
+		detail: msg,
+		status: http.StatusRequestEntityTooLarge,
+	}
+}
+
 func scimErrorBadRequest(msg string) scimError {
 	return scimError{
 		detail: msg,
@@ -57,49 +71,62 @@ func scimErrorBadRequest(msg string) scimError {
 
 var (
 	scimErrorUniqueness = scimError{
-		scimType: scimTypeUniqueness,
+		scimType: errors.ScimTypeUniqueness,
 		detail:   "One or more of the attribute values are already in use or are reserved.",
 		status:   http.StatusConflict,
 	}
 	scimErrorMutability = scimError{
-		scimType: scimTypeMutability,
+		scimType: errors.ScimTypeMutability,
 		detail:   "The attempted modification is not compatible with the target attribute's mutability or current state.",
 		status:   http.StatusBadRequest,
 	}
 	scimErrorInvalidSyntax = scimError{
-		scimType: scimTypeInvalidSyntax,
+		scimType: errors.ScimTypeInvalidSyntax,
 		detail:   "The request body message structure was invalid or did not conform to the request schema.",
 		status:   http.StatusBadRequest,
 	}
 	scimErrorInvalidValue = scimError{
-		scimType: scimTypeInvalidValue,
+		scimType: errors.ScimTypeInvalidValue,
 		detail:   "A required value was missing, or the value specified was not compatible with the operation or attribute type, or resource schema.",
 		status:   http.StatusBadRequest,
 	}
 	scimErrorInternalServer = scimError{
 		status: http.StatusInternalServerError,
 	}
+	scimErrorFilterNotSupported = scimError{
+		scimType: errors.ScimTypeInvalidFilter,
+		detail:   "The service provider does not support filtering.",
+		status:   http.StatusBadRequest,
+	}
 	scimErrorNotImplemented = scimError{
-		scimType: scimTypeNotImplemented,
+		scimType: errors.ScimTypeNotImplemented,
+		status:   http.StatusNotImplemented,
+	}
+	scimErrorChangePasswordNotSupported = scimError{
+		scimType: errors.ScimTypeNotImplemented,
+		detail:   "The service provider does not support changing passwords.",
 		status:   http.StatusNotImplemented,
 	}
 )
 
 type scimError struct {
 	// scimType is a SCIM detail error keyword.
-	scimType scimType
+	scimType errors.ScimType
 	// detail is a detailed human-readable message.
 	detail string
 	// status is the HTTP status code expressed as a JSON string. REQUIRED.
 	status int
+	// retryAfter is the delay after which the client may retry the request. It is not part of the response body, but
+	// sent in the "Retry-After" header.
+	retryAfter time.Duration
 }
 
 func (e scimError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Schemas  []string `json:"schemas"`
-		ScimType scimType `json:"scimType,omitempty"`
-		Detail   string   `json:"detail,omitempty"`
-		Status   string   `json:"status"`
+		Schemas  []string        `json:"schemas"`
+		ScimType errors.ScimType `json:"scimType,omitempty"`
+		Detail   string          `json:"detail,omitempty"`
+		Status   string          `json:"status"`
 	}{
 		Schemas:  []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
 		ScimType: e.scimType,
@@ -110,7 +137,7 @@ func (e scimError) MarshalJSON() ([]byte, error) {
 
 func (e *scimError) UnmarshalJSON(data []byte) error {
 	var tmpScimError struct {
-		ScimType scimType
+		ScimType errors.ScimType
 		Detail   string
 		Status   string
 	}
@@ -134,14 +161,38 @@ func (e *scimError) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// scimCustomError converts a custom error returned by a callback method to its corresponding SCIM error. Errors without
+// a valid error status code are converted to internal server errors.
+func scimCustomError(err errors.ScimError) scimError {
+	if err.Status < 400 || err.Status > 599 {
+		return scimErrorInternalServer
+	}
+	if err.Detail == "" {
+		switch err.Status {
+		case http.StatusTooManyRequests:
+			err.Detail = "The service provider is temporarily overloaded, retry the request later."
+		case http.StatusServiceUnavailable:
+			err.Detail = "The service provider is temporarily unavailable, retry the request later."
+		}
+	}
+	return scimError{
+		scimType:   err.ScimType,
+		detail:     err.Detail,
+		status:     err.Status,
+		retryAfter: err.RetryAfter,
+	}
+}
+
 func scimGetError(getError errors.GetError, id string) scimError {
 	switch getError {
 	case errors.GetErrorNotImplemented:
 		return scimErrorNotImplemented
 	case errors.GetErrorResourceNotFound:
 		return scimErrorResourceNotFound(id)
+	case errors.GetErrorForbidden:
+		return scimErrorForbidden(id)
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(getError))
 	}
 }
 
@@ -150,7 +201,7 @@ func scimGetAllError(getError errors.GetError) scimError {
 	case errors.GetErrorNotImplemented:
 		return scimErrorNotImplemented
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(getError))
 	}
 }
 
@@ -164,8 +215,10 @@ func scimPatchError(patchError errors.PatchError, id string) scimError {
 		return scimErrorMutability
 	case errors.PatchErrorResourceNotFound:
 		return scimErrorResourceNotFound(id)
+	case errors.PatchErrorForbidden:
+		return scimErrorForbidden(id)
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(patchError))
 	}
 }
 
@@ -176,7 +229,7 @@ func scimPostError(postError errors.PostError) scimError {
 	case errors.PostErrorUniqueness:
 		return scimErrorUniqueness
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(postError))
 	}
 }
 
@@ -190,8 +243,10 @@ func scimPutError(putError errors.PutError, id string) scimError {
 		return scimErrorMutability
 	case errors.PutErrorResourceNotFound:
 		return scimErrorResourceNotFound(id)
+	case errors.PutErrorForbidden:
+		return scimErrorForbidden(id)
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(putError))
 	}
 }
 
@@ -201,8 +256,10 @@ func scimDeleteError(deleteError errors.DeleteError, id string) scimError {
 		return scimErrorNotImplemented
 	case errors.DeleteErrorResourceNotFound:
 		return scimErrorResourceNotFound(id)
+	case errors.DeleteErrorForbidden:
+		return scimErrorForbidden(id)
 	default:
-		return scimErrorInternalServer
+		return scimCustomError(errors.ScimError(deleteError))
 	}
 }
 
@@ -212,6 +269,8 @@ func scimValidationError(validationError errors.ValidationError) scimError {
 		return scimErrorInvalidSyntax
 	case errors.ValidationErrorInvalidValue:
 		return scimErrorInvalidValue
+	case errors.ValidationErrorMutability:
+		return scimErrorMutability
 	default:
 		return scimErrorInternalServer
 	}
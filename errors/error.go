@@ -1,79 +1,241 @@
 package errors
 
-// GetError represents an error that is returned by a GET HTTP request.
-type GetError int
+import (
+	"net/http"
+	"time"
+)
+
+// ScimType is a SCIM detail error keyword. Besides the keywords defined in RFC 7644, custom keywords can be used to
+// report domain specific errors. These should be prefixed to avoid collisions, e.g. "urn:example:licenseExceeded".
+type ScimType string
 
 const (
+	// ScimTypeInvalidFilter indicates that the specified filter syntax was invalid or the specified attribute and
+	// filter comparison combination is not supported.
+	ScimTypeInvalidFilter ScimType = "invalidFilter"
+	// ScimTypeTooMany indicates that the specified filter yields many more results than the server is willing to
+	// calculate or process.
+	ScimTypeTooMany ScimType = "tooMany"
+	// ScimTypeUniqueness indicates that one or more of the attribute values are already in use or are reserved.
+	ScimTypeUniqueness ScimType = "uniqueness"
+	// ScimTypeMutability indicates that the attempted modification is not compatible with the target attribute's
+	// mutability or current state.
+	ScimTypeMutability ScimType = "mutability"
+	// ScimTypeInvalidSyntax indicates that the request body message structure was invalid or did not conform to the
+	// request schema.
+	ScimTypeInvalidSyntax ScimType = "invalidSyntax"
+	// ScimTypeInvalidPath indicates that the "path" attribute was invalid or malformed.
+	ScimTypeInvalidPath ScimType = "invalidPath"
+	// ScimTypeNoTarget indicates that the specified "path" did not yield an attribute or attribute value that could be
+	// operated on.
+	ScimTypeNoTarget ScimType = "noTarget"
+	// ScimTypeInvalidValue indicates that a required value was missing, or the value specified was not compatible with
+	// the operation or attribute type, or resource schema.
+	ScimTypeInvalidValue ScimType = "invalidValue"
+	// ScimTypeInvalidVersion indicates that the specified SCIM protocol version is not supported.
+	ScimTypeInvalidVersion ScimType = "invalidVers"
+	// ScimTypeSensitive indicates that the specified request cannot be completed, due to the passing of sensitive
+	// information in a request URI.
+	ScimTypeSensitive ScimType = "sensitive"
+	// ScimTypeNotImplemented indicates that the endpoint or operation is not implemented.
+	ScimTypeNotImplemented ScimType = "notImplemented"
+)
+
+// ScimError describes an error that is returned by a callback method. The zero value indicates that no error occurred.
+// Next to the predefined errors, callback methods can return custom errors by specifying the HTTP status code, a
+// (custom) SCIM detail error keyword and a human-readable message, e.g.:
+//
+//	errors.PostError{
+//		ScimType: "urn:example:licenseExceeded",
+//		Detail:   "No licenses left to assign to new users.",
+//		Status:   http.StatusForbidden,
+//	}
+//
+// Errors with a status code outside of the 4xx and 5xx ranges are returned as internal server errors, except for
+// status code 202 (Accepted), which signals that the operation was queued for asynchronous processing instead, see
+// e.g. PostErrorAccepted.
+type ScimError struct {
+	// ScimType is a SCIM detail error keyword. It is optional.
+	ScimType ScimType
+	// Detail is a detailed human-readable message. It is optional.
+	Detail string
+	// Status is the HTTP status code of the error.
+	Status int
+	// RetryAfter is the delay after which the client may retry the request, sent in the "Retry-After" header of
+	// responses with status code 429 (Too Many Requests) or 503 (Service Unavailable). It is optional.
+	RetryAfter time.Duration
+	// Location is the URI of a resource that describes the status of an operation that was accepted for asynchronous
+	// processing, sent in the "Location" header of responses with status code 202 (Accepted). It is optional.
+	Location string
+}
+
+// GetError represents an error that is returned by a GET HTTP request.
+type GetError ScimError
+
+var (
 	// GetErrorNil indicates that no error occurred during handling a GET HTTP request.
-	GetErrorNil GetError = iota
+	GetErrorNil = GetError{}
 	// GetErrorResourceNotFound returns an error with status code 404 and a human readable message containing the identifier
 	// of the resource that was requested but not found.
-	GetErrorResourceNotFound
+	GetErrorResourceNotFound = GetError{Status: http.StatusNotFound}
 	// GetErrorNotImplemented allows consumers to create a get handler that simply returns an unsupported error.
-	GetErrorNotImplemented
+	GetErrorNotImplemented = GetError{ScimType: ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// GetErrorTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	GetErrorTooManyRequests = GetError{Status: http.StatusTooManyRequests}
+	// GetErrorForbidden signals that the client is not allowed to access the resource. Depending on the disclosure policy of
+	// the server, it is returned to the client as is or as if the resource does not exist.
+	GetErrorForbidden = GetError{Status: http.StatusForbidden}
+	// GetErrorServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	GetErrorServiceUnavailable = GetError{Status: http.StatusServiceUnavailable}
 )
 
+// WithDetail returns a copy of the error with given human-readable message, e.g.
+// errors.GetErrorForbidden.WithDetail("Users of another tenant can not be retrieved.").
+func (e GetError) WithDetail(detail string) GetError {
+	e.Detail = detail
+	return e
+}
+
 // PatchError represents an error that is returned by a PATCH HTTP request.
-type PatchError int
+type PatchError ScimError
 
-const (
+var (
 	// PatchErrorNil indicates that no error occurred during handling a PUT HTTP request.
-	PatchErrorNil PatchError = iota
+	PatchErrorNil = PatchError{}
 	// PatchErrorUniqueness shall be returned when one or more of the attribute values are already in use or are reserved.
-	PatchErrorUniqueness
+	PatchErrorUniqueness = PatchError{ScimType: ScimTypeUniqueness, Status: http.StatusConflict}
 	// PatchErrorMutability shall be returned when the attempted modification is not compatible with the target
 	// attribute's mutability or current state.
-	PatchErrorMutability
+	PatchErrorMutability = PatchError{ScimType: ScimTypeMutability, Status: http.StatusBadRequest}
 	// PatchErrorResourceNotFound returns an error with status code 404 and a human readable message containing the
 	// identifier of the resource that was requested to be replaced but not found.
-	PatchErrorResourceNotFound
+	PatchErrorResourceNotFound = PatchError{Status: http.StatusNotFound}
 	// PatchErrorNotImplemented allows consumers to create a patch handler that simply returns an unsupported error.
-	PatchErrorNotImplemented
+	PatchErrorNotImplemented = PatchError{ScimType: ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// PatchErrorTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	PatchErrorTooManyRequests = PatchError{Status: http.StatusTooManyRequests}
+	// PatchErrorForbidden signals that the client is not allowed to access the resource. Depending on the disclosure policy of
+	// the server, it is returned to the client as is or as if the resource does not exist.
+	PatchErrorForbidden = PatchError{Status: http.StatusForbidden}
+	// PatchErrorServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	PatchErrorServiceUnavailable = PatchError{Status: http.StatusServiceUnavailable}
 )
 
+// WithDetail returns a copy of the error with given human-readable message, see GetError.WithDetail.
+func (e PatchError) WithDetail(detail string) PatchError {
+	e.Detail = detail
+	return e
+}
+
+// PatchErrorAccepted signals that the resource is not patched yet, but that the request was queued for asynchronous
+// processing, e.g. by a ticketing system or an HR workflow. The client receives a "202 Accepted" response with a
+// "Location" header pointing to given resource that describes the status of the operation.
+func PatchErrorAccepted(location string) PatchError {
+	return PatchError{Status: http.StatusAccepted, Location: location}
+}
+
 // PostError represents an error that is returned by a POST HTTP request.
-type PostError int
+type PostError ScimError
 
-const (
+var (
 	// PostErrorNil indicates that no error occurred during handling a POST HTTP request.
-	PostErrorNil PostError = iota
+	PostErrorNil = PostError{}
 	// PostErrorUniqueness shall be returned when one or more of the attribute values are already in use or are reserved.
-	PostErrorUniqueness
+	PostErrorUniqueness = PostError{ScimType: ScimTypeUniqueness, Status: http.StatusConflict}
 	// PostErrorNotImplemented allows consumers to create a get handler that simply returns an unsupported error.
-	PostErrorNotImplemented
+	PostErrorNotImplemented = PostError{ScimType: ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// PostErrorTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	PostErrorTooManyRequests = PostError{Status: http.StatusTooManyRequests}
+	// PostErrorForbidden signals that the client is not allowed to create the resource.
+	PostErrorForbidden = PostError{Status: http.StatusForbidden}
+	// PostErrorServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	PostErrorServiceUnavailable = PostError{Status: http.StatusServiceUnavailable}
 )
 
+// WithDetail returns a copy of the error with given human-readable message, see GetError.WithDetail.
+func (e PostError) WithDetail(detail string) PostError {
+	e.Detail = detail
+	return e
+}
+
+// PostErrorAccepted signals that the resource is not created yet, but that the request was queued for asynchronous
+// processing, e.g. by a ticketing system or an HR workflow. The client receives a "202 Accepted" response with a
+// "Location" header pointing to given resource that describes the status of the operation.
+func PostErrorAccepted(location string) PostError {
+	return PostError{Status: http.StatusAccepted, Location: location}
+}
+
 // PutError represents an error that is returned by a PUT HTTP request.
-type PutError int
+type PutError ScimError
 
-const (
+var (
 	// PutErrorNil indicates that no error occurred during handling a PUT HTTP request.
-	PutErrorNil PutError = iota
+	PutErrorNil = PutError{}
 	// PutErrorUniqueness shall be returned when one or more of the attribute values are already in use or are reserved.
-	PutErrorUniqueness
+	PutErrorUniqueness = PutError{ScimType: ScimTypeUniqueness, Status: http.StatusConflict}
 	// PutErrorMutability shall be returned when the attempted modification is not compatible with the target
 	// attribute's mutability or current state.
-	PutErrorMutability
+	PutErrorMutability = PutError{ScimType: ScimTypeMutability, Status: http.StatusBadRequest}
 	// PutErrorResourceNotFound returns an error with status code 404 and a human readable message containing the
 	// identifier of the resource that was requested to be replaced but not found.
-	PutErrorResourceNotFound
+	PutErrorResourceNotFound = PutError{Status: http.StatusNotFound}
 	// PutErrorNotImplemented allows consumers to create a get handler that simply returns an unsupported error.
-	PutErrorNotImplemented
+	PutErrorNotImplemented = PutError{ScimType: ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// PutErrorTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	PutErrorTooManyRequests = PutError{Status: http.StatusTooManyRequests}
+	// PutErrorForbidden signals that the client is not allowed to access the resource. Depending on the disclosure policy of
+	// the server, it is returned to the client as is or as if the resource does not exist.
+	PutErrorForbidden = PutError{Status: http.StatusForbidden}
+	// PutErrorServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	PutErrorServiceUnavailable = PutError{Status: http.StatusServiceUnavailable}
 )
 
+// WithDetail returns a copy of the error with given human-readable message, see GetError.WithDetail.
+func (e PutError) WithDetail(detail string) PutError {
+	e.Detail = detail
+	return e
+}
+
+// PutErrorAccepted signals that the resource is not replaced yet, but that the request was queued for asynchronous
+// processing, e.g. by a ticketing system or an HR workflow. The client receives a "202 Accepted" response with a
+// "Location" header pointing to given resource that describes the status of the operation.
+func PutErrorAccepted(location string) PutError {
+	return PutError{Status: http.StatusAccepted, Location: location}
+}
+
 // DeleteError represents an error that is returned by a DELETE HTTP request.
-type DeleteError int
+type DeleteError ScimError
 
-const (
+var (
 	// DeleteErrorNil indicates that no error occurred during handling a DELETE HTTP request.
-	DeleteErrorNil DeleteError = iota
+	DeleteErrorNil = DeleteError{}
 	// DeleteErrorResourceNotFound returns an error with status code 404 and a human readable message containing the
 	// identifier of the resource that was requested to be deleted but not found.
-	DeleteErrorResourceNotFound
+	DeleteErrorResourceNotFound = DeleteError{Status: http.StatusNotFound}
 	// DeleteErrorNotImplemented allows consumers to create a get handler that simply returns an unsupported error.
-	DeleteErrorNotImplemented
+	DeleteErrorNotImplemented = DeleteError{ScimType: ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// DeleteErrorTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	DeleteErrorTooManyRequests = DeleteError{Status: http.StatusTooManyRequests}
+	// DeleteErrorForbidden signals that the client is not allowed to access the resource. Depending on the disclosure policy of
+	// the server, it is returned to the client as is or as if the resource does not exist.
+	DeleteErrorForbidden = DeleteError{Status: http.StatusForbidden}
+	// DeleteErrorServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	DeleteErrorServiceUnavailable = DeleteError{Status: http.StatusServiceUnavailable}
 )
 
+// WithDetail returns a copy of the error with given human-readable message, see GetError.WithDetail.
+func (e DeleteError) WithDetail(detail string) DeleteError {
+	e.Detail = detail
+	return e
+}
+
+// DeleteErrorAccepted signals that the resource is not deleted yet, but that the request was queued for asynchronous
+// processing, e.g. by a ticketing system or an HR workflow. The client receives a "202 Accepted" response with a
+// "Location" header pointing to given resource that describes the status of the operation.
+func DeleteErrorAccepted(location string) DeleteError {
+	return DeleteError{Status: http.StatusAccepted, Location: location}
+}
+
 // ValidationError represents an error that is returned during a resource validation.
 type ValidationError int
 
@@ -86,4 +248,7 @@ const (
 	// ValidationErrorInvalidValue indicates that a required value was missing or the value specified was not
 	// compatible with the operation, attribute type or resource schema.
 	ValidationErrorInvalidValue
+	// ValidationErrorMutability indicates that the request attempted to set an attribute that is not compatible with
+	// its mutability, e.g. a read-only attribute.
+	ValidationErrorMutability
 )
@@ -0,0 +1,197 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+var scimErrorPreconditionFailed = scimError{
+	detail: "Failed to update. Resource changed on the server.",
+	status: http.StatusPreconditionFailed,
+}
+
+// formatETag returns the entity tag of given resource version. Versions that are not yet formatted as an entity tag
+// (e.g. `W/"3694e05e9dff591"`) are returned as a weak entity tag. An empty version has no entity tag.
+func formatETag(version string) string {
+	if version == "" || strings.HasPrefix(version, `W/"`) || strings.HasPrefix(version, `"`) {
+		return version
+	}
+	return `W/"` + version + `"`
+}
+
+// setETag adds the "ETag" header with the version of given resource to the response, if the resource has a version.
+func setETag(w http.ResponseWriter, resource Resource) {
+	if etag := formatETag(resource.Version); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+}
+
+// matchesETag reports whether the entity tag of given version matches one of the entity tags in given "If-Match" or
+// "If-None-Match" header value. The comparison is weak, as described in RFC 7232 section 2.3.2.
+func matchesETag(header, version string) bool {
+	current := strings.TrimPrefix(formatETag(version), "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionGetter is an optional interface of resource handlers that can retrieve the versions of multiple resources at
+// once, e.g. with a single database query. If the service provider supports entity tags, the server uses it to evaluate
+// the "If-Match" and "If-None-Match" headers of requests instead of retrieving the resources, and to retrieve the
+// versions of all resources that are modified by a bulk request at once. The context resource handler of ContextHandler
+// can implement it as well.
+type VersionGetter interface {
+	// Versions returns the versions of the resources with given identifiers by identifier, see Resource.Version.
+	// Identifiers of resources that do not exist (or that the client is not allowed to access) are left out.
+	Versions(ctx context.Context, ids []string) (map[string]string, error)
+}
+
+type versionsContextKey struct{}
+
+// prefetchedVersions are the versions of resources that are retrieved in advance, by the endpoint of their resource
+// type and their identifier. Each version is used once, since the resource might be modified afterwards.
+type prefetchedVersions map[string]map[string]prefetchedVersion
+
+type prefetchedVersion struct {
+	version string
+	exists  bool
+}
+
+// prefetchVersions retrieves the versions of the resources that are modified by given bulk operations with a single
+// call per resource type whose handler implements VersionGetter, if the operations have preconditions. The versions are
+// added to the context of the returned request, where checkPreconditions finds them. Only the version of the first
+// operation on a resource is retrieved in advance: the operations before the others may have modified the resource.
+func (s Server) prefetchVersions(r *http.Request, operations []bulkOperation) *http.Request {
+	if !s.Config.features().ETag.Supported {
+		return r
+	}
+	// The headers of the request apply to all operations, the version of an operation only to itself.
+	headers := r.Header.Get("If-Match") != "" || r.Header.Get("If-None-Match") != ""
+
+	ids := make(map[string][]string)
+	modified := make(map[string]bool)
+	for _, op := range operations {
+		switch strings.ToUpper(op.Method) {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			continue
+		}
+		for _, resourceType := range s.ResourceTypes {
+			if !strings.HasPrefix(op.Path, resourceType.Endpoint+"/") {
+				continue
+			}
+			// References to resources that are created by the same request can not be resolved in advance.
+			id, err := parseIdentifier(op.Path, resourceType.Endpoint)
+			if err != nil || strings.HasPrefix(id, "bulkId:") {
+				break
+			}
+			key := resourceType.Endpoint + "/" + id
+			if (headers || op.Version != "") && !modified[key] {
+				ids[resourceType.Endpoint] = append(ids[resourceType.Endpoint], id)
+			}
+			modified[key] = true
+			break
+		}
+	}
+
+	prefetched := make(prefetchedVersions)
+	for _, resourceType := range s.ResourceTypes {
+		getter, ok := resourceType.Handler.(VersionGetter)
+		if !ok || len(ids[resourceType.Endpoint]) == 0 {
+			continue
+		}
+		versions, err := getter.Versions(r.Context(), ids[resourceType.Endpoint])
+		if err != nil {
+			// The versions are retrieved again for every operation, which reports the error.
+			continue
+		}
+		entries := make(map[string]prefetchedVersion, len(ids[resourceType.Endpoint]))
+		for _, id := range ids[resourceType.Endpoint] {
+			version, exists := versions[id]
+			entries[id] = prefetchedVersion{version: version, exists: exists}
+		}
+		prefetched[resourceType.Endpoint] = entries
+	}
+	if len(prefetched) == 0 {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), versionsContextKey{}, prefetched))
+}
+
+// currentVersion returns the current version of the resource with given identifier and whether it exists. The
+// version is taken from the prefetched versions of a bulk request or retrieved with the VersionGetter of the handler,
+// in which case only the identifier and version of the returned resource are set. Otherwise the resource is retrieved.
+func (s Server) currentVersion(r *http.Request, resourceType ResourceType, id string) (Resource, bool, *scimError) {
+	if prefetched, ok := r.Context().Value(versionsContextKey{}).(prefetchedVersions); ok {
+		if v, ok := prefetched[resourceType.Endpoint][id]; ok {
+			delete(prefetched[resourceType.Endpoint], id)
+			return Resource{ID: id, Version: v.version}, v.exists, nil
+		}
+	}
+
+	if getter, ok := resourceType.Handler.(VersionGetter); ok {
+		versions, err := getter.Versions(r.Context(), []string{id})
+		if err != nil {
+			scimErr := scimCustomError(scimErrorFromError(r.Context(), err))
+			return Resource{}, false, &scimErr
+		}
+		version, exists := versions[id]
+		return Resource{ID: id, Version: version}, exists, nil
+	}
+
+	resource, getErr := resourceType.Handler.Get(r, id)
+	return resource, getErr == errors.GetErrorNil, nil
+}
+
+// checkPreconditions evaluates the "If-Match" and "If-None-Match" headers of a request that modifies the resource with
+// given identifier, if the service provider supports entity tags. It returns an error if the current version of the
+// resource does not meet the preconditions. Resources that do not exist are left to the callback method.
+func (s Server) checkPreconditions(r *http.Request, resourceType ResourceType, id string) *scimError {
+	_, _, preconditionErr := s.evaluatePreconditions(r, resourceType, id)
+	return preconditionErr
+}
+
+// evaluatePreconditions is the counterpart of checkPreconditions that also reports whether the preconditions failed
+// because the resource was changed concurrently, i.e. the "If-Match" header does not match the current version, and
+// returns the current resource in that case.
+func (s Server) evaluatePreconditions(r *http.Request, resourceType ResourceType, id string) (Resource, bool, *scimError) {
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if !s.Config.features().ETag.Supported || ifMatch == "" && ifNoneMatch == "" {
+		return Resource{}, false, nil
+	}
+
+	resource, exists, scimErr := s.currentVersion(r, resourceType, id)
+	if scimErr != nil || !exists {
+		return Resource{}, false, scimErr
+	}
+	if ifNoneMatch != "" && (strings.TrimSpace(ifNoneMatch) == "*" ||
+		resource.Version != "" && matchesETag(ifNoneMatch, resource.Version)) {
+		return Resource{}, false, &scimErrorPreconditionFailed
+	}
+	if ifMatch != "" && (resource.Version == "" && strings.TrimSpace(ifMatch) != "*" ||
+		resource.Version != "" && !matchesETag(ifMatch, resource.Version)) {
+		if resource.Attributes == nil && len(resourceType.MergePolicies) != 0 {
+			// The merge policies need the attributes of the current resource.
+			var getErr errors.GetError
+			if resource, getErr = resourceType.Handler.Get(r, id); getErr != errors.GetErrorNil {
+				return Resource{}, false, &scimErrorPreconditionFailed
+			}
+		}
+		return resource, true, &scimErrorPreconditionFailed
+	}
+	return Resource{}, false, nil
+}
+
+// notModified reports whether the client already has the current version of given resource, based on the
+// "If-None-Match" header of a conditional GET request, if the service provider supports entity tags.
+func (s Server) notModified(r *http.Request, resource Resource) bool {
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	return s.Config.features().ETag.Supported && ifNoneMatch != "" && resource.Version != "" && matchesETag(ifNoneMatch, resource.Version)
+}
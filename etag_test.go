@@ -0,0 +1,298 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// versionedResourceHandler returns the resources with a fixed version.
+type versionedResourceHandler struct {
+	testResourceHandler
+}
+
+func (h versionedResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	resource, err := h.testResourceHandler.Get(r, id)
+	resource.Version = "1"
+	return resource, err
+}
+
+func newVersionedTestServer(supportETag bool) Server {
+	server := newTestServer()
+	server.Config.SupportETag = supportETag
+	server.ResourceTypes[0].Handler = versionedResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+	}
+	return server
+}
+
+func TestServerResourceGetHandlerETag(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newVersionedTestServer(true).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+	if etag := rr.Header().Get("ETag"); etag != `W/"1"` {
+		t.Errorf("unexpected entity tag: %q", etag)
+	}
+
+	var resource struct {
+		Meta struct {
+			Version string
+		}
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if resource.Meta.Version != `W/"1"` {
+		t.Errorf("unexpected version: %q", resource.Meta.Version)
+	}
+}
+
+func TestServerPreconditions(t *testing.T) {
+	for _, test := range []struct {
+		supportETag bool
+		method      string
+		header      string
+		value       string
+		expected    int
+	}{
+		{true, http.MethodPut, "If-Match", `W/"1"`, http.StatusOK},
+		{true, http.MethodPut, "If-Match", `"1"`, http.StatusOK},
+		{true, http.MethodPut, "If-Match", `W/"2", *`, http.StatusOK},
+		{true, http.MethodPut, "If-Match", `W/"2"`, http.StatusPreconditionFailed},
+		{false, http.MethodPut, "If-Match", `W/"2"`, http.StatusOK},
+		{true, http.MethodPatch, "If-Match", `W/"2"`, http.StatusPreconditionFailed},
+		{true, http.MethodDelete, "If-Match", `W/"1"`, http.StatusNoContent},
+		{true, http.MethodDelete, "If-None-Match", "*", http.StatusPreconditionFailed},
+		{true, http.MethodDelete, "If-None-Match", `W/"2"`, http.StatusNoContent},
+	} {
+		var body string
+		switch test.method {
+		case http.MethodPut:
+			body = `{"userName": "test"}`
+		case http.MethodPatch:
+			body = `{
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [{"op": "replace", "path": "userName", "value": "test"}]
+			}`
+		}
+		req := httptest.NewRequest(test.method, "/Users/0001", strings.NewReader(body))
+		req.Header.Set(test.header, test.value)
+
+		rr := httptest.NewRecorder()
+		newVersionedTestServer(test.supportETag).ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: %s: handler returned wrong status code: got %v want %v", test.method, test.header, test.value, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestServerResourceGetHandlerNotModified(t *testing.T) {
+	for _, test := range []struct {
+		supportETag bool
+		ifNoneMatch string
+		expected    int
+	}{
+		{true, `W/"1"`, http.StatusNotModified},
+		{true, `W/"0", W/"1"`, http.StatusNotModified},
+		{true, `W/"2"`, http.StatusOK},
+		{false, `W/"1"`, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+		req.Header.Set("If-None-Match", test.ifNoneMatch)
+
+		rr := httptest.NewRecorder()
+		newVersionedTestServer(test.supportETag).ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", test.ifNoneMatch, rr.Code, test.expected)
+		}
+		if rr.Code == http.StatusNotModified && (rr.Body.Len() != 0 || rr.Header().Get("ETag") != `W/"1"`) {
+			t.Errorf("%s: unexpected response: %q %v", test.ifNoneMatch, rr.Body.String(), rr.Header())
+		}
+	}
+}
+
+// versionsResourceHandler retrieves the versions of its resources with Versions and counts the calls of Get and
+// Versions.
+type versionsResourceHandler struct {
+	versionedResourceHandler
+	gets, versions *int
+}
+
+func (h versionsResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	*h.gets++
+	return h.versionedResourceHandler.Get(r, id)
+}
+
+func (h versionsResourceHandler) Versions(ctx context.Context, ids []string) (map[string]string, error) {
+	*h.versions++
+	versions := make(map[string]string)
+	for _, id := range ids {
+		if _, ok := h.data[id]; ok {
+			versions[id] = "1"
+		}
+	}
+	return versions, nil
+}
+
+func newVersionsTestServer() (Server, *int, *int) {
+	var gets, versions int
+	server := newVersionedTestServer(true)
+	server.Config.SupportBulk = true
+	server.ResourceTypes[0].Handler = versionsResourceHandler{
+		versionedResourceHandler: server.ResourceTypes[0].Handler.(versionedResourceHandler),
+		gets:                     &gets,
+		versions:                 &versions,
+	}
+	return server, &gets, &versions
+}
+
+func TestServerPreconditionsVersionGetter(t *testing.T) {
+	for _, test := range []struct {
+		ifMatch  string
+		expected int
+	}{
+		{`W/"1"`, http.StatusOK},
+		{`W/"2"`, http.StatusPreconditionFailed},
+	} {
+		server, gets, versions := newVersionsTestServer()
+		req := httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(`{"userName": "test"}`))
+		req.Header.Set("If-Match", test.ifMatch)
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", test.ifMatch, rr.Code, test.expected)
+		}
+		if *gets != 0 || *versions != 1 {
+			t.Errorf("%s: expected a single call of Versions, got %d calls of Get and %d of Versions", test.ifMatch, *gets, *versions)
+		}
+	}
+}
+
+func TestServerBulkHandlerVersionGetter(t *testing.T) {
+	server, gets, versions := newVersionsTestServer()
+	req := httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{"method": "PUT", "path": "/Users/0001", "data": {"userName": "alice"}},
+			{"method": "DELETE", "path": "/Users/0002"},
+			{"method": "DELETE", "path": "/Users/0003"},
+			{"method": "DELETE", "path": "/Users/0003"}
+		]
+	}`))
+	req.Header.Set("If-Match", `W/"1"`)
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	var response bulkResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, op := range response.Operations {
+		statuses = append(statuses, op.Status)
+	}
+	if expected := "200,204,204,404"; strings.Join(statuses, ",") != expected {
+		t.Errorf("got statuses %v want %s", statuses, expected)
+	}
+	// The second deletion of the same resource retrieves its version again.
+	if *gets != 0 || *versions != 2 {
+		t.Errorf("expected two calls of Versions, got %d calls of Get and %d of Versions", *gets, *versions)
+	}
+}
+
+func TestServerBulkHandlerOperationVersion(t *testing.T) {
+	server, gets, versions := newVersionsTestServer()
+	req := httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{"method": "PUT", "path": "/Users/0001", "version": "W/\"1\"", "data": {"userName": "alice"}},
+			{"method": "DELETE", "path": "/Users/0002", "version": "W/\"2\""},
+			{"method": "DELETE", "path": "/Users/0003", "version": "1"},
+			{"method": "DELETE", "path": "/Users/0004"}
+		]
+	}`))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	var response bulkResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, op := range response.Operations {
+		statuses = append(statuses, op.Status)
+	}
+	if expected := "200,412,204,204"; strings.Join(statuses, ",") != expected {
+		t.Errorf("got statuses %v want %s", statuses, expected)
+	}
+	if *gets != 0 || *versions != 1 {
+		t.Errorf("expected a single call of Versions, got %d calls of Get and %d of Versions", *gets, *versions)
+	}
+}
+
+// revisionResourceHandler increments the version of a resource with every PATCH request.
+type revisionResourceHandler struct {
+	testResourceHandler
+	revisions map[string]int
+}
+
+func (h revisionResourceHandler) Patch(r *http.Request, id string, req PatchRequest) (Resource, errors.PatchError) {
+	resource, err := h.testResourceHandler.Patch(r, id, req)
+	h.revisions[id]++
+	resource.Version = strconv.Itoa(h.revisions[id])
+	return resource, err
+}
+
+func (h revisionResourceHandler) Versions(ctx context.Context, ids []string) (map[string]string, error) {
+	versions := make(map[string]string)
+	for _, id := range ids {
+		if _, ok := h.data[id]; ok {
+			versions[id] = strconv.Itoa(h.revisions[id])
+		}
+	}
+	return versions, nil
+}
+
+func TestServerBulkHandlerModifiedVersion(t *testing.T) {
+	server := newTestServer()
+	server.Config.SupportETag = true
+	server.Config.SupportBulk = true
+	handler := revisionResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		revisions:           map[string]int{"0001": 1},
+	}
+	server.ResourceTypes[0].Handler = handler
+
+	patch := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "displayName", "value": "Test"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{"method": "PATCH", "path": "/Users/0001", "data": `+patch+`},
+			{"method": "PATCH", "path": "/Users/0001", "version": "W/\"1\"", "data": `+patch+`}
+		]
+	}`))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	var response bulkResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, op := range response.Operations {
+		statuses = append(statuses, op.Status)
+	}
+	// The first operation modified the resource, so the version of the second one is stale.
+	if expected := "200,412"; strings.Join(statuses, ",") != expected {
+		t.Errorf("got statuses %v want %s", statuses, expected)
+	}
+	if handler.revisions["0001"] != 2 {
+		t.Errorf("expected a single modification, got version %d", handler.revisions["0001"])
+	}
+}
@@ -0,0 +1,54 @@
+// Command azure runs an in-memory SCIM server that is configured for provisioning from Azure Active Directory. It
+// serves the endpoints under "/scim", which is the tenant URL to configure in Azure, supports the enterprise User
+// extension and accepts the string-encoded booleans that Azure sends in PATCH requests (e.g. "False").
+//
+//	go run ./examples/azure -addr :8080
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/examples/internal/resources"
+	"github.com/elimity-com/scim/examples/memstore"
+)
+
+func newHandler() http.Handler {
+	enterprise := scim.SchemaExtension{Schema: resources.EnterpriseUserSchema()}
+	server := scim.NewServer(
+		scim.WithServiceProviderConfig(scim.ServiceProviderConfig{
+			Features: scim.Features{
+				Filter: scim.FilterFeature{Supported: true},
+				Patch:  scim.PatchFeature{Supported: true},
+			},
+			AuthenticationSchemes: []scim.AuthenticationScheme{
+				{
+					Type:        scim.AuthenticationTypeOauthBearerToken,
+					Name:        "OAuth Bearer Token",
+					Description: "Authentication scheme using the OAuth Bearer Token Standard",
+					Primary:     true,
+				},
+			},
+		}),
+		scim.WithResourceType(
+			resources.UserResourceType(scim.ContextHandler(memstore.New(resources.UserSchema(), enterprise)), enterprise),
+			resources.GroupResourceType(scim.ContextHandler(memstore.New(resources.GroupSchema()))),
+		),
+		scim.WithBasePath("/scim"),
+		scim.WithCompatibilityProfile(scim.AzureADProfile),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/scim/", server)
+	return mux
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newHandler()))
+}
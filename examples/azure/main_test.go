@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func do(t *testing.T, handler http.Handler, method, target, body string) map[string]interface{} {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code >= 300 {
+		t.Fatalf("%s %s: unexpected status %d: %s", method, target, rr.Code, rr.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// TestProvisioning follows the requests that Azure sends when provisioning a user.
+func TestProvisioning(t *testing.T) {
+	handler := newHandler()
+
+	list := do(t, handler, http.MethodGet, "/scim/Users?filter="+url.QueryEscape(`userName eq "bjensen@example.com"`), "")
+	if list["totalResults"] != 0.0 {
+		t.Fatalf("expected no users, got %v", list["totalResults"])
+	}
+
+	user := do(t, handler, http.MethodPost, "/scim/Users", `{
+		"schemas": [
+			"urn:ietf:params:scim:schemas:core:2.0:User",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+		],
+		"externalId": "bjensen",
+		"userName": "bjensen@example.com",
+		"active": true,
+		"displayName": "Barbara Jensen",
+		"emails": [{"primary": true, "type": "work", "value": "bjensen@example.com"}],
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Tour Operations"}
+	}`)
+
+	patched := do(t, handler, http.MethodPatch, "/scim/Users/"+user["id"].(string), `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "active", "value": "False"}]
+	}`)
+	if patched["active"] != false {
+		t.Errorf("expected the user to be deactivated, got %v", patched["active"])
+	}
+	enterprise, _ := patched["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"].(map[string]interface{})
+	if enterprise["department"] != "Tour Operations" {
+		t.Errorf("expected the department to be kept, got %v", enterprise["department"])
+	}
+
+	list = do(t, handler, http.MethodGet, "/scim/Users?filter="+url.QueryEscape(`userName eq "bjensen@example.com"`), "")
+	if list["totalResults"] != 1.0 {
+		t.Errorf("expected a single user, got %v", list["totalResults"])
+	}
+}
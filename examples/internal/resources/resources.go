@@ -0,0 +1,86 @@
+// Package resources contains the resource types that are shared by the examples.
+package resources
+
+import (
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+// UserSchema returns a subset of the core User schema.
+func UserSchema() schema.Schema {
+	return schema.Schema{
+		ID:          "urn:ietf:params:scim:schemas:core:2.0:User",
+		Name:        optional.NewString("User"),
+		Description: optional.NewString("User Account"),
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+				Name:       "userName",
+				Required:   true,
+				Uniqueness: schema.AttributeUniquenessServer(),
+			})),
+			schema.ComplexCoreAttribute(schema.ComplexParams{
+				Name: "name",
+				SubAttributes: []schema.SimpleParams{
+					schema.SimpleStringParams(schema.StringParams{Name: "formatted"}),
+					schema.SimpleStringParams(schema.StringParams{Name: "familyName"}),
+					schema.SimpleStringParams(schema.StringParams{Name: "givenName"}),
+				},
+			}),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+				Name: "displayName",
+			})),
+			schema.SimpleCoreAttribute(schema.SimpleBooleanParams(schema.BooleanParams{
+				Name: "active",
+			})),
+			schema.CoreUserEmails(),
+		},
+	}
+}
+
+// EnterpriseUserSchema returns a subset of the enterprise User schema extension.
+func EnterpriseUserSchema() schema.Schema {
+	return schema.Schema{
+		ID:          "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+		Name:        optional.NewString("EnterpriseUser"),
+		Description: optional.NewString("Enterprise User"),
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+				Name: "employeeNumber",
+			})),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+				Name: "department",
+			})),
+		},
+	}
+}
+
+// GroupSchema returns the core Group schema.
+func GroupSchema() schema.Schema {
+	return schema.CoreGroupSchema()
+}
+
+// UserResourceType returns the User resource type with given handler.
+func UserResourceType(handler scim.ResourceHandler, extensions ...scim.SchemaExtension) scim.ResourceType {
+	return scim.ResourceType{
+		ID:               optional.NewString("User"),
+		Name:             "User",
+		Endpoint:         "/Users",
+		Description:      optional.NewString("User Account"),
+		Schema:           UserSchema(),
+		SchemaExtensions: extensions,
+		Handler:          handler,
+	}
+}
+
+// GroupResourceType returns the Group resource type with given handler.
+func GroupResourceType(handler scim.ResourceHandler) scim.ResourceType {
+	return scim.ResourceType{
+		ID:          optional.NewString("Group"),
+		Name:        "Group",
+		Endpoint:    "/Groups",
+		Description: optional.NewString("Group"),
+		Schema:      GroupSchema(),
+		Handler:     handler,
+	}
+}
@@ -0,0 +1,37 @@
+// Command memory runs a SCIM server that keeps its users and groups in memory.
+//
+//	go run ./examples/memory -addr :8080
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/examples/internal/resources"
+	"github.com/elimity-com/scim/examples/memstore"
+)
+
+func newServer() scim.Server {
+	return scim.Server{
+		Config: scim.ServiceProviderConfig{
+			Features: scim.Features{
+				Filter: scim.FilterFeature{Supported: true},
+				Patch:  scim.PatchFeature{Supported: true},
+			},
+		},
+		ResourceTypes: []scim.ResourceType{
+			resources.UserResourceType(scim.ContextHandler(memstore.New(resources.UserSchema()))),
+			resources.GroupResourceType(scim.ContextHandler(memstore.New(resources.GroupSchema()))),
+		},
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newServer()))
+}
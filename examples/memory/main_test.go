@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim"
+)
+
+func do(t *testing.T, handler http.Handler, method, target, body string) map[string]interface{} {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code >= 300 {
+		t.Fatalf("%s %s: unexpected status %d: %s", method, target, rr.Code, rr.Body.String())
+	}
+	if rr.Code == http.StatusNoContent {
+		return nil
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestServer(t *testing.T) {
+	server := newServer()
+
+	user := do(t, server, http.MethodPost, "/Users", `{"userName": "bjensen", "active": true}`)
+	do(t, server, http.MethodPost, "/Users", `{"userName": "jsmith"}`)
+	do(t, server, http.MethodPost, "/Groups", `{
+		"displayName": "Tour Guides",
+		"members": [{"value": "`+user["id"].(string)+`", "type": "User"}]
+	}`)
+
+	list := do(t, server, http.MethodGet, "/Users?filter="+url.QueryEscape(`userName eq "BJENSEN"`), "")
+	if list["totalResults"] != 1.0 {
+		t.Errorf("expected a single user, got %v", list["totalResults"])
+	}
+
+	patched := do(t, server, http.MethodPatch, "/Users/"+user["id"].(string), `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "active", "value": false}]
+	}`)
+	if patched["active"] != false {
+		t.Errorf("expected the user to be deactivated, got %v", patched["active"])
+	}
+
+	list = do(t, server, http.MethodGet, "/Groups?filter="+url.QueryEscape(`members[value eq "`+user["id"].(string)+`"]`), "")
+	if list["totalResults"] != 1.0 {
+		t.Errorf("expected a single group, got %v", list["totalResults"])
+	}
+
+	group := do(t, server, http.MethodPatch, "/Groups/"+list["Resources"].([]interface{})[0].(map[string]interface{})["id"].(string), `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "remove", "path": "members[value eq \"`+user["id"].(string)+`\"]"}]
+	}`)
+	if group["members"] != nil {
+		t.Errorf("expected the member to be removed, got %v", group["members"])
+	}
+
+	do(t, server, http.MethodDelete, "/Users/"+user["id"].(string), "")
+	list = do(t, server, http.MethodGet, "/Users", "")
+	if list["totalResults"] != 1.0 {
+		t.Errorf("expected a single user after deletion, got %v", list["totalResults"])
+	}
+}
+
+func TestServerMeta(t *testing.T) {
+	server := newServer()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server.Clock = scim.ClockFunc(func() time.Time { return now })
+
+	created := do(t, server, http.MethodPost, "/Users", `{"userName": "bjensen"}`)["meta"].(map[string]interface{})
+	if created["created"] != "2020-01-02T03:04:05Z" || created["lastModified"] != "2020-01-02T03:04:05Z" {
+		t.Errorf("expected the timestamps of the clock, got %v", created)
+	}
+
+	now = now.Add(time.Hour)
+	patched := do(t, server, http.MethodPatch, "/Users/1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "active", "value": false}]
+	}`)["meta"].(map[string]interface{})
+	if patched["created"] != "2020-01-02T03:04:05Z" || patched["lastModified"] != "2020-01-02T04:04:05Z" {
+		t.Errorf("expected the modification to be recorded, got %v", patched)
+	}
+	if patched["version"] == nil || patched["version"] == created["version"] {
+		t.Errorf("expected the version to change, got %v and %v", created["version"], patched["version"])
+	}
+}
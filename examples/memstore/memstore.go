@@ -0,0 +1,245 @@
+// Package memstore provides a (context) resource handler that keeps its resources in memory. It is used by the examples and can
+// serve as a starting point for your own resource handler, but it is not meant for production use.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+// Handler is a context resource handler that keeps its resources in memory, use scim.ContextHandler to assign it to a
+// resource type. It is safe for concurrent use.
+//
+// The "created" and "lastModified" meta attributes of the resources are taken from the clock of the server, see
+// scim.ClockFromContext. Their version consists of their revision and the time of their last modification, so it
+// changes with every modification, even if the clock is fixed, and it is deterministic for a fixed clock.
+type Handler struct {
+	// resourceType only holds the schemas of the resources, which are used to evaluate filters and apply patches.
+	resourceType scim.ResourceType
+
+	mu          sync.RWMutex
+	data        map[string]scim.ResourceAttributes
+	externalIDs map[string]optional.String
+	meta        map[string]meta
+	lastID      int
+}
+
+// meta holds the timestamps and revision of a stored resource.
+type meta struct {
+	created, lastModified time.Time
+	revision              int
+}
+
+// New creates an empty handler for resources with given schema and schema extensions.
+func New(s schema.Schema, extensions ...scim.SchemaExtension) *Handler {
+	return &Handler{
+		resourceType: scim.ResourceType{
+			Schema:           s,
+			SchemaExtensions: extensions,
+		},
+		data:        make(map[string]scim.ResourceAttributes),
+		externalIDs: make(map[string]optional.String),
+		meta:        make(map[string]meta),
+	}
+}
+
+// Create stores given attributes and external identifier under a new identifier.
+func (h *Handler) Create(ctx context.Context, attributes scim.ResourceAttributes, externalID optional.String) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	id := strconv.Itoa(h.lastID)
+	h.data[id] = copyAttributes(attributes)
+	h.externalIDs[id] = externalID
+	now := scim.ClockFromContext(ctx).Now()
+	h.meta[id] = meta{created: now, lastModified: now, revision: 1}
+	return h.resource(id), nil
+}
+
+// Get returns the resource with given identifier.
+func (h *Handler) Get(_ context.Context, id string) (scim.Resource, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.data[id]; !ok {
+		return scim.Resource{}, scim.ErrResourceNotFound
+	}
+	return h.resource(id), nil
+}
+
+// GetAll returns the resources that match the filter of given parameters, ordered by their creation.
+func (h *Handler) GetAll(_ context.Context, params scim.ListRequestParams) (scim.Page, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ids := make([]string, 0, len(h.data))
+	for id := range h.data {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	resources := make([]scim.Resource, 0)
+	for _, id := range ids {
+		if params.Filter == nil || h.resourceType.MatchesFilter(params.Filter, h.data[id]) {
+			resources = append(resources, h.resource(id))
+		}
+	}
+
+	start, end := params.Window(len(resources))
+	return scim.Page{
+		TotalResults: len(resources),
+		Resources:    resources[start:end],
+	}, nil
+}
+
+// Replace replaces all attributes and the external identifier of the resource with given identifier.
+func (h *Handler) Replace(ctx context.Context, id string, attributes scim.ResourceAttributes, externalID optional.String) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.data[id]; !ok {
+		return scim.Resource{}, scim.ErrResourceNotFound
+	}
+	h.data[id] = copyAttributes(attributes)
+	h.externalIDs[id] = externalID
+	h.modified(ctx, id)
+	return h.resource(id), nil
+}
+
+// Delete removes the resource with given identifier.
+func (h *Handler) Delete(_ context.Context, id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.data[id]; !ok {
+		return scim.ErrResourceNotFound
+	}
+	delete(h.data, id)
+	delete(h.externalIDs, id)
+	delete(h.meta, id)
+	return nil
+}
+
+// Patch applies given operations to the resource with given identifier, see scim.ResourceType.ApplyPatch.
+func (h *Handler) Patch(ctx context.Context, id string, request scim.PatchRequest) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stored, ok := h.data[id]
+	if !ok {
+		return scim.Resource{}, scim.ErrResourceNotFound
+	}
+
+	// The external identifier is not part of the attributes, so it is patched separately.
+	externalID := h.externalIDs[id]
+	var operations []scim.PatchOperation
+	for _, op := range request.Operations {
+		if strings.EqualFold(op.Path, "externalId") {
+			externalID = patchExternalID(op.Op, op.Value)
+			continue
+		}
+		if values, ok := op.Value.(map[string]interface{}); ok && op.Path == "" {
+			attributes := make(map[string]interface{}, len(values))
+			for k, v := range values {
+				if strings.EqualFold(k, "externalId") {
+					externalID = patchExternalID(op.Op, v)
+					continue
+				}
+				attributes[k] = v
+			}
+			op.Value = attributes
+		}
+		operations = append(operations, op)
+	}
+	request.Operations = operations
+
+	// The operations are applied on a copy, so no changes are made when one of the operations fails.
+	attributes, err := h.resourceType.ApplyPatch(stored, request)
+	if err != nil {
+		return scim.Resource{}, &scim.Error{
+			ScimType: errors.ScimTypeNoTarget,
+			Detail:   err.Error(),
+			Status:   http.StatusBadRequest,
+			Err:      err,
+		}
+	}
+
+	h.data[id] = attributes
+	h.externalIDs[id] = externalID
+	h.modified(ctx, id)
+	return h.resource(id), nil
+}
+
+// modified records a modification of the resource with given identifier at the time of the clock of the server.
+func (h *Handler) modified(ctx context.Context, id string) {
+	m := h.meta[id]
+	m.lastModified = scim.ClockFromContext(ctx).Now()
+	m.revision++
+	h.meta[id] = m
+}
+
+// resource returns a copy of the stored resource with given identifier, so the server can not modify the stored
+// attributes when writing its response.
+func (h *Handler) resource(id string) scim.Resource {
+	m := h.meta[id]
+	return scim.Resource{
+		ID:         id,
+		Attributes: copyAttributes(h.data[id]),
+		ExternalID: h.externalIDs[id],
+		Meta: scim.Meta{
+			Created:      &m.created,
+			LastModified: &m.lastModified,
+		},
+		Version: fmt.Sprintf("%d-%x", m.revision, m.lastModified.UnixNano()),
+	}
+}
+
+// patchExternalID returns the external identifier after a patch operation with given value, which the server already
+// validated to be a string.
+func patchExternalID(op string, value interface{}) optional.String {
+	if s, ok := value.(string); ok && op != scim.PatchOperationRemove {
+		return optional.NewString(s)
+	}
+	return optional.String{}
+}
+
+func copyAttributes(attributes map[string]interface{}) scim.ResourceAttributes {
+	c := make(scim.ResourceAttributes, len(attributes))
+	for k, v := range attributes {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}(copyAttributes(v))
+	case scim.ResourceAttributes:
+		return map[string]interface{}(copyAttributes(v))
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyValue(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,71 @@
+// Command multitenant runs a SCIM server with a separate in-memory directory per tenant. The tenant is part of the
+// URL, e.g. "/tenants/acme/Users", and its directory is created on first use. A real deployment should of course
+// authenticate the client and verify that it is allowed to access the tenant.
+//
+//	go run ./examples/multitenant -addr :8080
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/examples/internal/resources"
+	"github.com/elimity-com/scim/examples/memstore"
+)
+
+// tenants routes requests to the SCIM server of the tenant in the URL.
+type tenants struct {
+	mu      sync.Mutex
+	servers map[string]http.Handler
+}
+
+func newTenants() *tenants {
+	return &tenants{servers: make(map[string]http.Handler)}
+}
+
+func (t *tenants) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/tenants/")
+	i := strings.Index(path, "/")
+	if path == r.URL.Path || i <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+	tenant := path[:i]
+	http.StripPrefix("/tenants/"+tenant, t.server(tenant)).ServeHTTP(w, r)
+}
+
+// server returns the SCIM server of given tenant, which is created if it does not exist yet.
+func (t *tenants) server(tenant string) http.Handler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	server, ok := t.servers[tenant]
+	if !ok {
+		server = scim.Server{
+			Config: scim.ServiceProviderConfig{
+				Features: scim.Features{
+					Filter: scim.FilterFeature{Supported: true},
+					Patch:  scim.PatchFeature{Supported: true},
+				},
+			},
+			ResourceTypes: []scim.ResourceType{
+				resources.UserResourceType(scim.ContextHandler(memstore.New(resources.UserSchema()))),
+				resources.GroupResourceType(scim.ContextHandler(memstore.New(resources.GroupSchema()))),
+			},
+		}
+		t.servers[tenant] = server
+	}
+	return server
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newTenants()))
+}
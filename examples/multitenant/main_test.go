@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	tenants := newTenants()
+
+	req := httptest.NewRequest(http.MethodPost, "/tenants/acme/Users", strings.NewReader(`{"userName": "bjensen"}`))
+	rr := httptest.NewRecorder()
+	tenants.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	for tenant, expected := range map[string]float64{"acme": 1, "initech": 0} {
+		req := httptest.NewRequest(http.MethodGet, "/tenants/"+tenant+"/Users", nil)
+		rr := httptest.NewRecorder()
+		tenants.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d: %s", tenant, rr.Code, rr.Body.String())
+		}
+
+		var list map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatal(err)
+		}
+		if list["totalResults"] != expected {
+			t.Errorf("%s: wrong number of users: got %v want %v", tenant, list["totalResults"], expected)
+		}
+	}
+
+	for _, path := range []string{"/Users", "/tenants/", "/tenants/acme"} {
+		rr := httptest.NewRecorder()
+		tenants.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Command sqlstore runs a SCIM server that stores its users in a SQL database, using the filter translation of the
+// scim package to push filters down to the database. To keep the module free of database dependencies, the example
+// does not register a database driver, so it does not run as is. To run it, import a driver in a copy of the example,
+// e.g. for SQLite:
+//
+//	import _ "github.com/mattn/go-sqlite3"
+//
+// and run the copy with the name of the driver and the data source name of the database:
+//
+//	go run . -driver sqlite3 -dsn users.db
+//
+// The users are stored in the following table:
+//
+//	CREATE TABLE users (
+//		id           INTEGER PRIMARY KEY,
+//		external_id  TEXT,
+//		user_name    TEXT NOT NULL UNIQUE COLLATE NOCASE,
+//		display_name TEXT,
+//		active       BOOLEAN
+//	);
+//
+// Since user names are case-insensitive in SCIM, the column uses a case-insensitive collation.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/examples/internal/resources"
+	"github.com/elimity-com/scim/optional"
+)
+
+// columns maps the supported attributes to the columns of the users table.
+var columns = map[string]string{
+	"id":          "id",
+	"externalId":  "external_id",
+	"userName":    "user_name",
+	"displayName": "display_name",
+	"active":      "active",
+}
+
+// userHandler is a context resource handler that stores users in the users table. The user name is the only constraint
+// of the table, so a failing insert or update is reported as a uniqueness conflict. Errors of the database are never
+// returned to the client.
+type userHandler struct {
+	db *sql.DB
+}
+
+// listQueries returns the queries to count and select the users that match the filter of given parameters.
+func listQueries(params scim.ListRequestParams) (count string, list string, args []interface{}, err error) {
+	where := "1 = 1"
+	if params.Filter != nil {
+		where, args, err = scim.SQLFilter{
+			Columns: columns,
+			// The schemas provide the data types of the attributes, e.g. to compare the active column with a boolean.
+			SchemaSet: resources.UserResourceType(nil).SchemaSet(),
+		}.Where(params.Filter)
+		if err != nil {
+			return "", "", nil, err
+		}
+	}
+	count = "SELECT COUNT(*) FROM users WHERE " + where
+	list = fmt.Sprintf(
+		"SELECT id, external_id, user_name, display_name, active FROM users WHERE %s ORDER BY id LIMIT %d OFFSET %d",
+		where, params.Count, params.Offset(),
+	)
+	return count, list, args, nil
+}
+
+func (h userHandler) Create(ctx context.Context, attributes scim.ResourceAttributes, externalID optional.String) (scim.Resource, error) {
+	result, err := h.db.ExecContext(
+		ctx,
+		"INSERT INTO users (external_id, user_name, display_name, active) VALUES (?, ?, ?, ?)",
+		nullString(externalID), attributes["userName"], attributes["displayName"], attributes["active"],
+	)
+	if err != nil {
+		return scim.Resource{}, &scim.Error{ScimType: errors.ScimTypeUniqueness, Status: http.StatusConflict, Err: err}
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return scim.Resource{}, fmt.Errorf("failed to retrieve identifier of created user: %w", err)
+	}
+	return scim.Resource{
+		ID:         strconv.FormatInt(id, 10),
+		Attributes: attributes,
+		ExternalID: externalID,
+	}, nil
+}
+
+func (h userHandler) Get(ctx context.Context, id string) (scim.Resource, error) {
+	row := h.db.QueryRowContext(
+		ctx,
+		"SELECT id, external_id, user_name, display_name, active FROM users WHERE id = ?",
+		id,
+	)
+	resource, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return scim.Resource{}, &scim.Error{Status: http.StatusNotFound, Err: err}
+	}
+	if err != nil {
+		return scim.Resource{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return resource, nil
+}
+
+func (h userHandler) GetAll(ctx context.Context, params scim.ListRequestParams) (scim.Page, error) {
+	countQuery, listQuery, args, err := listQueries(params)
+	if err != nil {
+		return scim.Page{}, &scim.Error{
+			ScimType: errors.ScimTypeInvalidFilter,
+			Detail:   err.Error(),
+			Status:   http.StatusBadRequest,
+		}
+	}
+
+	var total int
+	if err := h.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return scim.Page{}, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	rows, err := h.db.QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		return scim.Page{}, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []scim.Resource
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return scim.Page{}, fmt.Errorf("failed to read user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return scim.Page{}, fmt.Errorf("failed to list users: %w", err)
+	}
+	return scim.Page{TotalResults: total, Resources: users}, nil
+}
+
+func (h userHandler) Replace(ctx context.Context, id string, attributes scim.ResourceAttributes, externalID optional.String) (scim.Resource, error) {
+	result, err := h.db.ExecContext(
+		ctx,
+		"UPDATE users SET external_id = ?, user_name = ?, display_name = ?, active = ? WHERE id = ?",
+		nullString(externalID), attributes["userName"], attributes["displayName"], attributes["active"], id,
+	)
+	if err != nil {
+		return scim.Resource{}, &scim.Error{ScimType: errors.ScimTypeUniqueness, Status: http.StatusConflict, Err: err}
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return scim.Resource{}, scim.ErrResourceNotFound
+	}
+	return scim.Resource{ID: id, Attributes: attributes, ExternalID: externalID}, nil
+}
+
+func (h userHandler) Delete(ctx context.Context, id string) error {
+	result, err := h.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return scim.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (h userHandler) Patch(ctx context.Context, id string, request scim.PatchRequest) (scim.Resource, error) {
+	return scim.Resource{}, scim.ErrNotImplemented
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row scanner) (scim.Resource, error) {
+	var (
+		id          int64
+		externalID  sql.NullString
+		userName    string
+		displayName sql.NullString
+		active      sql.NullBool
+	)
+	if err := row.Scan(&id, &externalID, &userName, &displayName, &active); err != nil {
+		return scim.Resource{}, err
+	}
+
+	attributes := scim.ResourceAttributes{"userName": userName}
+	if displayName.Valid {
+		attributes["displayName"] = displayName.String
+	}
+	if active.Valid {
+		attributes["active"] = active.Bool
+	}
+	resource := scim.Resource{ID: strconv.FormatInt(id, 10), Attributes: attributes}
+	if externalID.Valid {
+		resource.ExternalID = optional.NewString(externalID.String)
+	}
+	return resource, nil
+}
+
+// nullString converts given optional string to a nullable column value.
+func nullString(s optional.String) sql.NullString {
+	return sql.NullString{String: s.Value(), Valid: s.Present()}
+}
+
+func newServer(db *sql.DB) scim.Server {
+	return scim.Server{
+		Config: scim.ServiceProviderConfig{
+			Features: scim.Features{
+				Filter: scim.FilterFeature{Supported: true},
+			},
+		},
+		ResourceTypes: []scim.ResourceType{
+			resources.UserResourceType(scim.ContextHandler(userHandler{db: db})),
+		},
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	driver := flag.String("driver", "sqlite3", "name of the (imported) database driver")
+	dsn := flag.String("dsn", "users.db", "data source name of the database")
+	flag.Parse()
+
+	if !registered(*driver) {
+		log.Fatalf("database driver %q is not registered, import it in a copy of this example", *driver)
+	}
+	db, err := sql.Open(*driver, *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newServer(db)))
+}
+
+// registered reports whether the database driver with given name is registered.
+func registered(driver string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim"
+)
+
+func TestListQueries(t *testing.T) {
+	expression, err := filter.NewParser(strings.NewReader(`userName sw "b" and active eq true`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, list, args, err := listQueries(scim.ListRequestParams{
+		Count:      10,
+		Filter:     expression,
+		StartIndex: 21,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	where := `(user_name LIKE ? ESCAPE '\' AND active = ?)`
+	if expected := "SELECT COUNT(*) FROM users WHERE " + where; count != expected {
+		t.Errorf("wrong count query: got %s want %s", count, expected)
+	}
+	if expected := "SELECT id, external_id, user_name, display_name, active FROM users WHERE " + where + " ORDER BY id LIMIT 10 OFFSET 20"; list != expected {
+		t.Errorf("wrong list query: got %s want %s", list, expected)
+	}
+	if expected := []interface{}{"b%", true}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("wrong arguments: got %v want %v", args, expected)
+	}
+}
+
+func TestListQueriesActive(t *testing.T) {
+	for query, expected := range map[string][]interface{}{
+		`active eq false`:                    {false},
+		`not (active eq true)`:               {true},
+		`displayName eq "true" or active pr`: {"true"},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(query)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, args, err := listQueries(scim.ListRequestParams{Count: 10, Filter: expression, StartIndex: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The active column is a BOOLEAN, which does not compare with strings in every database.
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("%s: wrong arguments: got %#v want %#v", query, args, expected)
+		}
+	}
+}
+
+func TestListQueriesUnknownAttribute(t *testing.T) {
+	expression, err := filter.NewParser(strings.NewReader(`title eq "Tour Guide"`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := listQueries(scim.ListRequestParams{Count: 10, Filter: expression, StartIndex: 1}); err == nil {
+		t.Error("expected an error for an unsupported attribute")
+	}
+}
@@ -6,16 +6,15 @@ import (
 )
 
 func ExampleNewServer() {
-	log.Fatal(http.ListenAndServe(":7643", Server{
-		Config:        ServiceProviderConfig{},
-		ResourceTypes: nil,
-	}))
+	log.Fatal(http.ListenAndServe(":7643", NewServer(
+		WithServiceProviderConfig(ServiceProviderConfig{}),
+	)))
 }
 
 func ExampleNewServer_basePath() {
-	http.Handle("/scim/", http.StripPrefix("/scim", Server{
-		Config:        ServiceProviderConfig{},
-		ResourceTypes: nil,
-	}))
+	http.Handle("/scim/", NewServer(
+		WithServiceProviderConfig(ServiceProviderConfig{}),
+		WithBasePath("/scim"),
+	))
 	log.Fatal(http.ListenAndServe(":7643", nil))
 }
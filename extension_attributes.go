@@ -0,0 +1,105 @@
+package scim
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// namespaced returns a copy of given decoded resource in which the attributes whose keys are prefixed with the URI of
+// the schema of the resource type are unprefixed, and the attributes whose keys are prefixed with the URI of one of
+// its schema extensions are nested in the object of that extension, e.g.
+//
+//	{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "701984"}
+//
+// becomes
+//
+//	{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}}
+//
+// An attribute that is given in both forms is invalid.
+func (t ResourceType) namespaced(m map[string]interface{}) (map[string]interface{}, errors.ValidationError) {
+	result := make(map[string]interface{}, len(m))
+	var prefixed []string
+	for k, v := range m {
+		if _, _, ok := t.splitPrefixedKey(k); ok {
+			prefixed = append(prefixed, k)
+			continue
+		}
+		result[k] = v
+	}
+
+	for _, k := range prefixed {
+		s, name, _ := t.splitPrefixedKey(k)
+		values := result
+		if s.ID != t.Schema.ID {
+			key := keyFold(result, s.ID)
+			switch nested := result[key].(type) {
+			case nil:
+				values = make(map[string]interface{})
+			case map[string]interface{}:
+				values = make(map[string]interface{}, len(nested)+1)
+				for k, v := range nested {
+					values[k] = v
+				}
+			default:
+				return nil, errors.ValidationErrorInvalidSyntax
+			}
+			result[key] = values
+		}
+		if _, ok := values[keyFold(values, name)]; ok {
+			return nil, errors.ValidationErrorInvalidSyntax
+		}
+		values[name] = m[k]
+	}
+	return result, errors.ValidationErrorNil
+}
+
+// splitPrefixedKey splits given key of an attribute that is prefixed with the URI of the schema or one of the schema
+// extensions of the resource type, e.g. "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+// into the schema and the name of the attribute. Only attributes that are defined by the schema are split.
+func (t ResourceType) splitPrefixedKey(k string) (schema.Schema, string, bool) {
+	var match schema.Schema
+	var name string
+	for _, s := range append([]schema.Schema{t.Schema}, t.extensionSchemas()...) {
+		if len(k) <= len(s.ID)+1 || k[len(s.ID)] != ':' || !strings.EqualFold(k[:len(s.ID)], s.ID) {
+			continue
+		}
+		// The longest URI wins, in case the URI of a schema is a prefix of another one.
+		if len(s.ID) > len(match.ID) {
+			match, name = s, k[len(s.ID)+1:]
+		}
+	}
+	if match.ID == "" {
+		return schema.Schema{}, "", false
+	}
+	if _, ok := match.Attribute(name); !ok {
+		return schema.Schema{}, "", false
+	}
+	return match, name, true
+}
+
+// WithExtension returns a copy of the resource type that is extended with given schema extension, e.g. a vendor
+// extension that is defined with schema.NewExtension. Once the resource type is added to a server, the extension is
+// listed by the "/Schemas" and "/ResourceTypes" endpoints, the URI of the extension is listed in the "schemas" of the
+// resources that have values for its attributes and these values are nested in the object of the extension, also if
+// the handler returns them with prefixed keys, e.g. "urn:mycorp:scim:schemas:extension:hr:1.0:User:costCenter".
+//
+// It returns an error if the extension has no URI or if the resource type already has a schema (extension) with the
+// same URI.
+func (t ResourceType) WithExtension(extension schema.Schema, required bool) (ResourceType, error) {
+	if extension.ID == "" {
+		return ResourceType{}, fmt.Errorf("resource type %q: the schema extension has no URI", t.Name)
+	}
+	for _, s := range append([]schema.Schema{t.Schema}, t.extensionSchemas()...) {
+		if strings.EqualFold(s.ID, extension.ID) {
+			return ResourceType{}, fmt.Errorf("resource type %q: it already has schema %q", t.Name, s.ID)
+		}
+	}
+	t.SchemaExtensions = append(append([]SchemaExtension(nil), t.SchemaExtensions...), SchemaExtension{
+		Schema:   extension,
+		Required: required,
+	})
+	return t, nil
+}
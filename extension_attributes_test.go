@@ -0,0 +1,113 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestServerPrefixedExtensionAttributes(t *testing.T) {
+	for _, test := range []struct {
+		body     string
+		expected int
+	}{
+		{`{
+			"urn:ietf:params:scim:schemas:core:2.0:User:userName": "bjensen",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "701984",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"organization": "Universal Studios"}
+		}`, http.StatusCreated},
+		{`{
+			"userName": "bjensen",
+			"URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER:employeeNumber": "701984",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:organization": "Universal Studios"
+		}`, http.StatusCreated},
+		// The same attribute in both forms.
+		{`{
+			"userName": "bjensen",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "701984",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701985"}
+		}`, http.StatusBadRequest},
+		{`{
+			"userName": "bjensen",
+			"urn:ietf:params:scim:schemas:core:2.0:User:userName": "bjensen"
+		}`, http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		handler := server.ResourceTypes[1].Handler.(testResourceHandler)
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/EnterpriseUser", strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Fatalf("expected %d, got %d: %s", test.expected, rr.Code, rr.Body.String())
+		}
+		if rr.Code != http.StatusCreated {
+			continue
+		}
+
+		var resource struct{ ID string }
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		stored := handler.data[resource.ID]
+		if stored["userName"] != "bjensen" {
+			t.Errorf("expected the unprefixed userName, got %v", stored)
+		}
+		extension, _ := stored["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"].(map[string]interface{})
+		if extension["employeeNumber"] != "701984" || extension["organization"] != "Universal Studios" {
+			t.Errorf("expected the extension attributes to be nested, got %v", stored)
+		}
+	}
+}
+
+func TestResourceTypeWithExtension(t *testing.T) {
+	hr, err := schema.NewExtension("urn:mycorp:scim:schemas:extension:hr:1.0:User",
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "costCenter"})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	users := server.ResourceTypes[0]
+	extended, err := users.WithExtension(hr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.SchemaExtensions) != 0 {
+		t.Error("expected the original resource type not to be modified")
+	}
+	if _, err := extended.WithExtension(hr, false); err == nil {
+		t.Error("expected an error for a duplicate extension")
+	}
+	server.ResourceTypes[0] = extended
+	server.ResourceTypes[0].Handler = testResourceHandler{
+		data: map[string]ResourceAttributes{"0001": {
+			"userName": "bjensen",
+			"urn:mycorp:scim:schemas:extension:hr:1.0:User:costCenter": "4130",
+		}},
+		externalIDs: make(map[string]optional.String),
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if extension, _ := resource[hr.ID].(map[string]interface{}); extension["costCenter"] != "4130" {
+		t.Errorf("expected the cost center to be nested in the extension, got %s", rr.Body.String())
+	}
+	if schemas, _ := resource["schemas"].([]interface{}); len(schemas) != 2 || schemas[1] != hr.ID {
+		t.Errorf("expected the extension to be listed in the schemas, got %v", resource["schemas"])
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Schemas/"+hr.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected the extension to be discoverable, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package scim
+
+import (
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// externalIDAttribute is the name of the "externalId" attribute, which is common to all resource types. It is an
+// identifier of the resource as defined by the provisioning client, e.g. the identifier of a user at the identity
+// provider, and is therefore not part of the schema of a resource type.
+const externalIDAttribute = "externalId"
+
+// popExternalID removes the "externalId" attribute from given decoded resource and returns its value. Attribute names
+// are case insensitive. A null value is treated as absent, any other value that is not a string is invalid.
+func popExternalID(m map[string]interface{}) (optional.String, errors.ValidationError) {
+	var externalID optional.String
+	for k, v := range m {
+		if !strings.EqualFold(k, externalIDAttribute) {
+			continue
+		}
+		delete(m, k)
+		if v == nil {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return optional.String{}, errors.ValidationErrorInvalidValue
+		}
+		externalID = optional.NewString(s)
+	}
+	return externalID, errors.ValidationErrorNil
+}
+
+// withoutExternalID validates the "externalId" attribute within given value of a PATCH operation and returns a copy of
+// the value without it, so that the remaining attributes can be validated against the schema of the resource type.
+func withoutExternalID(operation string, value map[string]interface{}) (map[string]interface{}, errors.ValidationError) {
+	rest := make(map[string]interface{}, len(value))
+	for k, v := range value {
+		if !strings.EqualFold(k, externalIDAttribute) {
+			rest[k] = v
+			continue
+		}
+		if _, ok := v.(string); !ok && operation != PatchOperationRemove {
+			return nil, errors.ValidationErrorInvalidValue
+		}
+	}
+	return rest, errors.ValidationErrorNil
+}
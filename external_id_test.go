@@ -0,0 +1,77 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerExternalID(t *testing.T) {
+	server := newTestServer()
+	server.IDGenerator = SequentialIDGenerator("user-%d")
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{
+		"userName": "test",
+		"externalId": "idp-1"
+	}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	handler := server.ResourceTypes[0].Handler.(testResourceHandler)
+	if externalID := handler.externalIDs["user-1"]; externalID.Value() != "idp-1" {
+		t.Errorf("handler did not receive the external identifier: %v", externalID)
+	}
+
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected string
+	}{
+		{http.MethodGet, "/Users/user-1", "", "idp-1"},
+		{http.MethodPut, "/Users/user-1", `{"userName": "test", "externalId": "idp-2"}`, "idp-2"},
+		{http.MethodPatch, "/Users/user-1", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "externalId", "value": "idp-3"}]
+		}`, "idp-3"},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", test.method, http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		var resource map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		if resource["externalId"] != test.expected {
+			t.Errorf("%s: expected external identifier %q, got %v", test.method, test.expected, resource["externalId"])
+		}
+	}
+}
+
+func TestServerExternalIDInvalid(t *testing.T) {
+	for _, test := range []struct {
+		method string
+		target string
+		body   string
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test", "externalId": 1}`},
+		{http.MethodPut, "/Users/0001", `{"userName": "test", "ExternalId": true}`},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "value": {"externalId": ["idp-1"]}}]
+		}`},
+	} {
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", test.method, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
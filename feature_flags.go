@@ -0,0 +1,191 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// FeatureFlags are features that are enabled for a single request, e.g. verbose errors for the requests of a support
+// engineer who is debugging a provisioning issue. Flags only enable features: features that are enabled for the server
+// itself stay enabled.
+type FeatureFlags struct {
+	// VerboseErrors adds the cause of an error to the "detail" of the error response, e.g. the attribute whose value is
+	// invalid or the error that a callback method returned. Causes can reveal the internals of the service provider,
+	// so only enable this for trusted clients.
+	VerboseErrors bool
+	// CoercePatchValues enables Server.CoercePatchValues for the request.
+	CoercePatchValues bool
+	// StrictReadOnly enables Server.StrictReadOnly for the request.
+	StrictReadOnly bool
+}
+
+type featureFlagsContextKey struct{}
+
+// WithFeatureFlags returns a copy of given context that enables given features for the request, e.g. in middleware
+// or an Authenticator that recognizes a debugging session by a header of an authenticated client. Flags that are added
+// after the request is authenticated by the server, i.e. in callback methods, have no effect.
+func WithFeatureFlags(ctx context.Context, flags FeatureFlags) context.Context {
+	return context.WithValue(ctx, featureFlagsContextKey{}, flags)
+}
+
+// FeatureFlagsFromContext returns the feature flags that are enabled for the request with given context.
+func FeatureFlagsFromContext(ctx context.Context) FeatureFlags {
+	flags, _ := ctx.Value(featureFlagsContextKey{}).(FeatureFlags)
+	return flags
+}
+
+// withFeatureFlags returns a copy of the server with the features that are enabled for given request, and a shallow
+// copy of the request that collects the causes of its errors if verbose errors are enabled.
+func (s Server) withFeatureFlags(r *http.Request) (Server, *http.Request) {
+	flags := FeatureFlagsFromContext(r.Context())
+	s.CoercePatchValues = s.CoercePatchValues || flags.CoercePatchValues
+	s.StrictReadOnly = s.StrictReadOnly || flags.StrictReadOnly
+	if flags.VerboseErrors {
+		r = r.WithContext(context.WithValue(r.Context(), errorCauseContextKey{}, &errorCause{}))
+	}
+	return s, r
+}
+
+// errorCause holds the cause of the next error response of a request with verbose errors.
+type errorCause struct {
+	mu    sync.Mutex
+	cause string
+}
+
+type errorCauseContextKey struct{}
+
+// recordErrorCause records the cause of the next error response of the request with given context, if verbose errors
+// are enabled for it. The cause is computed lazily, since it is of no use otherwise.
+func recordErrorCause(ctx context.Context, cause func() string) {
+	holder, ok := ctx.Value(errorCauseContextKey{}).(*errorCause)
+	if !ok {
+		return
+	}
+	c := cause()
+	if c == "" {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	if holder.cause == "" {
+		holder.cause = c
+	}
+}
+
+// takeErrorCause returns and clears the recorded cause of the next error response of given request.
+func takeErrorCause(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	holder, ok := r.Context().Value(errorCauseContextKey{}).(*errorCause)
+	if !ok {
+		return ""
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	cause := holder.cause
+	holder.cause = ""
+	return cause
+}
+
+// textValidationCause describes why the body of a request does not pass the text validation of the server.
+func textValidationCause() string {
+	return "the body contains invalid UTF-8 or control characters"
+}
+
+// resourceBodyValidationCause is the counterpart of resourceValidationCause for the undecoded body of a POST or PUT
+// request.
+func (t ResourceType) resourceBodyValidationCause(data []byte, strictReadOnly bool) string {
+	var m map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil || m == nil {
+		return "the body is not a JSON object"
+	}
+	return t.resourceValidationCause(m, strictReadOnly)
+}
+
+// resourceValidationCause describes the first attribute of given resource, the decoded body of a POST or PUT request,
+// that does not pass the validation against the schema or schema extensions of the resource type.
+func (t ResourceType) resourceValidationCause(m map[string]interface{}, strictReadOnly bool) string {
+	m, scimErr := t.namespaced(m)
+	if scimErr != errors.ValidationErrorNil {
+		return "the body contains attributes that are given both with and without their schema URI"
+	}
+	if _, readOnly := t.withoutReadOnly(m); readOnly && strictReadOnly {
+		return "the body contains values of read-only attributes"
+	}
+
+	if cause := attributesValidationCause(t.Schema, m, ""); cause != "" {
+		return cause
+	}
+	for _, extension := range t.SchemaExtensions {
+		values := lookupFold(m, extension.Schema.ID)
+		if values == nil {
+			if extension.Required {
+				return fmt.Sprintf("the required schema extension %q is missing", extension.Schema.ID)
+			}
+			continue
+		}
+		complex, ok := values.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("the value of schema extension %q is not a JSON object", extension.Schema.ID)
+		}
+		if cause := attributesValidationCause(extension.Schema, complex, extension.Schema.ID+":"); cause != "" {
+			return cause
+		}
+	}
+	return ""
+}
+
+// attributesValidationCause describes the first attribute of given schema whose value in given attributes is missing
+// or invalid. The name of the attribute is prefixed with given prefix.
+func attributesValidationCause(s schema.Schema, attributes map[string]interface{}, prefix string) string {
+	for _, attribute := range s.Attributes {
+		value := lookupFold(attributes, attribute.Name())
+		single := schema.Schema{ID: s.ID, Attributes: []schema.CoreAttribute{attribute}}
+		if _, scimErr := single.Validate(map[string]interface{}{attribute.Name(): value}); scimErr == errors.ValidationErrorNil {
+			continue
+		}
+		if value == nil {
+			return fmt.Sprintf("the required attribute %q is missing", prefix+attribute.Name())
+		}
+		return fmt.Sprintf("the value of attribute %q is invalid", prefix+attribute.Name())
+	}
+	return ""
+}
+
+// patchValidationCause describes the first operation of given PATCH request, the undecoded body of a PATCH request,
+// that does not pass validation.
+func (t ResourceType) patchValidationCause(data []byte, coerce bool) string {
+	var req PatchRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return "the body is not a valid PATCH request"
+	}
+	return t.patchOperationsValidationCause(req, coerce)
+}
+
+// patchOperationsValidationCause describes the first of given PATCH operations that does not pass validation.
+func (t ResourceType) patchOperationsValidationCause(req PatchRequest, coerce bool) string {
+	if len(req.Operations) == 0 {
+		return "the request contains no operations"
+	}
+	for i, op := range req.Operations {
+		op.Op = strings.ToLower(op.Op)
+		if coerce {
+			op = t.coerceOperationValue(op)
+		}
+		if causes := t.validateOperation(op); len(causes) != 0 {
+			return fmt.Sprintf("operation %d: %s", i+1, strings.Join(causes, ", "))
+		}
+	}
+	return ""
+}
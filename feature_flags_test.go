@@ -0,0 +1,78 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeatureFlagsVerboseErrors(t *testing.T) {
+	for _, test := range []struct {
+		method, target, body string
+		cause                string
+	}{
+		{http.MethodPost, "/Users", `{"active": true}`, `the required attribute "userName" is missing`},
+		{http.MethodPost, "/Users", `{"userName": 42}`, `the value of attribute "userName" is invalid`},
+		{
+			http.MethodPost, "/EnterpriseUser",
+			`{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"organization": 1}}`,
+			`the value of attribute "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:organization" is invalid`,
+		},
+		{http.MethodPut, "/Users/0001", `[]`, "the body is not a JSON object"},
+		{
+			http.MethodPatch, "/Users/0001",
+			`{"Operations": [{"op": "add", "path": "displayName", "value": "Babs"}, {"op": "remove"}]}`,
+			"operation 2: path is required on a remove operation",
+		},
+	} {
+		for _, verbose := range []bool{false, true} {
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			r = r.WithContext(WithFeatureFlags(r.Context(), FeatureFlags{VerboseErrors: verbose}))
+			rr := httptest.NewRecorder()
+			newTestServer().ServeHTTP(rr, r)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("%s %s: expected %d, got %d: %s", test.method, test.body, http.StatusBadRequest, rr.Code, rr.Body.String())
+			}
+			var response struct {
+				Detail string
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if verbose && !strings.Contains(response.Detail, " Cause: "+test.cause) {
+				t.Errorf("%s %s: expected the cause %q, got %q", test.method, test.body, test.cause, response.Detail)
+			}
+			if !verbose && strings.Contains(response.Detail, "Cause") {
+				t.Errorf("%s %s: expected no cause, got %q", test.method, test.body, response.Detail)
+			}
+		}
+	}
+}
+
+func TestFeatureFlagsStrictReadOnly(t *testing.T) {
+	server := NewServer(
+		WithServiceProviderConfig(newTestServer().Config),
+		WithResourceType(newTestServer().ResourceTypes...),
+		WithAuthenticator(AuthenticatorFunc(func(r *http.Request) (*http.Request, error) {
+			flags := FeatureFlags{StrictReadOnly: r.Header.Get("X-Strict") == "true"}
+			return r.WithContext(WithFeatureFlags(r.Context(), flags)), nil
+		})),
+	)
+	for _, strict := range []bool{false, true} {
+		expected := http.StatusCreated
+		if strict {
+			expected = http.StatusBadRequest
+		}
+		r := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{"userName": "test", "readonlyThing": "x"}`))
+		if strict {
+			r.Header.Set("X-Strict", "true")
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, r)
+		if rr.Code != expected {
+			t.Errorf("strict: %t: expected %d, got %d: %s", strict, expected, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceProviderConfigFeatures(t *testing.T) {
+	for _, test := range []struct {
+		config   ServiceProviderConfig
+		expected Features
+	}{
+		{
+			ServiceProviderConfig{},
+			Features{
+				Bulk:   BulkFeature{MaxOperations: 1000, MaxPayloadSize: 1048576},
+				Filter: FilterFeature{MaxResults: 100},
+			},
+		},
+		{
+			ServiceProviderConfig{SupportBulk: true, BulkMaxOpts: 10, SupportSort: true, MaxResults: 50},
+			Features{
+				Bulk:   BulkFeature{Supported: true, MaxOperations: 10, MaxPayloadSize: 1048576},
+				Filter: FilterFeature{MaxResults: 50},
+				Sort:   SortFeature{Supported: true},
+			},
+		},
+		{
+			ServiceProviderConfig{
+				Features: Features{
+					Patch:  PatchFeature{Supported: true, MaxOperations: 5},
+					Filter: FilterFeature{MaxResults: 20},
+					ETag:   ETagFeature{Supported: true},
+				},
+				MaxResults: 50,
+			},
+			Features{
+				Patch:  PatchFeature{Supported: true, MaxOperations: 5},
+				Bulk:   BulkFeature{MaxOperations: 1000, MaxPayloadSize: 1048576},
+				Filter: FilterFeature{MaxResults: 20},
+				ETag:   ETagFeature{Supported: true},
+			},
+		},
+	} {
+		if features := test.config.features(); !reflect.DeepEqual(features, test.expected) {
+			t.Errorf("unexpected features: got %+v want %+v", features, test.expected)
+		}
+	}
+}
+
+func TestServerPatchMaxOperations(t *testing.T) {
+	server := newTestServer()
+	server.Config.Features.Patch = PatchFeature{Supported: true, MaxOperations: 1}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "replace", "path": "displayName", "value": "a"},
+			{"op": "replace", "path": "displayName", "value": "b"}
+		]
+	}`)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"scimType":"tooMany"`) {
+		t.Errorf("expected too many operations, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ServiceProviderConfig", nil))
+	if !strings.Contains(rr.Body.String(), `"patch":{"maxOperations":1,"supported":true}`) {
+		t.Errorf("expected the patch feature in the service provider configuration, got %s", rr.Body.String())
+	}
+}
+
+func TestServerPatchNotSupported(t *testing.T) {
+	server := newTestServer()
+	server.Config.Features.Patch.Supported = false
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "displayName", "value": "a"}]
+	}`)))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("expected PATCH requests not to be implemented, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resource, _ := server.ResourceTypes[0].Handler.Get(nil, "0001"); resource.Attributes["displayName"] != nil {
+		t.Errorf("expected the request not to be forwarded to the handler, got %v", resource.Attributes)
+	}
+}
+
+func TestServerFilterNotSupported(t *testing.T) {
+	for _, test := range []struct {
+		policy         UnsupportedFilterPolicy
+		expectedStatus int
+		expectedFilter string
+	}{
+		{UnsupportedFilterPassThrough, http.StatusOK, `userName eq "bjensen"`},
+		{UnsupportedFilterIgnore, http.StatusOK, ""},
+		{UnsupportedFilterReject, http.StatusBadRequest, ""},
+	} {
+		var params ListRequestParams
+		server := newTestServer()
+		server.Config.Features.Filter.Unsupported = test.policy
+		server.ResourceTypes[0].Handler = paramsResourceHandler{params: &params}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?filter="+url.QueryEscape(`userName eq "bjensen"`), nil))
+		if rr.Code != test.expectedStatus {
+			t.Errorf("policy %d: got status %d, want %d: %s", test.policy, rr.Code, test.expectedStatus, rr.Body.String())
+		}
+		if params.RawFilter != test.expectedFilter || (test.expectedFilter == "") != (params.Filter == nil) {
+			t.Errorf("policy %d: got filter %q, want %q", test.policy, params.RawFilter, test.expectedFilter)
+		}
+		if test.expectedStatus == http.StatusBadRequest && !strings.Contains(rr.Body.String(), `"scimType":"invalidFilter"`) {
+			t.Errorf("policy %d: expected an invalid filter error, got %s", test.policy, rr.Body.String())
+		}
+
+		// Filters are always passed if filtering is supported.
+		server.Config.Features.Filter.Supported = true
+		rr = httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?filter="+url.QueryEscape(`userName eq "bjensen"`), nil))
+		if rr.Code != http.StatusOK || params.RawFilter != `userName eq "bjensen"` {
+			t.Errorf("policy %d: expected the filter to be passed, got %d %q", test.policy, rr.Code, params.RawFilter)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/schema"
+)
+
+// MatchesFilter reports whether given resource attributes match given (parsed) filter expression, so handlers that keep
+// their resources in memory can support filtering without implementing their own matcher. The attributes of the schema
+// and schema extensions of the resource type determine how values are compared (e.g. their data type and case
+// exactness). Attributes that are not defined by any of the schemas are compared case-insensitively based on their
+// JSON value. Comparisons that are not supported by the data type of the attribute (e.g. "gt" on a boolean) never match.
+func (t ResourceType) MatchesFilter(expression filter.Expression, attributes ResourceAttributes) bool {
+	return filterMatcher{
+		attributes: t.Schema.Attributes,
+		extensions: t.SchemaExtensions,
+	}.matches(expression, attributes)
+}
+
+// MatchesValueFilter reports whether given value of a multi-valued attribute matches the value filter of given PATCH
+// path, e.g. the member {"value": "2819c223"} for `members[value eq "2819c223"]`, so handlers can select the values
+// that a PATCH operation targets. The sub-attributes of the attribute determine how values are compared. Values always
+// match paths without a value filter.
+func (t ResourceType) MatchesValueFilter(path PatchPath, value interface{}) bool {
+	if path.ValueFilter == nil {
+		return true
+	}
+	complex, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	var m filterMatcher
+	if attribute, ok := t.SchemaSet().attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName}); ok {
+		m.attributes = attribute.SubAttributes()
+	}
+	return m.matches(path.ValueFilter, complex)
+}
+
+// filterMatcher evaluates filter expressions against the attributes of a resource, or against the sub-attributes of a
+// complex value within a value path filter.
+type filterMatcher struct {
+	attributes []schema.CoreAttribute
+	extensions []SchemaExtension
+}
+
+func (m filterMatcher) matches(expression filter.Expression, values map[string]interface{}) bool {
+	switch e := expression.(type) {
+	case filter.BinaryExpression:
+		switch e.CompareOperator {
+		case filter.AND:
+			return m.matches(e.X, values) && m.matches(e.Y, values)
+		case filter.OR:
+			return m.matches(e.X, values) || m.matches(e.Y, values)
+		}
+	case filter.UnaryExpression:
+		if e.CompareOperator == filter.NOT {
+			return !m.matches(e.X, values)
+		}
+	case filter.AttributeExpression:
+		attribute, value := m.lookup(e.AttributePath.URIPrefix, e.AttributePath.AttributeName, values)
+		return matchesAttribute(attribute, value, e.AttributePath.SubAttribute, e.CompareOperator, e.CompareValue)
+	case filter.ValuePath:
+		attribute, value := m.lookup("", e.AttributeName, values)
+		var sub filterMatcher
+		if attribute != nil {
+			sub.attributes = attribute.SubAttributes()
+		}
+		for _, v := range flatten(value) {
+			if complex, ok := v.(map[string]interface{}); ok && sub.matches(e.ValueExpression, complex) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup returns the attribute definition (nil if unknown) and value of the attribute with given name. Attributes of
+// schema extensions are nested in the value of the extension's URI, as described in RFC 7643 section 3.3.
+func (m filterMatcher) lookup(uri, name string, values map[string]interface{}) (*schema.CoreAttribute, interface{}) {
+	for _, extension := range m.extensions {
+		if uri != "" && strings.EqualFold(uri, extension.Schema.ID) {
+			extensionValues, _ := getCaseInsensitive(values, extension.Schema.ID).(map[string]interface{})
+			attribute, _ := extension.Schema.Attribute(name)
+			return attribute, getCaseInsensitive(extensionValues, name)
+		}
+	}
+
+	if attribute := findAttribute(m.attributes, name); attribute != nil || uri != "" {
+		return attribute, getCaseInsensitive(values, name)
+	}
+
+	// Without a URI prefix, the attribute might still be defined by one of the extensions.
+	for _, extension := range m.extensions {
+		if attribute, ok := extension.Schema.Attribute(name); ok {
+			extensionValues, _ := getCaseInsensitive(values, extension.Schema.ID).(map[string]interface{})
+			return attribute, getCaseInsensitive(extensionValues, name)
+		}
+	}
+	return nil, getCaseInsensitive(values, name)
+}
+
+func matchesAttribute(attribute *schema.CoreAttribute, value interface{}, subAttribute string, operator filter.Token, compareValue string) bool {
+	values := flatten(value)
+
+	// Filters on a complex attribute without a sub-attribute are applied to its "value" sub-attribute, except for the
+	// presence operator, which checks the complex attribute itself.
+	if subAttribute == "" && operator != filter.PR && isComplex(attribute, values) {
+		subAttribute = "value"
+	}
+	if subAttribute != "" {
+		var sub *schema.CoreAttribute
+		if attribute != nil {
+			sub, _ = attribute.SubAttribute(subAttribute)
+		}
+		var subValues []interface{}
+		for _, v := range values {
+			if complex, ok := v.(map[string]interface{}); ok {
+				subValues = append(subValues, flatten(getCaseInsensitive(complex, subAttribute))...)
+			}
+		}
+		attribute, values = sub, subValues
+	}
+
+	if operator == filter.NE {
+		// A multi-valued attribute is not equal to the value if none of its values are.
+		return !matchesAny(attribute, values, filter.EQ, compareValue)
+	}
+	return matchesAny(attribute, values, operator, compareValue)
+}
+
+func matchesAny(attribute *schema.CoreAttribute, values []interface{}, operator filter.Token, compareValue string) bool {
+	for _, v := range values {
+		if compare(attribute, v, operator, compareValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares a single (non-complex) value with the value of a filter expression with given operator.
+func compare(attribute *schema.CoreAttribute, value interface{}, operator filter.Token, compareValue string) bool {
+	if operator == filter.PR {
+		return isPresent(value)
+	}
+
+	var typ string
+	var caseExact bool
+	if attribute != nil {
+		typ = attribute.Type().String()
+		caseExact = attribute.CaseExact()
+	} else {
+		typ = inferDataType(value)
+	}
+
+	switch typ {
+	case "string", "reference", "binary":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if !caseExact {
+			s, compareValue = strings.ToLower(s), strings.ToLower(compareValue)
+		}
+		switch operator {
+		case filter.CO:
+			return strings.Contains(s, compareValue)
+		case filter.SW:
+			return strings.HasPrefix(s, compareValue)
+		case filter.EW:
+			return strings.HasSuffix(s, compareValue)
+		}
+		if typ == "binary" && operator != filter.EQ {
+			// Binary values have no ordering.
+			return false
+		}
+		return compareOrdered(strings.Compare(s, compareValue), operator)
+	case "dateTime":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		d, err := time.Parse(time.RFC3339, s)
+		compareDate, compareErr := time.Parse(time.RFC3339, compareValue)
+		if err != nil || compareErr != nil {
+			return compare(nil, s, operator, compareValue)
+		}
+		switch {
+		case d.Before(compareDate):
+			return compareOrdered(-1, operator)
+		case d.After(compareDate):
+			return compareOrdered(1, operator)
+		default:
+			return compareOrdered(0, operator)
+		}
+	case "decimal", "integer":
+		f, ok := toFloat(value)
+		compareFloat, err := strconv.ParseFloat(compareValue, 64)
+		if !ok || err != nil {
+			return false
+		}
+		switch {
+		case f < compareFloat:
+			return compareOrdered(-1, operator)
+		case f > compareFloat:
+			return compareOrdered(1, operator)
+		default:
+			return compareOrdered(0, operator)
+		}
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok || operator != filter.EQ {
+			return false
+		}
+		return strings.EqualFold(compareValue, strconv.FormatBool(b))
+	}
+	return false
+}
+
+// compareOrdered reports whether the result of a comparison (-1, 0 or +1) satisfies given operator.
+func compareOrdered(c int, operator filter.Token) bool {
+	switch operator {
+	case filter.EQ:
+		return c == 0
+	case filter.GT:
+		return c > 0
+	case filter.GE:
+		return c >= 0
+	case filter.LT:
+		return c < 0
+	case filter.LE:
+		return c <= 0
+	default:
+		return false
+	}
+}
+
+func findAttribute(attributes []schema.CoreAttribute, name string) *schema.CoreAttribute {
+	for i, attribute := range attributes {
+		if strings.EqualFold(attribute.Name(), name) {
+			return &attributes[i]
+		}
+	}
+	return nil
+}
+
+func getCaseInsensitive(values map[string]interface{}, name string) interface{} {
+	for k, v := range values {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// flatten returns the values of a multi-valued attribute, or the value of a single-valued attribute as a slice.
+func flatten(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func isComplex(attribute *schema.CoreAttribute, values []interface{}) bool {
+	if attribute != nil {
+		return attribute.Type().String() == "complex"
+	}
+	for _, v := range values {
+		if _, ok := v.(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isPresent(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case map[string]interface{}:
+		for _, sub := range v {
+			if isPresent(sub) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func inferDataType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, int32, json.Number:
+		return "decimal"
+	default:
+		return ""
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,280 @@
+package scim
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/schema"
+)
+
+// SQLFilter translates parsed filter expressions into parameterized SQL WHERE clauses, so handlers that store their
+// resources in a database do not need to implement the translation themselves. Compare values are never inlined into
+// the clause, they are passed as arguments of the data type of the attribute, see SchemaSet.
+type SQLFilter struct {
+	// Columns maps attribute paths to the (quoted) column names in the database. Paths are matched case-insensitively
+	// and consist of the attribute name and an optional sub-attribute, e.g. "userName" or "name.familyName". Attributes
+	// of schema extensions are prefixed with the URI of the schema, e.g.
+	// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber". Sub-attributes within a value path
+	// filter, e.g. `emails[type eq "work"]`, map to the same path as the attribute expression "emails.type".
+	Columns map[string]string
+	// Placeholder returns the placeholder of the n-th (1-based) argument, e.g. "$1" for PostgreSQL. If nil, the
+	// placeholder "?" is used for every argument.
+	Placeholder func(n int) string
+	// CaseInsensitive compares strings case-insensitively by lowering both the column and the argument, which matches
+	// the default case exactness of SCIM attributes when the collation of the database is case-sensitive. Attributes
+	// that are case-exact according to the SchemaSet, e.g. "id", are compared as is.
+	CaseInsensitive bool
+	// SchemaSet provides the data types of the attributes, to pass compare values as arguments of the same type, and
+	// their index hints, see schema.AttributeIndex, which are used by Strict and CreateIndexes.
+	SchemaSet SchemaSet
+	// Strict rejects filters that compare attributes whose index hint does not support the compare operator with an
+	// UnindexedFilterError, instead of translating them into clauses that require a full scan of the table.
+	Strict bool
+}
+
+// UnindexedFilterError is the error that a strict SQLFilter returns for a filter that compares an attribute whose
+// index hint does not support the compare operator. Handlers typically return it as an "invalidFilter" error.
+type UnindexedFilterError struct {
+	// Path is the path of the attribute, e.g. "name.familyName".
+	Path string
+	// Operator is the compare operator, e.g. "co".
+	Operator string
+}
+
+func (e UnindexedFilterError) Error() string {
+	return fmt.Sprintf("filtering on attribute %q with operator %q is not supported, since the attribute is not indexed for it", e.Path, e.Operator)
+}
+
+// CreateIndexes returns the statements that create the indexes of given table for the mapped attributes with an index
+// hint, in the order of their paths. The names of the indexes are derived from the table and column names.
+func (f SQLFilter) CreateIndexes(table string) []string {
+	paths := make([]string, 0, len(f.Columns))
+	for path := range f.Columns {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var statements []string
+	for _, path := range paths {
+		attribute, ok := f.SchemaSet.Attribute(path)
+		if !ok || attribute.Index() == schema.AttributeIndexNone() {
+			continue
+		}
+		column := f.Columns[path]
+		expression := column
+		if f.CaseInsensitive && !attribute.CaseExact() {
+			expression = fmt.Sprintf("LOWER(%s)", column)
+		}
+		statements = append(statements, fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS %s ON %s (%s)", indexName(table, column), table, expression,
+		))
+	}
+	return statements
+}
+
+// indexName returns the name of the index of given column of given table, e.g. "users_user_name_idx".
+func indexName(table, column string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, table+"_"+column)
+	return name + "_idx"
+}
+
+// Where returns the SQL WHERE clause (without the "WHERE" keyword) for given filter expression and the arguments for
+// its placeholders. An error is returned if the filter refers to an attribute that is not mapped to a column or
+// contains an unsupported operator.
+func (f SQLFilter) Where(expression filter.Expression) (string, []interface{}, error) {
+	var args []interface{}
+	clause, err := f.where(expression, "", &args)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause, args, nil
+}
+
+func (f SQLFilter) where(expression filter.Expression, valuePath string, args *[]interface{}) (string, error) {
+	switch e := expression.(type) {
+	case filter.BinaryExpression:
+		var operator string
+		switch e.CompareOperator {
+		case filter.AND:
+			operator = "AND"
+		case filter.OR:
+			operator = "OR"
+		default:
+			return "", fmt.Errorf("unsupported logical operator %v", e.CompareOperator)
+		}
+		x, err := f.where(e.X, valuePath, args)
+		if err != nil {
+			return "", err
+		}
+		y, err := f.where(e.Y, valuePath, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", x, operator, y), nil
+	case filter.UnaryExpression:
+		if e.CompareOperator != filter.NOT {
+			return "", fmt.Errorf("unsupported logical operator %v", e.CompareOperator)
+		}
+		x, err := f.where(e.X, valuePath, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("NOT (%s)", x), nil
+	case filter.ValuePath:
+		if valuePath != "" {
+			return "", fmt.Errorf("nested value path %q is not supported", e.AttributeName)
+		}
+		return f.where(e.ValueExpression, e.AttributeName, args)
+	case filter.AttributeExpression:
+		return f.compare(e, valuePath, args)
+	default:
+		return "", fmt.Errorf("unsupported filter expression %T", expression)
+	}
+}
+
+func (f SQLFilter) compare(e filter.AttributeExpression, valuePath string, args *[]interface{}) (string, error) {
+	path := e.AttributePath.AttributeName
+	if valuePath != "" {
+		path = valuePath + "." + path
+	} else if e.AttributePath.SubAttribute != "" {
+		path += "." + e.AttributePath.SubAttribute
+	}
+	if e.AttributePath.URIPrefix != "" {
+		path = e.AttributePath.URIPrefix + ":" + path
+	}
+
+	column, ok := f.column(path)
+	if !ok {
+		return "", fmt.Errorf("attribute %q is not mapped to a column", path)
+	}
+	if f.Strict {
+		operator := formatOperator(e.CompareOperator)
+		if attribute, ok := f.SchemaSet.Attribute(path); !ok || !attribute.Index().Supports(operator) {
+			return "", UnindexedFilterError{Path: path, Operator: operator}
+		}
+	}
+
+	if e.CompareOperator == filter.PR {
+		return fmt.Sprintf("%s IS NOT NULL", column), nil
+	}
+
+	value, err := f.argument(path, e)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		switch e.CompareOperator {
+		case filter.EQ:
+			return fmt.Sprintf("%s IS NULL", column), nil
+		case filter.NE:
+			return fmt.Sprintf("%s IS NOT NULL", column), nil
+		default:
+			return "", fmt.Errorf("attribute %q can only be compared to null with eq or ne", path)
+		}
+	}
+	*args = append(*args, value)
+	placeholder := "?"
+	if f.Placeholder != nil {
+		placeholder = f.Placeholder(len(*args))
+	}
+	if _, ok := value.(string); ok && f.CaseInsensitive && !f.caseExact(path) {
+		column, placeholder = fmt.Sprintf("LOWER(%s)", column), fmt.Sprintf("LOWER(%s)", placeholder)
+	}
+
+	switch e.CompareOperator {
+	case filter.EQ:
+		return fmt.Sprintf("%s = %s", column, placeholder), nil
+	case filter.NE:
+		// Absent attributes are not equal to any value.
+		return fmt.Sprintf("(%s <> %s OR %s IS NULL)", column, placeholder, column), nil
+	case filter.CO, filter.SW, filter.EW:
+		return fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, column, placeholder), nil
+	case filter.GT:
+		return fmt.Sprintf("%s > %s", column, placeholder), nil
+	case filter.GE:
+		return fmt.Sprintf("%s >= %s", column, placeholder), nil
+	case filter.LT:
+		return fmt.Sprintf("%s < %s", column, placeholder), nil
+	case filter.LE:
+		return fmt.Sprintf("%s <= %s", column, placeholder), nil
+	default:
+		return "", fmt.Errorf("unsupported compare operator %v", e.CompareOperator)
+	}
+}
+
+// argument returns the compare value of given expression as an argument of the data type of the attribute with given
+// path, e.g. a bool for `active eq true` and an int64 for `age gt 21`, so that it can be compared with BOOLEAN and
+// numeric columns. The literal null is returned as nil. Without a schema for the attribute, only the literals true and
+// false are converted, other values are passed as strings, since e.g. "701984" is a valid employee number.
+func (f SQLFilter) argument(path string, e filter.AttributeExpression) (interface{}, error) {
+	value := e.CompareValue
+	switch e.CompareOperator {
+	case filter.CO:
+		return "%" + escapeLike(value) + "%", nil
+	case filter.SW:
+		return escapeLike(value) + "%", nil
+	case filter.EW:
+		return "%" + escapeLike(value), nil
+	}
+	if value == "null" {
+		return nil, nil
+	}
+
+	var typ string
+	if attribute, ok := f.SchemaSet.Attribute(path); ok {
+		typ = attribute.Type().String()
+	}
+	switch typ {
+	case "boolean", "":
+		switch {
+		case strings.EqualFold(value, "true"):
+			return true, nil
+		case strings.EqualFold(value, "false"):
+			return false, nil
+		case typ == "boolean":
+			return nil, fmt.Errorf("invalid boolean %q for attribute %q", value, path)
+		}
+	case "integer":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q for attribute %q", value, path)
+		}
+		return i, nil
+	case "decimal":
+		d, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal %q for attribute %q", value, path)
+		}
+		return d, nil
+	}
+	return value, nil
+}
+
+// caseExact returns whether the attribute with given path is case-exact. Attributes without a schema are not, which
+// is the default of SCIM attributes.
+func (f SQLFilter) caseExact(path string) bool {
+	attribute, ok := f.SchemaSet.Attribute(path)
+	return ok && attribute.CaseExact()
+}
+
+func (f SQLFilter) column(path string) (string, bool) {
+	for k, column := range f.Columns {
+		if strings.EqualFold(k, path) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
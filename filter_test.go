@@ -0,0 +1,307 @@
+package scim
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestResourceTypeMatchesFilter(t *testing.T) {
+	resourceType := newTestServer().ResourceTypes[1]
+	attributes := ResourceAttributes{
+		"userName": "BJensen",
+		"active":   true,
+		"name": map[string]interface{}{
+			"familyName": "Jensen",
+			"givenName":  "Barbara",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work", "primary": true},
+			map[string]interface{}{"value": "babs@jensen.org", "type": "home"},
+		},
+		"meta": map[string]interface{}{
+			"lastModified": "2011-05-13T04:42:34Z",
+		},
+		"loginCount": 42.0,
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "701984",
+		},
+	}
+
+	for _, test := range []struct {
+		filter  string
+		matches bool
+	}{
+		{`userName eq "bjensen"`, true},
+		{`userName ne "bjensen"`, false},
+		{`userName sw "bj"`, true},
+		{`userName ew "sen"`, true},
+		{`userName co "xyz"`, false},
+		{`userName gt "a"`, true},
+		{`displayName pr`, false},
+		{`displayName ne "Babs"`, true},
+		{`name pr`, true},
+		{`name.familyName eq "jensen"`, true},
+		{`name.givenName eq "Babs"`, false},
+		{`emails co "jensen.org"`, true},
+		{`emails.type eq "home"`, true},
+		{`emails.type eq "other"`, false},
+		{`emails.type ne "other"`, true},
+		{`emails[type eq "work" and value co "jensen.org"]`, false},
+		{`emails[type eq "home" and value co "jensen.org"]`, true},
+		{`emails[primary eq true]`, true},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`active gt false`, false},
+		{`loginCount ge 42`, true},
+		{`loginCount lt 42`, false},
+		{`meta.lastModified gt "2011-05-13T04:42:00Z"`, true},
+		{`meta.lastModified lt "2011-05-13T04:42:00Z"`, false},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "701984"`, true},
+		{`employeeNumber eq "701985"`, false},
+		{`userName eq "bjensen" and not (active eq false)`, true},
+		{`userName eq "alice" or emails[type eq "work"]`, true},
+		{`userName eq "alice" or active eq false`, false},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(test.filter)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.filter, err)
+		}
+		if matches := resourceType.MatchesFilter(expression, attributes); matches != test.matches {
+			t.Errorf("%s: got %v want %v", test.filter, matches, test.matches)
+		}
+	}
+}
+
+func TestSQLFilterWhere(t *testing.T) {
+	f := SQLFilter{
+		Columns: map[string]string{
+			"userName":        "user_name",
+			"name.familyName": "family_name",
+			"emails.type":     "email_type",
+			"emails.value":    "email",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "employee_number",
+		},
+		Placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
+	}
+
+	for _, test := range []struct {
+		filter string
+		clause string
+		args   []interface{}
+	}{
+		{
+			filter: `userName eq "bjensen"`,
+			clause: "user_name = $1",
+			args:   []interface{}{"bjensen"},
+		},
+		{
+			filter: `name.familyName co "O'Malley_%" or userName pr`,
+			clause: `(family_name LIKE $1 ESCAPE '\' OR user_name IS NOT NULL)`,
+			args:   []interface{}{`%O'Malley\_\%%`},
+		},
+		{
+			filter: `emails[type eq "work" and value ew "@example.com"] and not (userName ne "bjensen")`,
+			clause: `((email_type = $1 AND email LIKE $2 ESCAPE '\') AND NOT ((user_name <> $3 OR user_name IS NULL)))`,
+			args:   []interface{}{"work", "%@example.com", "bjensen"},
+		},
+		{
+			filter: `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber ge "701984"`,
+			clause: "employee_number >= $1",
+			args:   []interface{}{"701984"},
+		},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(test.filter)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.filter, err)
+		}
+		clause, args, err := f.Where(expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if clause != test.clause || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s: got %s %v want %s %v", test.filter, clause, args, test.clause, test.args)
+		}
+	}
+
+	expression, _ := filter.NewParser(strings.NewReader(`title eq "Tour Guide"`)).Parse()
+	if _, _, err := f.Where(expression); err == nil {
+		t.Error("expected an error for an unmapped attribute")
+	}
+
+	f.CaseInsensitive = true
+	f.Placeholder = nil
+	expression, _ = filter.NewParser(strings.NewReader(`userName eq "BJensen"`)).Parse()
+	if clause, _, _ := f.Where(expression); clause != "LOWER(user_name) = LOWER(?)" {
+		t.Errorf("wrong case-insensitive clause: %s", clause)
+	}
+}
+
+func TestSQLFilterArguments(t *testing.T) {
+	resourceType := ResourceType{
+		Schema: schema.Schema{
+			ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+			Attributes: []schema.CoreAttribute{
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "userName"})),
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+					Name:      "externalId",
+					CaseExact: true,
+				})),
+				schema.SimpleCoreAttribute(schema.SimpleBooleanParams(schema.BooleanParams{Name: "active"})),
+				schema.SimpleCoreAttribute(schema.SimpleNumberParams(schema.NumberParams{
+					Name: "logins",
+					Type: schema.AttributeTypeInteger(),
+				})),
+				schema.SimpleCoreAttribute(schema.SimpleNumberParams(schema.NumberParams{
+					Name: "score",
+					Type: schema.AttributeTypeDecimal(),
+				})),
+			},
+		},
+	}
+	f := SQLFilter{
+		Columns: map[string]string{
+			"userName":   "user_name",
+			"externalId": "external_id",
+			"active":     "active",
+			"logins":     "logins",
+			"score":      "score",
+			"nickName":   "nick_name",
+		},
+		CaseInsensitive: true,
+		SchemaSet:       resourceType.SchemaSet(),
+	}
+
+	for _, test := range []struct {
+		filter string
+		clause string
+		args   []interface{}
+	}{
+		{`active eq true`, "active = ?", []interface{}{true}},
+		{`active eq False`, "active = ?", []interface{}{false}},
+		{`logins ge 3`, "logins >= ?", []interface{}{int64(3)}},
+		{`score lt 0.5`, "score < ?", []interface{}{0.5}},
+		{`userName eq "true"`, "LOWER(user_name) = LOWER(?)", []interface{}{"true"}},
+		{`userName eq null`, "user_name IS NULL", nil},
+		{`userName ne null`, "user_name IS NOT NULL", nil},
+		// Case-exact attributes are compared as is.
+		{`externalId eq "AB12"`, "external_id = ?", []interface{}{"AB12"}},
+		{`externalId sw "AB"`, `external_id LIKE ? ESCAPE '\'`, []interface{}{"AB%"}},
+		// Attributes without a schema only get their booleans converted.
+		{`nickName eq true`, "nick_name = ?", []interface{}{true}},
+		{`nickName eq "701984"`, "LOWER(nick_name) = LOWER(?)", []interface{}{"701984"}},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(test.filter)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.filter, err)
+		}
+		clause, args, err := f.Where(expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if clause != test.clause || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s: got %s %v want %s %v", test.filter, clause, args, test.clause, test.args)
+		}
+	}
+
+	for _, invalid := range []string{`active eq "yes"`, `logins gt 1.5`, `score gt "high"`, `userName gt null`} {
+		expression, _ := filter.NewParser(strings.NewReader(invalid)).Parse()
+		if _, _, err := f.Where(expression); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
+
+func TestSQLFilterStrict(t *testing.T) {
+	resourceType := ResourceType{
+		Schema: schema.Schema{
+			ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+			Attributes: []schema.CoreAttribute{
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+					Name:  "userName",
+					Index: schema.AttributeIndexPrefix(),
+				})),
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+					Name:      "externalId",
+					CaseExact: true,
+					Index:     schema.AttributeIndexEquality(),
+				})),
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+					Name: "displayName",
+				})),
+			},
+		},
+	}
+	f := SQLFilter{
+		Columns: map[string]string{
+			"userName":    "user_name",
+			"externalId":  "external_id",
+			"displayName": "display_name",
+		},
+		CaseInsensitive: true,
+		SchemaSet:       resourceType.SchemaSet(),
+		Strict:          true,
+	}
+
+	for _, test := range []struct {
+		filter string
+		err    error
+	}{
+		{`userName eq "bjensen"`, nil},
+		{`userName sw "bj"`, nil},
+		{`userName co "jens"`, UnindexedFilterError{Path: "userName", Operator: "co"}},
+		{`externalId eq "701984" and userName pr`, nil},
+		{`externalId sw "70"`, UnindexedFilterError{Path: "externalId", Operator: "sw"}},
+		{`userName eq "bjensen" or displayName eq "Babs"`, UnindexedFilterError{Path: "displayName", Operator: "eq"}},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(test.filter)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.filter, err)
+		}
+		if _, _, err := f.Where(expression); err != test.err {
+			t.Errorf("%s: expected error %v, got %v", test.filter, test.err, err)
+		}
+	}
+
+	expected := []string{
+		"CREATE INDEX IF NOT EXISTS users_external_id_idx ON users (external_id)",
+		"CREATE INDEX IF NOT EXISTS users_user_name_idx ON users (LOWER(user_name))",
+	}
+	if statements := f.CreateIndexes("users"); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("expected %v, got %v", expected, statements)
+	}
+}
+
+func TestResourceTypeMatchesValueFilter(t *testing.T) {
+	resourceType := newTestServer().ResourceTypes[0]
+	path, err := ParsePatchPath(`emails[type eq "WORK" and primary eq true]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		value    interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"value": "bjensen@example.com", "type": "work", "primary": true}, true},
+		{map[string]interface{}{"value": "bjensen@example.com", "type": "work"}, false},
+		{map[string]interface{}{"value": "babs@jensen.org", "type": "home", "primary": true}, false},
+		{"bjensen@example.com", false},
+	} {
+		if matches := resourceType.MatchesValueFilter(path, test.value); matches != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.value, test.expected, matches)
+		}
+	}
+
+	if !resourceType.MatchesValueFilter(PatchPath{AttributeName: "emails"}, "bjensen@example.com") {
+		t.Error("expected values to match a path without a value filter")
+	}
+}
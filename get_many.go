@@ -0,0 +1,160 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+const (
+	// GetManyRequestSchema is the URI of the schema of the body of a request to the "/.getMany" endpoint of a resource
+	// type, e.g. "/Users/.getMany".
+	GetManyRequestSchema = "urn:elimity:params:scim:api:messages:2.0:GetManyRequest"
+
+	// maxGetManyIDs is the maximum number of identifiers of a single get many request.
+	maxGetManyIDs = 1000
+	// getManyConcurrency is the number of resources that are retrieved concurrently when the handler does not
+	// implement ManyGetter.
+	getManyConcurrency = 8
+)
+
+// ManyGetter is an optional interface of resource handlers that can retrieve multiple resources at once, e.g. with a
+// single database query. Handlers that do not implement it are called once per identifier, concurrently. Context
+// resource handlers implement ContextManyGetter instead.
+type ManyGetter interface {
+	// GetMany returns the resources with given identifiers. Identifiers of resources that do not exist (or that the
+	// client is not allowed to access) are skipped.
+	GetMany(r *http.Request, ids []string) ([]Resource, errors.GetError)
+}
+
+// getManyRequest is the body of a request to the "/.getMany" endpoint of a resource type. This vendor extension
+// retrieves the resources with the listed identifiers in a single request, e.g. to reconcile a known set of resources
+// without sending a GET request for each of them.
+type getManyRequest struct {
+	Schemas            []string `json:"schemas"`
+	IDs                []string `json:"ids"`
+	Attributes         []string `json:"attributes"`
+	ExcludedAttributes []string `json:"excludedAttributes"`
+}
+
+// getManyHandler receives an HTTP POST to the "/.getMany" endpoint of a resource type, e.g. "/Users/.getMany", to
+// retrieve the resources with the identifiers in the body. The resources are returned as a list response in the order
+// of the identifiers, resources that do not exist are left out.
+func (s Server) getManyHandler(w http.ResponseWriter, r *http.Request, resourceType ResourceType) {
+	data, _ := ioutil.ReadAll(r.Body)
+	var req getManyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		errorHandler(w, r, scimErrorInvalidSyntax)
+		return
+	}
+	if len(req.Schemas) != 1 || req.Schemas[0] != GetManyRequestSchema {
+		errorHandler(w, r, scimErrorInvalidValue)
+		return
+	}
+	if len(req.IDs) > maxGetManyIDs {
+		errorHandler(w, r, scimError{
+			scimType: errors.ScimTypeTooMany,
+			detail:   fmt.Sprintf("The number of identifiers exceeds the maximum (%d).", maxGetManyIDs),
+			status:   http.StatusBadRequest,
+		})
+		return
+	}
+
+	resources, getErr := getMany(r, resourceType.Handler, req.IDs)
+	if getErr != errors.GetErrorNil {
+		errorHandler(w, r, scimGetAllError(getErr))
+		return
+	}
+
+	query := make(url.Values)
+	if len(req.Attributes) != 0 {
+		query.Set("attributes", strings.Join(req.Attributes, ","))
+	}
+	if len(req.ExcludedAttributes) != 0 {
+		query.Set("excludedAttributes", strings.Join(req.ExcludedAttributes, ","))
+	}
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	projectionRequest := r.WithContext(r.Context())
+	projectionRequest.URL = &u
+	projection := resourceType.parseProjection(projectionRequest)
+
+	list := make([]interface{}, 0, len(resources))
+	var excluded []string
+	for _, resource := range resources {
+		response, trimmed := s.trimResource(resourceType.project(resource.response(r, resourceType), projection))
+		list = append(list, response)
+		excluded = appendMissing(excluded, trimmed...)
+	}
+	setTrimmedWarning(w, excluded)
+
+	raw, err := json.Marshal(listResponse{
+		TotalResults: len(list),
+		Resources:    list,
+		StartIndex:   defaultStartIndex,
+		ItemsPerPage: len(list),
+	})
+	if err != nil {
+		errorHandler(w, r, scimErrorInternalServer)
+		log.Fatalf("failed marshalling list response: %v", err)
+		return
+	}
+	_, err = w.Write(raw)
+	if err != nil {
+		logf(r, "failed writing response: %v", err)
+	}
+}
+
+// getMany retrieves the resources with given identifiers with the GetMany method of given handler, or falls back to
+// concurrent calls of its Get method.
+func getMany(r *http.Request, handler ResourceHandler, ids []string) ([]Resource, errors.GetError) {
+	if getter, ok := handler.(ManyGetter); ok {
+		return getter.GetMany(r, ids)
+	}
+	return getEach(r, handler, ids)
+}
+
+// getEach retrieves the resources with given identifiers with concurrent calls of the Get method of given handler.
+func getEach(r *http.Request, handler ResourceHandler, ids []string) ([]Resource, errors.GetError) {
+	results := make([]*Resource, len(ids))
+	getErrs := make([]errors.GetError, len(ids))
+	sem := make(chan struct{}, getManyConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resource, getErr := handler.Get(r, id)
+			switch getErr {
+			case errors.GetErrorNil:
+				results[i] = &resource
+			case errors.GetErrorResourceNotFound, errors.GetErrorForbidden:
+			default:
+				getErrs[i] = getErr
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	resources := make([]Resource, 0, len(ids))
+	for i, resource := range results {
+		if getErrs[i] != errors.GetErrorNil {
+			return nil, getErrs[i]
+		}
+		if resource != nil {
+			resources = append(resources, *resource)
+		}
+	}
+	return resources, errors.GetErrorNil
+}
@@ -0,0 +1,92 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// manyGetterResourceHandler counts the calls of its GetMany method.
+type manyGetterResourceHandler struct {
+	testResourceHandler
+	calls *int32
+}
+
+func (h manyGetterResourceHandler) GetMany(r *http.Request, ids []string) ([]Resource, errors.GetError) {
+	atomic.AddInt32(h.calls, 1)
+	var resources []Resource
+	for _, id := range ids {
+		if resource, getErr := h.Get(r, id); getErr == errors.GetErrorNil {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, errors.GetErrorNil
+}
+
+func TestServerGetManyHandler(t *testing.T) {
+	var calls int32
+	for _, handler := range []ResourceHandler{
+		newTestResourceHandler(),
+		manyGetterResourceHandler{
+			testResourceHandler: newTestResourceHandler().(testResourceHandler),
+			calls:               &calls,
+		},
+	} {
+		server := newTestServer()
+		server.ResourceTypes[0].Handler = handler
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users/.getMany", strings.NewReader(`{
+			"schemas": ["urn:elimity:params:scim:api:messages:2.0:GetManyRequest"],
+			"ids": ["0003", "9999", "0001"],
+			"attributes": ["userName"]
+		}`)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var response struct {
+			TotalResults int
+			Resources    []map[string]interface{}
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalResults != 2 || len(response.Resources) != 2 {
+			t.Fatalf("unexpected response: %s", rr.Body.String())
+		}
+		for i, id := range []string{"0003", "0001"} {
+			if resource := response.Resources[i]; resource["id"] != id || resource["userName"] == nil || resource["active"] != nil {
+				t.Errorf("unexpected resource %d: %v", i, resource)
+			}
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected GetMany to be called once, got %d", calls)
+	}
+}
+
+func TestServerGetManyHandlerInvalid(t *testing.T) {
+	ids := make([]string, maxGetManyIDs+1)
+	for i := range ids {
+		ids[i] = "0001"
+	}
+	tooMany, _ := json.Marshal(getManyRequest{Schemas: []string{GetManyRequestSchema}, IDs: ids})
+
+	for _, body := range []string{
+		`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"], "ids": ["0001"]}`,
+		`{"schemas": ["urn:elimity:params:scim:api:messages:2.0:GetManyRequest"], "ids": "0001"}`,
+		string(tooMany),
+	} {
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users/.getMany", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// grantAttributes are the names of the multi-valued attributes of the core User schema whose values grant access.
+var grantAttributes = []string{"roles", "entitlements"}
+
+// GrantCatalog validates the values of the "roles" and "entitlements" attributes of the resources that are created,
+// replaced or patched. Values that are not in the catalog are rejected as invalid, before they reach the callback
+// methods.
+type GrantCatalog interface {
+	// Allowed reports whether given value of the attribute with given name, i.e. "roles" or "entitlements", can be
+	// granted.
+	Allowed(r *http.Request, attribute, value string) bool
+}
+
+// StaticGrantCatalog is a grant catalog with a fixed list of allowed values per attribute name, e.g.
+// {"roles": {"admin", "user"}}. Attributes that are not in the map allow no values at all.
+type StaticGrantCatalog map[string][]string
+
+// Allowed reports whether given value is in the list of allowed values of the attribute.
+func (c StaticGrantCatalog) Allowed(_ *http.Request, attribute, value string) bool {
+	for name, values := range c {
+		if !strings.EqualFold(name, attribute) {
+			continue
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkGrants checks the values of the roles and entitlements in given attributes against the grant catalog.
+func (s Server) checkGrants(r *http.Request, attributes map[string]interface{}) *scimError {
+	if s.GrantCatalog == nil {
+		return nil
+	}
+	for k, v := range attributes {
+		for _, attribute := range grantAttributes {
+			if strings.EqualFold(k, attribute) {
+				if scimErr := s.checkGrantValues(r, attribute, v); scimErr != nil {
+					return scimErr
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkPatchGrants checks the roles and entitlements that are added or replaced by given patch request against the
+// grant catalog. Paths are resolved against the resource type, so "roles" and
+// "urn:ietf:params:scim:schemas:core:2.0:User:roles" both refer to the roles of a user.
+func (s Server) checkPatchGrants(r *http.Request, resourceType ResourceType, patch PatchRequest) *scimError {
+	if s.GrantCatalog == nil {
+		return nil
+	}
+	for _, op := range patch.Operations {
+		if strings.EqualFold(op.Op, PatchOperationRemove) {
+			continue
+		}
+		if op.Path == "" {
+			attributes, _ := op.Value.(map[string]interface{})
+			for k, v := range attributes {
+				// The attributes of the schema of the resource type can also be nested under its URI.
+				if nested, ok := v.(map[string]interface{}); ok && strings.EqualFold(k, resourceType.Schema.ID) {
+					for nestedKey, nestedValue := range nested {
+						if scimErr := s.checkPathGrants(r, resourceType, nestedKey, nestedValue); scimErr != nil {
+							return scimErr
+						}
+					}
+					continue
+				}
+				if scimErr := s.checkPathGrants(r, resourceType, k, v); scimErr != nil {
+					return scimErr
+				}
+			}
+			continue
+		}
+		if scimErr := s.checkPathGrants(r, resourceType, op.Path, op.Value); scimErr != nil {
+			return scimErr
+		}
+	}
+	return nil
+}
+
+// checkPathGrants checks given value that is set at given attribute path against the grant catalog, if the path refers
+// to the roles or entitlements of the resource type or to their "value" sub-attributes.
+func (s Server) checkPathGrants(r *http.Request, resourceType ResourceType, path string, value interface{}) *scimError {
+	p, err := ParseAttributePath(path)
+	if err != nil {
+		// Invalid paths are rejected by the validation of the request.
+		return nil
+	}
+	if p.SubAttribute != "" && !strings.EqualFold(p.SubAttribute, "value") {
+		return nil
+	}
+	for _, attribute := range grantAttributes {
+		if resourceType.samePath(p, AttributePath{AttributeName: attribute}) {
+			return s.checkGrantValues(r, attribute, value)
+		}
+	}
+	return nil
+}
+
+// checkGrantValues checks given value(s) of the roles or entitlements attribute, or of their "value" sub-attribute.
+// Complex values are checked by their "value" sub-attribute, strings are checked as they are.
+func (s Server) checkGrantValues(r *http.Request, attribute string, value interface{}) *scimError {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		var grant interface{}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			grant = getCaseInsensitive(v, "value")
+		default:
+			grant = v
+		}
+		if grant == nil || grant == "" {
+			continue
+		}
+		if g, ok := grant.(string); ok && s.GrantCatalog.Allowed(r, attribute, g) {
+			continue
+		}
+		return &scimError{
+			scimType: errors.ScimTypeInvalidValue,
+			detail:   fmt.Sprintf("The value %v of the %q attribute is not in the catalog.", formatGrant(grant), attribute),
+			status:   http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// formatGrant returns given grant as it is shown in error details: strings are quoted, other values are not.
+func formatGrant(grant interface{}) string {
+	if g, ok := grant.(string); ok {
+		return fmt.Sprintf("%q", g)
+	}
+	return fmt.Sprint(grant)
+}
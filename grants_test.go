@@ -0,0 +1,83 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestServerGrantCatalog(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test", "roles": [{"value": "admin"}]}`, http.StatusCreated},
+		{http.MethodPost, "/Users", `{"userName": "test", "roles": [{"value": "root"}]}`, http.StatusBadRequest},
+		{http.MethodPut, "/Users/0001", `{"userName": "test", "entitlements": [{"value": "delete"}]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "roles", "value": [{"value": "user"}]}]
+		}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "roles", "value": [{"value": "root"}]}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "value": {"entitlements": [{"value": "delete"}]}}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "remove", "path": "roles"}]
+		}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "urn:ietf:params:scim:schemas:core:2.0:User:roles", "value": [{"value": "root"}]}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "urn:ietf:params:scim:schemas:core:2.0:User:roles", "value": "root"}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "roles", "value": ["user", "root"]}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "roles[type eq \"work\"].value", "value": "root"}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "roles[type eq \"work\"].display", "value": "Root"}]
+		}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "value": {"urn:ietf:params:scim:schemas:core:2.0:User:roles": [{"value": "root"}]}}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "value": {"urn:ietf:params:scim:schemas:core:2.0:User": {"entitlements": [{"value": "delete"}]}}}]
+		}`, http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.ResourceTypes[0].Schema.Attributes = append(server.ResourceTypes[0].Schema.Attributes,
+			schema.CoreUserRoles(),
+			schema.CoreUserEntitlements(),
+		)
+		server.GrantCatalog = StaticGrantCatalog{
+			"roles":        {"admin", "user"},
+			"entitlements": {"read"},
+		}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: handler returned wrong status code: got %v want %v", test.method, test.body, rr.Code, test.expected)
+		}
+	}
+}
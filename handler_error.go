@@ -0,0 +1,127 @@
+package scim
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+var (
+	// ErrResourceNotFound signals that the resource with the requested identifier does not exist.
+	ErrResourceNotFound = &Error{Status: http.StatusNotFound}
+	// ErrUniqueness signals that one or more of the attribute values are already in use or are reserved.
+	ErrUniqueness = &Error{ScimType: errors.ScimTypeUniqueness, Status: http.StatusConflict}
+	// ErrMutability signals that the attempted modification is not compatible with the target attribute's mutability
+	// or current state.
+	ErrMutability = &Error{ScimType: errors.ScimTypeMutability, Status: http.StatusBadRequest}
+	// ErrNotImplemented signals that the operation is not supported.
+	ErrNotImplemented = &Error{ScimType: errors.ScimTypeNotImplemented, Status: http.StatusNotImplemented}
+	// ErrTooManyRequests signals that the provider is overloaded and the client should retry the request later.
+	ErrTooManyRequests = &Error{Status: http.StatusTooManyRequests}
+	// ErrForbidden signals that the client is not allowed to access the resource. Depending on the disclosure policy of
+	// the server, it is returned to the client as is or as if the resource does not exist.
+	ErrForbidden = &Error{Status: http.StatusForbidden}
+	// ErrServiceUnavailable signals that the provider is temporarily unavailable, e.g. during maintenance.
+	ErrServiceUnavailable = &Error{Status: http.StatusServiceUnavailable}
+)
+
+// Error is an error that is returned by the callback methods of a ContextResourceHandler. Unlike the error types of
+// the errors package, it implements the error interface, so it can wrap the error that caused it and be matched with
+// errors.Is and errors.As, e.g.:
+//
+//	if err := db.QueryRowContext(ctx, query, id).Scan(&userName); err == sql.ErrNoRows {
+//		return scim.Resource{}, &scim.Error{Status: http.StatusNotFound, Err: err}
+//	}
+//
+// A callback method that returns an error that is not (and does not wrap) an Error, or an Error with a status code
+// outside of the 4xx and 5xx ranges (other than 202 Accepted, see Accepted), results in a "500 Internal Server Error"
+// response, without exposing the error to the client.
+type Error struct {
+	// Status is the HTTP status code of the error.
+	Status int
+	// ScimType is a SCIM detail error keyword. It is optional.
+	ScimType errors.ScimType
+	// Detail is a detailed human-readable message. It is optional.
+	Detail string
+	// RetryAfter is the delay after which the client may retry the request, see errors.ScimError. It is optional.
+	RetryAfter time.Duration
+	// Location is the URI of a resource that describes the status of an accepted operation, see errors.ScimError. It is
+	// optional.
+	Location string
+	// Err is the error that caused this error. It is not returned to the client.
+	Err error
+}
+
+// Accepted returns an error that signals that the operation was queued for asynchronous processing. The client
+// receives a "202 Accepted" response with a "Location" header pointing to given resource that describes the status of
+// the operation.
+func Accepted(location string) error {
+	return &Error{Status: http.StatusAccepted, Location: location}
+}
+
+// Error returns a description of the error.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("scim: %d %s", e.Status, http.StatusText(e.Status))
+	if e.ScimType != "" {
+		msg += fmt.Sprintf(" (%s)", e.ScimType)
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error that caused this error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether this error matches given target error, i.e. whether the target is an Error with the same status
+// code and, if the target specifies them, the same SCIM detail error keyword and detail. This makes
+// errors.Is(err, scim.ErrResourceNotFound) true for all errors with status code 404.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Status == t.Status &&
+		(t.ScimType == "" || e.ScimType == t.ScimType) &&
+		(t.Detail == "" || e.Detail == t.Detail)
+}
+
+// scimErrorFromError converts an error that is returned by a callback method of a context resource handler to the
+// error types of the errors package.
+func scimErrorFromError(ctx context.Context, err error) errors.ScimError {
+	if err == nil {
+		return errors.ScimError{}
+	}
+	var e *Error
+	if !stderrors.As(err, &e) {
+		LoggerFromContext(ctx).Printf("callback method failed: %v", err)
+		recordErrorCause(ctx, err.Error)
+		return errors.ScimError{Status: http.StatusInternalServerError}
+	}
+	if e.Err != nil {
+		recordErrorCause(ctx, e.Err.Error)
+	}
+	// The zero status code would signal success, so errors without a valid status code result in a "500 Internal
+	// Server Error" response, like errors that are not an Error.
+	if e.Status != http.StatusAccepted && (e.Status < 400 || e.Status > 599) {
+		LoggerFromContext(ctx).Printf("callback method failed with invalid status code %d: %v", e.Status, err)
+		return errors.ScimError{Status: http.StatusInternalServerError}
+	}
+	return errors.ScimError{
+		ScimType:   e.ScimType,
+		Detail:     e.Detail,
+		Status:     e.Status,
+		RetryAfter: e.RetryAfter,
+		Location:   e.Location,
+	}
+}
@@ -0,0 +1,71 @@
+package scim
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingResourceHandler returns the given error from its Get callback method.
+type failingResourceHandler struct {
+	contextResourceHandler
+	err error
+}
+
+func (h failingResourceHandler) Get(ctx context.Context, id string) (Resource, error) {
+	return Resource{}, h.err
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("failed retrieving user: %w", &Error{
+		Status: http.StatusNotFound,
+		Detail: "User 0001 does not exist.",
+		Err:    stderrors.New("no rows"),
+	})
+	if !stderrors.Is(err, ErrResourceNotFound) {
+		t.Error("expected the error to match ErrResourceNotFound")
+	}
+	if stderrors.Is(err, ErrForbidden) {
+		t.Error("expected the error not to match ErrForbidden")
+	}
+	var scimErr *Error
+	if !stderrors.As(err, &scimErr) || scimErr.Detail != "User 0001 does not exist." {
+		t.Errorf("expected the error to unwrap to the SCIM error, got %v", scimErr)
+	}
+	if stderrors.Unwrap(scimErr).Error() != "no rows" {
+		t.Error("expected the SCIM error to wrap its cause")
+	}
+}
+
+func TestContextHandlerErrors(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected int
+		detail   string
+	}{
+		{fmt.Errorf("lookup failed: %w", ErrResourceNotFound), http.StatusNotFound, "Resource 0001 not found."},
+		{&Error{Status: http.StatusConflict, Detail: "The user is locked."}, http.StatusConflict, "The user is locked."},
+		{stderrors.New("connection refused"), http.StatusInternalServerError, ""},
+		{&Error{Err: stderrors.New("connection refused")}, http.StatusInternalServerError, ""},
+		{&Error{Status: http.StatusOK, Err: stderrors.New("connection refused")}, http.StatusInternalServerError, ""},
+	} {
+		server := newTestServer()
+		server.ResourceTypes[0].Handler = ContextHandler(failingResourceHandler{err: test.err})
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+		if rr.Code != test.expected {
+			t.Errorf("%v: expected status %d, got %d", test.err, test.expected, rr.Code)
+		}
+		if test.detail != "" && !strings.Contains(rr.Body.String(), test.detail) {
+			t.Errorf("%v: expected detail %q, got %s", test.err, test.detail, rr.Body.String())
+		}
+		if strings.Contains(rr.Body.String(), "connection refused") {
+			t.Error("expected the cause of an internal error not to be exposed")
+		}
+	}
+}
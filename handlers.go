@@ -1,23 +1,31 @@
 package scim
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/elimity-com/scim/errors"
 )
 
-func errorHandler(w http.ResponseWriter, _ *http.Request, scimErr scimError) {
+func errorHandler(w http.ResponseWriter, r *http.Request, scimErr scimError) {
+	if cause := takeErrorCause(r); cause != "" {
+		scimErr.detail = strings.TrimSpace(scimErr.detail + " Cause: " + cause + ".")
+	}
 	raw, err := json.Marshal(scimErr)
 	if err != nil {
 		log.Fatalf("failed marshaling scim error: %v", err)
 	}
+	if scimErr.retryAfter > 0 {
+		w.Header().Set("Retry-After", formatRetryAfter(scimErr.retryAfter))
+	}
 	w.WriteHeader(scimErr.status)
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -50,7 +58,7 @@ func (s Server) schemasHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -71,7 +79,7 @@ func (s Server) schemaHandler(w http.ResponseWriter, r *http.Request, id string)
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -104,7 +112,7 @@ func (s Server) resourceTypesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -131,14 +139,20 @@ func (s Server) resourceTypeHandler(w http.ResponseWriter, r *http.Request, name
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
 // serviceProviderConfigHandler receives an HTTP GET to this endpoint will return a JSON structure that describes the
 // SCIM specification features available on a service provider.
 func (s Server) serviceProviderConfigHandler(w http.ResponseWriter, r *http.Request) {
-	raw, err := json.Marshal(s.Config.getRaw())
+	config := s.Config.getRaw()
+	if s.Capabilities != nil {
+		config["schemas"] = append(config["schemas"].([]string), CapabilitiesExtensionID)
+		config[CapabilitiesExtensionID] = s.Capabilities.getRaw(s)
+	}
+
+	raw, err := json.Marshal(config)
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshaling service provider config: %v", err)
@@ -146,26 +160,85 @@ func (s Server) serviceProviderConfigHandler(w http.ResponseWriter, r *http.Requ
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
 // resourcePatchHandler receives an HTTP PATCH to the resource endpoint, e.g., "/Users/{id}" or "/Groups/{id}", where
 // "{id}" is a resource identifier to replace a resource's attributes.
 func (s Server) resourcePatchHandler(w http.ResponseWriter, r *http.Request, id string, resourceType ResourceType) {
-	patch, scimErr := resourceType.validatePatch(r)
+	if !s.Config.features().Patch.Supported {
+		errorHandler(w, r, scimError{
+			scimType: errors.ScimTypeNotImplemented,
+			detail:   "The service provider does not support PATCH requests.",
+			status:   http.StatusNotImplemented,
+		})
+		return
+	}
+
+	patch, scimErr := s.validatePatchRequest(r, resourceType)
 	if scimErr != errors.ValidationErrorNil {
 		errorHandler(w, r, scimValidationError(scimErr))
 		return
 	}
+	if maxOperations := s.Config.features().Patch.MaxOperations; maxOperations > 0 && len(patch.Operations) > maxOperations {
+		errorHandler(w, r, scimError{
+			scimType: errors.ScimTypeTooMany,
+			detail:   fmt.Sprintf("The number of operations exceeds the maximum (%d).", maxOperations),
+			status:   http.StatusBadRequest,
+		})
+		return
+	}
+	if grantErr := s.checkPatchGrants(r, resourceType, patch); grantErr != nil {
+		errorHandler(w, r, *grantErr)
+		return
+	}
+	if passwordErr := s.checkPatchChangePassword(resourceType, patch); passwordErr != nil {
+		errorHandler(w, r, *passwordErr)
+		return
+	}
+	if patchedErr := s.checkPatchedResource(r, resourceType, id, patch); patchedErr != nil {
+		errorHandler(w, r, *patchedErr)
+		return
+	}
 
-	resource, patchErr := resourceType.Handler.Patch(r, id, patch)
-	if patchErr != errors.PatchErrorNil {
-		errorHandler(w, r, scimPatchError(patchErr, id))
+	patch, unchanged, preconditionErr := s.mergePatch(r, resourceType, id, patch)
+	if preconditionErr != nil {
+		errorHandler(w, r, *preconditionErr)
 		return
 	}
 
-	raw, err := json.Marshal(resource.response(resourceType))
+	var resource Resource
+	var statistics PatchStatistics
+	// Without changes, there is nothing to count.
+	counted := unchanged != nil
+	if unchanged != nil {
+		// All changes were discarded in favor of concurrent changes.
+		resource = *unchanged
+	} else {
+		var before ResourceAttributes
+		if s.Auditor != nil || s.PatchStatistics {
+			before = snapshot(r, resourceType, id)
+		}
+		var patchErr errors.PatchError
+		resource, patchErr = resourceType.Handler.Patch(r, id, patch)
+		if patchErr != errors.PatchErrorNil {
+			if !acceptedHandler(w, errors.ScimError(patchErr)) {
+				errorHandler(w, r, s.disclose(scimPatchError(patchErr, id), id))
+			}
+			return
+		}
+		s.audit(r, AuditOperationPatch, resourceType, id, before, resource.Attributes)
+		statistics, counted = patchStatisticsOf(resourceType, patch, before, resource.Attributes)
+	}
+
+	setETag(w, resource)
+	response, excluded := s.trimResource(resourceType.project(resource.response(r, resourceType), resourceType.parseProjection(r)))
+	setTrimmedWarning(w, excluded)
+	if s.PatchStatistics && counted {
+		response = statistics.addTo(response)
+	}
+	raw, err := json.Marshal(response)
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshaling resource: %v", err)
@@ -174,37 +247,50 @@ func (s Server) resourcePatchHandler(w http.ResponseWriter, r *http.Request, id
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
 // resourcePostHandler receives an HTTP POST request to the resource endpoint, such as "/Users" or "/Groups", as
 // defined by the associated resource type endpoint discovery to create new resources.
 func (s Server) resourcePostHandler(w http.ResponseWriter, r *http.Request, resourceType ResourceType) {
-	data, _ := ioutil.ReadAll(r.Body)
-
-	attributes, scimErr := resourceType.validate(data)
+	attributes, externalID, scimErr := s.validateResource(r, resourceType)
 	if scimErr != errors.ValidationErrorNil {
 		errorHandler(w, r, scimValidationError(scimErr))
 		return
 	}
+	if grantErr := s.checkGrants(r, attributes); grantErr != nil {
+		errorHandler(w, r, *grantErr)
+		return
+	}
+	if constraintErr := s.checkConstraints(r, resourceType, attributes); constraintErr != nil {
+		errorHandler(w, r, *constraintErr)
+		return
+	}
 
-	resource, postErr := resourceType.Handler.Create(r, attributes)
+	resource, postErr := resourceType.Handler.Create(r, attributes, externalID)
 	if postErr != errors.PostErrorNil {
-		errorHandler(w, r, scimPostError(postErr))
+		if !acceptedHandler(w, errors.ScimError(postErr)) {
+			errorHandler(w, r, scimPostError(postErr))
+		}
 		return
 	}
+	s.audit(r, AuditOperationCreate, resourceType, resource.ID, nil, resource.Attributes)
 
-	raw, err := json.Marshal(resource.response(resourceType))
+	setETag(w, resource)
+	response, excluded := s.trimResource(resourceType.project(resource.response(r, resourceType), resourceType.parseProjection(r)))
+	setTrimmedWarning(w, excluded)
+	raw, err := json.Marshal(response)
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshaling resource: %v", err)
 		return
 	}
+	w.Header().Set("Location", resourceLocation(r, resourceType, resource.ID))
 	w.WriteHeader(http.StatusCreated)
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -213,11 +299,18 @@ func (s Server) resourcePostHandler(w http.ResponseWriter, r *http.Request, reso
 func (s Server) resourceGetHandler(w http.ResponseWriter, r *http.Request, id string, resourceType ResourceType) {
 	resource, getErr := resourceType.Handler.Get(r, id)
 	if getErr != errors.GetErrorNil {
-		errorHandler(w, r, scimGetError(getErr, id))
+		errorHandler(w, r, s.disclose(scimGetError(getErr, id), id))
 		return
 	}
 
-	raw, err := json.Marshal(resource.response(resourceType))
+	setETag(w, resource)
+	if s.notModified(r, resource) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	response, excluded := s.trimResource(resourceType.project(resource.response(r, resourceType), resourceType.parseProjection(r)))
+	setTrimmedWarning(w, excluded)
+	raw, err := json.Marshal(response)
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshaling resource: %v", err)
@@ -225,7 +318,7 @@ func (s Server) resourceGetHandler(w http.ResponseWriter, r *http.Request, id st
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
@@ -237,24 +330,46 @@ func (s Server) resourcesGetHandler(w http.ResponseWriter, r *http.Request, reso
 		errorHandler(w, r, *paramsErr)
 		return
 	}
+	if params.SortBy != "" && resourceType.lookupAttribute(params.SortBy) == nil {
+		errorHandler(w, r, scimErrorBadParams([]string{"sortBy"}))
+		return
+	}
+
+	getAllRequest, cancel := s.withPaginationDeadline(r)
+	defer cancel()
 
-	page, getError := resourceType.Handler.GetAll(r, params)
+	page, getError := resourceType.Handler.GetAll(getAllRequest, params)
 	if getError != errors.GetErrorNil {
 		errorHandler(w, r, scimGetAllError(getError))
 		return
 	}
+	if sorter, ok := resourceType.Handler.(Sorter); ok && !sorter.SupportsSort() {
+		resourceType.SchemaSet().sortResources(page.Resources, params)
+	}
 
+	projection := resourceType.parseProjection(r)
 	var resources []interface{}
+	var excluded []string
 	for _, v := range page.Resources {
-		resources = append(resources, v.response(resourceType))
+		response, trimmed := s.trimResource(resourceType.project(v.response(r, resourceType), projection))
+		resources = append(resources, response)
+		excluded = appendMissing(excluded, trimmed...)
 	}
+	setTrimmedWarning(w, excluded)
 
-	raw, err := json.Marshal(listResponse{
-		TotalResults: page.TotalResults,
+	itemsPerPage := params.Count
+	if s.PaginationDeadlineMargin > 0 && getAllRequest.Context().Err() == context.DeadlineExceeded &&
+		len(resources) < itemsPerPage {
+		// The page got shortened because the deadline was approaching.
+		itemsPerPage = len(resources)
+	}
+
+	total, exact := page.totalResults(params)
+	raw, err := json.Marshal(s.withTotalResults(listResponse{
 		Resources:    resources,
 		StartIndex:   params.StartIndex,
-		ItemsPerPage: params.Count,
-	})
+		ItemsPerPage: itemsPerPage,
+	}, total, exact))
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshalling list response: %v", err)
@@ -262,28 +377,55 @@ func (s Server) resourcesGetHandler(w http.ResponseWriter, r *http.Request, reso
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
 // resourcePutHandler receives an HTTP PUT to the resource endpoint, e.g., "/Users/{id}" or "/Groups/{id}", where
 // "{id}" is a resource identifier to replace a resource's attributes.
 func (s Server) resourcePutHandler(w http.ResponseWriter, r *http.Request, id string, resourceType ResourceType) {
-	data, _ := ioutil.ReadAll(r.Body)
-
-	attributes, scimErr := resourceType.validate(data)
+	attributes, externalID, scimErr := s.validateResource(r, resourceType)
 	if scimErr != errors.ValidationErrorNil {
 		errorHandler(w, r, scimValidationError(scimErr))
 		return
 	}
+	if grantErr := s.checkGrants(r, attributes); grantErr != nil {
+		errorHandler(w, r, *grantErr)
+		return
+	}
+	if passwordErr := s.checkChangePassword(attributes); passwordErr != nil {
+		errorHandler(w, r, *passwordErr)
+		return
+	}
+	if constraintErr := s.checkConstraints(r, resourceType, attributes); constraintErr != nil {
+		errorHandler(w, r, *constraintErr)
+		return
+	}
+	if immutableErr := s.checkReplaceImmutable(r, resourceType, id, attributes); immutableErr != nil {
+		errorHandler(w, r, *immutableErr)
+		return
+	}
 
-	resource, putError := resourceType.Handler.Replace(r, id, attributes)
+	attributes, preconditionErr := s.mergeReplace(r, resourceType, id, attributes)
+	if preconditionErr != nil {
+		errorHandler(w, r, *preconditionErr)
+		return
+	}
+
+	before := s.auditSnapshot(r, resourceType, id)
+	resource, putError := resourceType.Handler.Replace(r, id, attributes, externalID)
 	if putError != errors.PutErrorNil {
-		errorHandler(w, r, scimPutError(putError, id))
+		if !acceptedHandler(w, errors.ScimError(putError)) {
+			errorHandler(w, r, s.disclose(scimPutError(putError, id), id))
+		}
 		return
 	}
+	s.audit(r, AuditOperationReplace, resourceType, id, before, resource.Attributes)
 
-	raw, err := json.Marshal(resource.response(resourceType))
+	setETag(w, resource)
+	response, excluded := s.trimResource(resourceType.project(resource.response(r, resourceType), resourceType.parseProjection(r)))
+	setTrimmedWarning(w, excluded)
+	raw, err := json.Marshal(response)
 	if err != nil {
 		errorHandler(w, r, scimErrorInternalServer)
 		log.Fatalf("failed marshaling resource: %v", err)
@@ -291,17 +433,25 @@ func (s Server) resourcePutHandler(w http.ResponseWriter, r *http.Request, id st
 	}
 	_, err = w.Write(raw)
 	if err != nil {
-		log.Printf("failed writing response: %v", err)
+		logf(r, "failed writing response: %v", err)
 	}
 }
 
 // resourceDeleteHandler receives an HTTP DELETE request to the resource endpoint, e.g., "/Users/{id}" or "/Groups/{id}",
 // where "{id}" is a resource identifier to delete a known resource.
 func (s Server) resourceDeleteHandler(w http.ResponseWriter, r *http.Request, id string, resourceType ResourceType) {
+	if preconditionErr := s.checkPreconditions(r, resourceType, id); preconditionErr != nil {
+		errorHandler(w, r, *preconditionErr)
+		return
+	}
+
 	deleteErr := resourceType.Handler.Delete(r, id)
 	if deleteErr != errors.DeleteErrorNil {
-		errorHandler(w, r, scimDeleteError(deleteErr, id))
+		if !acceptedHandler(w, errors.ScimError(deleteErr)) {
+			errorHandler(w, r, s.disclose(scimDeleteError(deleteErr, id), id))
+		}
 		return
 	}
+	s.audit(r, AuditOperationDelete, resourceType, id, nil, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -1,13 +1,19 @@
 package scim
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/errors"
 	"github.com/elimity-com/scim/optional"
 	"github.com/elimity-com/scim/schema"
 )
@@ -91,7 +97,9 @@ func newTestServer() Server {
 	}
 
 	return Server{
-		Config: ServiceProviderConfig{},
+		Config: ServiceProviderConfig{
+			Features: Features{Patch: PatchFeature{Supported: true}},
+		},
 		ResourceTypes: []ResourceType{
 			{
 				ID:          optional.NewString("User"),
@@ -127,7 +135,8 @@ func newTestResourceHandler() ResourceHandler {
 	}
 
 	return testResourceHandler{
-		data: data,
+		data:        data,
+		externalIDs: make(map[string]optional.String),
 	}
 }
 
@@ -275,6 +284,43 @@ func TestServerServiceProviderConfigHandler(t *testing.T) {
 	}
 }
 
+func TestServerServiceProviderConfigHandlerCapabilities(t *testing.T) {
+	server := newTestServer()
+	server.CoercePatchValues = true
+	server.Capabilities = &Capabilities{
+		Profiles: []string{"azure"},
+		Features: map[string]bool{"custom": true},
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ServiceProviderConfig", nil))
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var config struct {
+		Schemas      []string
+		Capabilities struct {
+			Version  string
+			Profiles []string
+			Features map[string]bool
+		} `json:"urn:elimity:params:scim:schemas:extension:capabilities:2.0"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Schemas) != 2 || config.Schemas[1] != CapabilitiesExtensionID {
+		t.Errorf("unexpected schemas: %v", config.Schemas)
+	}
+	capabilities := config.Capabilities
+	if capabilities.Version != Version || len(capabilities.Profiles) != 1 || capabilities.Profiles[0] != "azure" {
+		t.Errorf("unexpected capabilities: %+v", capabilities)
+	}
+	if !capabilities.Features["coercePatchValues"] || !capabilities.Features["custom"] || capabilities.Features["bulk"] {
+		t.Errorf("unexpected features: %v", capabilities.Features)
+	}
+}
+
 func TestServerResourcePostHandlerInvalid(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{"id": "other"}`))
 	rr := httptest.NewRecorder()
@@ -563,6 +609,39 @@ func TestServerResourcePutHandlerValid(t *testing.T) {
 	}
 }
 
+func TestServerResourceReadOnlyAttributes(t *testing.T) {
+	for _, test := range []struct {
+		method, target string
+		expected       int
+	}{
+		{http.MethodPost, "/Users", http.StatusCreated},
+		{http.MethodPut, "/Users/0001", http.StatusOK},
+	} {
+		// Values of read-only attributes are not validated either.
+		body := `{"userName": "test", "readonlyThing": 42}`
+		server := newTestServer()
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(body)))
+		if rr.Code != test.expected {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v", test.method, rr.Code, test.expected)
+		}
+		var resource map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		if resource["readonlyThing"] != nil {
+			t.Errorf("%s: expected the read-only attribute to be ignored, got %v", test.method, resource)
+		}
+
+		server.StrictReadOnly = true
+		rr = httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"mutability"`) {
+			t.Errorf("%s: expected a mutability error in strict mode, got %d: %s", test.method, rr.Code, rr.Body.String())
+		}
+	}
+}
+
 func TestServerResourcePutHandlerNotFound(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPut, "/Users/9999", strings.NewReader(`{"userName": "other"}`))
 	rr := httptest.NewRecorder()
@@ -610,3 +689,472 @@ func TestServerResourceDeleteHandlerNotFound(t *testing.T) {
 		t.Errorf("wrong scim error: %v", scimErr)
 	}
 }
+
+func TestServerResourcePatchHandlerCoerceValues(t *testing.T) {
+	body := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations":[
+		  {
+		    "op":"replace",
+		    "path":"active",
+		    "value":"False"
+		  }
+		]
+	}`
+
+	for _, test := range []struct {
+		coerce         bool
+		expectedStatus int
+	}{
+		{coerce: false, expectedStatus: http.StatusBadRequest},
+		{coerce: true, expectedStatus: http.StatusOK},
+	} {
+		server := newTestServer()
+		server.CoercePatchValues = test.coerce
+
+		req := httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != test.expectedStatus {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatus)
+		}
+
+		if !test.coerce {
+			continue
+		}
+
+		var resource map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		if resource["active"] != false {
+			t.Errorf("handler did not receive the coerced value: %v", resource["active"])
+		}
+	}
+}
+
+func TestResourceTypeCoerceOperationValue(t *testing.T) {
+	extensionID := "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	resourceType := newTestServer().ResourceTypes[1]
+	resourceType.SchemaExtensions[0].Schema.Attributes = []schema.CoreAttribute{
+		schema.SimpleCoreAttribute(schema.SimpleBooleanParams(schema.BooleanParams{Name: "contractor"})),
+		schema.ComplexCoreAttribute(schema.ComplexParams{
+			Name: "badge",
+			SubAttributes: []schema.SimpleParams{
+				schema.SimpleNumberParams(schema.NumberParams{Name: "number", Type: schema.AttributeTypeInteger()}),
+			},
+		}),
+	}
+
+	for _, test := range []struct {
+		name     string
+		path     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"extension", extensionID + ":contractor", "True", true},
+		{"extension sub-attribute", extensionID + ":badge.number", "42", 42},
+		{"value filter", `emails[type eq "work"]`, map[string]interface{}{"primary": "True"}, map[string]interface{}{"primary": true}},
+		{"value filter sub-attribute", `emails[type eq "work"].primary`, "False", false},
+		{
+			"extension object", "",
+			map[string]interface{}{"active": "true", extensionID: map[string]interface{}{"contractor": "False"}},
+			map[string]interface{}{"active": true, extensionID: map[string]interface{}{"contractor": false}},
+		},
+		{
+			"extension prefix", "",
+			map[string]interface{}{extensionID + ":contractor": "true", extensionID + ":badge.number": "7"},
+			map[string]interface{}{extensionID + ":contractor": true, extensionID + ":badge.number": 7},
+		},
+	} {
+		op := resourceType.coerceOperationValue(PatchOperation{Op: PatchOperationReplace, Path: test.path, Value: test.value})
+		if !reflect.DeepEqual(op.Value, test.expected) {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.expected, op.Value)
+		}
+	}
+}
+
+// licenseResourceHandler rejects the creation of new resources with a custom error.
+type licenseResourceHandler struct {
+	testResourceHandler
+}
+
+func (h licenseResourceHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	return Resource{}, errors.PostError{
+		ScimType: "urn:example:licenseExceeded",
+		Detail:   "No licenses left.",
+		Status:   http.StatusForbidden,
+	}
+}
+
+func TestServerResourcePostHandlerCustomError(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = licenseResourceHandler{}
+
+	req := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{"userName": "test1"}`))
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+
+	var scimErr scimError
+	if err := json.Unmarshal(rr.Body.Bytes(), &scimErr); err != nil {
+		t.Fatal(err)
+	}
+	if scimErr.scimType != "urn:example:licenseExceeded" || scimErr.detail != "No licenses left." {
+		t.Errorf("wrong scim error: %v", scimErr)
+	}
+}
+
+// unavailableResourceHandler rejects all requests with custom errors.
+type unavailableResourceHandler struct {
+	testResourceHandler
+}
+
+func (h unavailableResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	err := errors.GetErrorServiceUnavailable
+	err.RetryAfter = 30 * time.Second
+	return Resource{}, err
+}
+
+func (h unavailableResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	return Resource{}, errors.PutErrorUniqueness.WithDetail("The user name test is already taken.")
+}
+
+func TestServerCustomErrorDetail(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = unavailableResourceHandler{}
+
+	for _, test := range []struct {
+		req        *http.Request
+		status     int
+		scimType   errors.ScimType
+		detail     string
+		retryAfter string
+	}{
+		{
+			httptest.NewRequest(http.MethodGet, "/Users/0001", nil),
+			http.StatusServiceUnavailable, "",
+			"The service provider is temporarily unavailable, retry the request later.", "30",
+		},
+		{
+			httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(`{"userName": "test"}`)),
+			http.StatusConflict, errors.ScimTypeUniqueness,
+			"The user name test is already taken.", "",
+		},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, test.req)
+		if rr.Code != test.status {
+			t.Errorf("%s: wrong status code: got %v want %v", test.req.Method, rr.Code, test.status)
+		}
+		if retryAfter := rr.Header().Get("Retry-After"); retryAfter != test.retryAfter {
+			t.Errorf("%s: wrong Retry-After header: got %q want %q", test.req.Method, retryAfter, test.retryAfter)
+		}
+
+		var scimErr scimError
+		if err := json.Unmarshal(rr.Body.Bytes(), &scimErr); err != nil {
+			t.Fatal(err)
+		}
+		if scimErr.scimType != test.scimType || scimErr.detail != test.detail {
+			t.Errorf("%s: wrong scim error: %v", test.req.Method, scimErr)
+		}
+	}
+}
+
+// slowResourceHandler lists a single resource per second, until its context is done.
+type slowResourceHandler struct {
+	testResourceHandler
+}
+
+func (h slowResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	var resources []Resource
+	for i := 0; i < params.Count; i++ {
+		resources = append(resources, Resource{
+			ID:         fmt.Sprintf("%04d", i),
+			Attributes: ResourceAttributes{"userName": fmt.Sprintf("test%d", i)},
+		})
+		select {
+		case <-r.Context().Done():
+			return Page{TotalResults: params.Count, Resources: resources}, errors.GetErrorNil
+		case <-time.After(time.Second):
+		}
+	}
+	return Page{TotalResults: params.Count, Resources: resources}, errors.GetErrorNil
+}
+
+func TestServerResourcesGetHandlerPaginationDeadline(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = slowResourceHandler{}
+	server.PaginationDeadlineMargin = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute+50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/Users?count=10", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response listResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.ItemsPerPage != 1 || len(response.Resources) != 1 {
+		t.Errorf("expected a shortened page with a single resource, got %d items per page and %d resources",
+			response.ItemsPerPage, len(response.Resources))
+	}
+	if response.TotalResults != 10 {
+		t.Errorf("wrong total results: got %d want %d", response.TotalResults, 10)
+	}
+}
+
+// paramsResourceHandler records the list request parameters that are passed to it.
+type paramsResourceHandler struct {
+	testResourceHandler
+	params *ListRequestParams
+}
+
+func (h paramsResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	*h.params = params
+	return Page{}, errors.GetErrorNil
+}
+
+func TestServerResourcesGetHandlerFilter(t *testing.T) {
+	var params ListRequestParams
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = paramsResourceHandler{params: &params}
+
+	req := httptest.NewRequest(http.MethodGet, "/Users?filter="+url.QueryEscape(` userName eq "bjensen" `), nil)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	if params.RawFilter != `userName eq "bjensen"` {
+		t.Errorf("wrong raw filter: %q", params.RawFilter)
+	}
+	expression, ok := params.Filter.(filter.AttributeExpression)
+	if !ok {
+		t.Fatalf("expected an attribute expression, got %T", params.Filter)
+	}
+	if expression.AttributePath.AttributeName != "userName" || expression.CompareValue != "bjensen" {
+		t.Errorf("wrong filter expression: %v", expression)
+	}
+}
+
+func TestServerAcceptHeader(t *testing.T) {
+	for _, test := range []struct {
+		accept   []string
+		expected int
+	}{
+		{nil, http.StatusOK},
+		{[]string{""}, http.StatusOK},
+		{[]string{"application/scim+json"}, http.StatusOK},
+		{[]string{"application/json"}, http.StatusOK},
+		{[]string{"application/json; charset=utf-8"}, http.StatusOK},
+		{[]string{"Application/SCIM+JSON"}, http.StatusOK},
+		{[]string{"*/*"}, http.StatusOK},
+		{[]string{"*"}, http.StatusOK},
+		{[]string{"application/*"}, http.StatusOK},
+		{[]string{"text/html, application/xhtml+xml, application/xml;q=0.9, */*;q=0.8"}, http.StatusOK},
+		{[]string{"text/plain", "application/json"}, http.StatusOK},
+		{[]string{"text/html"}, http.StatusNotAcceptable},
+		{[]string{"application/xml, text/xml"}, http.StatusNotAcceptable},
+		{[]string{"application/json;q=0, text/plain"}, http.StatusNotAcceptable},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+		for _, accept := range test.accept {
+			req.Header.Add("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, req)
+
+		if rr.Code != test.expected {
+			t.Errorf("%q: handler returned wrong status code: got %v want %v", test.accept, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestServerResourcesGetHandlerSort(t *testing.T) {
+	for _, test := range []struct {
+		query         string
+		supportSort   bool
+		expected      int
+		expectedBy    string
+		expectedOrder SortOrder
+	}{
+		{"sortBy=userName", true, http.StatusOK, "userName", SortOrderAscending},
+		{"sortBy=name.familyName&sortOrder=Descending", true, http.StatusOK, "name.familyName", SortOrderDescending},
+		{"sortBy=urn:ietf:params:scim:schemas:core:2.0:User:userName", true, http.StatusOK, "urn:ietf:params:scim:schemas:core:2.0:User:userName", SortOrderAscending},
+		{"sortOrder=descending", true, http.StatusOK, "", ""},
+		{"sortBy=userName", false, http.StatusOK, "", ""},
+		{"sortBy=unknown", true, http.StatusBadRequest, "", ""},
+		{"sortBy=name", true, http.StatusBadRequest, "", ""},
+		{"sortBy=userName&sortOrder=random", true, http.StatusBadRequest, "", ""},
+	} {
+		var params ListRequestParams
+		server := newTestServer()
+		server.Config.SupportSort = test.supportSort
+		server.ResourceTypes[0].Handler = paramsResourceHandler{params: &params}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?"+test.query, nil))
+
+		if rr.Code != test.expected {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", test.query, rr.Code, test.expected)
+			continue
+		}
+		if params.SortBy != test.expectedBy || params.SortOrder != test.expectedOrder {
+			t.Errorf("%s: wrong sort parameters: got %q %q", test.query, params.SortBy, params.SortOrder)
+		}
+	}
+}
+
+func TestServerTextValidation(t *testing.T) {
+	for _, test := range []struct {
+		validation TextValidation
+		method     string
+		target     string
+		body       string
+		expected   int
+	}{
+		{TextValidation{}, http.MethodPost, "/Users", "{\"userName\": \"test\xff\"}", http.StatusCreated},
+		{TextValidation{RejectInvalidUTF8: true}, http.MethodPost, "/Users", "{\"userName\": \"test\xff\"}", http.StatusBadRequest},
+		{TextValidation{RejectInvalidUTF8: true}, http.MethodPost, "/Users", `{"userName": "tëst"}`, http.StatusCreated},
+		{TextValidation{RejectControlCharacters: true}, http.MethodPost, "/Users", `{"userName": "test\u0000"}`, http.StatusBadRequest},
+		{TextValidation{RejectControlCharacters: true}, http.MethodPut, "/Users/0001", `{"userName": "test\u0085"}`, http.StatusBadRequest},
+		{TextValidation{RejectControlCharacters: true}, http.MethodPut, "/Users/0001", `{"userName": "test\n"}`, http.StatusBadRequest},
+		{TextValidation{RejectControlCharacters: true, AllowedControlCharacters: "\n"}, http.MethodPut, "/Users/0001", `{"userName": "test\n"}`, http.StatusOK},
+		{TextValidation{RejectControlCharacters: true}, http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "displayName", "value": "test\u001b"}]
+		}`, http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.TextValidation = test.validation
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %q: handler returned wrong status code: got %v want %v", test.method, test.body, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestServerRequiredSchemaExtension(t *testing.T) {
+	server := newTestServer()
+	extension := &server.ResourceTypes[1].SchemaExtensions[0]
+	extension.Required = true
+	extension.Schema.Attributes = []schema.CoreAttribute{
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:     "employeeNumber",
+			Required: true,
+		})),
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name: "organization",
+		})),
+	}
+
+	for _, test := range []struct {
+		method, target, body string
+		expected             int
+	}{
+		{http.MethodPost, "/EnterpriseUser", `{"userName": "test"}`, http.StatusBadRequest},
+		{http.MethodPost, "/EnterpriseUser", `{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": null}`, http.StatusBadRequest},
+		{http.MethodPost, "/EnterpriseUser", `{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {}}`, http.StatusBadRequest},
+		{http.MethodPost, "/EnterpriseUser", `{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}}`, http.StatusCreated},
+		{http.MethodPut, "/EnterpriseUser/0001", `{"userName": "test"}`, http.StatusBadRequest},
+		{http.MethodPut, "/EnterpriseUser/0001", `{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}}`, http.StatusOK},
+		{http.MethodPatch, "/EnterpriseUser/0001", `{"Operations": [{"op": "remove", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber"}]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/EnterpriseUser/0001", `{"Operations": [{"op": "remove", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:organization"}]}`, http.StatusOK},
+		{http.MethodPatch, "/EnterpriseUser/0001", `{"Operations": [{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", "value": "701985"}]}`, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: expected %d, got %d: %s", test.method, test.body, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Attributes of optional schema extensions can be removed.
+	extension.Required = false
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/EnterpriseUser/0001", strings.NewReader(
+		`{"Operations": [{"op": "remove", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber"}]}`,
+	)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerResourceSchemas(t *testing.T) {
+	for _, test := range []struct {
+		schemas  string
+		expected int
+	}{
+		{`["urn:ietf:params:scim:schemas:core:2.0:User"]`, http.StatusCreated},
+		{`["URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER", "urn:ietf:params:scim:schemas:core:2.0:User"]`, http.StatusCreated},
+		{`[]`, http.StatusCreated},
+		{`["urn:ietf:params:scim:schemas:core:2.0:Group"]`, http.StatusBadRequest},
+		{`"urn:ietf:params:scim:schemas:core:2.0:User"`, http.StatusBadRequest},
+	} {
+		body := `{"schemas": ` + test.schemas + `, "userName": "test"}`
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/EnterpriseUser", strings.NewReader(body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected %d, got %d: %s", test.schemas, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The schema extension is unknown to the resource type of the users.
+	body := `{"schemas": ["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"], "userName": "test"}`
+	rr := httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalidValue") {
+		t.Errorf("expected an invalidValue error, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerResponseSchemas(t *testing.T) {
+	for _, test := range []struct {
+		body     string
+		expected []interface{}
+	}{
+		{
+			`{"userName": "test"}`,
+			[]interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		},
+		{
+			`{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"organization": null}}`,
+			[]interface{}{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		},
+		{
+			`{"userName": "test", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}}`,
+			[]interface{}{
+				"urn:ietf:params:scim:schemas:core:2.0:User",
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+			},
+		},
+	} {
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/EnterpriseUser", strings.NewReader(test.body)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		var resource map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resource["schemas"], test.expected) {
+			t.Errorf("%s: expected schemas %v, got %v", test.body, test.expected, resource["schemas"])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// checkReplaceImmutable checks that given validated attributes of a PUT request do not change the values of the
+// immutable attributes of the resource with given identifier, see checkImmutable. Immutable attributes that are
+// omitted from the request are not considered to be changed, so the resource is only retrieved if the request contains
+// a value of an immutable attribute. Errors of retrieving the resource are left to the Replace callback method.
+func (s Server) checkReplaceImmutable(r *http.Request, resourceType ResourceType, id string, attributes ResourceAttributes) *scimError {
+	if validationSkipped(r) {
+		return nil
+	}
+	var requested bool
+	for _, attribute := range resourceType.immutableAttributes() {
+		requested = requested || attribute.valueOf(attributes) != nil
+	}
+	if !requested {
+		return nil
+	}
+	resource, getErr := resourceType.Handler.Get(r, id)
+	if getErr != errors.GetErrorNil {
+		return nil
+	}
+	return resourceType.checkImmutable(resource.Attributes, attributes, true)
+}
+
+// checkImmutable returns an error if given attributes, which replace given current attributes of a resource, change
+// the value of one of its immutable (top-level) attributes. Immutable attributes can only be assigned a value if they
+// do not have one yet, as defined in RFC 7643, section 2.2. Values are compared with SchemaSet.Equal, so e.g. the
+// values of a multi-valued attribute can be reordered. Attributes that are omitted from given attributes are only
+// checked if keepOmitted is false.
+func (t ResourceType) checkImmutable(current, attributes ResourceAttributes, keepOmitted bool) *scimError {
+	schemaSet := t.SchemaSet()
+	for _, attribute := range t.immutableAttributes() {
+		stored := attribute.valueOf(current)
+		requested := attribute.valueOf(attributes)
+		if stored == nil || requested == nil && keepOmitted {
+			continue
+		}
+		if !schemaSet.Equal(attribute.wrap(stored), attribute.wrap(requested)) {
+			return &scimError{
+				scimType: errors.ScimTypeMutability,
+				detail:   fmt.Sprintf("The immutable attribute %q can not be changed.", attribute.name),
+				status:   http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+// immutableAttribute is an immutable top-level attribute of a resource type.
+type immutableAttribute struct {
+	// uri is the identifier of the schema that defines the attribute.
+	uri string
+	// extension is true if the attribute is defined by a schema extension, whose attributes are nested under its uri.
+	extension bool
+	name      string
+}
+
+// immutableAttributes returns the immutable top-level attributes of the schema and schema extensions of the resource
+// type.
+func (t ResourceType) immutableAttributes() []immutableAttribute {
+	var attributes []immutableAttribute
+	collect := func(s schema.Schema, extension bool) {
+		for _, attribute := range s.Attributes {
+			if attribute.Mutability() == schema.AttributeMutabilityImmutable() {
+				attributes = append(attributes, immutableAttribute{uri: s.ID, extension: extension, name: attribute.Name()})
+			}
+		}
+	}
+	collect(t.Schema, false)
+	for _, extension := range t.SchemaExtensions {
+		collect(extension.Schema, true)
+	}
+	return attributes
+}
+
+// valueOf returns the value of the attribute in given attributes of a resource.
+func (a immutableAttribute) valueOf(attributes ResourceAttributes) interface{} {
+	if !a.extension {
+		return lookupFold(attributes, a.name)
+	}
+	values, _ := lookupFold(attributes, a.uri).(map[string]interface{})
+	return lookupFold(values, a.name)
+}
+
+// targetedBy reports whether given PATCH operation (possibly) changes the value of the attribute.
+func (a immutableAttribute) targetedBy(op PatchOperation) bool {
+	values, _ := op.Value.(map[string]interface{})
+	switch {
+	case op.Path == "":
+		return a.valueOf(values) != nil || lookupFold(values, a.uri+":"+a.name) != nil
+	case a.extension && strings.EqualFold(op.Path, a.uri):
+		return lookupFold(values, a.name) != nil
+	}
+	path, err := ParsePatchPath(op.Path)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(path.AttributeName, a.name) && (path.URI == "" || strings.EqualFold(path.URI, a.uri))
+}
+
+// wrap returns the attributes of a resource that only have given value of the attribute, so that it can be compared
+// with SchemaSet.Equal.
+func (a immutableAttribute) wrap(value interface{}) ResourceAttributes {
+	if !a.extension {
+		return ResourceAttributes{a.name: value}
+	}
+	return ResourceAttributes{a.uri: map[string]interface{}{a.name: value}}
+}
+
+// patchesImmutable reports whether one of the operations of given PATCH request targets an immutable attribute of the
+// resource type.
+func (t ResourceType) patchesImmutable(patch PatchRequest) bool {
+	for _, attribute := range t.immutableAttributes() {
+		for _, op := range patch.Operations {
+			if attribute.targetedBy(op) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerImmutable(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		// The immutable attribute of 0001 has no value yet, so it can be assigned one.
+		{http.MethodPut, "/Users/0001", `{"userName": "test1", "immutableThing": "b"}`, http.StatusOK},
+		{http.MethodPut, "/Users/0002", `{"userName": "test2", "immutableThing": "a"}`, http.StatusOK},
+		{http.MethodPut, "/Users/0002", `{"userName": "test2", "immutableThing": "b"}`, http.StatusBadRequest},
+		// Omitting an immutable attribute does not change it.
+		{http.MethodPut, "/Users/0002", `{"userName": "test2"}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", patchBody(`{"op": "add", "path": "immutableThing", "value": "b"}`), http.StatusOK},
+		{http.MethodPatch, "/Users/0002", patchBody(`{"op": "add", "path": "immutableThing", "value": "a"}`), http.StatusOK},
+		{http.MethodPatch, "/Users/0002", patchBody(`{"op": "add", "value": {"immutableThing": "b"}}`), http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.ResourceTypes[0].Handler.(testResourceHandler).data["0002"]["immutableThing"] = "a"
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s %s: got status %d, want %d: %s", test.method, test.target, test.body, rr.Code, test.expected, rr.Body.String())
+		}
+		if rr.Code == http.StatusBadRequest && !strings.Contains(rr.Body.String(), "mutability") {
+			t.Errorf("expected a mutability error, got %s", rr.Body.String())
+		}
+	}
+}
@@ -4,14 +4,68 @@ import (
 	"encoding/json"
 )
 
+// UnknownTotalResults is the total number of results of a page whose total is unknown, e.g. because counting the
+// resources of a very large directory is too expensive. See UnknownTotalResultsPolicy.
+const UnknownTotalResults = -1
+
+// TotalResultsExtensionID is the URI of the extension of list responses whose "totalResults" is not exact. These
+// responses list the URI in their "schemas" attribute and contain an object with the URI as key and a single "exact"
+// attribute that is false:
+//
+//	{
+//		"schemas": [
+//			"urn:ietf:params:scim:api:messages:2.0:ListResponse",
+//			"urn:elimity:params:scim:api:messages:2.0:TotalResults"
+//		],
+//		"totalResults": 101,
+//		"urn:elimity:params:scim:api:messages:2.0:TotalResults": {"exact": false},
+//		...
+//	}
+const TotalResultsExtensionID = "urn:elimity:params:scim:api:messages:2.0:TotalResults"
+
+// UnknownTotalResultsPolicy decides how the server reports the total number of results of a list response if the
+// resource handler does not know it, i.e. it returns UnknownTotalResults.
+type UnknownTotalResultsPolicy int
+
+const (
+	// UnknownTotalResultsEstimate reports an estimate as "totalResults": the estimate of the page if any, but at least
+	// the number of results up to and including the page, plus one if the page is full so that clients request the
+	// next page. This is the default, since the attribute is required by RFC 7644.
+	UnknownTotalResultsEstimate UnknownTotalResultsPolicy = iota
+	// UnknownTotalResultsOmit leaves out the "totalResults" attribute. Clients then page through the results until
+	// they receive an empty page.
+	UnknownTotalResultsOmit
+)
+
 // Page represents a paginated resource query response.
 type Page struct {
-	// TotalResults is the total number of results returned by the list or query operation.
+	// TotalResults is the total number of results returned by the list or query operation. It is UnknownTotalResults
+	// if the total is unknown, in which case the server reports it according to its UnknownTotalResultsPolicy.
 	TotalResults int
+	// EstimatedTotalResults is an estimate of the total number of results, e.g. based on the statistics of a
+	// database table. It is only used if TotalResults is UnknownTotalResults.
+	EstimatedTotalResults int
 	// Resources is a multi-valued list of complex objects containing the requested resources.
 	Resources []Resource
 }
 
+// totalResults returns the total number of results of given page, which was requested with given parameters, and
+// whether it is exact. Unknown totals are estimated.
+func (p Page) totalResults(params ListRequestParams) (int, bool) {
+	if p.TotalResults != UnknownTotalResults {
+		return p.TotalResults, true
+	}
+	total := params.Offset() + len(p.Resources)
+	if len(p.Resources) != 0 && len(p.Resources) >= params.Count {
+		// The page is full, so there are probably more results.
+		total++
+	}
+	if p.EstimatedTotalResults > total {
+		total = p.EstimatedTotalResults
+	}
+	return total, false
+}
+
 // listResponse identifies a query response.
 type listResponse struct {
 	// TotalResults is the total number of results returned by the list or query operation.
@@ -32,14 +86,39 @@ type listResponse struct {
 	// This may be a subset of the full set of resources if pagination is requested.
 	// REQUIRED if TotalResults is non-zero.
 	Resources []interface{}
+
+	// Inexact indicates that TotalResults is an estimate, see TotalResultsExtensionID.
+	Inexact bool
+	// OmitTotalResults leaves out TotalResults, see UnknownTotalResultsOmit.
+	OmitTotalResults bool
+}
+
+// withTotalResults returns a copy of given list response with given total number of results, which is reported
+// according to the UnknownTotalResultsPolicy of the server if it is not exact.
+func (s Server) withTotalResults(l listResponse, total int, exact bool) listResponse {
+	l.TotalResults = total
+	if !exact {
+		l.Inexact = true
+		l.OmitTotalResults = s.UnknownTotalResults == UnknownTotalResultsOmit
+	}
+	return l
 }
 
 func (l listResponse) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"schemas":      []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"},
+	schemas := []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"}
+	raw := map[string]interface{}{
 		"totalResults": l.TotalResults,
 		"itemsPerPage": l.ItemsPerPage,
 		"startIndex":   l.StartIndex,
 		"Resources":    l.Resources,
-	})
+	}
+	if l.Inexact {
+		schemas = append(schemas, TotalResultsExtensionID)
+		raw[TotalResultsExtensionID] = map[string]bool{"exact": false}
+	}
+	if l.OmitTotalResults {
+		delete(raw, "totalResults")
+	}
+	raw["schemas"] = schemas
+	return json.Marshal(raw)
 }
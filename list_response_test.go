@@ -0,0 +1,71 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// uncountedResourceHandler does not count its resources, like a handler of a very large directory.
+type uncountedResourceHandler struct {
+	testResourceHandler
+	estimate int
+}
+
+func (h uncountedResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	page, err := h.testResourceHandler.GetAll(r, params)
+	page.TotalResults = UnknownTotalResults
+	page.EstimatedTotalResults = h.estimate
+	return page, err
+}
+
+func TestServerUnknownTotalResults(t *testing.T) {
+	data := make(map[string]ResourceAttributes)
+	for i := 0; i < 5; i++ {
+		data[fmt.Sprintf("%04d", i)] = ResourceAttributes{"userName": fmt.Sprintf("test%d", i)}
+	}
+
+	for _, test := range []struct {
+		target   string
+		estimate int
+		policy   UnknownTotalResultsPolicy
+		expected interface{}
+	}{
+		// A full page, so there are probably more results.
+		{"/Users?count=2", 0, UnknownTotalResultsEstimate, 3.0},
+		{"/Users?count=2&startIndex=5", 0, UnknownTotalResultsEstimate, 5.0},
+		{"/Users?count=2", 1000, UnknownTotalResultsEstimate, 1000.0},
+		{"/Users?count=2", 1000, UnknownTotalResultsOmit, nil},
+	} {
+		server := newTestServer()
+		server.UnknownTotalResults = test.policy
+		server.ResourceTypes[0].Handler = uncountedResourceHandler{
+			testResourceHandler: testResourceHandler{data: data},
+			estimate:            test.estimate,
+		}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, test.target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected %d, got %d: %s", test.target, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if total := response["totalResults"]; total != test.expected {
+			t.Errorf("%s: expected totalResults %v, got %v", test.target, test.expected, total)
+		}
+		extension, _ := response[TotalResultsExtensionID].(map[string]interface{})
+		if exact, ok := extension["exact"]; !ok || exact != false {
+			t.Errorf("%s: expected the total results extension, got %v", test.target, response)
+		}
+		if schemas, _ := response["schemas"].([]interface{}); len(schemas) != 2 || schemas[1] != TotalResultsExtensionID {
+			t.Errorf("%s: expected the URI of the extension in the schemas, got %v", test.target, response["schemas"])
+		}
+	}
+}
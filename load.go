@@ -0,0 +1,204 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// The files from which LoadServer loads the configuration of a server. They hold the responses of the endpoints of the
+// same name, so the configuration of a running server can be saved and loaded again.
+const (
+	schemasFile               = "/Schemas.json"
+	resourceTypesFile         = "/ResourceTypes.json"
+	serviceProviderConfigFile = "/ServiceProviderConfig.json"
+)
+
+// LoadServer returns a server whose schemas, resource types and service provider configuration are loaded from their
+// JSON representations in given file system, e.g. http.Dir("config"), or http.FS(files) for an embedded embed.FS:
+//
+//	Schemas.json                the schemas, see schema.ListFromJSON
+//	ResourceTypes.json          the resource types, see ResourceTypeFromJSON
+//	ServiceProviderConfig.json  the service provider configuration (optional), see ServiceProviderConfigFromJSON
+//
+// The files hold a single representation, an array of them or a list response, so the responses of the "/Schemas",
+// "/ResourceTypes" and "/ServiceProviderConfig" endpoints of a server can be used as they are. The handlers of the
+// resource types are given by their names. Given options are applied to the server after the loaded configuration.
+//
+// It returns an error if a file can not be read or is invalid, if a resource type refers to an unknown schema, if
+// the resource types and the handlers do not match or if the routes of the resource types conflict, see
+// Server.RouteConflicts.
+func LoadServer(fsys http.FileSystem, handlers map[string]ResourceHandler, opts ...ServerOption) (Server, error) {
+	data, err := readFile(fsys, schemasFile)
+	if err != nil {
+		return Server{}, err
+	}
+	schemas, err := schema.ListFromJSON(data)
+	if err != nil {
+		return Server{}, fmt.Errorf("%s: %v", schemasFile, err)
+	}
+
+	data, err = readFile(fsys, resourceTypesFile)
+	if err != nil {
+		return Server{}, err
+	}
+	documents, err := jsonDocuments(data)
+	if err != nil {
+		return Server{}, fmt.Errorf("%s: %v", resourceTypesFile, err)
+	}
+	unused := make(map[string]bool, len(handlers))
+	for name := range handlers {
+		unused[name] = true
+	}
+	resourceTypes := make([]ResourceType, len(documents))
+	for i, document := range documents {
+		resourceType, err := ResourceTypeFromJSON(document, schemas, nil)
+		if err != nil {
+			return Server{}, fmt.Errorf("%s: %v", resourceTypesFile, err)
+		}
+		handler, ok := handlers[resourceType.Name]
+		if !ok {
+			return Server{}, fmt.Errorf("%s: no handler for resource type %q", resourceTypesFile, resourceType.Name)
+		}
+		delete(unused, resourceType.Name)
+		resourceType.Handler = handler
+		resourceTypes[i] = resourceType
+	}
+	if len(unused) != 0 {
+		names := make([]string, 0, len(unused))
+		for name := range unused {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Server{}, fmt.Errorf("%s: no resource types for handlers %s", resourceTypesFile, strings.Join(names, ", "))
+	}
+
+	var config ServiceProviderConfig
+	data, err = readFile(fsys, serviceProviderConfigFile)
+	switch {
+	case err == nil:
+		if config, err = ServiceProviderConfigFromJSON(data); err != nil {
+			return Server{}, fmt.Errorf("%s: %v", serviceProviderConfigFile, err)
+		}
+	case !os.IsNotExist(err):
+		return Server{}, err
+	}
+
+	server := newServer(append([]ServerOption{
+		WithServiceProviderConfig(config),
+		WithResourceType(resourceTypes...),
+	}, opts...)...)
+	if conflicts := server.RouteConflicts(); len(conflicts) != 0 {
+		messages := make([]string, len(conflicts))
+		for i, conflict := range conflicts {
+			messages[i] = conflict.Error()
+		}
+		return Server{}, fmt.Errorf("%s: %s", resourceTypesFile, strings.Join(messages, "; "))
+	}
+	return server, nil
+}
+
+// ResourceTypeFromJSON returns the resource type of given JSON representation, as defined in RFC 7643, section 6, and
+// as returned by the "/ResourceTypes" endpoint, that is handled by given handler. The schema and the schema extensions
+// of the resource type are looked up by their IDs in given schemas.
+func ResourceTypeFromJSON(data []byte, schemas []schema.Schema, handler ResourceHandler) (ResourceType, error) {
+	var definition struct {
+		ID               string `json:"id"`
+		Name             string `json:"name"`
+		Description      string `json:"description"`
+		Endpoint         string `json:"endpoint"`
+		Schema           string `json:"schema"`
+		SchemaExtensions []struct {
+			Schema   string `json:"schema"`
+			Required bool   `json:"required"`
+		} `json:"schemaExtensions"`
+	}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return ResourceType{}, err
+	}
+	if definition.Name == "" || definition.Endpoint == "" {
+		return ResourceType{}, fmt.Errorf("invalid resource type %q: missing name or endpoint", definition.ID)
+	}
+
+	lookup := func(id string) (schema.Schema, error) {
+		for _, s := range schemas {
+			if strings.EqualFold(s.ID, id) {
+				return s, nil
+			}
+		}
+		return schema.Schema{}, fmt.Errorf("invalid resource type %q: unknown schema %q", definition.Name, id)
+	}
+	s, err := lookup(definition.Schema)
+	if err != nil {
+		return ResourceType{}, err
+	}
+	resourceType := ResourceType{
+		ID:          optionalString(definition.ID),
+		Name:        definition.Name,
+		Description: optionalString(definition.Description),
+		Endpoint:    definition.Endpoint,
+		Schema:      s,
+		Handler:     handler,
+	}
+	for _, extension := range definition.SchemaExtensions {
+		s, err := lookup(extension.Schema)
+		if err != nil {
+			return ResourceType{}, err
+		}
+		resourceType.SchemaExtensions = append(resourceType.SchemaExtensions, SchemaExtension{
+			Schema:   s,
+			Required: extension.Required,
+		})
+	}
+	return resourceType, nil
+}
+
+// ServiceProviderConfigFromJSON returns the service provider configuration of given JSON representation, as defined in
+// RFC 7643, section 5, and as returned by the "/ServiceProviderConfig" endpoint. See ServiceProviderConfig.UnmarshalJSON.
+func ServiceProviderConfigFromJSON(data []byte) (ServiceProviderConfig, error) {
+	var config ServiceProviderConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ServiceProviderConfig{}, err
+	}
+	return config, nil
+}
+
+// jsonDocuments returns the JSON documents in given data, which holds a single document, an array of documents or a
+// list response.
+func jsonDocuments(data []byte) ([]json.RawMessage, error) {
+	var documents []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, err
+		}
+		return documents, nil
+	}
+
+	var list struct {
+		Resources []json.RawMessage `json:"Resources"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	if list.Resources != nil {
+		return list.Resources, nil
+	}
+	return []json.RawMessage{data}, nil
+}
+
+// readFile returns the contents of the file with given name in given file system.
+func readFile(fsys http.FileSystem, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
@@ -0,0 +1,134 @@
+package scim
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRetryAfter = time.Minute
+
+// LoadShedder protects the service provider against overload by rejecting requests with a "429 Too Many Requests"
+// response. Requests are rejected when the rate limiter triggers or when a callback method signals overload by
+// returning an error with status code 429 (e.g. errors.PostErrorTooManyRequests). Rejected responses always include a
+// "Retry-After" header, which identity providers use to back off their provisioning cycles.
+type LoadShedder struct {
+	// Limiter limits the rate at which requests are handled. It is optional.
+	Limiter RateLimiter
+	// RetryAfter is the delay suggested to clients when a callback method signals overload without specifying a delay
+	// itself. It defaults to one minute.
+	RetryAfter time.Duration
+
+	rateLimited uint64
+	overloaded  uint64
+}
+
+// LoadSheddingStats contains the counters of a load shedder, which can be used to tune its limits.
+type LoadSheddingStats struct {
+	// RateLimited is the number of requests that were rejected by the rate limiter.
+	RateLimited uint64
+	// Overloaded is the number of requests that were rejected because a callback method signaled overload.
+	Overloaded uint64
+}
+
+// Stats returns a snapshot of the counters of the load shedder.
+func (l *LoadShedder) Stats() LoadSheddingStats {
+	return LoadSheddingStats{
+		RateLimited: atomic.LoadUint64(&l.rateLimited),
+		Overloaded:  atomic.LoadUint64(&l.overloaded),
+	}
+}
+
+// allow reports whether the request may be handled. If not, it returns the delay after which it may be retried.
+func (l *LoadShedder) allow(r *http.Request) (bool, time.Duration) {
+	if l.Limiter == nil {
+		return true, 0
+	}
+	ok, retryAfter := l.Limiter.Allow(r)
+	if !ok {
+		atomic.AddUint64(&l.rateLimited, 1)
+		if retryAfter <= 0 {
+			retryAfter = l.getRetryAfter()
+		}
+	}
+	return ok, retryAfter
+}
+
+func (l *LoadShedder) getRetryAfter() time.Duration {
+	if l.RetryAfter <= 0 {
+		return defaultRetryAfter
+	}
+	return l.RetryAfter
+}
+
+// loadSheddingWriter counts the overload errors signaled by the callback methods and makes sure that a "Retry-After"
+// header is present on their responses.
+type loadSheddingWriter struct {
+	http.ResponseWriter
+	shedder *LoadShedder
+}
+
+func (w loadSheddingWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusTooManyRequests {
+		atomic.AddUint64(&w.shedder.overloaded, 1)
+		if w.Header().Get("Retry-After") == "" {
+			w.Header().Set("Retry-After", formatRetryAfter(w.shedder.getRetryAfter()))
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// RateLimiter decides whether requests may be handled by the service provider.
+type RateLimiter interface {
+	// Allow reports whether given request may be handled now. If not, it can return the delay after which the
+	// request may be retried, zero if unknown.
+	Allow(r *http.Request) (bool, time.Duration)
+}
+
+// NewRateLimiter returns a rate limiter that allows requests at given rate per second, with bursts of at most burst
+// requests. The rate is measured with the clock of the server, see Server.Clock.
+func NewRateLimiter(rate float64, burst int) RateLimiter {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// tokenBucket is a rate limiter that is refilled with tokens at a fixed rate. Each request takes one token.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) Allow(r *http.Request) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := ClockFromContext(r.Context()).Now()
+	if b.last.IsZero() {
+		b.last = now
+	}
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// formatRetryAfter formats given delay as the number of seconds to wait, rounded up.
+func formatRetryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
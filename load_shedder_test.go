@@ -0,0 +1,65 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// overloadedResourceHandler signals overload on every retrieval.
+type overloadedResourceHandler struct {
+	testResourceHandler
+}
+
+func (h overloadedResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	return Resource{}, errors.GetErrorTooManyRequests
+}
+
+func TestLoadShedderRateLimiter(t *testing.T) {
+	server := newTestServer()
+	server.LoadShedder = &LoadShedder{
+		Limiter: NewRateLimiter(0.5, 1),
+	}
+
+	for i, expectedStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != expectedStatus {
+			t.Errorf("request %d returned wrong status code: got %v want %v", i, status, expectedStatus)
+		}
+		if expectedStatus == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "2" {
+			t.Errorf("unexpected Retry-After header: %q", rr.Header().Get("Retry-After"))
+		}
+	}
+
+	if stats := server.LoadShedder.Stats(); stats.RateLimited != 1 || stats.Overloaded != 0 {
+		t.Errorf("unexpected load shedding stats: %+v", stats)
+	}
+}
+
+func TestLoadShedderOverloadedHandler(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = overloadedResourceHandler{}
+	server.LoadShedder = &LoadShedder{
+		RetryAfter: 90 * time.Second,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") != "90" {
+		t.Errorf("unexpected Retry-After header: %q", rr.Header().Get("Retry-After"))
+	}
+	if stats := server.LoadShedder.Stats(); stats.Overloaded != 1 {
+		t.Errorf("unexpected load shedding stats: %+v", stats)
+	}
+}
@@ -0,0 +1,160 @@
+package scim
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// writeDiscoveryFiles writes the responses of the discovery endpoints of given server to files in given directory.
+func writeDiscoveryFiles(t *testing.T, server Server, dir string) {
+	for _, endpoint := range []string{"/Schemas", "/ResourceTypes", "/ServiceProviderConfig"} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, endpoint, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status code %d", endpoint, rr.Code)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, endpoint+".json"), rr.Body.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := newTestServer()
+	original.Config.AuthenticationSchemes = []AuthenticationScheme{{
+		Type:        AuthenticationTypeOauthBearerToken,
+		Name:        "OAuth Bearer Token",
+		Description: "Authentication scheme using the OAuth Bearer Token Standard",
+		Primary:     true,
+	}}
+	writeDiscoveryFiles(t, original, dir)
+
+	handlers := map[string]ResourceHandler{
+		"User":           newTestResourceHandler(),
+		"EnterpriseUser": newTestResourceHandler(),
+	}
+	loaded, err := LoadServer(http.Dir(dir), handlers, WithBasePath("/scim"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.BasePath != "/scim" || len(loaded.ResourceTypes) != 2 || loaded.ResourceTypes[1].Handler == nil {
+		t.Fatalf("unexpected server %+v", loaded)
+	}
+	for _, endpoint := range []string{"/Schemas", "/ResourceTypes", "/ServiceProviderConfig"} {
+		expected := httptest.NewRecorder()
+		original.ServeHTTP(expected, httptest.NewRequest(http.MethodGet, endpoint, nil))
+		actual := httptest.NewRecorder()
+		loaded.ServeHTTP(actual, httptest.NewRequest(http.MethodGet, "/scim"+endpoint, nil))
+		if actual.Code != expected.Code || actual.Body.String() != expected.Body.String() {
+			t.Errorf("%s: expected %d %s, got %d %s", endpoint, expected.Code, expected.Body, actual.Code, actual.Body)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(dir, "ServiceProviderConfig.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadServer(http.Dir(dir), handlers); err != nil {
+		t.Errorf("expected the service provider configuration to be optional, got %v", err)
+	}
+
+	for _, test := range []struct {
+		handlers map[string]ResourceHandler
+		expected string
+	}{
+		{map[string]ResourceHandler{"User": newTestResourceHandler()}, `no handler for resource type "EnterpriseUser"`},
+		{map[string]ResourceHandler{"User": newTestResourceHandler(), "EnterpriseUser": newTestResourceHandler(), "Group": newTestResourceHandler()}, "no resource types for handlers Group"},
+	} {
+		if _, err := LoadServer(http.Dir(dir), test.handlers); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected an error containing %q, got %v", test.expected, err)
+		}
+	}
+
+	conflicting := WithResourceType(ResourceType{Name: "Admin", Endpoint: "/Users/Admins", Handler: newTestResourceHandler()})
+	if _, err := LoadServer(http.Dir(dir), handlers, conflicting); err == nil || !strings.Contains(err.Error(), `resource type "Admin" (/Users/Admins): the endpoint is nested`) {
+		t.Errorf("expected a route conflict error, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "Schemas.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadServer(http.Dir(dir), handlers); !os.IsNotExist(err) {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+}
+
+func TestResourceTypeFromJSON(t *testing.T) {
+	schemas := []schema.Schema{schema.CoreUserSchema()}
+	resourceType, err := ResourceTypeFromJSON([]byte(`{
+		"id": "User",
+		"name": "User",
+		"endpoint": "/Users",
+		"schema": "urn:ietf:params:scim:schemas:core:2.0:User"
+	}`), schemas, newTestResourceHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resourceType.ID.Value() != "User" || resourceType.Schema.ID != schema.CoreUserSchema().ID || resourceType.Handler == nil {
+		t.Errorf("unexpected resource type %+v", resourceType)
+	}
+
+	for _, test := range []struct {
+		json     string
+		expected string
+	}{
+		{`{"name": "User", "schema": "urn:ietf:params:scim:schemas:core:2.0:User"}`, "missing name or endpoint"},
+		{`{"name": "Group", "endpoint": "/Groups", "schema": "urn:ietf:params:scim:schemas:core:2.0:Group"}`, `unknown schema "urn:ietf:params:scim:schemas:core:2.0:Group"`},
+		{`{"name": "User", "endpoint": "/Users", "schema": "urn:ietf:params:scim:schemas:core:2.0:User", "schemaExtensions": [{"schema": "urn:example:2.0:User"}]}`, `unknown schema "urn:example:2.0:User"`},
+	} {
+		if _, err := ResourceTypeFromJSON([]byte(test.json), schemas, nil); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected an error containing %q, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestServiceProviderConfigFromJSON(t *testing.T) {
+	config, err := ServiceProviderConfigFromJSON([]byte(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"],
+		"documentationUri": "https://example.com/help/scim.html",
+		"patch": {"supported": true, "maxOperations": 10},
+		"bulk": {"supported": true, "maxOperations": 100, "maxPayloadSize": 2048},
+		"filter": {"supported": true, "maxResults": 200},
+		"changePassword": {"supported": false},
+		"sort": {"supported": true},
+		"etag": {"supported": true},
+		"authenticationSchemes": [{
+			"type": "httpbasic",
+			"name": "HTTP Basic",
+			"description": "Authentication via HTTP Basic",
+			"specUri": "https://www.rfc-editor.org/info/rfc2617"
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := config.Features
+	if config.DocumentationURI.Value() != "https://example.com/help/scim.html" ||
+		!features.Patch.Supported || features.Patch.MaxOperations != 10 ||
+		!features.Bulk.Supported || features.Bulk.MaxOperations != 100 || features.Bulk.MaxPayloadSize != 2048 ||
+		!features.Filter.Supported || features.Filter.MaxResults != 200 ||
+		features.ChangePassword.Supported || !features.Sort.Supported || !features.ETag.Supported {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+	if len(config.AuthenticationSchemes) != 1 || config.AuthenticationSchemes[0].Type != AuthenticationTypeHTTPBasic ||
+		config.AuthenticationSchemes[0].SpecURI.Value() != "https://www.rfc-editor.org/info/rfc2617" ||
+		config.AuthenticationSchemes[0].DocumentationURI.Present() {
+		t.Errorf("unexpected authentication schemes %+v", config.AuthenticationSchemes)
+	}
+}
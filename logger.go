@@ -0,0 +1,46 @@
+package scim
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// Logger logs the errors that the server cannot report to its clients, e.g. failures to write a response or errors of
+// callback methods that are hidden behind a "500 Internal Server Error" response. A *log.Logger is a Logger. Callback
+// methods can get the logger of the server with LoggerFromContext.
+type Logger interface {
+	// Printf logs a message, formatted in the manner of fmt.Printf.
+	Printf(format string, v ...interface{})
+}
+
+// standardLogger is the default logger, which is the standard logger of the log package.
+type standardLogger struct{}
+
+func (standardLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+type loggerContextKey struct{}
+
+// LoggerFromContext returns the logger of the server that handles the request. It returns the standard logger of the
+// log package if the context does not originate from a request to the server.
+func LoggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(Logger); ok {
+		return logger
+	}
+	return standardLogger{}
+}
+
+// withLogger returns a shallow copy of given request with the logger of the server added to its context.
+func (s Server) withLogger(r *http.Request) *http.Request {
+	if s.Logger == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), loggerContextKey{}, s.Logger))
+}
+
+// logf logs a message with the logger of the server that handles given request.
+func logf(r *http.Request, format string, v ...interface{}) {
+	LoggerFromContext(r.Context()).Printf(format, v...)
+}
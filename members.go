@@ -0,0 +1,147 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MemberRef references a member of a group, as defined in the "members" attribute of the Group resource.
+type MemberRef struct {
+	// Value is the identifier of the member.
+	Value string
+	// Type is the type of the member, i.e. "User" or "Group". It is optional.
+	Type string
+	// Display is a human-readable name of the member. It is not used to identify the member.
+	Display string
+}
+
+// matches reports whether both references point to the same member. The types are only compared if both are present.
+func (m MemberRef) matches(other MemberRef) bool {
+	if m.Value != other.Value {
+		return false
+	}
+	return m.Type == "" || other.Type == "" || strings.EqualFold(m.Type, other.Type)
+}
+
+func (m MemberRef) attributes() map[string]interface{} {
+	attributes := map[string]interface{}{
+		"value": m.Value,
+	}
+	if m.Type != "" {
+		attributes["type"] = m.Type
+	}
+	if m.Display != "" {
+		attributes["display"] = m.Display
+	}
+	return attributes
+}
+
+// ReconcileMembers compares the current members of a group with the desired members and returns the members that need
+// to be added and removed to go from the current to the desired state. Duplicate members are only returned once and
+// the order of the given members is preserved.
+func ReconcileMembers(current, desired []MemberRef) (add, remove []MemberRef) {
+	for _, member := range desired {
+		if !containsMember(current, member) && !containsMember(add, member) {
+			add = append(add, member)
+		}
+	}
+	for _, member := range current {
+		if !containsMember(desired, member) && !containsMember(remove, member) {
+			remove = append(remove, member)
+		}
+	}
+	return add, remove
+}
+
+func containsMember(members []MemberRef, member MemberRef) bool {
+	for _, m := range members {
+		if m.matches(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// MembersFromAttributes returns the members in the "members" attribute of given (Group) resource attributes. Members
+// without a value are ignored.
+func MembersFromAttributes(attributes ResourceAttributes) []MemberRef {
+	var members []MemberRef
+	for k, v := range attributes {
+		if !strings.EqualFold(k, "members") {
+			continue
+		}
+		arr, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, ele := range arr {
+			m, ok := ele.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var member MemberRef
+			for name, value := range m {
+				s, _ := value.(string)
+				switch strings.ToLower(name) {
+				case "value":
+					member.Value = s
+				case "type":
+					member.Type = s
+				case "display":
+					member.Display = s
+				}
+			}
+			if member.Value != "" {
+				members = append(members, member)
+			}
+		}
+	}
+	return members
+}
+
+// MembershipPatchOperations translates the replacement of a group with given attributes (i.e. a PUT request) into the
+// patch operations that add and remove members, given the current members of the group. This allows backends that
+// store memberships separately to apply the difference instead of replacing all members.
+func MembershipPatchOperations(current []MemberRef, attributes ResourceAttributes) []PatchOperation {
+	add, remove := ReconcileMembers(current, MembersFromAttributes(attributes))
+
+	var operations []PatchOperation
+	if len(add) != 0 {
+		values := make([]interface{}, len(add))
+		for i, member := range add {
+			values[i] = member.attributes()
+		}
+		operations = append(operations, PatchOperation{
+			Op:    PatchOperationAdd,
+			Path:  "members",
+			Value: values,
+		})
+	}
+	for _, member := range remove {
+		operations = append(operations, PatchOperation{
+			Op:   PatchOperationRemove,
+			Path: "members[" + member.filter() + "]",
+		})
+	}
+	return operations
+}
+
+// filter returns the value filter that matches the member, e.g. `value eq "2819c223" and type eq "User"`. The type is
+// part of the filter, so that a member that changes type is not removed along with the member of its new type.
+func (m MemberRef) filter() string {
+	filter := "value eq " + jsonString(m.Value)
+	if m.Type != "" {
+		filter += " and type eq " + jsonString(m.Type)
+	}
+	return filter
+}
+
+// jsonString returns given string as a JSON string literal, e.g. to use it in a filter. HTML characters are not
+// escaped, since e.g. "\u0026" in a filter does not match the "&" of a value.
+func jsonString(s string) string {
+	var b strings.Builder
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	_ = e.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
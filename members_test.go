@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconcileMembers(t *testing.T) {
+	current := []MemberRef{
+		{Value: "1", Type: "User"},
+		{Value: "2", Type: "User"},
+		{Value: "3", Type: "Group"},
+	}
+	desired := []MemberRef{
+		{Value: "2"},
+		{Value: "3", Type: "User"},
+		{Value: "4", Type: "User"},
+		{Value: "4", Type: "User"},
+	}
+
+	add, remove := ReconcileMembers(current, desired)
+	if expected := []MemberRef{{Value: "3", Type: "User"}, {Value: "4", Type: "User"}}; !reflect.DeepEqual(add, expected) {
+		t.Errorf("unexpected members to add: got %v want %v", add, expected)
+	}
+	if expected := []MemberRef{{Value: "1", Type: "User"}, {Value: "3", Type: "Group"}}; !reflect.DeepEqual(remove, expected) {
+		t.Errorf("unexpected members to remove: got %v want %v", remove, expected)
+	}
+}
+
+func TestMembershipPatchOperations(t *testing.T) {
+	operations := MembershipPatchOperations([]MemberRef{{Value: "1"}, {Value: "2"}}, ResourceAttributes{
+		"displayName": "Tour Guides",
+		"Members": []interface{}{
+			map[string]interface{}{"value": "2"},
+			map[string]interface{}{"Value": "3", "type": "User"},
+		},
+	})
+
+	expected := []PatchOperation{
+		{
+			Op:    PatchOperationAdd,
+			Path:  "members",
+			Value: []interface{}{map[string]interface{}{"value": "3", "type": "User"}},
+		},
+		{
+			Op:   PatchOperationRemove,
+			Path: `members[value eq "1"]`,
+		},
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("unexpected operations: got %v want %v", operations, expected)
+	}
+
+	// A member that changes type is removed by its value and its current type only.
+	operations = MembershipPatchOperations([]MemberRef{{Value: `4"5`, Type: "User"}}, ResourceAttributes{
+		"members": []interface{}{
+			map[string]interface{}{"value": `4"5`, "type": "Group"},
+		},
+	})
+	if len(operations) != 2 || operations[1].Path != `members[value eq "4\"5" and type eq "User"]` {
+		t.Errorf("unexpected operations: got %v", operations)
+	}
+	if _, err := operations[1].ParsePath(); err != nil {
+		t.Error(err)
+	}
+
+	// HTML characters are not escaped.
+	operations = MembershipPatchOperations([]MemberRef{{Value: "<R&D>"}}, ResourceAttributes{"members": []interface{}{}})
+	if len(operations) != 1 || operations[0].Path != `members[value eq "<R&D>"]` {
+		t.Errorf("unexpected operations: got %v", operations)
+	}
+}
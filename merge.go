@@ -0,0 +1,248 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MergePolicy decides how the server resolves a conflict between a PUT or PATCH request and a concurrent change of an
+// attribute, i.e. when the "If-Match" header of the request does not match the current version of the resource.
+type MergePolicy int
+
+const (
+	// MergePolicyReject rejects the request with a "412 Precondition Failed" response. This is the default.
+	MergePolicyReject MergePolicy = iota
+	// MergePolicyLastWriterWins applies the change of the request, overwriting the concurrent change.
+	MergePolicyLastWriterWins
+	// MergePolicyServerWins ignores the change of the request, keeping the concurrent change.
+	MergePolicyServerWins
+)
+
+// mergePolicy returns the merge policy of the attribute with given path, e.g. "name.givenName". Attributes without a
+// policy of their own inherit the policy of their parent attribute.
+func (t ResourceType) mergePolicy(path string) MergePolicy {
+	p, err := ParseAttributePath(path)
+	if err != nil {
+		return MergePolicyReject
+	}
+	p = t.withoutMainSchemaURI(p)
+	for {
+		for k, policy := range t.MergePolicies {
+			if policyPath, err := ParseAttributePath(k); err == nil && t.withoutMainSchemaURI(policyPath).Equal(p) {
+				return policy
+			}
+		}
+		if p.SubAttribute == "" {
+			return MergePolicyReject
+		}
+		p.SubAttribute = ""
+	}
+}
+
+// withoutMainSchemaURI removes the URI of the main schema from given path, since core attributes can be referred to
+// with or without it.
+func (t ResourceType) withoutMainSchemaURI(path AttributePath) AttributePath {
+	if strings.EqualFold(path.URI, t.Schema.ID) {
+		path.URI = ""
+	}
+	return path
+}
+
+// extensionPath splits given attribute path in the URI of the schema extension that contains the attribute and the
+// path of the attribute within the extension, e.g. "manager.value". The URI is empty for core attributes.
+func (t ResourceType) extensionPath(path string) (string, string) {
+	for _, extension := range t.SchemaExtensions {
+		if len(path) > len(extension.Schema.ID) && strings.EqualFold(path[:len(extension.Schema.ID)+1], extension.Schema.ID+":") {
+			return extension.Schema.ID, path[len(extension.Schema.ID)+1:]
+		}
+	}
+	return "", path
+}
+
+// flattenAttributes returns the attributes of given resource by path, where the attributes of schema extensions are
+// prefixed with the URI of their extension. The "id", "schemas" and "meta" attributes are left out.
+func (t ResourceType) flattenAttributes(attributes ResourceAttributes) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(attributes))
+	for k, v := range attributes {
+		switch strings.ToLower(k) {
+		case "id", "schemas", "meta":
+			continue
+		}
+		if extension, ok := v.(map[string]interface{}); ok && strings.Contains(k, ":") {
+			for name, value := range extension {
+				flattened[k+":"+name] = value
+			}
+			continue
+		}
+		flattened[k] = v
+	}
+	return flattened
+}
+
+// mergeReplace resolves a conflict between the attributes of a PUT request and the current resource with the merge
+// policies of the resource type. It returns the attributes to store, or an error if the conflict can not be resolved.
+func (s Server) mergeReplace(r *http.Request, resourceType ResourceType, id string, attributes ResourceAttributes) (ResourceAttributes, *scimError) {
+	current, conflict, preconditionErr := s.evaluatePreconditions(r, resourceType, id)
+	if !conflict || len(resourceType.MergePolicies) == 0 {
+		return attributes, preconditionErr
+	}
+
+	requested := resourceType.flattenAttributes(attributes)
+	stored := resourceType.flattenAttributes(current.Attributes)
+	merged := make(map[string]interface{}, len(requested))
+	for path, value := range requested {
+		merged[path] = value
+	}
+	for path := range unionKeys(requested, stored) {
+		value, ok := resourceType.mergeValue(path, lookupFold(requested, path), lookupFold(stored, path))
+		if !ok {
+			return nil, preconditionErr
+		}
+		deleteFold(merged, path)
+		if value != nil {
+			merged[path] = value
+		}
+	}
+
+	result := make(ResourceAttributes, len(merged))
+	for path, value := range merged {
+		extension, name := resourceType.extensionPath(path)
+		if extension == "" {
+			result[path] = value
+			continue
+		}
+		extensionAttributes, ok := result[extension].(map[string]interface{})
+		if !ok {
+			extensionAttributes = make(map[string]interface{})
+			result[extension] = extensionAttributes
+		}
+		extensionAttributes[name] = value
+	}
+	return result, nil
+}
+
+// mergeValue returns the value of the attribute with given path that results from merging given requested value with
+// given stored value according to the merge policies, where nil means that the attribute has no value. Singular complex
+// attributes are merged per sub-attribute, so that their sub-attributes can have policies of their own. The boolean is
+// false if the values conflict and the policy rejects the conflict.
+func (t ResourceType) mergeValue(path string, requested, stored interface{}) (interface{}, bool) {
+	if t.SchemaSet().ValuesEqual(path, requested, stored) {
+		return requested, true
+	}
+
+	requestedValues, requestedComplex := requested.(map[string]interface{})
+	storedValues, storedComplex := stored.(map[string]interface{})
+	if (requestedComplex || requested == nil) && (storedComplex || stored == nil) {
+		merged := make(map[string]interface{}, len(requestedValues))
+		for name := range unionKeys(requestedValues, storedValues) {
+			value, ok := t.mergeValue(path+"."+name, lookupFold(requestedValues, name), lookupFold(storedValues, name))
+			if !ok {
+				return nil, false
+			}
+			if value != nil {
+				merged[name] = value
+			}
+		}
+		if len(merged) == 0 {
+			return nil, true
+		}
+		return merged, true
+	}
+
+	switch t.mergePolicy(path) {
+	case MergePolicyLastWriterWins:
+		return requested, true
+	case MergePolicyServerWins:
+		return stored, true
+	default:
+		return nil, false
+	}
+}
+
+// mergePatch resolves a conflict between the operations of a PATCH request and the current resource with the merge
+// policies of the resource type. It returns the operations to apply, or an error if the conflict can not be resolved.
+// If no operations remain, the current resource is returned as well.
+func (s Server) mergePatch(r *http.Request, resourceType ResourceType, id string, patch PatchRequest) (PatchRequest, *Resource, *scimError) {
+	current, conflict, preconditionErr := s.evaluatePreconditions(r, resourceType, id)
+	if !conflict || len(resourceType.MergePolicies) == 0 {
+		return patch, nil, preconditionErr
+	}
+
+	merged := PatchRequest{Schemas: patch.Schemas}
+	for _, op := range patch.Operations {
+		if op.Path != "" {
+			path, _ := op.ParsePath()
+			switch resourceType.mergePolicy(path.attributePath()) {
+			case MergePolicyLastWriterWins:
+				merged.Operations = append(merged.Operations, op)
+			case MergePolicyServerWins:
+			default:
+				return PatchRequest{}, nil, preconditionErr
+			}
+			continue
+		}
+
+		values, _ := op.Value.(map[string]interface{})
+		kept := make(map[string]interface{}, len(values))
+		for path, value := range resourceType.flattenAttributes(values) {
+			switch resourceType.mergePolicy(path) {
+			case MergePolicyLastWriterWins:
+				extension, name := resourceType.extensionPath(path)
+				if extension == "" {
+					kept[path] = value
+					continue
+				}
+				extensionValues, ok := kept[extension].(map[string]interface{})
+				if !ok {
+					extensionValues = make(map[string]interface{})
+					kept[extension] = extensionValues
+				}
+				extensionValues[name] = value
+			case MergePolicyServerWins:
+			default:
+				return PatchRequest{}, nil, preconditionErr
+			}
+		}
+		if len(kept) != 0 {
+			op.Value = kept
+			merged.Operations = append(merged.Operations, op)
+		}
+	}
+
+	if len(merged.Operations) == 0 {
+		return merged, &current, nil
+	}
+	return merged, nil, nil
+}
+
+func unionKeys(a, b map[string]interface{}) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		if lookupFold(a, k) == nil {
+			keys[k] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// lookupFold returns the value of given key in given map, comparing keys case-insensitively.
+func lookupFold(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// deleteFold deletes given key from given map, comparing keys case-insensitively.
+func deleteFold(m map[string]interface{}, key string) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			delete(m, k)
+		}
+	}
+}
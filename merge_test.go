@@ -0,0 +1,133 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerMergePolicies(t *testing.T) {
+	server := newVersionedTestServer(true)
+	server.ResourceTypes[0].MergePolicies = map[string]MergePolicy{
+		"displayName": MergePolicyLastWriterWins,
+		"name":        MergePolicyServerWins,
+	}
+	data := server.ResourceTypes[0].Handler.(versionedResourceHandler).data
+	data["0001"]["displayName"] = "Server"
+	data["0001"]["name"] = map[string]interface{}{"givenName": "Server"}
+
+	for _, test := range []struct {
+		method      string
+		body        string
+		expected    int
+		displayName string
+		givenName   string
+	}{
+		{
+			http.MethodPut, `{"userName": "test1", "displayName": "Client", "name": {"givenName": "Client"}}`,
+			http.StatusOK, "Client", "Server",
+		},
+		{
+			http.MethodPut, `{"userName": "changed", "displayName": "Client"}`,
+			http.StatusPreconditionFailed, "Client", "Server",
+		},
+		{
+			http.MethodPatch, `{
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [
+					{"op": "replace", "path": "displayName", "value": "Patched"},
+					{"op": "replace", "value": {"displayName": "Patched", "name": {"givenName": "Patched"}}}
+				]
+			}`,
+			http.StatusOK, "Patched", "Server",
+		},
+		{
+			http.MethodPatch, `{
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [{"op": "replace", "value": {"name": {"givenName": "Patched"}}}]
+			}`,
+			http.StatusOK, "Patched", "Server",
+		},
+		{
+			http.MethodPatch, `{
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [{"op": "replace", "path": "userName", "value": "changed"}]
+			}`,
+			http.StatusPreconditionFailed, "Patched", "Server",
+		},
+	} {
+		req := httptest.NewRequest(test.method, "/Users/0001", strings.NewReader(test.body))
+		req.Header.Set("If-Match", `W/"2"`)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", test.method, test.body, test.expected, rr.Code, rr.Body.String())
+		}
+
+		if data["0001"]["displayName"] != test.displayName {
+			t.Errorf("%s %s: expected display name %q, got %v", test.method, test.body, test.displayName, data["0001"]["displayName"])
+		}
+		if name, _ := lookupFold(data["0001"], "name").(map[string]interface{}); name["givenName"] != test.givenName {
+			t.Errorf("%s %s: expected given name %q, got %v", test.method, test.body, test.givenName, name["givenName"])
+		}
+	}
+}
+
+func TestServerMergePoliciesWithoutConflict(t *testing.T) {
+	server := newVersionedTestServer(true)
+	server.ResourceTypes[0].MergePolicies = map[string]MergePolicy{"name": MergePolicyServerWins}
+
+	req := httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(`{"userName": "test1", "name": {"givenName": "Client"}}`))
+	req.Header.Set("If-Match", `W/"1"`)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"givenName":"Client"`) {
+		t.Errorf("expected the change to be applied without a conflict, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerMergePoliciesSubAttributes(t *testing.T) {
+	server := newVersionedTestServer(true)
+	policies := map[string]MergePolicy{
+		"name.givenName":  MergePolicyLastWriterWins,
+		"name.familyName": MergePolicyServerWins,
+	}
+
+	for _, test := range []struct {
+		// rejected is a sub-attribute whose policy is removed.
+		rejected   string
+		body       string
+		expected   int
+		givenName  interface{}
+		familyName interface{}
+	}{
+		{"", `{"userName": "test1", "name": {"givenName": "Client", "familyName": "Client"}}`, http.StatusOK, "Client", "Server"},
+		{"", `{"userName": "test1", "name": {"familyName": "Client"}}`, http.StatusOK, nil, "Server"},
+		{"", `{"userName": "test1"}`, http.StatusOK, nil, "Server"},
+		{"name.familyName", `{"userName": "test1", "name": {"givenName": "Client", "familyName": "Client"}}`, http.StatusPreconditionFailed, "Server", "Server"},
+	} {
+		server.ResourceTypes[0].MergePolicies = make(map[string]MergePolicy)
+		for path, policy := range policies {
+			if path != test.rejected {
+				server.ResourceTypes[0].MergePolicies[path] = policy
+			}
+		}
+		data := server.ResourceTypes[0].Handler.(versionedResourceHandler).data
+		deleteFold(data["0001"], "name")
+		data["0001"]["name"] = map[string]interface{}{"givenName": "Server", "familyName": "Server"}
+
+		req := httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(test.body))
+		req.Header.Set("If-Match", `W/"2"`)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Fatalf("%s: expected status %d, got %d: %s", test.body, test.expected, rr.Code, rr.Body.String())
+		}
+
+		name, _ := lookupFold(data["0001"], "name").(map[string]interface{})
+		if name["givenName"] != test.givenName || name["familyName"] != test.familyName {
+			t.Errorf("%s: expected given name %v and family name %v, got %v", test.body, test.givenName, test.familyName, name)
+		}
+	}
+}
@@ -1,21 +1,114 @@
 package scim
 
-// meta is a complex attribute containing resource metadata. All "meta" sub-attributes are assigned by the service
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Meta is a complex attribute containing resource metadata. All "meta" sub-attributes are assigned by the service
 // provider (have a "mutability" of "readOnly"), and all of these sub-attributes have a "returned" characteristic of
 // "default". This attribute SHALL be ignored when provided by clients.
-type meta struct {
+//
+// Callback methods only set the "created" and "lastModified" timestamps of the resources they return, the other
+// sub-attributes are set by the server.
+type Meta struct {
 	// ResourceType is the name of the resource type of the resource.
 	ResourceType string `json:"resourceType"`
 	// Created is the "DateTime" that the resource was added to the service provider.
-	Created string `json:"created,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
 	// LastModified is the most recent DateTime that the details of this resource were updated at the service provider.
 	// If this resource has never been modified since its initial creation, the value MUST be the same as the value of
-	// "created".
-	LastModified string `json:"lastModified,omitempty"`
+	// "created". It defaults to the value of "created".
+	LastModified *time.Time `json:"lastModified,omitempty"`
 	// Location is the URI of the resource being returned. This value MUST be the same as the "Content-Location" HTTP
 	// response header.
 	Location string `json:"location"`
 	// Version is the version of the resource being returned.  This value must be the same as the entity-tag (ETag) HTTP
-	// response header.
+	// response header. It is derived from Resource.Version.
 	Version string `json:"version,omitempty"`
 }
+
+type baseURLContextKey struct{}
+
+// withBaseURL returns a shallow copy of given request with the base URL of the server added to its context.
+func (s Server) withBaseURL(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), baseURLContextKey{}, s.baseURL(r)))
+}
+
+// baseURL returns the URL under which the endpoints of the server are reachable for the client that sent given
+// request, e.g. "https://example.com/v2".
+func (s Server) baseURL(r *http.Request) string {
+	if s.BaseURL != "" {
+		return strings.TrimSuffix(s.BaseURL, "/")
+	}
+
+	prefix := s.basePath()
+	if strings.HasPrefix(r.URL.Path, "/v2/") {
+		prefix += "/v2"
+	}
+	host := r.Host
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if s.TrustForwardedHeaders {
+		if forwarded := firstHeaderValue(r, "X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+		if forwarded := firstHeaderValue(r, "X-Forwarded-Proto"); forwarded != "" {
+			scheme = strings.ToLower(forwarded)
+		}
+	}
+	if host == "" {
+		return prefix
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, prefix)
+}
+
+// basePath returns the base path of the server without trailing slash, e.g. "/scim", or an empty string if it has none.
+func (s Server) basePath() string {
+	path := strings.TrimSuffix(s.BasePath, "/")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// trimBasePath returns a shallow copy of given request whose path is relative to the base path of the server. It
+// returns false if the request is not addressed to a path under the base path.
+func (s Server) trimBasePath(r *http.Request) (*http.Request, bool) {
+	prefix := s.basePath()
+	if prefix == "" {
+		return r, true
+	}
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	if path == r.URL.Path || (path != "" && !strings.HasPrefix(path, "/")) {
+		return r, false
+	}
+	trimmed := new(http.Request)
+	*trimmed = *r
+	trimmed.URL = new(url.URL)
+	*trimmed.URL = *r.URL
+	trimmed.URL.Path, trimmed.URL.RawPath = path, ""
+	return trimmed, true
+}
+
+// firstHeaderValue returns the first value of a header with given name, which may be a comma-separated list of values
+// added by a chain of proxies.
+func firstHeaderValue(r *http.Request, name string) string {
+	return strings.TrimSpace(strings.SplitN(r.Header.Get(name), ",", 2)[0])
+}
+
+// resourceLocation returns the URI of the resource with given identifier, based on the base URL of the server and the
+// endpoint of the resource type, e.g. "https://example.com/v2/Users/0001".
+func resourceLocation(r *http.Request, resourceType ResourceType, id string) string {
+	base, ok := r.Context().Value(baseURLContextKey{}).(string)
+	if !ok {
+		base = Server{}.baseURL(r)
+	}
+	return fmt.Sprintf("%s%s/%s", base, resourceType.Endpoint, url.PathEscape(id))
+}
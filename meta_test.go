@@ -0,0 +1,122 @@
+package scim
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// timestampedResourceHandler returns the resources with fixed timestamps.
+type timestampedResourceHandler struct {
+	testResourceHandler
+	created time.Time
+}
+
+func (h timestampedResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	resource, err := h.testResourceHandler.Get(r, id)
+	resource.Meta.Created = &h.created
+	return resource, err
+}
+
+func TestServerResourceMeta(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = timestampedResourceHandler{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		created:             created,
+	}
+
+	for _, test := range []struct {
+		target   string
+		tls      bool
+		location string
+	}{
+		{"/Users/0001", false, "http://example.com/Users/0001"},
+		{"/v2/Users/0001", false, "http://example.com/v2/Users/0001"},
+		{"/v2/Users/0001", true, "https://example.com/v2/Users/0001"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, test.target, nil)
+		if test.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+
+		var resource struct {
+			Meta Meta
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		m := resource.Meta
+		if m.ResourceType != "User" || m.Location != test.location {
+			t.Errorf("%s: unexpected meta: %+v", test.target, m)
+		}
+		if m.Created == nil || !m.Created.Equal(created) || m.LastModified == nil || !m.LastModified.Equal(created) {
+			t.Errorf("%s: unexpected timestamps: %v %v", test.target, m.Created, m.LastModified)
+		}
+	}
+}
+
+func TestServerResourcePostHandlerLocation(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v2/Users", strings.NewReader(`{"userName": "test"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+
+	var resource struct {
+		ID   string
+		Meta Meta
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if location := rr.Header().Get("Location"); location != resource.Meta.Location || location != "http://example.com/v2/Users/"+resource.ID {
+		t.Errorf("unexpected location: header %q, meta %q", location, resource.Meta.Location)
+	}
+}
+
+func TestServerBaseURL(t *testing.T) {
+	for _, test := range []struct {
+		baseURL  string
+		trust    bool
+		headers  map[string]string
+		location string
+	}{
+		{"https://scim.example.com/api/v2/", false, nil, "https://scim.example.com/api/v2/Users/0001"},
+		{"", false, map[string]string{"X-Forwarded-Host": "scim.example.com"}, "http://example.com/Users/0001"},
+		{"", true, map[string]string{
+			"X-Forwarded-Host":  "scim.example.com, proxy.internal",
+			"X-Forwarded-Proto": "HTTPS",
+		}, "https://scim.example.com/Users/0001"},
+		{"https://scim.example.com", true, map[string]string{"X-Forwarded-Host": "proxy.internal"}, "https://scim.example.com/Users/0001"},
+	} {
+		server := newTestServer()
+		server.BaseURL = test.baseURL
+		server.TrustForwardedHeaders = test.trust
+
+		req := httptest.NewRequest(http.MethodGet, "/Users/0001", nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+
+		var resource struct {
+			Meta Meta
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		if resource.Meta.Location != test.location {
+			t.Errorf("unexpected location: got %q want %q", resource.Meta.Location, test.location)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// Operation classifies the operation that is performed on a resource type. The classification is added to the context
+// of the requests that are passed to the callback methods, which can be used to route them to the right backend.
+type Operation int
+
+const (
+	// OperationRead indicates that a single resource is retrieved.
+	OperationRead Operation = iota + 1
+	// OperationList indicates that a (paginated) list of resources is retrieved.
+	OperationList
+	// OperationWrite indicates that a resource is created, replaced, patched or deleted.
+	OperationWrite
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationRead:
+		return "read"
+	case OperationList:
+		return "list"
+	case OperationWrite:
+		return "write"
+	default:
+		return "unknown"
+	}
+}
+
+type operationContextKey struct{}
+
+// OperationFromContext returns the classification of the operation that is being performed. The boolean is false if
+// the context does not originate from a request to a resource endpoint.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	operation, ok := ctx.Value(operationContextKey{}).(Operation)
+	return operation, ok
+}
+
+// withOperation returns a shallow copy of given request with the operation classification added to its context.
+func withOperation(r *http.Request, operation Operation) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), operationContextKey{}, operation))
+}
+
+// ReadReplicaHandler is a resource handler that routes read and list operations to a (read) replica and all write
+// operations to the primary, which is a common setup for high-volume directories. Keep in mind that replicas might
+// lag behind, so a resource that was just created might not be retrievable immediately.
+type ReadReplicaHandler struct {
+	// Primary handles the operations that create, replace, patch or delete resources.
+	Primary ResourceHandler
+	// Replica handles the operations that retrieve resources.
+	Replica ResourceHandler
+}
+
+// Create forwards the creation of a resource to the primary.
+func (h ReadReplicaHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	return h.Primary.Create(r, attributes, externalID)
+}
+
+// Get retrieves the resource from the replica.
+func (h ReadReplicaHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	return h.Replica.Get(r, id)
+}
+
+// GetAll retrieves the list of resources from the replica.
+func (h ReadReplicaHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	return h.Replica.GetAll(r, params)
+}
+
+// Replace forwards the replacement of a resource to the primary.
+func (h ReadReplicaHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	return h.Primary.Replace(r, id, attributes, externalID)
+}
+
+// Delete forwards the deletion of a resource to the primary.
+func (h ReadReplicaHandler) Delete(r *http.Request, id string) errors.DeleteError {
+	return h.Primary.Delete(r, id)
+}
+
+// Patch forwards the patch of a resource to the primary.
+func (h ReadReplicaHandler) Patch(r *http.Request, id string, request PatchRequest) (Resource, errors.PatchError) {
+	return h.Primary.Patch(r, id, request)
+}
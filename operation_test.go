@@ -0,0 +1,63 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// operationRecorder records the operation classifications of the requests it receives.
+type operationRecorder struct {
+	testResourceHandler
+	operations *[]Operation
+}
+
+func (h operationRecorder) record(r *http.Request) {
+	operation, _ := OperationFromContext(r.Context())
+	*h.operations = append(*h.operations, operation)
+}
+
+func (h operationRecorder) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	h.record(r)
+	return h.testResourceHandler.Get(r, id)
+}
+
+func (h operationRecorder) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	h.record(r)
+	return h.testResourceHandler.GetAll(r, params)
+}
+
+func (h operationRecorder) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	h.record(r)
+	return h.testResourceHandler.Replace(r, id, attributes, externalID)
+}
+
+func TestReadReplicaHandler(t *testing.T) {
+	var primaryOperations, replicaOperations []Operation
+	data := newTestResourceHandler().(testResourceHandler)
+
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = ReadReplicaHandler{
+		Primary: operationRecorder{testResourceHandler: data, operations: &primaryOperations},
+		Replica: operationRecorder{testResourceHandler: data, operations: &replicaOperations},
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/Users", nil),
+		httptest.NewRequest(http.MethodGet, "/Users/0001", nil),
+		httptest.NewRequest(http.MethodPut, "/Users/0001", strings.NewReader(`{"userName": "other"}`)),
+	} {
+		server.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(replicaOperations) != 2 || replicaOperations[0] != OperationList || replicaOperations[1] != OperationRead {
+		t.Errorf("unexpected replica operations: %v", replicaOperations)
+	}
+	if len(primaryOperations) != 1 || primaryOperations[0] != OperationWrite {
+		t.Errorf("unexpected primary operations: %v", primaryOperations)
+	}
+}
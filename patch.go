@@ -32,6 +32,8 @@ type PatchRequest struct {
 
 // GetPathFilter parses patch operation path to determine if it is a attribute filter.
 // If it is, filter.Expression will be returned, nil otherwise.
+//
+// Deprecated: use ParsePath, which also parses value filters and sub-attributes.
 func (p PatchOperation) GetPathFilter() *filter.AttributeExpression {
 	parser := filter.NewParser(strings.NewReader(p.Path))
 	pathFilter, err := parser.Parse()
@@ -0,0 +1,19 @@
+package scim
+
+// PatchPath is a parsed PATCH operation path (RFC 7644, section 3.5.2), which targets an attribute, e.g. "userName",
+// a sub-attribute, e.g. "name.givenName", or (a sub-attribute of) the values of a multi-valued attribute that match a
+// value filter, e.g. `members[value eq "2819c223"]` or `emails[type eq "work"].value`.
+type PatchPath = AttributePath
+
+// ParsePatchPath parses given PATCH operation path, see ParseAttributePath.
+func ParsePatchPath(path string) (PatchPath, error) {
+	return ParseAttributePath(path)
+}
+
+// ParsePath parses the path of the operation. The path is the zero value if the operation has no path.
+func (p PatchOperation) ParsePath() (PatchPath, error) {
+	if p.Path == "" {
+		return PatchPath{}, nil
+	}
+	return ParsePatchPath(p.Path)
+}
@@ -0,0 +1,149 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	filter "github.com/di-wu/scim-filter-parser"
+)
+
+func TestParsePatchPath(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		expected PatchPath
+	}{
+		{
+			path:     "userName",
+			expected: PatchPath{AttributeName: "userName"},
+		},
+		{
+			path:     "name.givenName",
+			expected: PatchPath{AttributeName: "name", SubAttribute: "givenName"},
+		},
+		{
+			path:     "members.$ref",
+			expected: PatchPath{AttributeName: "members", SubAttribute: "$ref"},
+		},
+		{
+			path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value",
+			expected: PatchPath{
+				URI:           "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+				AttributeName: "manager",
+				SubAttribute:  "value",
+			},
+		},
+		{
+			path: `members[value eq "2819c223"]`,
+			expected: PatchPath{
+				AttributeName: "members",
+				ValueFilter: filter.AttributeExpression{
+					AttributePath:   filter.AttributePath{AttributeName: "value"},
+					CompareOperator: filter.EQ,
+					CompareValue:    "2819c223",
+				},
+			},
+		},
+		{
+			path: `emails[type eq "work"].value`,
+			expected: PatchPath{
+				AttributeName: "emails",
+				ValueFilter: filter.AttributeExpression{
+					AttributePath:   filter.AttributePath{AttributeName: "type"},
+					CompareOperator: filter.EQ,
+					CompareValue:    "work",
+				},
+				SubAttribute: "value",
+			},
+		},
+		{
+			path: `emails[value eq "a]b"]`,
+			expected: PatchPath{
+				AttributeName: "emails",
+				ValueFilter: filter.AttributeExpression{
+					AttributePath:   filter.AttributePath{AttributeName: "value"},
+					CompareOperator: filter.EQ,
+					CompareValue:    "a]b",
+				},
+			},
+		},
+	} {
+		path, err := ParsePatchPath(test.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(path, test.expected) {
+			t.Errorf("%s: got %+v, want %+v", test.path, path, test.expected)
+		}
+	}
+}
+
+func TestParsePatchPathInvalid(t *testing.T) {
+	for _, path := range []string{
+		"",
+		"1name",
+		"name.",
+		"name.given name",
+		`emails[type eq "work"`,
+		`emails[]`,
+		`emails[type eq]`,
+		`emails[type eq "work"]value`,
+		`name.givenName[value eq "a"]`,
+	} {
+		if _, err := ParsePatchPath(path); err == nil {
+			t.Errorf("%q: expected an error", path)
+		}
+	}
+}
+
+func TestServerResourcePatchHandlerPaths(t *testing.T) {
+	for _, test := range []struct {
+		op, path, value string
+		expectedStatus  int
+	}{
+		{PatchOperationRemove, `emails[type eq \"work\"]`, `null`, http.StatusOK},
+		{PatchOperationReplace, `emails[type eq \"work\"].value`, `"bjensen@example.com"`, http.StatusOK},
+		{PatchOperationReplace, "name.givenName", `"Barbara"`, http.StatusOK},
+		{PatchOperationReplace, "name", `{"givenName": "Barbara"}`, http.StatusOK},
+		{PatchOperationReplace, "name.unknown", `"Barbara"`, http.StatusBadRequest},
+		{PatchOperationReplace, "name.givenName", `42`, http.StatusBadRequest},
+		{PatchOperationReplace, `emails[type eq \"work\"].value`, `42`, http.StatusBadRequest},
+		{PatchOperationReplace, "emails.primary", `true`, http.StatusOK},
+		{PatchOperationReplace, "emails.primary", `"yes"`, http.StatusBadRequest},
+		{PatchOperationRemove, `name[givenName eq \"Barbara\"]`, `null`, http.StatusBadRequest},
+		{PatchOperationRemove, `emails[unknown eq \"work\"]`, `null`, http.StatusBadRequest},
+		{PatchOperationRemove, `emails[type eq \"work\" and primary eq true]`, `null`, http.StatusOK},
+		{PatchOperationRemove, `emails[type eq \"work\"`, `null`, http.StatusBadRequest},
+		{"Replace", "name.givenName", `"Barbara"`, http.StatusOK},
+		{"ADD", "emails", `[{"value": "bjensen@example.com"}]`, http.StatusOK},
+		{"Remove", `emails[type eq \"work\"]`, `null`, http.StatusOK},
+		{"Move", "displayName", `"Babs"`, http.StatusBadRequest},
+	} {
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(fmt.Sprintf(`{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": %q, "path": "%s", "value": %s}]
+		}`, test.op, test.path, test.value))))
+		if rr.Code != test.expectedStatus {
+			t.Errorf("%s %s: got status %d, want %d: %s", test.op, test.path, rr.Code, test.expectedStatus, rr.Body.String())
+		}
+	}
+}
+
+func TestServerResourcePatchHandlerCoerceSubAttribute(t *testing.T) {
+	server := newTestServer()
+	server.CoercePatchValues = true
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/Users/0001", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "emails[type eq \"work\"].primary", "value": "True"}]
+	}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected the value to be coerced, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
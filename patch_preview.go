@@ -0,0 +1,278 @@
+package scim
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// PreviewPatch returns the attributes that result from applying given PATCH request to given attributes of a resource
+// with given schema, together with the changes that the request makes. Nothing is applied: the given attributes are
+// not modified. It can be used to show what the PATCH request of an identity provider will do before it is handled.
+//
+// The operations are validated like the operations of a PATCH request that is sent to the server. Paths within schema
+// extensions are not supported. An operation that adds or replaces the values that match a value filter fails if no
+// value matches the filter.
+func PreviewPatch(current ResourceAttributes, req PatchRequest, s schema.Schema) (ResourceAttributes, []AttributeChange, error) {
+	if len(req.Operations) == 0 {
+		return nil, nil, fmt.Errorf("a patch request must contain at least one operation")
+	}
+
+	t := ResourceType{Schema: s}
+	attributes := copyValue(map[string]interface{}(current)).(map[string]interface{})
+	for i, op := range req.Operations {
+		op.Op = strings.ToLower(op.Op)
+		if causes := t.validateOperation(op); len(causes) != 0 {
+			return nil, nil, fmt.Errorf("operation %d: %s", i+1, strings.Join(causes, ", "))
+		}
+		if err := t.applyOperation(attributes, op); err != nil {
+			return nil, nil, fmt.Errorf("operation %d: %v", i+1, err)
+		}
+	}
+
+	result := ResourceAttributes(attributes)
+	return result, t.SchemaSet().DiffResources(withoutCommonAttributes(current), withoutCommonAttributes(result)), nil
+}
+
+// applyOperation applies given validated operation to given attributes.
+func (t ResourceType) applyOperation(attributes map[string]interface{}, op PatchOperation) error {
+	if op.Path == "" {
+		// The keys of an operation without a path are the paths of the attributes to modify.
+		for k, v := range op.Value.(map[string]interface{}) {
+			path, err := ParsePatchPath(k)
+			if err != nil {
+				return err
+			}
+			t.applyValue(attributes, path, op.Op, copyValue(v))
+		}
+		return nil
+	}
+
+	path, err := op.ParsePath()
+	if err != nil {
+		return err
+	}
+	if path.ValueFilter != nil {
+		return t.applyFilteredValue(attributes, path, op.Op, copyValue(op.Value))
+	}
+	t.applyValue(attributes, path, op.Op, copyValue(op.Value))
+	return nil
+}
+
+// applyValue applies a single operation to the (sub-)attribute with given path, which has no value filter. A
+// sub-attribute of a multi-valued attribute targets the sub-attribute of all its values.
+func (t ResourceType) applyValue(attributes map[string]interface{}, path PatchPath, op string, value interface{}) {
+	key := keyFold(attributes, path.AttributeName)
+	if path.SubAttribute != "" {
+		switch current := attributes[key].(type) {
+		case []interface{}:
+			for _, v := range current {
+				if complex, ok := v.(map[string]interface{}); ok {
+					applySubAttribute(complex, path.SubAttribute, op, copyValue(value))
+				}
+			}
+		case map[string]interface{}:
+			applySubAttribute(current, path.SubAttribute, op, value)
+			if len(current) == 0 {
+				delete(attributes, key)
+			}
+		default:
+			if op != PatchOperationRemove {
+				attributes[key] = map[string]interface{}{path.SubAttribute: value}
+			}
+		}
+		return
+	}
+
+	attribute, _ := t.Schema.Attribute(path.AttributeName)
+	multiValued := attribute != nil && attribute.MultiValued()
+	current, exists := attributes[key]
+	switch {
+	case op == PatchOperationRemove:
+		delete(attributes, key)
+	case op == PatchOperationAdd && multiValued:
+		attributes[key] = appendValues(*attribute, current, value)
+	case !multiValued && exists:
+		// The sub-attributes of a complex attribute that are not specified are left unchanged.
+		complex, currentIsComplex := current.(map[string]interface{})
+		values, valueIsComplex := value.(map[string]interface{})
+		if !currentIsComplex || !valueIsComplex {
+			attributes[key] = value
+			return
+		}
+		for k, v := range values {
+			complex[keyFold(complex, k)] = v
+		}
+	default:
+		attributes[key] = value
+	}
+}
+
+// applyFilteredValue applies a single operation to the values of a multi-valued attribute that match the value filter
+// of given path, or only to their sub-attribute if the path targets one. The attribute is removed once it has no values
+// left.
+func (t ResourceType) applyFilteredValue(attributes map[string]interface{}, path PatchPath, op string, value interface{}) error {
+	key := keyFold(attributes, path.AttributeName)
+	values, _ := attributes[key].([]interface{})
+	kept := make([]interface{}, 0, len(values))
+	var matched bool
+	for _, v := range values {
+		if !t.MatchesValueFilter(path, v) {
+			kept = append(kept, v)
+			continue
+		}
+		matched = true
+
+		complex := v.(map[string]interface{})
+		switch {
+		case path.SubAttribute != "":
+			applySubAttribute(complex, path.SubAttribute, op, copyValue(value))
+		case op == PatchOperationRemove:
+			continue
+		default:
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("the values of %q that match the value filter can only be replaced by a complex value", path.AttributeName)
+			}
+			if op == PatchOperationReplace {
+				complex = make(map[string]interface{}, len(values))
+			}
+			for k, v := range copyValue(values).(map[string]interface{}) {
+				complex[keyFold(complex, k)] = v
+			}
+		}
+		kept = append(kept, complex)
+	}
+
+	switch {
+	case !matched && op != PatchOperationRemove:
+		return fmt.Errorf("no values of %q match the value filter", path.AttributeName)
+	case len(kept) == 0:
+		delete(attributes, key)
+	default:
+		attributes[key] = kept
+	}
+	return nil
+}
+
+// applySubAttribute applies a single operation to the sub-attribute with given name of given complex value.
+func applySubAttribute(complex map[string]interface{}, name, op string, value interface{}) {
+	if op == PatchOperationRemove {
+		deleteFold(complex, name)
+		return
+	}
+	complex[keyFold(complex, name)] = value
+}
+
+// appendValues adds given value, or values if it is an array, to the values of given multi-valued attribute. Values
+// that are already present are not added again, where values are compared according to the attribute, e.g.
+// case-insensitively unless it is case exact.
+func appendValues(attribute schema.CoreAttribute, current, value interface{}) []interface{} {
+	values, ok := current.([]interface{})
+	if !ok && current != nil {
+		values = []interface{}{current}
+	}
+	added, ok := value.([]interface{})
+	if !ok {
+		added = []interface{}{value}
+	}
+
+outer:
+	for _, a := range added {
+		for _, v := range values {
+			if attribute.ValuesEqual(a, v) {
+				continue outer
+			}
+		}
+		values = append(values, a)
+	}
+	return values
+}
+
+// keyFold returns the key of given map that equals given name case-insensitively, or the name itself if there is none.
+func keyFold(m map[string]interface{}, name string) string {
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+// ApplyPatch returns a copy of given attributes of a resource of the resource type to which the operations of given
+// PATCH request are applied, the way PreviewPatch does, including operations on the attributes of schema extensions.
+// Handlers that store resources as attributes can use it to implement the Patch callback method. The operations are
+// expected to be validated by the server; an error is returned if an operation can not be applied, e.g. if no value
+// matches the value filter of an operation that adds or replaces values.
+func (t ResourceType) ApplyPatch(current ResourceAttributes, patch PatchRequest) (ResourceAttributes, error) {
+	attributes := copyValue(map[string]interface{}(current)).(map[string]interface{})
+	for _, op := range patch.Operations {
+		op.Op = strings.ToLower(op.Op)
+		if op.Path != "" {
+			path, err := op.ParsePath()
+			if err != nil {
+				return nil, err
+			}
+			if extension, ok := t.extensionType(path.URI); ok {
+				op.Path = strings.TrimPrefix(op.Path[len(path.URI):], ":")
+				if err := extension.applyOperation(extensionValues(attributes, extension.Schema.ID), op); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := t.applyOperation(attributes, op); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		values, _ := op.Value.(map[string]interface{})
+		for k, v := range values {
+			nested, isComplex := v.(map[string]interface{})
+			if extension, ok := t.extensionType(k); ok && isComplex {
+				extensionOp := PatchOperation{Op: op.Op, Value: nested}
+				if err := extension.applyOperation(extensionValues(attributes, extension.Schema.ID), extensionOp); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if path, err := ParsePatchPath(k); err == nil {
+				if extension, ok := t.extensionType(path.URI); ok {
+					extensionOp := PatchOperation{Op: op.Op, Value: map[string]interface{}{k[len(path.URI)+1:]: v}}
+					if err := extension.applyOperation(extensionValues(attributes, extension.Schema.ID), extensionOp); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			if err := t.applyOperation(attributes, PatchOperation{Op: op.Op, Value: map[string]interface{}{k: v}}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return attributes, nil
+}
+
+// extensionType returns a resource type with the schema of the extension with given (case insensitive) URI, to apply
+// operations to the attributes of the extension.
+func (t ResourceType) extensionType(uri string) (ResourceType, bool) {
+	for _, extension := range t.SchemaExtensions {
+		if strings.EqualFold(extension.Schema.ID, uri) {
+			return ResourceType{Schema: extension.Schema}, true
+		}
+	}
+	return ResourceType{}, false
+}
+
+// extensionValues returns the attributes of the extension with given URI in given attributes, which are added if they
+// are not present.
+func extensionValues(attributes map[string]interface{}, uri string) map[string]interface{} {
+	key := keyFold(attributes, uri)
+	values, ok := attributes[key].(map[string]interface{})
+	if !ok {
+		values = make(map[string]interface{})
+		attributes[key] = values
+	}
+	return values
+}
@@ -0,0 +1,151 @@
+package scim
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestPreviewPatch(t *testing.T) {
+	current := ResourceAttributes{
+		"id":       "0001",
+		"userName": "bjensen",
+		"name": map[string]interface{}{
+			"givenName":  "Barbara",
+			"familyName": "Jensen",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+			map[string]interface{}{"value": "babs@example.com", "type": "home"},
+		},
+	}
+	req := PatchRequest{Operations: []PatchOperation{
+		{Op: "Replace", Path: "name", Value: map[string]interface{}{"givenName": "Babs"}},
+		{Op: PatchOperationRemove, Path: `emails[type eq "home"]`},
+		{Op: PatchOperationAdd, Path: "emails", Value: []interface{}{
+			map[string]interface{}{"value": "barbara@example.com", "type": "other"},
+		}},
+		{Op: PatchOperationReplace, Path: `emails[type eq "work"].value`, Value: "barbara.jensen@example.com"},
+		{Op: PatchOperationAdd, Value: map[string]interface{}{"displayName": "Babs Jensen"}},
+	}}
+
+	result, changes, err := PreviewPatch(current, req, schema.CoreUserSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ResourceAttributes{
+		"id":       "0001",
+		"userName": "bjensen",
+		"name": map[string]interface{}{
+			"givenName":  "Babs",
+			"familyName": "Jensen",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "barbara.jensen@example.com", "type": "work"},
+			map[string]interface{}{"value": "barbara@example.com", "type": "other"},
+		},
+		"displayName": "Babs Jensen",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	if expected := []string{"displayName", "emails", "name.givenName"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected changes of %v, got %v", expected, paths)
+	}
+
+	if current["name"].(map[string]interface{})["givenName"] != "Barbara" || len(current["emails"].([]interface{})) != 2 {
+		t.Errorf("the current attributes were modified: %v", current)
+	}
+}
+
+func TestPreviewPatchCaseExact(t *testing.T) {
+	current := ResourceAttributes{
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+		},
+	}
+	req := PatchRequest{Operations: []PatchOperation{
+		{Op: PatchOperationAdd, Path: "emails", Value: []interface{}{
+			map[string]interface{}{"value": "BJensen@example.com", "type": "Work"},
+		}},
+	}}
+	result, changes, err := PreviewPatch(current, req, schema.CoreUserSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result["emails"].([]interface{})) != 1 || len(changes) != 0 {
+		t.Errorf("expected a value that only differs in case not to be added, got %v", result["emails"])
+	}
+}
+
+func TestPreviewPatchInvalid(t *testing.T) {
+	current := ResourceAttributes{
+		"userName": "bjensen",
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+		},
+	}
+	for _, op := range []PatchOperation{
+		{Op: "move", Path: "userName", Value: "babs"},
+		{Op: PatchOperationReplace, Path: "unknown", Value: "babs"},
+		{Op: PatchOperationReplace, Path: "userName", Value: 42},
+		{Op: PatchOperationReplace, Path: `emails[type eq "home"].value`, Value: "babs@example.com"},
+	} {
+		if _, _, err := PreviewPatch(current, PatchRequest{Operations: []PatchOperation{op}}, schema.CoreUserSchema()); err == nil {
+			t.Errorf("expected %s %s to fail", op.Op, op.Path)
+		}
+	}
+	if _, _, err := PreviewPatch(current, PatchRequest{}, schema.CoreUserSchema()); err == nil {
+		t.Error("expected a request without operations to fail")
+	}
+}
+
+func TestResourceTypeApplyPatch(t *testing.T) {
+	enterprise := "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	resourceType := ResourceType{
+		Schema: schema.CoreUserSchema(),
+		SchemaExtensions: []SchemaExtension{{Schema: schema.Schema{
+			ID: enterprise,
+			Attributes: []schema.CoreAttribute{
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "employeeNumber"})),
+				schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "department"})),
+			},
+		}}},
+	}
+	current := ResourceAttributes{
+		"userName": "bjensen",
+		enterprise: map[string]interface{}{"employeeNumber": "701984"},
+	}
+
+	attributes, err := resourceType.ApplyPatch(current, PatchRequest{Operations: []PatchOperation{
+		{Op: PatchOperationReplace, Path: enterprise + ":employeeNumber", Value: "701985"},
+		{Op: PatchOperationAdd, Value: map[string]interface{}{
+			enterprise + ":department": "Tour Operations",
+			"displayName":              "Babs",
+		}},
+		{Op: PatchOperationRemove, Path: "userName"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ResourceAttributes{
+		"displayName": "Babs",
+		enterprise: map[string]interface{}{
+			"employeeNumber": "701985",
+			"department":     "Tour Operations",
+		},
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("unexpected attributes: got %v want %v", attributes, expected)
+	}
+	if current[enterprise].(map[string]interface{})["employeeNumber"] != "701984" {
+		t.Error("expected the current attributes to be left unchanged")
+	}
+}
@@ -0,0 +1,72 @@
+package scim
+
+import "strings"
+
+// PatchStatisticsExtensionID is the URI of the extension of the responses of PATCH requests that summarizes the
+// changes, see Server.PatchStatistics. These responses list the URI in their "schemas" attribute and contain an object
+// with the URI as key:
+//
+//	{
+//		"schemas": [
+//			"urn:ietf:params:scim:schemas:core:2.0:Group",
+//			"urn:elimity:params:scim:api:messages:2.0:PatchStatistics"
+//		],
+//		"urn:elimity:params:scim:api:messages:2.0:PatchStatistics": {
+//			"membersAdded": 250,
+//			"membersRemoved": 3,
+//			"attributesChanged": 1
+//		},
+//		...
+//	}
+const PatchStatisticsExtensionID = "urn:elimity:params:scim:api:messages:2.0:PatchStatistics"
+
+// PatchStatistics summarizes the changes of a PATCH request. The server retrieves the resource before the request
+// first. The members are counted by applying the operations of the request to it, so the "Patch" callback method does
+// not have to return the members of large groups, the other attributes are compared with the resource that the callback
+// method returns. The statistics are left out of the response if the resource could not be retrieved or the operations
+// could not be applied to it.
+type PatchStatistics struct {
+	// MembersAdded is the number of members that were added to the "members" attribute of a group.
+	MembersAdded int `json:"membersAdded"`
+	// MembersRemoved is the number of members that were removed from the "members" attribute of a group.
+	MembersRemoved int `json:"membersRemoved"`
+	// AttributesChanged is the number of other (sub-)attributes whose value changed, see DiffResources. Values that are
+	// equal according to the schema, e.g. reordered values of multi-valued attributes, are not counted as changed.
+	AttributesChanged int `json:"attributesChanged"`
+}
+
+// patchStatisticsOf returns the statistics of given PATCH request, which changed a resource with given attributes before
+// the request into a resource with given attributes after the request. The boolean is false if there are no attributes
+// before the request, e.g. because the resource could not be retrieved, or if the operations can not be applied to
+// them.
+func patchStatisticsOf(resourceType ResourceType, patch PatchRequest, before, after ResourceAttributes) (PatchStatistics, bool) {
+	if before == nil {
+		return PatchStatistics{}, false
+	}
+	patched, err := resourceType.ApplyPatch(before, patch)
+	if err != nil {
+		return PatchStatistics{}, false
+	}
+	add, remove := ReconcileMembers(MembersFromAttributes(before), MembersFromAttributes(patched))
+	statistics := PatchStatistics{MembersAdded: len(add), MembersRemoved: len(remove)}
+	changes := resourceType.SchemaSet().DiffResources(withoutCommonAttributes(before), withoutCommonAttributes(after))
+	for _, change := range changes {
+		if !strings.EqualFold(strings.SplitN(change.Path, ".", 2)[0], "members") {
+			statistics.AttributesChanged++
+		}
+	}
+	return statistics, true
+}
+
+// addTo returns a copy of given resource representation with the statistics extension.
+func (p PatchStatistics) addTo(response ResourceAttributes) ResourceAttributes {
+	extended := make(ResourceAttributes, len(response)+1)
+	for k, v := range response {
+		extended[k] = v
+	}
+	if schemas, ok := response["schemas"].([]string); ok {
+		extended["schemas"] = append(append([]string(nil), schemas...), PatchStatisticsExtensionID)
+	}
+	extended[PatchStatisticsExtensionID] = p
+	return extended
+}
@@ -0,0 +1,99 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+// membersOmittingHandler leaves the members out of the resources that its Patch callback method returns, like handlers
+// of large groups do.
+type membersOmittingHandler struct {
+	testResourceHandler
+}
+
+func (h membersOmittingHandler) Patch(r *http.Request, id string, req PatchRequest) (Resource, errors.PatchError) {
+	resource, err := h.testResourceHandler.Patch(r, id, req)
+	attributes := make(ResourceAttributes, len(resource.Attributes))
+	for k, v := range resource.Attributes {
+		if k != "members" {
+			attributes[k] = v
+		}
+	}
+	resource.Attributes = attributes
+	return resource, err
+}
+
+func TestServerPatchStatistics(t *testing.T) {
+	member := func(id string) map[string]interface{} {
+		return map[string]interface{}{"value": id, "type": "User"}
+	}
+	handler := testResourceHandler{
+		data:        make(map[string]ResourceAttributes),
+		externalIDs: make(map[string]optional.String),
+	}
+
+	for _, test := range []struct {
+		enabled bool
+		handler ResourceHandler
+	}{
+		{false, handler},
+		{true, handler},
+		{true, membersOmittingHandler{handler}},
+	} {
+		handler.data["0001"] = ResourceAttributes{
+			"displayName": "Tour Guides",
+			"members":     []interface{}{member("a"), member("b")},
+		}
+		server := newTestServer()
+		server.PatchStatistics = test.enabled
+		server.ResourceTypes = []ResourceType{{
+			Name:     "Group",
+			Endpoint: "/Groups",
+			Schema:   schema.CoreGroupSchema(),
+			Handler:  test.handler,
+		}}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/Groups/0001", strings.NewReader(`{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "replace", "path": "displayName", "value": "Guides"},
+				{"op": "replace", "path": "members", "value": [
+					{"value": "b", "type": "User"},
+					{"value": "c", "type": "User"},
+					{"value": "d", "type": "User"}
+				]}
+			]
+		}`)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Schemas    []string         `json:"schemas"`
+			Statistics *PatchStatistics `json:"urn:elimity:params:scim:api:messages:2.0:PatchStatistics"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !test.enabled {
+			if response.Statistics != nil || contains(response.Schemas, PatchStatisticsExtensionID) {
+				t.Errorf("expected no statistics, got %s", rr.Body.String())
+			}
+			continue
+		}
+		if !contains(response.Schemas, PatchStatisticsExtensionID) {
+			t.Errorf("expected the extension to be listed in the schemas, got %v", response.Schemas)
+		}
+		expected := PatchStatistics{MembersAdded: 2, MembersRemoved: 1, AttributesChanged: 1}
+		if response.Statistics == nil || *response.Statistics != expected {
+			t.Errorf("expected statistics %+v, got %s", expected, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// projection represents the "attributes" and "excludedAttributes" query parameters, which are used to override the
+// default list of attributes that are returned in a response, as described in RFC 7644 section 3.9. The paths consist
+// of the lowercase names of the (sub-)attributes, attributes of schema extensions are prefixed with the URI of the
+// extension.
+type projection struct {
+	attributes         [][]string
+	excludedAttributes [][]string
+}
+
+// parseProjection parses the projection of given request for resources of given resource type.
+func (t ResourceType) parseProjection(r *http.Request) projection {
+	return projection{
+		attributes:         t.parseAttributePaths(r.URL.Query().Get("attributes")),
+		excludedAttributes: t.parseAttributePaths(r.URL.Query().Get("excludedAttributes")),
+	}
+}
+
+// parseAttributePaths parses the comma-separated list of attribute paths, e.g. "userName,name.givenName".
+func (t ResourceType) parseAttributePaths(param string) [][]string {
+	var paths [][]string
+	for _, p := range strings.Split(param, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if path := t.splitAttributePath(p); path != nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// splitAttributePath splits given lower case attribute path in the names of the (sub-)attributes, preceded by the URI
+// of the schema extension that contains the attribute, if any. A path may refer to a schema extension as a whole. It
+// returns nil if the path is invalid.
+func (t ResourceType) splitAttributePath(path string) []string {
+	for _, extension := range t.SchemaExtensions {
+		if id := strings.ToLower(extension.Schema.ID); path == id {
+			return []string{id}
+		}
+	}
+
+	p, err := ParseAttributePath(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	if p.URI != "" && p.URI != strings.ToLower(t.Schema.ID) {
+		names = append(names, p.URI)
+	}
+	names = append(names, p.AttributeName)
+	if p.SubAttribute != "" {
+		names = append(names, p.SubAttribute)
+	}
+	return names
+}
+
+// project returns the attributes of a resource that are to be returned according to given projection and the
+// "returned" characteristics of the attributes. Attributes that are never returned and write-only attributes are always
+// removed.
+func (t ResourceType) project(attributes ResourceAttributes, p projection) ResourceAttributes {
+	projected := make(ResourceAttributes, len(attributes))
+	for k, v := range attributes {
+		switch strings.ToLower(k) {
+		case "id", "schemas":
+			projected[k] = v
+			continue
+		}
+
+		var extension *schema.Schema
+		for i, e := range t.SchemaExtensions {
+			if strings.EqualFold(e.Schema.ID, k) {
+				extension = &t.SchemaExtensions[i].Schema
+			}
+		}
+		if extension != nil {
+			path := []string{strings.ToLower(extension.ID)}
+			complex, ok := v.(map[string]interface{})
+			if !ok || !p.includesComplex(path) {
+				continue
+			}
+			if values := p.projectComplex(complex, extension.Attributes, path); len(values) != 0 {
+				projected[k] = values
+			}
+			continue
+		}
+
+		attribute, _ := t.Schema.Attribute(k)
+		if value, ok := p.projectAttribute(attribute, []string{strings.ToLower(k)}, v); ok {
+			projected[k] = value
+		}
+	}
+	if _, ok := projected["schemas"]; ok {
+		// Schema extensions whose attributes are all left out are not listed.
+		projected["schemas"] = t.schemas(projected)
+	}
+	return projected
+}
+
+// projectAttribute returns the projected value of the attribute with given path. The attribute is nil if it is not
+// defined by the schema, in which case it is returned by default.
+func (p projection) projectAttribute(attribute *schema.CoreAttribute, path []string, value interface{}) (interface{}, bool) {
+	returned := schema.AttributeReturnedDefault()
+	var subAttributes []schema.CoreAttribute
+	if attribute != nil {
+		returned = attribute.Returned()
+		subAttributes = attribute.SubAttributes()
+		// Write-only attributes are never returned, regardless of their "returned" characteristic.
+		if attribute.Mutability() == schema.AttributeMutabilityWriteOnly() {
+			returned = schema.AttributeReturnedNever()
+		}
+	}
+
+	switch returned {
+	case schema.AttributeReturnedNever():
+		return nil, false
+	case schema.AttributeReturnedAlways():
+	case schema.AttributeReturnedRequest():
+		if !listed(p.attributes, path) && !descendantListed(p.attributes, path) {
+			return nil, false
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if returned != schema.AttributeReturnedAlways() && !p.includesComplex(path) {
+			return nil, false
+		}
+		complex := p.projectComplex(v, subAttributes, path)
+		return complex, len(complex) != 0 || len(v) == 0
+	case []interface{}:
+		if returned != schema.AttributeReturnedAlways() && !p.includesComplex(path) {
+			return nil, false
+		}
+		values := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			complex, ok := e.(map[string]interface{})
+			if !ok {
+				values = append(values, e)
+				continue
+			}
+			if complex = p.projectComplex(complex, subAttributes, path); len(complex) != 0 {
+				values = append(values, complex)
+			}
+		}
+		return values, len(values) != 0 || len(v) == 0
+	default:
+		if returned == schema.AttributeReturnedAlways() {
+			return value, true
+		}
+		return value, p.includes(path)
+	}
+}
+
+func (p projection) projectComplex(values map[string]interface{}, subAttributes []schema.CoreAttribute, path []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		subPath := append(append([]string(nil), path...), strings.ToLower(k))
+		if value, ok := p.projectAttribute(findAttribute(subAttributes, k), subPath, v); ok {
+			projected[k] = value
+		}
+	}
+	return projected
+}
+
+// includes reports whether the (non-complex) attribute with given path is returned by default.
+func (p projection) includes(path []string) bool {
+	if len(p.attributes) != 0 {
+		return listed(p.attributes, path)
+	}
+	return !listed(p.excludedAttributes, path)
+}
+
+// includesComplex reports whether (some of) the sub-attributes of the complex attribute with given path could be
+// returned.
+func (p projection) includesComplex(path []string) bool {
+	if len(p.attributes) != 0 {
+		return listed(p.attributes, path) || descendantListed(p.attributes, path)
+	}
+	return !listed(p.excludedAttributes, path)
+}
+
+// listed reports whether given path, or one of its ancestors, is in given list of paths.
+func listed(paths [][]string, path []string) bool {
+	for _, p := range paths {
+		if len(p) <= len(path) && equalPaths(p, path[:len(p)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// descendantListed reports whether one of the descendants of given path is in given list of paths.
+func descendantListed(paths [][]string, path []string) bool {
+	for _, p := range paths {
+		if len(p) > len(path) && equalPaths(p[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalPaths(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestResourceTypeProject(t *testing.T) {
+	resourceType := newTestServer().ResourceTypes[1]
+	resourceType.Schema.Attributes = append(resourceType.Schema.Attributes,
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:       "password",
+			Mutability: schema.AttributeMutabilityWriteOnly(),
+			Returned:   schema.AttributeReturnedNever(),
+		})),
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:     "externalId",
+			Returned: schema.AttributeReturnedAlways(),
+		})),
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:     "nickName",
+			Returned: schema.AttributeReturnedRequest(),
+		})),
+	)
+	attributes := ResourceAttributes{
+		"id":         "0001",
+		"schemas":    []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName":   "bjensen",
+		"password":   "t1meMa$heen",
+		"externalId": "bjensen",
+		"nickName":   "Babs",
+		"name": map[string]interface{}{
+			"familyName": "Jensen",
+			"givenName":  "Barbara",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+		},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "701984",
+			"organization":   "Universal Studios",
+		},
+	}
+
+	for _, test := range []struct {
+		query    string
+		expected ResourceAttributes
+	}{
+		{
+			query: "",
+			expected: ResourceAttributes{
+				"id": "0001",
+				"schemas": []string{
+					"urn:ietf:params:scim:schemas:core:2.0:User",
+					"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+				},
+				"userName":   "bjensen",
+				"externalId": "bjensen",
+				"name":       attributes["name"],
+				"emails":     attributes["emails"],
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": attributes["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],
+			},
+		},
+		{
+			query: "attributes=userName,name.givenName,emails.value,nickName,password",
+			expected: ResourceAttributes{
+				"id":         "0001",
+				"schemas":    []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+				"userName":   "bjensen",
+				"externalId": "bjensen",
+				"nickName":   "Babs",
+				"name":       map[string]interface{}{"givenName": "Barbara"},
+				"emails": []interface{}{
+					map[string]interface{}{"value": "bjensen@example.com"},
+				},
+			},
+		},
+		{
+			query: "attributes=urn:ietf:params:scim:schemas:core:2.0:User:userName," +
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+			expected: ResourceAttributes{
+				"id": "0001",
+				"schemas": []string{
+					"urn:ietf:params:scim:schemas:core:2.0:User",
+					"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+				},
+				"userName":   "bjensen",
+				"externalId": "bjensen",
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+					"employeeNumber": "701984",
+				},
+			},
+		},
+		{
+			query: "excludedAttributes=name.familyName,emails,externalId," +
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+			expected: ResourceAttributes{
+				"id":         "0001",
+				"schemas":    []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+				"userName":   "bjensen",
+				"externalId": "bjensen",
+				"name":       map[string]interface{}{"givenName": "Barbara"},
+			},
+		},
+	} {
+		values, _ := url.ParseQuery(test.query)
+		r := httptest.NewRequest(http.MethodGet, "/EnterpriseUser?"+values.Encode(), nil)
+		if projected := resourceType.project(attributes, resourceType.parseProjection(r)); !reflect.DeepEqual(projected, test.expected) {
+			t.Errorf("%s: got %v want %v", test.query, projected, test.expected)
+		}
+	}
+}
+
+func TestServerResourceGetHandlerAttributes(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001?excludedAttributes=userName", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resource["userName"]; ok {
+		t.Errorf("expected the user name to be excluded: %v", resource)
+	}
+	if resource["id"] != "0001" {
+		t.Errorf("expected the id to be returned: %v", resource)
+	}
+}
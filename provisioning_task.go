@@ -0,0 +1,204 @@
+package scim
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+// ProvisioningTaskSchemaID is the URI of the schema of the provisioning task resource type.
+const ProvisioningTaskSchemaID = "urn:elimity:params:scim:schemas:core:2.0:ProvisioningTask"
+
+// ProvisioningTaskState is the state of an operation that was queued for asynchronous processing.
+type ProvisioningTaskState string
+
+const (
+	// ProvisioningTaskStatePending indicates that the processing of the operation did not start yet.
+	ProvisioningTaskStatePending ProvisioningTaskState = "pending"
+	// ProvisioningTaskStateRunning indicates that the operation is being processed.
+	ProvisioningTaskStateRunning ProvisioningTaskState = "running"
+	// ProvisioningTaskStateSucceeded indicates that the operation was processed successfully. It is a terminal state.
+	ProvisioningTaskStateSucceeded ProvisioningTaskState = "succeeded"
+	// ProvisioningTaskStateFailed indicates that the operation failed. It is a terminal state.
+	ProvisioningTaskStateFailed ProvisioningTaskState = "failed"
+)
+
+// ProvisioningTask describes the status of an operation that a callback method queued for asynchronous processing,
+// e.g. with errors.PostErrorAccepted.
+type ProvisioningTask struct {
+	// ID is the identifier of the task, which is part of the location that was returned when the operation was queued.
+	ID string
+	// Method is the HTTP method of the original operation, e.g. http.MethodPost.
+	Method string
+	// ResourceType is the name of the resource type of the resource that is changed by the operation, e.g. "User".
+	ResourceType string
+	// ResourceID is the identifier of the changed resource. It is empty as long as a resource is not created.
+	ResourceID string
+	// State is the state of the task.
+	State ProvisioningTaskState
+	// Location is the location of the changed resource, once the task succeeded. It is optional.
+	Location string
+	// Error is the error that the original operation would have returned if it had been processed synchronously, once
+	// the task failed.
+	Error errors.ScimError
+}
+
+// ProvisioningTaskHandler retrieves the status of the operations that were queued for asynchronous processing.
+type ProvisioningTaskHandler interface {
+	// Get returns the task with given identifier.
+	Get(r *http.Request, id string) (ProvisioningTask, errors.GetError)
+}
+
+// ProvisioningTaskSchema returns the schema of the provisioning task resource type. All of its attributes are
+// read-only. The "result" attribute is only present once the task reached a terminal state: it holds the status code
+// of the original operation and either the location of the changed resource or the SCIM error of the operation.
+func ProvisioningTaskSchema() schema.Schema {
+	readOnlyString := func(name, description string, canonicalValues ...string) schema.SimpleParams {
+		return schema.SimpleStringParams(schema.StringParams{
+			CanonicalValues: canonicalValues,
+			Description:     optional.NewString(description),
+			Mutability:      schema.AttributeMutabilityReadOnly(),
+			Name:            name,
+		})
+	}
+	return schema.Schema{
+		ID:          ProvisioningTaskSchemaID,
+		Name:        optional.NewString("ProvisioningTask"),
+		Description: optional.NewString("Operation that is queued for asynchronous processing"),
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(readOnlyString(
+				"method", "The HTTP method of the operation.",
+				http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+			)),
+			schema.SimpleCoreAttribute(readOnlyString(
+				"resourceType", "The name of the resource type of the resource that is changed by the operation.",
+			)),
+			schema.SimpleCoreAttribute(readOnlyString(
+				"resourceId", "The identifier of the resource that is changed by the operation.",
+			)),
+			schema.SimpleCoreAttribute(readOnlyString(
+				"state", "The state of the operation.",
+				string(ProvisioningTaskStatePending), string(ProvisioningTaskStateRunning),
+				string(ProvisioningTaskStateSucceeded), string(ProvisioningTaskStateFailed),
+			)),
+			schema.ComplexCoreAttribute(schema.ComplexParams{
+				Description: optional.NewString("The result of the operation, once it succeeded or failed."),
+				Mutability:  schema.AttributeMutabilityReadOnly(),
+				Name:        "result",
+				SubAttributes: []schema.SimpleParams{
+					readOnlyString("status", "The HTTP status code of the operation."),
+					schema.SimpleReferenceParams(schema.ReferenceParams{
+						Description:    optional.NewString("The location of the changed resource."),
+						Mutability:     schema.AttributeMutabilityReadOnly(),
+						Name:           "location",
+						ReferenceTypes: []schema.AttributeReferenceType{schema.AttributeReferenceTypeURI},
+					}),
+					readOnlyString("scimType", "The SCIM detail error keyword of a failed operation."),
+					readOnlyString("detail", "The human-readable error message of a failed operation."),
+				},
+			}),
+		},
+	}
+}
+
+// ProvisioningTaskResourceType returns a read-only resource type at the "/ProvisioningTasks" endpoint, so that clients
+// can poll the status of queued operations with standard GET requests, e.g. "/ProvisioningTasks/{id}". The locations
+// that are returned by the callback methods of the other resource types should point to this endpoint.
+func ProvisioningTaskResourceType(handler ProvisioningTaskHandler) ResourceType {
+	return ResourceType{
+		ID:          optional.NewString("ProvisioningTask"),
+		Name:        "ProvisioningTask",
+		Endpoint:    "/ProvisioningTasks",
+		Description: optional.NewString("Operation that is queued for asynchronous processing"),
+		Schema:      ProvisioningTaskSchema(),
+		Handler:     provisioningTaskResourceHandler{handler: handler},
+	}
+}
+
+// provisioningTaskResourceHandler adapts a provisioning task handler to a resource handler. Tasks can only be
+// retrieved one by one.
+type provisioningTaskResourceHandler struct {
+	handler ProvisioningTaskHandler
+}
+
+func (h provisioningTaskResourceHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	return Resource{}, errors.PostErrorNotImplemented
+}
+
+func (h provisioningTaskResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	task, getErr := h.handler.Get(r, id)
+	if getErr != errors.GetErrorNil {
+		return Resource{}, getErr
+	}
+	return Resource{
+		ID:         task.ID,
+		Attributes: task.attributes(),
+	}, errors.GetErrorNil
+}
+
+func (h provisioningTaskResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	return Page{}, errors.GetErrorNotImplemented
+}
+
+func (h provisioningTaskResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	return Resource{}, errors.PutErrorNotImplemented
+}
+
+func (h provisioningTaskResourceHandler) Delete(r *http.Request, id string) errors.DeleteError {
+	return errors.DeleteErrorNotImplemented
+}
+
+func (h provisioningTaskResourceHandler) Patch(r *http.Request, id string, req PatchRequest) (Resource, errors.PatchError) {
+	return Resource{}, errors.PatchErrorNotImplemented
+}
+
+// attributes returns the attributes of the task according to the provisioning task schema.
+func (t ProvisioningTask) attributes() ResourceAttributes {
+	attributes := ResourceAttributes{
+		"method":       t.Method,
+		"resourceType": t.ResourceType,
+		"state":        string(t.State),
+	}
+	if t.ResourceID != "" {
+		attributes["resourceId"] = t.ResourceID
+	}
+
+	switch t.State {
+	case ProvisioningTaskStateSucceeded:
+		result := map[string]interface{}{
+			"status": strconv.Itoa(successStatus(t.Method)),
+		}
+		if t.Location != "" {
+			result["location"] = t.Location
+		}
+		attributes["result"] = result
+	case ProvisioningTaskStateFailed:
+		scimErr := scimCustomError(t.Error)
+		result := map[string]interface{}{
+			"status": strconv.Itoa(scimErr.status),
+		}
+		if scimErr.scimType != "" {
+			result["scimType"] = string(scimErr.scimType)
+		}
+		if scimErr.detail != "" {
+			result["detail"] = scimErr.detail
+		}
+		attributes["result"] = result
+	}
+	return attributes
+}
+
+// successStatus returns the status code of a successful synchronous operation with given HTTP method.
+func successStatus(method string) int {
+	switch method {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
@@ -0,0 +1,76 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+type testProvisioningTaskHandler map[string]ProvisioningTask
+
+func (h testProvisioningTaskHandler) Get(r *http.Request, id string) (ProvisioningTask, errors.GetError) {
+	task, ok := h[id]
+	if !ok {
+		return ProvisioningTask{}, errors.GetErrorResourceNotFound
+	}
+	return task, errors.GetErrorNil
+}
+
+func TestProvisioningTaskResourceType(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes = append(server.ResourceTypes, ProvisioningTaskResourceType(testProvisioningTaskHandler{
+		"1": {ID: "1", Method: http.MethodPost, ResourceType: "User", State: ProvisioningTaskStatePending},
+		"2": {
+			ID: "2", Method: http.MethodPost, ResourceType: "User", ResourceID: "0042",
+			State: ProvisioningTaskStateSucceeded, Location: "https://example.com/v2/Users/0042",
+		},
+		"3": {
+			ID: "3", Method: http.MethodPost, ResourceType: "User", State: ProvisioningTaskStateFailed,
+			Error: errors.ScimError(errors.PostErrorUniqueness),
+		},
+	}))
+
+	for _, test := range []struct {
+		id     string
+		state  string
+		result map[string]interface{}
+	}{
+		{"1", "pending", nil},
+		{"2", "succeeded", map[string]interface{}{"status": "201", "location": "https://example.com/v2/Users/0042"}},
+		{"3", "failed", map[string]interface{}{"status": "409", "scimType": "uniqueness"}},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ProvisioningTasks/"+test.id, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v", test.id, rr.Code, http.StatusOK)
+		}
+
+		var task struct {
+			Schemas []string
+			State   string
+			Result  map[string]interface{}
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &task); err != nil {
+			t.Fatal(err)
+		}
+		if len(task.Schemas) != 1 || task.Schemas[0] != ProvisioningTaskSchemaID {
+			t.Errorf("%s: unexpected schemas: %v", test.id, task.Schemas)
+		}
+		if task.State != test.state {
+			t.Errorf("%s: unexpected state: got %q want %q", test.id, task.State, test.state)
+		}
+		if !reflect.DeepEqual(task.Result, test.result) {
+			t.Errorf("%s: unexpected result: got %v want %v", test.id, task.Result, test.result)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/ProvisioningTasks/1", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotImplemented)
+	}
+}
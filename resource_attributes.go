@@ -0,0 +1,103 @@
+package scim
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Get returns the value of the attribute with given name, e.g. "userName" or the URI of a schema extension. Names are
+// compared case-insensitively. It returns false if the attribute is absent or null.
+func (a ResourceAttributes) Get(name string) (interface{}, bool) {
+	value, ok := a[name]
+	if !ok {
+		value = lookupFold(a, name)
+	}
+	return value, value != nil
+}
+
+// GetString returns the value of the string (or reference, binary or dateTime) attribute with given name. It returns
+// false if the attribute is absent or its value is not a string.
+func (a ResourceAttributes) GetString(name string) (string, bool) {
+	value, _ := a.Get(name)
+	s, ok := value.(string)
+	return s, ok
+}
+
+// GetBool returns the value of the boolean attribute with given name. It returns false as second value if the
+// attribute is absent or its value is not a boolean.
+func (a ResourceAttributes) GetBool(name string) (bool, bool) {
+	value, _ := a.Get(name)
+	b, ok := value.(bool)
+	return b, ok
+}
+
+// GetInt returns the value of the integer attribute with given name. Numbers are decoded as json.Number by the server,
+// but values of other numeric types are accepted as well. It returns false if the attribute is absent or its value is
+// not a whole number that fits in an int.
+func (a ResourceAttributes) GetInt(name string) (int, bool) {
+	value, _ := a.Get(name)
+	switch v := value.(type) {
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil || int64(int(i)) != i {
+			return 0, false
+		}
+		return int(i), true
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		if int64(int(v)) != v {
+			return 0, false
+		}
+		return int(v), true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 || float64(int(v)) != v {
+			return 0, false
+		}
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// GetComplex returns the value of the (singular) complex attribute with given name, e.g. "name", or the attributes of
+// the schema extension with given URI. Its sub-attributes can be read with the accessors as well. It returns false if
+// the attribute is absent or its value is not an object.
+func (a ResourceAttributes) GetComplex(name string) (ResourceAttributes, bool) {
+	value, _ := a.Get(name)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case ResourceAttributes:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// GetMulti returns the values of the multi-valued attribute with given name, e.g. "emails". The values of complex
+// attributes are of type map[string]interface{}. It returns false if the attribute is absent or its value is not an
+// array.
+func (a ResourceAttributes) GetMulti(name string) ([]interface{}, bool) {
+	value, _ := a.Get(name)
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case []map[string]interface{}:
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			values[i] = e
+		}
+		return values, true
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			values[i] = e
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
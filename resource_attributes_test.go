@@ -0,0 +1,70 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResourceAttributesAccessors(t *testing.T) {
+	var attributes ResourceAttributes
+	d := json.NewDecoder(strings.NewReader(`{
+		"userName": "bjensen",
+		"active": true,
+		"age": 42,
+		"height": 1.75,
+		"nickName": null,
+		"name": {"givenName": "Barbara"},
+		"emails": [{"value": "bjensen@example.com"}],
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}
+	}`))
+	d.UseNumber()
+	if err := d.Decode(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	if userName, ok := attributes.GetString("USERNAME"); !ok || userName != "bjensen" {
+		t.Errorf("unexpected userName: %q, %t", userName, ok)
+	}
+	if _, ok := attributes.GetString("nickName"); ok {
+		t.Error("expected a null value not to be found")
+	}
+	if _, ok := attributes.GetString("active"); ok {
+		t.Error("expected a boolean not to be returned as a string")
+	}
+	if active, ok := attributes.GetBool("active"); !ok || !active {
+		t.Errorf("unexpected active: %t, %t", active, ok)
+	}
+	if age, ok := attributes.GetInt("age"); !ok || age != 42 {
+		t.Errorf("unexpected age: %d, %t", age, ok)
+	}
+	if _, ok := attributes.GetInt("height"); ok {
+		t.Error("expected a decimal not to be returned as an integer")
+	}
+	if age, ok := (ResourceAttributes{"age": 42.0}).GetInt("age"); !ok || age != 42 {
+		t.Errorf("unexpected age of a float: %d, %t", age, ok)
+	}
+
+	name, ok := attributes.GetComplex("name")
+	if !ok {
+		t.Fatal("expected name to be found")
+	}
+	if givenName, _ := name.GetString("givenName"); givenName != "Barbara" {
+		t.Errorf("unexpected givenName: %q", givenName)
+	}
+	enterprise, _ := attributes.GetComplex("urn:ietf:params:scim:schemas:extension:enterprise:2.0:User")
+	if employeeNumber, _ := enterprise.GetString("employeeNumber"); employeeNumber != "701984" {
+		t.Errorf("unexpected employeeNumber: %q", employeeNumber)
+	}
+
+	emails, ok := attributes.GetMulti("emails")
+	if !ok || len(emails) != 1 {
+		t.Fatalf("unexpected emails: %v", emails)
+	}
+	if _, ok := attributes.GetMulti("name"); ok {
+		t.Error("expected a complex value not to be returned as multi-valued")
+	}
+	if _, ok := attributes.GetComplex("unknown"); ok {
+		t.Error("expected an unknown attribute not to be found")
+	}
+}
@@ -1,12 +1,11 @@
 package scim
 
 import (
-	"fmt"
 	"net/http"
-	"net/url"
 
 	scim "github.com/di-wu/scim-filter-parser"
 	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
 )
 
 // ListRequestParams request parameters sent to the API via a "GetAll" route.
@@ -19,12 +18,75 @@ type ListRequestParams struct {
 	// It is an optional parameter and thus will be nil when the parameter is not present.
 	Filter scim.Expression
 
+	// RawFilter is the filter query parameter as it was sent by the client, e.g. `userName eq "bjensen"`. It is empty
+	// when the parameter is not present. Handlers that forward the filter to a backend that understands the SCIM filter
+	// syntax can use it instead of the parsed expression.
+	RawFilter string
+
 	// StartIndex The 1-based index of the first query result. A value less than 1 SHALL be interpreted as 1.
 	StartIndex int
+
+	// SortBy specifies the attribute whose value is used to order the returned resources, e.g. "userName" or
+	// "name.familyName". It is empty if the parameter is not present or sorting is not supported by the service provider.
+	SortBy string
+
+	// SortOrder is the order in which the "sortBy" parameter is applied. It defaults to ascending when "sortBy" is
+	// present, and is empty otherwise.
+	SortOrder SortOrder
+}
+
+// Offset returns the 0-based index of the first requested result, e.g. for the "OFFSET" clause of an SQL query. A start
+// index less than 1 is interpreted as 1.
+func (p ListRequestParams) Offset() int {
+	if p.StartIndex < 1 {
+		return 0
+	}
+	return p.StartIndex - 1
+}
+
+// Empty reports whether no results are requested, i.e. the count is zero or negative. Only the total number of results
+// is returned to the client, so handlers can skip retrieving the resources themselves.
+func (p ListRequestParams) Empty() bool {
+	return p.Count <= 0
+}
+
+// Window returns the bounds of the requested page within the list of all results, given its length, such that
+// results[start:end] are the results to return. The bounds are always valid, e.g. both equal the length if the start
+// index lies beyond the last result.
+func (p ListRequestParams) Window(total int) (start, end int) {
+	if total < 0 {
+		total = 0
+	}
+	start = p.Offset()
+	if start > total {
+		start = total
+	}
+	end = total
+	if count := total - start; p.Count < count {
+		end = start
+		if p.Count > 0 {
+			end += p.Count
+		}
+	}
+	return start, end
 }
 
+// SortOrder is the order in which the "sortBy" parameter is applied.
+type SortOrder string
+
+const (
+	// SortOrderAscending orders the resources from the lowest to the highest value.
+	SortOrderAscending SortOrder = "ascending"
+	// SortOrderDescending orders the resources from the highest to the lowest value.
+	SortOrderDescending SortOrder = "descending"
+)
+
 // ResourceAttributes represents a list of attributes given to the callback method to create or replace
 // a resource based on the given attributes.
+//
+// The attributes of schema extensions are namespaced: they are nested in an object whose key is the URI of the
+// extension, e.g. {"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}}, also
+// if the client prefixed their keys with the URI instead. Callback methods return them in the same way.
 type ResourceAttributes map[string]interface{}
 
 // Resource represents an entity returned by a callback method.
@@ -33,35 +95,64 @@ type Resource struct {
 	ID string
 	// Attributes is a list of attributes defining the resource.
 	Attributes ResourceAttributes
+	// Version is the version of the resource, e.g. a revision number or a hash of its attributes. It is optional. If set,
+	// it is returned in the "meta.version" attribute and the "ETag" header, and, if the service provider supports
+	// entity tags, it is used to evaluate the "If-Match" and "If-None-Match" headers of PUT, PATCH and DELETE requests.
+	Version string
+	// ExternalID is the identifier of the resource as defined by the provisioning client, e.g. the identifier of a user
+	// at the identity provider. It is optional. If set, it is returned in the "externalId" attribute.
+	ExternalID optional.String
+	// Meta contains the metadata of the resource. Only its timestamps are set by the callback methods, see Meta.
+	Meta Meta
+	// Tenant is the label of the tenant or owner of the resource. It is optional and only used by TenantGuard, it is
+	// never returned to clients.
+	Tenant string
 }
 
-func (r Resource) response(resourceType ResourceType) ResourceAttributes {
+func (r Resource) response(req *http.Request, resourceType ResourceType) ResourceAttributes {
 	response := r.Attributes
-	response["id"] = r.ID
-	schemas := []string{resourceType.Schema.ID}
-	for _, schema := range resourceType.SchemaExtensions {
-		schemas = append(schemas, schema.Schema.ID)
+	// Attributes of schema extensions that are returned with prefixed keys are nested in the object of their extension.
+	if namespaced, err := resourceType.namespaced(response); err == errors.ValidationErrorNil {
+		response = namespaced
 	}
-	response["schemas"] = schemas
-	response["meta"] = meta{
-		ResourceType: resourceType.Name,
-		Location:     fmt.Sprintf("%s/%s", resourceType.Endpoint[1:], url.PathEscape(r.ID)),
+	if resourceType.CanonicalResponseKeys {
+		response = resourceType.canonicalKeys(response)
 	}
+	response["id"] = r.ID
+	if r.ExternalID.Present() {
+		response[externalIDAttribute] = r.ExternalID.Value()
+	}
+	response["schemas"] = resourceType.schemas(response)
+	response["meta"] = r.meta(req, resourceType)
 
 	return response
 }
 
+// meta returns the meta attribute of the resource.
+func (r Resource) meta(req *http.Request, resourceType ResourceType) Meta {
+	m := r.Meta
+	m.ResourceType = resourceType.Name
+	m.Location = resourceLocation(req, resourceType, r.ID)
+	m.Version = formatETag(r.Version)
+	if m.LastModified == nil {
+		m.LastModified = m.Created
+	}
+	return m
+}
+
 // ResourceHandler represents a set of callback method that connect the SCIM server with a provider of a certain resource.
 type ResourceHandler interface {
-	// Create stores given attributes. Returns a resource with the attributes that are stored and a (new) unique identifier.
-	Create(r *http.Request, attributes ResourceAttributes) (Resource, errors.PostError)
+	// Create stores given attributes and the optional external identifier that the client assigned to the resource.
+	// Returns a resource with the attributes that are stored and a (new) unique identifier.
+	Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError)
 	// Get returns the resource corresponding with the given identifier.
 	Get(r *http.Request, id string) (Resource, errors.GetError)
 	// GetAll returns a paginated list of resources.
 	GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError)
 	// Replace replaces ALL existing attributes of the resource with given identifier. Given attributes that are empty
-	// are to be deleted. Returns a resource with the attributes that are stored.
-	Replace(r *http.Request, id string, attributes ResourceAttributes) (Resource, errors.PutError)
+	// are to be deleted, as is the external identifier if it is not present. Returns a resource with the attributes that
+	// are stored.
+	Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError)
 	// Delete removes the resource with corresponding ID.
 	Delete(r *http.Request, id string) errors.DeleteError
 	// Patch update one or more attributes of a SCIM resource using a sequence of
@@ -2,13 +2,44 @@ package scim
 
 import (
 	"fmt"
-	"math/rand"
 	"net/http"
-	"time"
+	"testing"
 
 	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
 )
 
+func TestListRequestParamsWindow(t *testing.T) {
+	for _, test := range []struct {
+		params     ListRequestParams
+		total      int
+		start, end int
+	}{
+		{ListRequestParams{StartIndex: 1, Count: 10}, 25, 0, 10},
+		{ListRequestParams{StartIndex: 21, Count: 10}, 25, 20, 25},
+		{ListRequestParams{StartIndex: 25, Count: 10}, 25, 24, 25},
+		{ListRequestParams{StartIndex: 26, Count: 10}, 25, 25, 25},
+		{ListRequestParams{StartIndex: 100, Count: 10}, 25, 25, 25},
+		{ListRequestParams{StartIndex: 0, Count: 10}, 25, 0, 10},
+		{ListRequestParams{StartIndex: -5, Count: 10}, 5, 0, 5},
+		{ListRequestParams{StartIndex: 3, Count: 0}, 25, 2, 2},
+		{ListRequestParams{StartIndex: 3, Count: -1}, 25, 2, 2},
+		{ListRequestParams{StartIndex: 1, Count: 10}, 0, 0, 0},
+	} {
+		start, end := test.params.Window(test.total)
+		if start != test.start || end != test.end {
+			t.Errorf("%+v of %d: expected [%d:%d], got [%d:%d]", test.params, test.total, test.start, test.end, start, end)
+		}
+	}
+
+	if !(ListRequestParams{Count: 0}).Empty() || (ListRequestParams{Count: 1}).Empty() {
+		t.Error("expected only a zero count to request no results")
+	}
+	if offset := (ListRequestParams{StartIndex: 21}).Offset(); offset != 20 {
+		t.Errorf("expected offset 20, got %d", offset)
+	}
+}
+
 func ExampleResourceHandler() {
 	var r interface{} = testResourceHandler{}
 	_, ok := r.(ResourceHandler)
@@ -18,21 +49,23 @@ func ExampleResourceHandler() {
 
 // simple in-memory resource database
 type testResourceHandler struct {
-	data map[string]ResourceAttributes
+	data        map[string]ResourceAttributes
+	externalIDs map[string]optional.String
 }
 
-func (h testResourceHandler) Create(r *http.Request, attributes ResourceAttributes) (Resource, errors.PostError) {
+func (h testResourceHandler) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
 	// create unique identifier
-	rand.Seed(time.Now().UnixNano())
-	id := fmt.Sprintf("%04d", rand.Intn(9999))
+	id := IDGeneratorFromContext(r.Context()).NewID()
 
 	// store resource
 	h.data[id] = attributes
+	h.externalIDs[id] = externalID
 
 	// return stored resource
 	return Resource{
 		ID:         id,
 		Attributes: attributes,
+		ExternalID: externalID,
 	}, errors.PostErrorNil
 }
 
@@ -47,34 +80,27 @@ func (h testResourceHandler) Get(r *http.Request, id string) (Resource, errors.G
 	return Resource{
 		ID:         id,
 		Attributes: data,
+		ExternalID: h.externalIDs[id],
 	}, errors.GetErrorNil
 }
 
 func (h testResourceHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
-	resources := make([]Resource, 0)
-	i := 1
-
+	resources := make([]Resource, 0, len(h.data))
 	for k, v := range h.data {
-		if i > (params.StartIndex + params.Count - 1) {
-			break
-		}
-
-		if i >= params.StartIndex {
-			resources = append(resources, Resource{
-				ID:         k,
-				Attributes: v,
-			})
-		}
-		i++
+		resources = append(resources, Resource{
+			ID:         k,
+			Attributes: v,
+		})
 	}
 
+	start, end := params.Window(len(resources))
 	return Page{
 		TotalResults: len(h.data),
-		Resources:    resources,
+		Resources:    resources[start:end],
 	}, errors.GetErrorNil
 }
 
-func (h testResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes) (Resource, errors.PutError) {
+func (h testResourceHandler) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
 	// check if resource exists
 	_, ok := h.data[id]
 	if !ok {
@@ -83,11 +109,13 @@ func (h testResourceHandler) Replace(r *http.Request, id string, attributes Reso
 
 	// replace (all) attributes
 	h.data[id] = attributes
+	h.externalIDs[id] = externalID
 
 	// return resource with replaced attributes
 	return Resource{
 		ID:         id,
 		Attributes: attributes,
+		ExternalID: externalID,
 	}, errors.PutErrorNil
 }
 
@@ -100,6 +128,7 @@ func (h testResourceHandler) Delete(r *http.Request, id string) errors.DeleteErr
 
 	// delete resource
 	delete(h.data, id)
+	delete(h.externalIDs, id)
 
 	return errors.DeleteErrorNil
 }
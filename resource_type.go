@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 
+	filter "github.com/di-wu/scim-filter-parser"
 	"github.com/elimity-com/scim/errors"
 	"github.com/elimity-com/scim/optional"
 	"github.com/elimity-com/scim/schema"
@@ -31,6 +30,32 @@ type ResourceType struct {
 
 	// Handler is the set of callback method that connect the SCIM server with a provider of the resource type.
 	Handler ResourceHandler
+
+	// UniquenessHashers are the transforms that are applied to the values of sensitive attributes before they are used
+	// as uniqueness keys, by attribute path, e.g. "nationalId" or "urn:example:2.0:User:nationalId". See
+	// SchemaSet.UniquenessKeys.
+	UniquenessHashers map[string]UniquenessHasher
+
+	// ValueTransforms are the transforms that are applied to the values of attributes before they reach the handler, by
+	// attribute path, e.g. "password" to hash passwords. They are applied to the attributes of POST and PUT requests
+	// and to the values of PATCH operations, but not to requests that skip validation, see WithoutValidation.
+	ValueTransforms map[string]ValueTransform
+
+	// MergePolicies are the policies that resolve conflicts between PUT or PATCH requests and concurrent changes, by
+	// attribute path, e.g. "displayName", "name.givenName" or "urn:example:2.0:User:costCenter". Attributes without a
+	// policy inherit the policy of their parent attribute, or reject the request. Conflicts are only detected if the
+	// service provider supports entity tags and the client sends an "If-Match" header, see MergePolicy.
+	MergePolicies map[string]MergePolicy
+
+	// CanonicalResponseKeys re-keys the attributes of the resources that are returned by the handler to the names of
+	// the attributes and the URIs of the schema extensions as defined by the schemas, e.g. when the resources are
+	// stored with lowercase keys. Attributes that are sent by clients are always passed to the handler with these
+	// names, regardless of the casing of the client.
+	CanonicalResponseKeys bool
+
+	// ConcurrencyLimiter, if set, limits the number of requests of the resource type that are handled at the same
+	// time. Requests that exceed a limit are rejected with a "503 Service Unavailable" response.
+	ConcurrencyLimiter *ConcurrencyLimiter
 }
 
 // SchemaExtension is one of the resource type's schema extensions.
@@ -41,42 +66,134 @@ type SchemaExtension struct {
 	// type. If true, a resource of this type MUST include this schema extension and also include any attributes
 	// declared as required in this schema extension. If false, a resource of this type MAY omit this schema
 	// extension.
+	//
+	// POST and PUT requests without the schema extension or one of its required attributes are rejected, as are PATCH
+	// requests that remove one of its required attributes.
 	Required bool
 }
 
-func (t ResourceType) validate(raw []byte) (ResourceAttributes, errors.ValidationError) {
+func (t ResourceType) validate(raw []byte, strictReadOnly bool) (ResourceAttributes, optional.String, errors.ValidationError) {
 	d := json.NewDecoder(bytes.NewReader(raw))
 	d.UseNumber()
 
 	var m map[string]interface{}
 	err := d.Decode(&m)
 	if err != nil {
-		return ResourceAttributes{}, errors.ValidationErrorInvalidSyntax
+		return ResourceAttributes{}, optional.String{}, errors.ValidationErrorInvalidSyntax
+	}
+	return t.validateAttributes(m, strictReadOnly)
+}
+
+// validateAttributes validates given decoded resource against the schema and schema extensions of the resource type.
+// The "externalId" attribute, which is common to all resource types, is returned separately. The keys of the returned
+// attributes are the names of the attributes and the URIs of the schema extensions as defined by the schemas.
+//
+// Attributes of schema extensions can be given both nested in the object of the extension and with keys that are
+// prefixed with its URI, see namespaced. They are always returned nested.
+//
+// Values of read-only attributes are ignored, or rejected if strictReadOnly is set.
+func (t ResourceType) validateAttributes(m map[string]interface{}, strictReadOnly bool) (ResourceAttributes, optional.String, errors.ValidationError) {
+	externalID, scimErr := popExternalID(m)
+	if scimErr != errors.ValidationErrorNil {
+		return ResourceAttributes{}, optional.String{}, scimErr
+	}
+	if scimErr := t.validateSchemas(m); scimErr != errors.ValidationErrorNil {
+		return ResourceAttributes{}, optional.String{}, scimErr
+	}
+
+	m, scimErr = t.namespaced(m)
+	if scimErr != errors.ValidationErrorNil {
+		return ResourceAttributes{}, optional.String{}, scimErr
+	}
+
+	m, readOnly := t.withoutReadOnly(m)
+	if readOnly && strictReadOnly {
+		return ResourceAttributes{}, optional.String{}, errors.ValidationErrorMutability
 	}
 
 	attributes, scimErr := t.Schema.Validate(m)
 	if scimErr != errors.ValidationErrorNil {
-		return ResourceAttributes{}, scimErr
+		return ResourceAttributes{}, optional.String{}, scimErr
 	}
 
 	for _, extension := range t.SchemaExtensions {
-		extensionField := m[extension.Schema.ID]
+		extensionField := lookupFold(m, extension.Schema.ID)
 		if extensionField == nil {
 			if extension.Required {
-				return ResourceAttributes{}, errors.ValidationErrorInvalidValue
+				return ResourceAttributes{}, optional.String{}, errors.ValidationErrorInvalidValue
 			}
 			continue
 		}
 
 		extensionAttributes, scimErr := extension.Schema.Validate(extensionField)
 		if scimErr != errors.ValidationErrorNil {
-			return ResourceAttributes{}, scimErr
+			return ResourceAttributes{}, optional.String{}, scimErr
 		}
 
 		attributes[extension.Schema.ID] = extensionAttributes
 	}
 
-	return attributes, errors.ValidationErrorNil
+	attributes, scimErr = t.transformAttributes(attributes)
+	if scimErr != errors.ValidationErrorNil {
+		return ResourceAttributes{}, optional.String{}, scimErr
+	}
+	return attributes, externalID, errors.ValidationErrorNil
+}
+
+// schemas returns the "schemas" attribute of the representation of a resource with given attributes: the URI of the
+// schema of the resource type and the URIs of the schema extensions of which the resource has values.
+func (t ResourceType) schemas(attributes ResourceAttributes) []string {
+	schemas := []string{t.Schema.ID}
+	for _, extension := range t.SchemaExtensions {
+		values, _ := lookupFold(attributes, extension.Schema.ID).(map[string]interface{})
+		for _, v := range values {
+			if v != nil {
+				schemas = append(schemas, extension.Schema.ID)
+				break
+			}
+		}
+	}
+	return schemas
+}
+
+// validateSchemas validates the "schemas" attribute of given decoded resource, if present. It must be an array of the
+// URIs of the schema and schema extensions of the resource type, in any order and casing.
+func (t ResourceType) validateSchemas(m map[string]interface{}) errors.ValidationError {
+	raw, ok := m[keyFold(m, "schemas")]
+	if !ok {
+		return errors.ValidationErrorNil
+	}
+	schemas, ok := raw.([]interface{})
+	if !ok {
+		return errors.ValidationErrorInvalidSyntax
+	}
+	for _, s := range schemas {
+		uri, ok := s.(string)
+		if !ok {
+			return errors.ValidationErrorInvalidSyntax
+		}
+		if _, ok := t.extension(uri); !ok && !strings.EqualFold(uri, t.Schema.ID) {
+			return errors.ValidationErrorInvalidValue
+		}
+	}
+	return errors.ValidationErrorNil
+}
+
+// withoutReadOnly returns a copy of given decoded resource without the values of read-only attributes, including those
+// of schema extensions. It reports whether any values were left out.
+func (t ResourceType) withoutReadOnly(m map[string]interface{}) (map[string]interface{}, bool) {
+	result, removed := t.Schema.WithoutReadOnly(m)
+	for _, extension := range t.SchemaExtensions {
+		key := keyFold(result, extension.Schema.ID)
+		values, ok := result[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var r bool
+		result[key], r = extension.Schema.WithoutReadOnly(values)
+		removed = removed || r
+	}
+	return result, removed
 }
 
 func (t ResourceType) getRaw() map[string]interface{} {
@@ -102,18 +219,33 @@ func (t ResourceType) getRawSchemaExtensions() []map[string]interface{} {
 	return schemas
 }
 
-// validatePatch parse and validate PATCH request
-func (t ResourceType) validatePatch(r *http.Request) (PatchRequest, errors.ValidationError) {
+// lookupAttribute returns the (non-complex) attribute with given path, e.g. "userName", "name.familyName" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber". It returns nil if the resource type has no
+// such attribute.
+func (t ResourceType) lookupAttribute(path string) *schema.CoreAttribute {
+	attribute, ok := t.SchemaSet().Attribute(path)
+	if !ok || attribute.Type().String() == "complex" {
+		return nil
+	}
+	return &attribute
+}
+
+// validatePatch parse and validate given PATCH request body. If coerce is true, string-encoded operation values are converted to
+// the native type of the attribute they target before validation.
+func (t ResourceType) validatePatch(data []byte, coerce bool) (PatchRequest, errors.ValidationError) {
 	var req PatchRequest
 
-	data, _ := ioutil.ReadAll(r.Body)
 	jsonErr := json.Unmarshal(data, &req)
 
 	if jsonErr != nil {
 		return req, errors.ValidationErrorInvalidSyntax
 	}
+	return t.validatePatchOperations(req, coerce)
+}
 
-	// Error causes are currently unused but could be logged or perhaps used to build a more detailed error message.
+// validatePatchOperations validates the operations of given decoded PATCH request.
+func (t ResourceType) validatePatchOperations(req PatchRequest, coerce bool) (PatchRequest, errors.ValidationError) {
+	// Error causes are only described to clients with verbose errors, see patchOperationsValidationCause.
 	errorCauses := make([]string, 0)
 
 	// The body of an HTTP PATCH request MUST contain the attribute "Operations",
@@ -122,7 +254,13 @@ func (t ResourceType) validatePatch(r *http.Request) (PatchRequest, errors.Valid
 		return req, errors.ValidationErrorInvalidValue
 	}
 
-	for _, op := range req.Operations {
+	for i, op := range req.Operations {
+		// Operation names are case insensitive, e.g. Azure AD sends "Replace".
+		op.Op = strings.ToLower(op.Op)
+		if coerce {
+			op = t.coerceOperationValue(op)
+		}
+		req.Operations[i] = op
 		errorCauses = append(errorCauses, t.validateOperation(op)...)
 	}
 
@@ -131,7 +269,12 @@ func (t ResourceType) validatePatch(r *http.Request) (PatchRequest, errors.Valid
 		return req, errors.ValidationErrorInvalidSyntax
 	}
 
-	return req, errors.ValidationErrorNil
+	// Handlers receive the attribute names as defined by the schemas, regardless of the casing of the client.
+	for i, op := range req.Operations {
+		req.Operations[i] = t.canonicalOperation(op)
+	}
+
+	return t.transformOperations(req)
 }
 
 func (t ResourceType) validateOperation(op PatchOperation) []string {
@@ -171,16 +314,162 @@ func (t ResourceType) validateOperation(op PatchOperation) []string {
 }
 
 func (t ResourceType) validateOperationValue(op PatchOperation) errors.ValidationError {
-	// Not attempting to validate value or path if it is a filter based path.
-	// Perhaps we could at least validate the ComparePath
-	if op.GetPathFilter() != nil {
-		return errors.ValidationErrorNil
+	path, err := op.ParsePath()
+	if err != nil {
+		return errors.ValidationErrorInvalidSyntax
 	}
 
-	mapValue, ok := op.Value.(map[string]interface{})
-	if !ok {
-		mapValue = map[string]interface{}{op.Path: op.Value}
+	var mapValue map[string]interface{}
+	switch {
+	case op.Path == "":
+		var ok bool
+		if mapValue, ok = op.Value.(map[string]interface{}); !ok {
+			return errors.ValidationErrorInvalidValue
+		}
+	case path.URI != "" && !strings.EqualFold(path.URI, t.Schema.ID):
+		if op.Op == PatchOperationRemove && t.requiredExtensionAttribute(path) {
+			return errors.ValidationErrorInvalidValue
+		}
+		return t.validateOperationTarget(op.Op, path)
+	case path.ValueFilter != nil:
+		if scimErr := t.validateOperationTarget(op.Op, path); scimErr != errors.ValidationErrorNil || path.SubAttribute == "" {
+			return scimErr
+		}
+		fallthrough
+	default:
+		// Sub-attributes are validated by their path, e.g. "name.givenName".
+		mapValue = map[string]interface{}{PatchPath{AttributeName: path.AttributeName, SubAttribute: path.SubAttribute}.attributePath(): op.Value}
+	}
+	mapValue, scimErr := withoutExternalID(op.Op, mapValue)
+	if scimErr != errors.ValidationErrorNil || len(mapValue) == 0 {
+		return scimErr
 	}
 
 	return t.Schema.ValidatePatchOperationValue(op.Op, mapValue)
 }
+
+// requiredExtensionAttribute reports whether given path refers to a required attribute of a required schema extension
+// as a whole, i.e. without value filter or sub-attribute.
+func (t ResourceType) requiredExtensionAttribute(path PatchPath) bool {
+	if path.ValueFilter != nil || path.SubAttribute != "" {
+		return false
+	}
+	for _, extension := range t.SchemaExtensions {
+		if !extension.Required || !strings.EqualFold(extension.Schema.ID, path.URI) {
+			continue
+		}
+		attribute, ok := t.SchemaSet().attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName})
+		return ok && attribute.Required()
+	}
+	return false
+}
+
+// validateOperationTarget validates that the (sub-)attribute that is targeted by given path exists and can be
+// modified by given operation. A value filter must target a multi-valued attribute. The value of the operation is not
+// validated.
+func (t ResourceType) validateOperationTarget(op string, path PatchPath) errors.ValidationError {
+	schemaSet := t.SchemaSet()
+	parent, ok := schemaSet.attribute(AttributePath{URI: path.URI, AttributeName: path.AttributeName})
+	if !ok || (path.ValueFilter != nil && !(parent.MultiValued() && validValueFilter(parent, path.ValueFilter))) {
+		return errors.ValidationErrorInvalidValue
+	}
+	attributes := []schema.CoreAttribute{parent}
+	if path.SubAttribute != "" {
+		attribute, ok := schemaSet.attribute(path)
+		if !ok {
+			return errors.ValidationErrorInvalidValue
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	for _, attribute := range attributes {
+		switch attribute.Mutability() {
+		case schema.AttributeMutabilityReadOnly():
+			return errors.ValidationErrorInvalidValue
+		case schema.AttributeMutabilityImmutable():
+			if op != PatchOperationAdd {
+				return errors.ValidationErrorInvalidValue
+			}
+		}
+	}
+	return errors.ValidationErrorNil
+}
+
+// validValueFilter reports whether the attributes in given value filter are sub-attributes of given attribute.
+func validValueFilter(attribute schema.CoreAttribute, expression filter.Expression) bool {
+	switch e := expression.(type) {
+	case filter.BinaryExpression:
+		return validValueFilter(attribute, e.X) && validValueFilter(attribute, e.Y)
+	case filter.UnaryExpression:
+		return validValueFilter(attribute, e.X)
+	case filter.AttributeExpression:
+		if e.AttributePath.URIPrefix != "" || e.AttributePath.SubAttribute != "" {
+			return false
+		}
+		_, ok := attribute.SubAttribute(e.AttributePath.AttributeName)
+		return ok
+	default:
+		return false
+	}
+}
+
+// coerceOperationValue converts the string-encoded booleans and numbers within the value of given operation, using
+// the schema of the core attributes or of the extension that the path refers to. The values of operations without a
+// path may contain the attributes of extensions either under the URI of their extension or prefixed with it.
+func (t ResourceType) coerceOperationValue(op PatchOperation) PatchOperation {
+	if op.Path == "" {
+		if mapValue, ok := op.Value.(map[string]interface{}); ok {
+			op.Value = t.coerceAttributes(mapValue)
+		}
+		return op
+	}
+
+	path, err := op.ParsePath()
+	if err != nil {
+		return op
+	}
+	s := t.Schema
+	if path.URI != "" && !strings.EqualFold(path.URI, t.Schema.ID) {
+		extension, ok := t.extensionType(path.URI)
+		if !ok {
+			return op
+		}
+		s = extension.Schema
+	}
+	// The value of a path with a value filter but without a sub-attribute is a value of the multi-valued attribute.
+	op.Value = coerceAttributeValue(s, path.AttributeName, path.SubAttribute, op.Value)
+	return op
+}
+
+// coerceAttributes converts the string-encoded booleans and numbers within given attributes of a resource.
+func (t ResourceType) coerceAttributes(attributes map[string]interface{}) map[string]interface{} {
+	coerced := t.Schema.Coerce(attributes)
+	for k, v := range coerced {
+		if uri, name := t.extensionPath(k); uri != "" {
+			extension, _ := t.extensionType(uri)
+			attributeName, subAttribute := name, ""
+			if i := strings.Index(name, "."); i != -1 {
+				attributeName, subAttribute = name[:i], name[i+1:]
+			}
+			coerced[k] = coerceAttributeValue(extension.Schema, attributeName, subAttribute, v)
+			continue
+		}
+		if values, ok := v.(map[string]interface{}); ok {
+			if extension, ok := t.extensionType(k); ok {
+				coerced[k] = extension.Schema.Coerce(values)
+			}
+		}
+	}
+	return coerced
+}
+
+// coerceAttributeValue converts the string-encoded booleans and numbers within given value of the attribute of given
+// schema with given name and, if not empty, sub-attribute.
+func coerceAttributeValue(s schema.Schema, name, subAttribute string, value interface{}) interface{} {
+	if subAttribute == "" {
+		return s.Coerce(map[string]interface{}{name: value})[name]
+	}
+	value = map[string]interface{}{subAttribute: value}
+	coerced, _ := s.Coerce(map[string]interface{}{name: value})[name].(map[string]interface{})
+	return coerced[subAttribute]
+}
@@ -0,0 +1,122 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// trimmedAttribute is a multi-valued attribute that is excluded from a resource that exceeds the maximum resource size.
+type trimmedAttribute struct {
+	// extension is the URI of the schema extension that contains the attribute, or empty for core attributes.
+	extension string
+	name      string
+	size      int
+}
+
+// path returns the attribute path of the attribute, e.g. "members" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager".
+func (a trimmedAttribute) path() string {
+	if a.extension == "" {
+		return a.name
+	}
+	return a.extension + ":" + a.name
+}
+
+// trimResource excludes the largest multi-valued attributes of given serialized resource until its size does not exceed
+// the maximum resource size of the server. It returns the (possibly trimmed) resource and the paths of the excluded
+// attributes. The given resource is not modified.
+func (s Server) trimResource(resource ResourceAttributes) (ResourceAttributes, []string) {
+	if s.MaxResourceSize <= 0 {
+		return resource, nil
+	}
+	raw, err := json.Marshal(resource)
+	if err != nil || len(raw) <= s.MaxResourceSize {
+		return resource, nil
+	}
+
+	var candidates []trimmedAttribute
+	for k, v := range resource {
+		switch v := v.(type) {
+		case []interface{}, []map[string]interface{}:
+			if !strings.EqualFold(k, "schemas") {
+				candidates = append(candidates, trimmedAttribute{name: k, size: jsonSize(v)})
+			}
+		case map[string]interface{}:
+			if !strings.Contains(k, ":") {
+				continue
+			}
+			for name, value := range v {
+				switch value.(type) {
+				case []interface{}, []map[string]interface{}:
+					candidates = append(candidates, trimmedAttribute{extension: k, name: name, size: jsonSize(value)})
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].size != candidates[j].size {
+			return candidates[i].size > candidates[j].size
+		}
+		return candidates[i].path() < candidates[j].path()
+	})
+
+	trimmed := make(ResourceAttributes, len(resource))
+	for k, v := range resource {
+		trimmed[k] = v
+	}
+	size := len(raw)
+	var excluded []string
+	for _, attribute := range candidates {
+		if size <= s.MaxResourceSize {
+			break
+		}
+		if attribute.extension == "" {
+			delete(trimmed, attribute.name)
+		} else {
+			extension := make(map[string]interface{})
+			for k, v := range trimmed[attribute.extension].(map[string]interface{}) {
+				if k != attribute.name {
+					extension[k] = v
+				}
+			}
+			trimmed[attribute.extension] = extension
+		}
+		size -= attribute.size
+		excluded = append(excluded, attribute.path())
+	}
+	return trimmed, excluded
+}
+
+// appendMissing appends the given values that are not yet in given list.
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// jsonSize returns the size of the JSON encoding of given value.
+func jsonSize(v interface{}) int {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(raw)
+}
+
+// setTrimmedWarning adds a "Warning" header to the response that lists the attributes that were excluded because the
+// returned resources exceed the maximum resource size, if any.
+func setTrimmedWarning(w http.ResponseWriter, excluded []string) {
+	if len(excluded) == 0 {
+		return
+	}
+	w.Header().Add("Warning", fmt.Sprintf(
+		"199 - %q",
+		fmt.Sprintf("The attributes %s were excluded since the resource exceeds the maximum size.", strings.Join(excluded, ", ")),
+	))
+}
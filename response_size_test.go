@@ -0,0 +1,59 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerMaxResourceSize(t *testing.T) {
+	server := newTestServer()
+	server.MaxResourceSize = 512
+
+	var emails []string
+	for i := 0; i < 20; i++ {
+		emails = append(emails, fmt.Sprintf(`{"value": "test%d@example.com"}`, i))
+	}
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(
+		`{"userName": "test", "emails": [`+strings.Join(emails, ",")+`]}`,
+	)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if rr.Body.Len() > server.MaxResourceSize {
+		t.Errorf("expected a resource of at most %d bytes, got %d", server.MaxResourceSize, rr.Body.Len())
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resource["emails"]; ok {
+		t.Error("expected the emails to be excluded")
+	}
+	if resource["userName"] != "test" {
+		t.Errorf("expected the user name to be kept, got %v", resource["userName"])
+	}
+	if warning := rr.Header().Get("Warning"); !strings.HasPrefix(warning, "199 - ") || !strings.Contains(warning, "emails") {
+		t.Errorf("expected a warning about the excluded emails, got %q", warning)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+	if warning := rr.Header().Get("Warning"); warning != "" {
+		t.Errorf("expected no warning for a small resource, got %q", warning)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?count=100", nil))
+	if strings.Contains(rr.Body.String(), "test0@example.com") {
+		t.Error("expected the emails to be excluded from the list response")
+	}
+	if warning := rr.Header().Get("Warning"); !strings.Contains(warning, "emails") {
+		t.Errorf("expected a warning about the excluded emails, got %q", warning)
+	}
+}
@@ -0,0 +1,112 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// reservedEndpoints are the endpoints that the server serves itself. Resource types can not use them, nor endpoints
+// below them.
+var reservedEndpoints = []string{"/Schemas", "/ResourceTypes", "/ServiceProviderConfig", "/Bulk", "/.search"}
+
+// RouteConflict is a conflict between the routes of a server that causes requests to be routed to another handler than
+// intended, e.g. two resource types with the same endpoint.
+type RouteConflict struct {
+	// ResourceType is the name of the resource type whose requests are (partly) routed elsewhere.
+	ResourceType string
+	// Endpoint is the endpoint of the resource type.
+	Endpoint string
+	// Message describes the conflict.
+	Message string
+}
+
+// Error returns a description of the conflict.
+func (c RouteConflict) Error() string {
+	return fmt.Sprintf("resource type %q (%s): %s", c.ResourceType, c.Endpoint, c.Message)
+}
+
+// RouteConflicts returns the conflicts between the routes of the resource types of the server, in the order of the
+// resource types. The server routes a request to the first resource type whose endpoint matches, so conflicts do not
+// cause errors, but silently route requests to the wrong resource type. A server should have no conflicts; they are
+// reported by SelfCheck as well.
+//
+// Conflicts are invalid endpoints, endpoints that are claimed by multiple resource types, endpoints that are nested in
+// the endpoint of another resource type (e.g. "/Users/Admins" below "/Users"), endpoints that are served by the server
+// itself (e.g. "/Schemas") and resource types with the same name, which are indistinguishable in the "/ResourceTypes"
+// endpoint.
+func (s Server) RouteConflicts() []RouteConflict {
+	var conflicts []RouteConflict
+	for i, resourceType := range s.ResourceTypes {
+		conflict := func(format string, args ...interface{}) {
+			conflicts = append(conflicts, RouteConflict{
+				ResourceType: resourceType.Name,
+				Endpoint:     resourceType.Endpoint,
+				Message:      fmt.Sprintf(format, args...),
+			})
+		}
+
+		endpoint := resourceType.Endpoint
+		if !strings.HasPrefix(endpoint, "/") || strings.HasSuffix(endpoint, "/") || strings.ContainsAny(endpoint, "?#") {
+			conflict("the endpoint must start with a slash and can not end with one or contain a query or fragment")
+			continue
+		}
+		for _, reserved := range reservedEndpoints {
+			if routesTo(endpoint, reserved) {
+				conflict("the endpoint is served by the server itself (%s)", reserved)
+			}
+		}
+		if routesTo(endpoint, "/v2") {
+			conflict("the /v2 prefix is stripped from the paths of requests, so the endpoint is unreachable")
+		}
+		if s.SCIM11Compatibility && routesTo(endpoint, "/v1") {
+			conflict("the endpoint is served by the SCIM 1.1 compatibility layer")
+		}
+		for _, other := range s.ResourceTypes[:i] {
+			switch {
+			case other.Endpoint == endpoint:
+				conflict("the endpoint is already claimed by resource type %q, which receives all its requests", other.Name)
+			case routesTo(endpoint, other.Endpoint):
+				conflict("the endpoint is nested in the endpoint of resource type %q, which receives all its requests", other.Name)
+			case routesTo(other.Endpoint, endpoint):
+				conflict("the endpoint of resource type %q is nested in the endpoint, resources with identifier %q are unreachable", other.Name, strings.TrimPrefix(other.Endpoint, endpoint+"/"))
+			}
+			if strings.EqualFold(other.Name, resourceType.Name) {
+				conflict("the name is already used by the resource type with endpoint %s", other.Endpoint)
+			}
+		}
+	}
+	return conflicts
+}
+
+// ResourceTypeFor returns the resource type that handles the requests with given path, e.g. "/Users" or
+// "/scim/v2/Users/2819c223-7f76-453a-919d-413861904646". The path includes the base path of the server, if any. It
+// returns false if the path is not addressed to the endpoint of one of the resource types, e.g. "/Schemas".
+func (s Server) ResourceTypeFor(path string) (ResourceType, bool) {
+	r, ok := s.trimBasePath(&http.Request{URL: &url.URL{Path: path}})
+	if !ok {
+		return ResourceType{}, false
+	}
+	path = r.URL.Path
+	if s.SCIM11Compatibility && isSCIM11Request(r) {
+		path = strings.TrimPrefix(path, "/v1")
+	}
+	path = strings.TrimPrefix(path, "/v2")
+	for _, reserved := range reservedEndpoints {
+		if routesTo(path, reserved) {
+			return ResourceType{}, false
+		}
+	}
+	for _, resourceType := range s.ResourceTypes {
+		if routesTo(path, resourceType.Endpoint) {
+			return resourceType, true
+		}
+	}
+	return ResourceType{}, false
+}
+
+// routesTo reports whether requests with given path are routed to given endpoint or one of its sub-paths.
+func routesTo(path, endpoint string) bool {
+	return path == endpoint || strings.HasPrefix(path, endpoint+"/")
+}
@@ -0,0 +1,83 @@
+package scim
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestServerRouteConflicts(t *testing.T) {
+	if conflicts := newTestServer().RouteConflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+
+	for _, test := range []struct {
+		name     string
+		endpoint string
+		scim11   bool
+		expected string
+	}{
+		{"Group", "/Groups", false, ""},
+		{"Group", "/Users", false, `resource type "Group" (/Users): the endpoint is already claimed by resource type "User", which receives all its requests`},
+		{"Group", "/Users/Admins", false, `resource type "Group" (/Users/Admins): the endpoint is nested in the endpoint of resource type "User", which receives all its requests`},
+		{"Group", "/Schemas", false, `resource type "Group" (/Schemas): the endpoint is served by the server itself (/Schemas)`},
+		{"Group", "Groups", false, `resource type "Group" (Groups): the endpoint must start with a slash and can not end with one or contain a query or fragment`},
+		{"Group", "/Groups/", false, `resource type "Group" (/Groups/): the endpoint must start with a slash and can not end with one or contain a query or fragment`},
+		{"Group", "/v2/Groups", false, `resource type "Group" (/v2/Groups): the /v2 prefix is stripped from the paths of requests, so the endpoint is unreachable`},
+		{"Group", "/v1/Groups", false, ""},
+		{"Group", "/v1/Groups", true, `resource type "Group" (/v1/Groups): the endpoint is served by the SCIM 1.1 compatibility layer`},
+		{"user", "/People", false, `resource type "user" (/People): the name is already used by the resource type with endpoint /Users`},
+	} {
+		server := newTestServer()
+		server.SCIM11Compatibility = test.scim11
+		server.ResourceTypes = append(server.ResourceTypes, ResourceType{
+			Name:     test.name,
+			Endpoint: test.endpoint,
+			Schema:   schema.CoreGroupSchema(),
+			Handler:  newTestResourceHandler(),
+		})
+		var messages []string
+		for _, conflict := range server.RouteConflicts() {
+			messages = append(messages, conflict.Error())
+		}
+		if message := strings.Join(messages, "; "); message != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.endpoint, test.expected, message)
+		}
+	}
+
+	server := newTestServer()
+	server.ResourceTypes = append([]ResourceType{{Name: "Admin", Endpoint: "/Users/Admins"}}, server.ResourceTypes...)
+	conflicts := server.RouteConflicts()
+	if len(conflicts) != 1 || conflicts[0].ResourceType != "User" ||
+		conflicts[0].Message != `the endpoint of resource type "Admin" is nested in the endpoint, resources with identifier "Admins" are unreachable` {
+		t.Errorf("unexpected conflicts: %v", conflicts)
+	}
+	report := server.SelfCheck(context.Background())
+	if report.Checks[0].Name != "routes" || report.Checks[0].Status != SelfCheckFailed {
+		t.Errorf("expected the routes check to fail, got %+v", report.Checks[0])
+	}
+}
+
+func TestServerResourceTypeFor(t *testing.T) {
+	server := newTestServer()
+	server.BasePath = "/scim"
+	for _, test := range []struct {
+		path     string
+		expected string
+	}{
+		{"/scim/Users", "User"},
+		{"/scim/v2/Users/0001", "User"},
+		{"/scim/Users/.search", "User"},
+		{"/scim/EnterpriseUser/0001", "EnterpriseUser"},
+		{"/scim/UsersAndMore", ""},
+		{"/scim/Schemas", ""},
+		{"/Users", ""},
+	} {
+		resourceType, ok := server.ResourceTypeFor(test.path)
+		if ok != (test.expected != "") || resourceType.Name != test.expected {
+			t.Errorf("%s: expected resource type %q, got %q (%t)", test.path, test.expected, resourceType.Name, ok)
+		}
+	}
+}
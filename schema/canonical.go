@@ -0,0 +1,48 @@
+package schema
+
+// CanonicalKeys returns a copy of given attributes whose keys, including the keys of the sub-attributes of complex
+// values, are the names of the attributes as defined by the schema, e.g. "userName" for "USERNAME". Keys of unknown
+// attributes are kept as is.
+func (s Schema) CanonicalKeys(attributes map[string]interface{}) map[string]interface{} {
+	if attributes == nil {
+		return nil
+	}
+	canonical := make(map[string]interface{}, len(attributes))
+	for k, v := range attributes {
+		attribute, ok := s.Attribute(k)
+		if !ok {
+			canonical[k] = v
+			continue
+		}
+		canonical[attribute.name] = attribute.CanonicalKeys(v)
+	}
+	return canonical
+}
+
+// CanonicalKeys returns a copy of given value of the attribute whose keys are the names of the sub-attributes as
+// defined by the schema. Values of attributes that are not complex are returned as is.
+func (a CoreAttribute) CanonicalKeys(value interface{}) interface{} {
+	if a.typ != attributeDataTypeComplex {
+		return value
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		canonical := make([]interface{}, len(v))
+		for i, e := range v {
+			canonical[i] = a.CanonicalKeys(e)
+		}
+		return canonical
+	case map[string]interface{}:
+		canonical := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			name := k
+			if sub, ok := a.SubAttribute(k); ok {
+				name = sub.name
+			}
+			canonical[name] = e
+		}
+		return canonical
+	default:
+		return value
+	}
+}
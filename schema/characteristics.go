@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 func checkAttributeName(name string) {
+	// "$ref" is a reserved sub-attribute name used to reference other resources.
+	if name == "$ref" {
+		return
+	}
+
 	// starts w/ a A-Za-z followed by a A-Za-z0-9, a dollar sign, a hyphen or an underscore
 	match, err := regexp.MatchString(`^[A-Za-z][\w$-]*$`, name)
 	if err != nil {
@@ -70,6 +76,25 @@ func (a attributeMutability) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// MarshalJSON returns the keyword of the mutability, e.g. "readOnly".
+func (m AttributeMutability) MarshalJSON() ([]byte, error) {
+	return m.m.MarshalJSON()
+}
+
+// UnmarshalJSON sets the mutability to the one with given keyword, e.g. "readOnly". Keywords are case-insensitive.
+func (m *AttributeMutability) UnmarshalJSON(data []byte) error {
+	keyword, err := unmarshalKeyword(data)
+	if err != nil || keyword == nil {
+		return err
+	}
+	mutability, ok := mutabilities[strings.ToLower(*keyword)]
+	if !ok {
+		return fmt.Errorf("invalid mutability %q", *keyword)
+	}
+	*m = mutability
+	return nil
+}
+
 // AttributeReferenceType is a single keyword indicating the reference type of the SCIM resource that may be referenced.
 // This attribute is only applicable for attributes that are of type "reference".
 type AttributeReferenceType string
@@ -131,13 +156,33 @@ func (a attributeReturned) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// MarshalJSON returns the keyword of the returned characteristic, e.g. "always".
+func (r AttributeReturned) MarshalJSON() ([]byte, error) {
+	return r.r.MarshalJSON()
+}
+
+// UnmarshalJSON sets the returned characteristic to the one with given keyword, e.g. "always". Keywords are
+// case-insensitive.
+func (r *AttributeReturned) UnmarshalJSON(data []byte) error {
+	keyword, err := unmarshalKeyword(data)
+	if err != nil || keyword == nil {
+		return err
+	}
+	returned, ok := returnedValues[strings.ToLower(*keyword)]
+	if !ok {
+		return fmt.Errorf("invalid returned %q", *keyword)
+	}
+	*r = returned
+	return nil
+}
+
 // AttributeDataType is a single keyword indicating the derived data type from JSON.
 type AttributeDataType struct {
 	t attributeType
 }
 
 // AttributeTypeDecimal indicates that the data type is a real number with at least one digit to the left and right of the period.
-// This is the default value.
+// This is the default value for number attributes, which is also used when the data type is not specified.
 func AttributeTypeDecimal() AttributeDataType {
 	return AttributeDataType{t: attributeDataTypeDecimal}
 }
@@ -150,7 +195,10 @@ func AttributeTypeInteger() AttributeDataType {
 type attributeType int
 
 const (
-	attributeDataTypeDecimal attributeType = iota
+	// attributeDataTypeUnspecified is the zero value of the data type. It is not a valid data type on its own and needs
+	// to be resolved (e.g. to a decimal for number attributes) before creating an attribute.
+	attributeDataTypeUnspecified attributeType = iota
+	attributeDataTypeDecimal
 	attributeDataTypeInteger
 
 	attributeDataTypeBinary
@@ -161,24 +209,66 @@ const (
 	attributeDataTypeString
 )
 
-func (a attributeType) MarshalJSON() ([]byte, error) {
+// String returns the name of the data type as defined in RFC 7643, e.g. "string" or "dateTime".
+func (t AttributeDataType) String() string {
+	return t.t.String()
+}
+
+func (a attributeType) String() string {
 	switch a {
 	case attributeDataTypeDecimal:
-		return json.Marshal("decimal")
+		return "decimal"
 	case attributeDataTypeInteger:
-		return json.Marshal("integer")
+		return "integer"
 	case attributeDataTypeBinary:
-		return json.Marshal("binary")
+		return "binary"
 	case attributeDataTypeBoolean:
-		return json.Marshal("boolean")
+		return "boolean"
 	case attributeDataTypeComplex:
-		return json.Marshal("complex")
+		return "complex"
 	case attributeDataTypeDateTime:
-		return json.Marshal("dateTime")
+		return "dateTime"
 	case attributeDataTypeReference:
-		return json.Marshal("reference")
+		return "reference"
+	case attributeDataTypeString:
+		return "string"
 	default:
-		return json.Marshal("string")
+		return ""
+	}
+}
+
+func (a attributeType) MarshalJSON() ([]byte, error) {
+	s := a.String()
+	if s == "" {
+		return nil, fmt.Errorf("unknown attribute data type %d", a)
+	}
+	return json.Marshal(s)
+}
+
+// MarshalJSON returns the name of the data type, e.g. "dateTime". It returns an error for the zero value, which is not
+// a data type.
+func (t AttributeDataType) MarshalJSON() ([]byte, error) {
+	return t.t.MarshalJSON()
+}
+
+// UnmarshalJSON sets the data type to the one with given name, e.g. "dateTime". Names are case-insensitive.
+func (t *AttributeDataType) UnmarshalJSON(data []byte) error {
+	name, err := unmarshalKeyword(data)
+	if err != nil || name == nil {
+		return err
+	}
+	typ, ok := dataTypes[strings.ToLower(*name)]
+	if !ok {
+		return fmt.Errorf("invalid type %q", *name)
+	}
+	t.t = typ
+	return nil
+}
+
+// checkAttributeType panics if given data type is not one of the data types defined in RFC 7643.
+func checkAttributeType(name string, typ attributeType) {
+	if typ < attributeDataTypeDecimal || typ > attributeDataTypeString {
+		panic(fmt.Sprintf("invalid data type %d for attribute %q", typ, name))
 	}
 }
 
@@ -223,3 +313,66 @@ func (a attributeUniqueness) MarshalJSON() ([]byte, error) {
 		return json.Marshal("none")
 	}
 }
+
+// MarshalJSON returns the keyword of the uniqueness, e.g. "server".
+func (u AttributeUniqueness) MarshalJSON() ([]byte, error) {
+	return u.u.MarshalJSON()
+}
+
+// UnmarshalJSON sets the uniqueness to the one with given keyword, e.g. "server". Keywords are case-insensitive.
+func (u *AttributeUniqueness) UnmarshalJSON(data []byte) error {
+	keyword, err := unmarshalKeyword(data)
+	if err != nil || keyword == nil {
+		return err
+	}
+	uniqueness, ok := uniquenesses[strings.ToLower(*keyword)]
+	if !ok {
+		return fmt.Errorf("invalid uniqueness %q", *keyword)
+	}
+	*u = uniqueness
+	return nil
+}
+
+// unmarshalKeyword returns the keyword of a characteristic in given JSON data. It returns nil for null, which leaves the
+// characteristic unchanged, like the JSON decoder does for other types.
+func unmarshalKeyword(data []byte) (*string, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+	var keyword string
+	if err := json.Unmarshal(data, &keyword); err != nil {
+		return nil, err
+	}
+	return &keyword, nil
+}
+
+// The characteristics of attributes by their (lowercase) keywords in the representation of a schema.
+var (
+	dataTypes = map[string]attributeType{
+		"binary":    attributeDataTypeBinary,
+		"boolean":   attributeDataTypeBoolean,
+		"complex":   attributeDataTypeComplex,
+		"datetime":  attributeDataTypeDateTime,
+		"decimal":   attributeDataTypeDecimal,
+		"integer":   attributeDataTypeInteger,
+		"reference": attributeDataTypeReference,
+		"string":    attributeDataTypeString,
+	}
+	mutabilities = map[string]AttributeMutability{
+		"immutable": AttributeMutabilityImmutable(),
+		"readonly":  AttributeMutabilityReadOnly(),
+		"readwrite": AttributeMutabilityReadWrite(),
+		"writeonly": AttributeMutabilityWriteOnly(),
+	}
+	returnedValues = map[string]AttributeReturned{
+		"always":  AttributeReturnedAlways(),
+		"default": AttributeReturnedDefault(),
+		"never":   AttributeReturnedNever(),
+		"request": AttributeReturnedRequest(),
+	}
+	uniquenesses = map[string]AttributeUniqueness{
+		"global": AttributeUniquenessGlobal(),
+		"none":   AttributeUniquenessNone(),
+		"server": AttributeUniquenessServer(),
+	}
+)
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCharacteristicsJSON(t *testing.T) {
+	type characteristics struct {
+		Type       AttributeDataType   `json:"type"`
+		Mutability AttributeMutability `json:"mutability"`
+		Returned   AttributeReturned   `json:"returned"`
+		Uniqueness AttributeUniqueness `json:"uniqueness"`
+	}
+	for _, expected := range []characteristics{
+		{AttributeDataType{t: attributeDataTypeString}, AttributeMutabilityReadWrite(), AttributeReturnedDefault(), AttributeUniquenessNone()},
+		{AttributeDataType{t: attributeDataTypeDateTime}, AttributeMutabilityReadOnly(), AttributeReturnedAlways(), AttributeUniquenessServer()},
+		{AttributeTypeInteger(), AttributeMutabilityImmutable(), AttributeReturnedNever(), AttributeUniquenessGlobal()},
+		{AttributeTypeDecimal(), AttributeMutabilityWriteOnly(), AttributeReturnedRequest(), AttributeUniquenessNone()},
+	} {
+		data, err := json.Marshal(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual characteristics
+		if err := json.Unmarshal(data, &actual); err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %+v, got %+v", data, expected, actual)
+		}
+	}
+
+	var actual characteristics
+	if err := json.Unmarshal([]byte(`{"type": "DATETIME", "mutability": "readonly", "returned": null}`), &actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Type.String() != "dateTime" || actual.Mutability != AttributeMutabilityReadOnly() || actual.Returned != AttributeReturnedDefault() {
+		t.Errorf("unexpected characteristics %+v", actual)
+	}
+
+	for data, expected := range map[string]string{
+		`{"type": "text"}`:           `invalid type "text"`,
+		`{"mutability": "writable"}`: `invalid mutability "writable"`,
+		`{"returned": "sometimes"}`:  `invalid returned "sometimes"`,
+		`{"uniqueness": "tenant"}`:   `invalid uniqueness "tenant"`,
+		`{"uniqueness": true}`:       "cannot unmarshal bool",
+	} {
+		if err := json.Unmarshal([]byte(data), &actual); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", data, expected, err)
+		}
+	}
+
+	if _, err := json.Marshal(AttributeDataType{}); err == nil {
+		t.Error("expected an error for the zero data type")
+	}
+}
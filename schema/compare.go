@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ValuesEqual reports whether given values of the attribute are equal according to its characteristics, the same way
+// the server compares them: strings and references are compared case-insensitively unless the attribute is case
+// exact, numbers by their value, date times by the instant they represent and complex values by their sub-attributes.
+// Two arrays of values of a multi-valued attribute are equal if they hold the same values, regardless of their order.
+// Absent and null values are equal.
+func (a CoreAttribute) ValuesEqual(x, y interface{}) bool {
+	xs, xok := x.([]interface{})
+	ys, yok := y.([]interface{})
+	if a.multiValued && xok && yok {
+		return a.sameValues(xs, ys)
+	}
+	return a.valueEqual(x, y)
+}
+
+// sameValues reports whether given arrays contain the same values, regardless of their order.
+func (a CoreAttribute) sameValues(xs, ys []interface{}) bool {
+	if len(xs) != len(ys) {
+		return false
+	}
+	matched := make([]bool, len(ys))
+outer:
+	for _, x := range xs {
+		for i, y := range ys {
+			if !matched[i] && a.valueEqual(x, y) {
+				matched[i] = true
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// valueEqual compares a single value of the attribute.
+func (a CoreAttribute) valueEqual(x, y interface{}) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+
+	switch a.typ {
+	case attributeDataTypeString, attributeDataTypeReference, attributeDataTypeBinary:
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		if !xok || !yok {
+			break
+		}
+		if a.caseExact {
+			return xs == ys
+		}
+		return strings.EqualFold(xs, ys)
+	case attributeDataTypeDateTime:
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		if !xok || !yok {
+			break
+		}
+		xt, xerr := time.Parse(time.RFC3339, xs)
+		yt, yerr := time.Parse(time.RFC3339, ys)
+		if xerr != nil || yerr != nil {
+			return xs == ys
+		}
+		return xt.Equal(yt)
+	case attributeDataTypeDecimal, attributeDataTypeInteger:
+		xf, xok := toFloat(x)
+		yf, yok := toFloat(y)
+		if !xok || !yok {
+			break
+		}
+		return xf == yf
+	case attributeDataTypeComplex:
+		xm, xok := x.(map[string]interface{})
+		ym, yok := y.(map[string]interface{})
+		if !xok || !yok {
+			break
+		}
+		return a.complexEqual(xm, ym)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// complexEqual compares two complex values by their sub-attributes. Sub-attribute names are compared
+// case-insensitively, unknown sub-attributes must be deeply equal.
+func (a CoreAttribute) complexEqual(x, y map[string]interface{}) bool {
+	keys := make(map[string]struct{}, len(x)+len(y))
+	for k := range x {
+		keys[strings.ToLower(k)] = struct{}{}
+	}
+	for k := range y {
+		keys[strings.ToLower(k)] = struct{}{}
+	}
+	for k := range keys {
+		xv, yv := lookupFold(x, k), lookupFold(y, k)
+		sub, ok := a.subAttribute(k)
+		if !ok {
+			if !reflect.DeepEqual(xv, yv) {
+				return false
+			}
+			continue
+		}
+		if !sub.ValuesEqual(xv, yv) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a CoreAttribute) subAttribute(name string) (CoreAttribute, bool) {
+	if sub, ok := a.SubAttribute(name); ok {
+		return *sub, true
+	}
+	return CoreAttribute{}, false
+}
+
+func lookupFold(m map[string]interface{}, name string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCoreAttributeValuesEqual(t *testing.T) {
+	emails := ComplexCoreAttribute(ComplexParams{
+		Name:        "emails",
+		MultiValued: true,
+		SubAttributes: []SimpleParams{
+			SimpleStringParams(StringParams{Name: "value"}),
+			SimpleStringParams(StringParams{Name: "type"}),
+			SimpleBooleanParams(BooleanParams{Name: "primary"}),
+		},
+	})
+	for _, test := range []struct {
+		attribute CoreAttribute
+		x, y      interface{}
+		equal     bool
+	}{
+		{SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "userName"})), "bjensen", "BJensen", true},
+		{SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "id", CaseExact: true})), "bjensen", "BJensen", false},
+		{SimpleCoreAttribute(SimpleReferenceParams(ReferenceParams{Name: "profileUrl", ReferenceTypes: []AttributeReferenceType{AttributeReferenceTypeExternal}})), "https://example.com/A", "https://example.com/a", false},
+		{SimpleCoreAttribute(SimpleBinaryParams(BinaryParams{Name: "certificate"})), "YQ==", "yQ==", false},
+		{SimpleCoreAttribute(SimpleNumberParams(NumberParams{Name: "age", Type: AttributeTypeInteger()})), json.Number("42"), float64(42), true},
+		{SimpleCoreAttribute(SimpleDateTimeParams(DateTimeParams{Name: "lastLogin"})), "2020-01-01T12:00:00Z", "2020-01-01T13:00:00+01:00", true},
+		{SimpleCoreAttribute(SimpleBooleanParams(BooleanParams{Name: "active"})), true, false, false},
+		{emails, map[string]interface{}{"value": "A@example.com", "Type": "work"}, map[string]interface{}{"value": "a@example.com", "type": "WORK"}, true},
+		{emails, map[string]interface{}{"value": "a@example.com", "primary": true}, map[string]interface{}{"value": "a@example.com"}, false},
+		{
+			emails,
+			[]interface{}{map[string]interface{}{"value": "a@example.com"}, map[string]interface{}{"value": "b@example.com"}},
+			[]interface{}{map[string]interface{}{"value": "B@example.com"}, map[string]interface{}{"value": "A@example.com"}},
+			true,
+		},
+		{emails, []interface{}{map[string]interface{}{"value": "a@example.com"}}, []interface{}{}, false},
+		{emails, nil, nil, true},
+	} {
+		if equal := test.attribute.ValuesEqual(test.x, test.y); equal != test.equal {
+			t.Errorf("%s: expected %v == %v to be %v", test.attribute.Name(), test.x, test.y, test.equal)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint restricts the combinations of attribute values of a resource, which can not be expressed by the
+// characteristics of the attributes themselves. Attributes are referred to by their name, sub-attributes are prefixed
+// with the name of their parent attribute, e.g., "name.givenName". Names are case insensitive.
+type Constraint struct {
+	exclusive []string
+	required  string
+	condition string
+	value     interface{}
+}
+
+// MutuallyExclusive returns a constraint that allows at most one of given attributes to have a value, e.g. "password"
+// and "federatedOnly".
+func MutuallyExclusive(names ...string) Constraint {
+	return Constraint{exclusive: names}
+}
+
+// RequiredIf returns a constraint that requires the attribute with given name to have a value when the condition
+// attribute has given value, e.g. RequiredIf("manager", "employeeType", "FTE"). String values are compared case
+// insensitively.
+func RequiredIf(name, condition string, value interface{}) Constraint {
+	return Constraint{
+		required:  name,
+		condition: condition,
+		value:     value,
+	}
+}
+
+// Check returns an error that describes how given resource violates the constraint, nil if it does not.
+func (c Constraint) Check(resource map[string]interface{}) error {
+	if c.required != "" {
+		if equalValues(lookupValue(resource, c.condition), c.value) && !assigned(lookupValue(resource, c.required)) {
+			return fmt.Errorf("the attribute %q is required when %q is %v", c.required, c.condition, c.value)
+		}
+		return nil
+	}
+
+	var names []string
+	for _, name := range c.exclusive {
+		if assigned(lookupValue(resource, name)) {
+			names = append(names, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(names) > 1 {
+		return fmt.Errorf("the attributes %s are mutually exclusive", strings.Join(names, " and "))
+	}
+	return nil
+}
+
+// names returns the names of the attributes that are referred to by the constraint.
+func (c Constraint) names() []string {
+	if c.required != "" {
+		return []string{c.required, c.condition}
+	}
+	return c.exclusive
+}
+
+// CheckConstraints checks given resource against the constraints of the schema. It returns the error of the first
+// constraint that is violated, nil if none are.
+func (s Schema) CheckConstraints(resource map[string]interface{}) error {
+	for _, constraint := range s.Constraints {
+		if err := constraint.Check(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupValue returns the value of the (sub-)attribute with given name.
+func lookupValue(resource map[string]interface{}, name string) interface{} {
+	names := strings.SplitN(name, ".", 2)
+	for k, v := range resource {
+		if !strings.EqualFold(k, names[0]) {
+			continue
+		}
+		if len(names) == 1 {
+			return v
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			return lookupValue(sub, names[1])
+		}
+		return nil
+	}
+	return nil
+}
+
+// assigned reports whether given value is assigned, i.e. it is not null or an empty (complex or multi-valued) value.
+func assigned(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		return len(value) != 0
+	case map[string]interface{}:
+		for _, v := range value {
+			if assigned(v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// equalValues reports whether given attribute value equals the expected value.
+func equalValues(value, expected interface{}) bool {
+	if s, ok := value.(string); ok {
+		e, ok := expected.(string)
+		return ok && strings.EqualFold(s, e)
+	}
+	return fmt.Sprint(value) == fmt.Sprint(expected) && value != nil
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	for _, test := range []struct {
+		constraint Constraint
+		resource   map[string]interface{}
+		violated   bool
+	}{
+		{MutuallyExclusive("password", "federatedOnly"), map[string]interface{}{"password": "secret"}, false},
+		{MutuallyExclusive("password", "federatedOnly"), map[string]interface{}{"password": "secret", "federatedOnly": nil}, false},
+		{MutuallyExclusive("password", "federatedOnly"), map[string]interface{}{"Password": "secret", "federatedOnly": true}, true},
+		{MutuallyExclusive("emails", "phoneNumbers"), map[string]interface{}{"emails": []interface{}{}, "phoneNumbers": []interface{}{"1"}}, false},
+		{RequiredIf("manager", "employeeType", "FTE"), map[string]interface{}{"employeeType": "Contractor"}, false},
+		{RequiredIf("manager", "employeeType", "FTE"), map[string]interface{}{"employeeType": "fte"}, true},
+		{RequiredIf("manager.value", "employeeType", "FTE"), map[string]interface{}{
+			"employeeType": "FTE",
+			"manager":      map[string]interface{}{"value": "26118915-6090-4610-87e4-49d8ca9f808d"},
+		}, false},
+		{RequiredIf("manager", "active", true), map[string]interface{}{"active": true}, true},
+		{RequiredIf("manager", "active", true), map[string]interface{}{}, false},
+	} {
+		if err := test.constraint.Check(test.resource); (err != nil) != test.violated {
+			t.Errorf("%v: expected violated to be %v, got %v", test.resource, test.violated, err)
+		}
+	}
+}
+
+func TestSchemaLintConstraints(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "userName"})),
+			CoreUserName(),
+		},
+		Constraints: []Constraint{
+			MutuallyExclusive("userName", "name.givenName"),
+			RequiredIf("name.familyName", "nickName", "Babs"),
+		},
+		Name: optional.NewString("User"),
+	}
+
+	issues := s.Lint()
+	if len(issues) != 1 || issues[0].Attribute != "nickName" || issues[0].Severity != LintSeverityError {
+		t.Errorf("unexpected lint issues: %v", issues)
+	}
+}
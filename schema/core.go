@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	datetime "github.com/di-wu/xsd-datetime"
 	"github.com/elimity-com/scim/errors"
@@ -14,17 +16,21 @@ import (
 // SimpleCoreAttribute creates a non-complex attribute based on given parameters.
 func SimpleCoreAttribute(params SimpleParams) CoreAttribute {
 	checkAttributeName(params.name)
+	checkAttributeType(params.name, params.typ)
 
 	return CoreAttribute{
 		canonicalValues: params.canonicalValues,
 		caseExact:       params.caseExact,
 		description:     params.description,
+		index:           params.index,
+		maxLength:       params.maxLength,
 		multiValued:     params.multiValued,
 		mutability:      params.mutability,
 		name:            params.name,
 		referenceTypes:  params.referenceTypes,
 		required:        params.required,
 		returned:        params.returned,
+		strictCanonical: params.strictCanonical,
 		typ:             params.typ,
 		uniqueness:      params.uniqueness,
 	}
@@ -42,50 +48,125 @@ func ComplexCoreAttribute(params ComplexParams) CoreAttribute {
 			panic(fmt.Errorf("duplicate name %q for sub-attributes %d and %d", name, i, j))
 		}
 		names[name] = i
+		checkAttributeType(a.name, a.typ)
 
 		sa = append(sa, CoreAttribute{
 			canonicalValues: a.canonicalValues,
 			caseExact:       a.caseExact,
 			description:     a.description,
+			index:           a.index,
+			maxLength:       a.maxLength,
 			multiValued:     a.multiValued,
 			mutability:      a.mutability,
 			name:            a.name,
 			referenceTypes:  a.referenceTypes,
 			required:        a.required,
 			returned:        a.returned,
+			strictCanonical: a.strictCanonical,
 			typ:             a.typ,
 			uniqueness:      a.uniqueness,
 		})
 	}
 
 	return CoreAttribute{
-		description:   params.Description,
-		multiValued:   params.MultiValued,
-		mutability:    params.Mutability.m,
-		name:          params.Name,
-		required:      params.Required,
-		returned:      params.Returned.r,
-		subAttributes: sa,
-		typ:           attributeDataTypeComplex,
-		uniqueness:    params.Uniqueness.u,
+		description:       params.Description,
+		multiValued:       params.MultiValued,
+		mutability:        params.Mutability.m,
+		name:              params.Name,
+		required:          params.Required,
+		returned:          params.Returned.r,
+		subAttributes:     sa,
+		subAttributeIndex: names,
+		typ:               attributeDataTypeComplex,
+		uniqueness:        params.Uniqueness.u,
 	}
 }
 
 // CoreAttribute represents those attributes that sit at the top level of the JSON object together with the common
 // attributes (such as the resource "id").
 type CoreAttribute struct {
-	canonicalValues []string
-	caseExact       bool
-	description     optional.String
-	multiValued     bool
-	mutability      attributeMutability
-	name            string
-	referenceTypes  []AttributeReferenceType
-	required        bool
-	returned        attributeReturned
-	subAttributes   []CoreAttribute
-	typ             attributeType
-	uniqueness      attributeUniqueness
+	canonicalValues   []string
+	caseExact         bool
+	description       optional.String
+	index             attributeIndex
+	maxLength         int
+	multiValued       bool
+	mutability        attributeMutability
+	name              string
+	photo             *PhotoParams
+	referenceTypes    []AttributeReferenceType
+	required          bool
+	returned          attributeReturned
+	strictCanonical   bool
+	subAttributes     []CoreAttribute
+	subAttributeIndex map[string]int
+	typ               attributeType
+	uniqueness        attributeUniqueness
+}
+
+// Name returns the name of the attribute.
+func (a CoreAttribute) Name() string {
+	return a.name
+}
+
+// CaseExact returns whether string values of the attribute are case sensitive.
+func (a CoreAttribute) CaseExact() bool {
+	return a.caseExact
+}
+
+// CanonicalValues returns the suggested canonical values of the attribute, e.g. "work", "home" and "other".
+func (a CoreAttribute) CanonicalValues() []string {
+	return a.canonicalValues
+}
+
+// StrictCanonicalValues returns whether values other than the canonical values of the attribute are rejected.
+func (a CoreAttribute) StrictCanonicalValues() bool {
+	return a.strictCanonical
+}
+
+// Index returns the index hint of the attribute for storage layers.
+func (a CoreAttribute) Index() AttributeIndex {
+	return AttributeIndex{i: a.index}
+}
+
+// MaxLength returns the maximum number of characters of a string value, zero if there is no limit.
+func (a CoreAttribute) MaxLength() int {
+	return a.maxLength
+}
+
+// MultiValued returns whether the attribute has multiple values.
+func (a CoreAttribute) MultiValued() bool {
+	return a.multiValued
+}
+
+// Required returns whether the attribute is required.
+func (a CoreAttribute) Required() bool {
+	return a.required
+}
+
+// SubAttributes returns the sub-attributes of a complex attribute.
+func (a CoreAttribute) SubAttributes() []CoreAttribute {
+	return a.subAttributes
+}
+
+// Type returns the data type of the attribute.
+func (a CoreAttribute) Type() AttributeDataType {
+	return AttributeDataType{t: a.typ}
+}
+
+// Mutability returns the circumstances under which the value of the attribute can be (re)defined.
+func (a CoreAttribute) Mutability() AttributeMutability {
+	return AttributeMutability{m: a.mutability}
+}
+
+// Returned returns the circumstances under which the attribute and associated values are returned.
+func (a CoreAttribute) Returned() AttributeReturned {
+	return AttributeReturned{r: a.returned}
+}
+
+// Uniqueness returns how the service provider enforces the uniqueness of the values of the attribute.
+func (a CoreAttribute) Uniqueness() AttributeUniqueness {
+	return AttributeUniqueness{u: a.uniqueness}
 }
 
 func (a CoreAttribute) validate(attribute interface{}) (interface{}, errors.ValidationError) {
@@ -173,6 +254,11 @@ func (a CoreAttribute) validateSingular(attribute interface{}) (interface{}, err
 			}
 			attributes[sub.name] = attr
 		}
+		if a.photo != nil {
+			if scimErr := a.photo.normalize(attributes); scimErr != errors.ValidationErrorNil {
+				return nil, scimErr
+			}
+		}
 		return attributes, errors.ValidationErrorNil
 	case attributeDataTypeDateTime:
 		date, ok := attribute.(string)
@@ -199,12 +285,33 @@ func (a CoreAttribute) validateSingular(attribute interface{}) (interface{}, err
 		if !ok {
 			return nil, errors.ValidationErrorInvalidValue
 		}
+		if a.maxLength > 0 && utf8.RuneCountInString(s) > a.maxLength {
+			return nil, errors.ValidationErrorInvalidValue
+		}
+		if len(a.canonicalValues) != 0 {
+			canonical, ok := a.canonicalValue(s)
+			if !ok && a.strictCanonical {
+				return nil, errors.ValidationErrorInvalidValue
+			}
+			s = canonical
+		}
 		return s, errors.ValidationErrorNil
 	default:
 		return nil, errors.ValidationErrorInvalidSyntax
 	}
 }
 
+// canonicalValue returns the canonical value that equals given value, compared case-insensitively unless the attribute
+// is case exact. It returns the value itself if it is not a canonical value.
+func (a CoreAttribute) canonicalValue(value string) (string, bool) {
+	for _, canonical := range a.canonicalValues {
+		if canonical == value || !a.caseExact && strings.EqualFold(canonical, value) {
+			return canonical, true
+		}
+	}
+	return value, false
+}
+
 func (a *CoreAttribute) getRawAttributes() map[string]interface{} {
 	rawSubAttributes := make([]map[string]interface{}, len(a.subAttributes))
 
@@ -212,7 +319,7 @@ func (a *CoreAttribute) getRawAttributes() map[string]interface{} {
 		rawSubAttributes[i] = subAttr.getRawAttributes()
 	}
 
-	return map[string]interface{}{
+	raw := map[string]interface{}{
 		"canonicalValues": a.canonicalValues,
 		"caseExact":       a.caseExact,
 		"description":     a.description.Value(),
@@ -226,4 +333,58 @@ func (a *CoreAttribute) getRawAttributes() map[string]interface{} {
 		"type":            a.typ,
 		"uniqueness":      a.uniqueness,
 	}
+	if a.maxLength > 0 {
+		raw[ConstraintsExtensionID] = map[string]interface{}{
+			"maxLength": a.maxLength,
+		}
+	}
+	return raw
+}
+
+func (a CoreAttribute) coerce(attribute interface{}) interface{} {
+	if arr, ok := attribute.([]interface{}); ok && a.multiValued {
+		coerced := make([]interface{}, len(arr))
+		for i, ele := range arr {
+			coerced[i] = a.coerceSingular(ele)
+		}
+		return coerced
+	}
+	return a.coerceSingular(attribute)
+}
+
+func (a CoreAttribute) coerceSingular(attribute interface{}) interface{} {
+	if complex, ok := attribute.(map[string]interface{}); ok && a.typ == attributeDataTypeComplex {
+		coerced := make(map[string]interface{}, len(complex))
+		for k, v := range complex {
+			coerced[k] = v
+			if sub, ok := a.SubAttribute(k); ok {
+				coerced[k] = sub.coerce(v)
+			}
+		}
+		return coerced
+	}
+
+	s, ok := attribute.(string)
+	if !ok {
+		return attribute
+	}
+
+	switch a.typ {
+	case attributeDataTypeBoolean:
+		switch {
+		case strings.EqualFold(s, "true"):
+			return true
+		case strings.EqualFold(s, "false"):
+			return false
+		}
+	case attributeDataTypeInteger:
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			return i
+		}
+	case attributeDataTypeDecimal:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f
+		}
+	}
+	return attribute
 }
@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Equal reports whether given attributes of a resource are equal according to the characteristics of the attributes
+// of given schema, see CoreAttribute.ValuesEqual: attribute names are compared case-insensitively, strings honor the
+// "caseExact" characteristic, the values of multi-valued attributes are compared as sets and the sub-attributes of
+// complex values regardless of their order. Absent and null values and empty arrays are equal. The values of attributes
+// that are not defined by the schema, e.g. the attributes of a schema extension, must be deeply equal.
+func Equal(a, b map[string]interface{}, s Schema) bool {
+	for _, name := range attributeNames(a, b) {
+		x, y := nonEmpty(lookupFold(a, name)), nonEmpty(lookupFold(b, name))
+		attribute, ok := s.Attribute(name)
+		if !ok {
+			if !reflect.DeepEqual(x, y) {
+				return false
+			}
+			continue
+		}
+		if !attribute.ValuesEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a hash of given attributes of a resource that is consistent with Equal: attributes that are equal
+// according to given schema have the same hash. It can be used to detect changes to resources, e.g. to compute their
+// version, or to find duplicates.
+func Hash(attributes map[string]interface{}, s Schema) uint64 {
+	h := fnv.New64a()
+	for _, name := range attributeNames(attributes) {
+		value := nonEmpty(lookupFold(attributes, name))
+		if value == nil {
+			continue
+		}
+		_, _ = h.Write([]byte(name))
+		var valueHash uint64
+		if attribute, ok := s.Attribute(name); ok {
+			valueHash = attribute.valuesHash(value)
+		} else {
+			valueHash = deepHash(value)
+		}
+		_ = binary.Write(h, binary.LittleEndian, valueHash)
+	}
+	return h.Sum64()
+}
+
+// valuesHash returns a hash of given value(s) of the attribute that is consistent with ValuesEqual.
+func (a CoreAttribute) valuesHash(value interface{}) uint64 {
+	values, ok := value.([]interface{})
+	if !a.multiValued || !ok {
+		return a.valueHash(value)
+	}
+	// Sets of values are equal regardless of their order, so the hashes of the values are sorted.
+	hashes := make([]uint64, len(values))
+	for i, v := range values {
+		hashes[i] = a.valueHash(v)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i] < hashes[j]
+	})
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, hashes)
+	return h.Sum64()
+}
+
+// valueHash returns a hash of a single value of the attribute that is consistent with valueEqual.
+func (a CoreAttribute) valueHash(value interface{}) uint64 {
+	if value == nil {
+		return 0
+	}
+
+	switch a.typ {
+	case attributeDataTypeString, attributeDataTypeReference, attributeDataTypeBinary:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		if !a.caseExact {
+			s = strings.ToLower(s)
+		}
+		return stringHash(s)
+	case attributeDataTypeDateTime:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			s = t.UTC().Format(time.RFC3339Nano)
+		}
+		return stringHash(s)
+	case attributeDataTypeDecimal, attributeDataTypeInteger:
+		f, ok := toFloat(value)
+		if !ok {
+			break
+		}
+		if f == 0 {
+			f = 0 // -0 equals 0.
+		}
+		return math.Float64bits(f)
+	case attributeDataTypeComplex:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		h := fnv.New64a()
+		for _, name := range attributeNames(m) {
+			subValue := lookupFold(m, name)
+			if subValue == nil {
+				continue
+			}
+			var subHash uint64
+			if sub, ok := a.subAttribute(name); ok {
+				subHash = sub.valuesHash(subValue)
+			} else {
+				subHash = deepHash(subValue)
+			}
+			_, _ = h.Write([]byte(name))
+			_ = binary.Write(h, binary.LittleEndian, subHash)
+		}
+		return h.Sum64()
+	}
+	return deepHash(value)
+}
+
+// attributeNames returns the lowercase names of the attributes in given maps, in order.
+func attributeNames(maps ...map[string]interface{}) []string {
+	set := make(map[string]struct{})
+	for _, m := range maps {
+		for k := range m {
+			set[strings.ToLower(k)] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// deepHash returns a hash of given value that is consistent with reflect.DeepEqual for decoded JSON values.
+func deepHash(value interface{}) uint64 {
+	if value == nil {
+		return 0
+	}
+	// The types of values are included, since values of different types are never deeply equal. Maps are encoded with
+	// sorted keys.
+	data, _ := json.Marshal(value)
+	return stringHash(reflect.TypeOf(value).String() + string(data))
+}
+
+// stringHash returns the FNV-1a hash of given string.
+func stringHash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// nonEmpty returns nil for empty arrays, which are equivalent to unassigned attributes, and given value otherwise.
+func nonEmpty(value interface{}) interface{} {
+	if values, ok := value.([]interface{}); ok && len(values) == 0 {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEqualAndHash(t *testing.T) {
+	s := CoreUserSchema()
+	bjensen := map[string]interface{}{
+		"userName": "bjensen",
+		"name":     map[string]interface{}{"givenName": "Barbara", "familyName": "Jensen"},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+			map[string]interface{}{"value": "babs@example.com", "type": "home"},
+		},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701984"},
+	}
+	for _, test := range []struct {
+		name  string
+		other map[string]interface{}
+		equal bool
+	}{
+		{"identical", bjensen, true},
+		{
+			"reordered",
+			map[string]interface{}{
+				"USERNAME": "BJensen",
+				"name":     map[string]interface{}{"FamilyName": "JENSEN", "givenName": "barbara"},
+				"emails": []interface{}{
+					map[string]interface{}{"type": "HOME", "value": "babs@example.com"},
+					map[string]interface{}{"value": "BJensen@example.com", "type": "work"},
+				},
+				"displayName":  nil,
+				"phoneNumbers": []interface{}{},
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701984"},
+			},
+			true,
+		},
+		{
+			"changed",
+			map[string]interface{}{
+				"userName": "bjensen",
+				"name":     map[string]interface{}{"givenName": "Barbara", "familyName": "Jensen"},
+				"emails": []interface{}{
+					map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+				},
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701984"},
+			},
+			false,
+		},
+		{
+			"unknown",
+			map[string]interface{}{
+				"userName": "bjensen",
+				"name":     map[string]interface{}{"givenName": "Barbara", "familyName": "Jensen"},
+				"emails": []interface{}{
+					map[string]interface{}{"value": "bjensen@example.com", "type": "work"},
+					map[string]interface{}{"value": "babs@example.com", "type": "home"},
+				},
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701985"},
+			},
+			false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if equal := Equal(bjensen, test.other, s); equal != test.equal {
+				t.Errorf("expected equality %t, got %t", test.equal, equal)
+			}
+			if equal := Equal(test.other, bjensen, s); equal != test.equal {
+				t.Errorf("expected symmetric equality %t, got %t", test.equal, equal)
+			}
+			if test.equal && Hash(bjensen, s) != Hash(test.other, s) {
+				t.Error("expected equal attributes to have the same hash")
+			}
+			if !test.equal && Hash(bjensen, s) == Hash(test.other, s) {
+				t.Error("expected different attributes to have a different hash")
+			}
+		})
+	}
+}
+
+func TestHashNumbersAndDateTimes(t *testing.T) {
+	s := Schema{
+		ID: "urn:example:params:scim:schemas:Device",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleNumberParams(NumberParams{Name: "cores", Type: AttributeTypeInteger()})),
+			SimpleCoreAttribute(SimpleDateTimeParams(DateTimeParams{Name: "lastSeen"})),
+		},
+	}
+	a := map[string]interface{}{"cores": json.Number("4"), "lastSeen": "2020-01-01T12:00:00Z"}
+	b := map[string]interface{}{"cores": float64(4), "lastSeen": "2020-01-01T13:00:00+01:00"}
+	if !Equal(a, b, s) {
+		t.Error("expected numbers and date times to be compared by their value")
+	}
+	if Hash(a, s) != Hash(b, s) {
+		t.Error("expected numbers and date times to be hashed by their value")
+	}
+}
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+// extensionURN matches the URNs of vendor extension schemas: a namespace identifier as defined by RFC 8141 and a
+// namespace-specific string that is limited to the characters that can be used in attribute paths and filters.
+var extensionURN = regexp.MustCompile(`^(?i:urn):([A-Za-z0-9][A-Za-z0-9-]{0,30}[A-Za-z0-9]):([A-Za-z0-9._-]+(:[A-Za-z0-9._-]+)*)$`)
+
+// NewExtension returns the schema of a vendor extension with given URN and attributes, e.g.
+//
+//	hr, err := schema.NewExtension("urn:mycorp:scim:schemas:extension:hr:1.0:User",
+//		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "costCenter"})),
+//	)
+//
+// The name of the schema is the last segment of the URN, e.g. "User". It can be changed on the returned schema.
+//
+// It returns an error if the URN is not valid, if it lies in the "ietf" namespace, which is reserved for the schemas of
+// the SCIM standard, if two attributes have the same name or if an attribute has a characteristic that is invalid (see
+// Lint). The namespace-specific part of the URN may only contain letters, digits, '-', '.', '_' and ':', so that the
+// attributes of the extension can be referred to in attribute paths and filters, e.g.
+// "urn:mycorp:scim:schemas:extension:hr:1.0:User:costCenter".
+func NewExtension(id string, attributes ...CoreAttribute) (Schema, error) {
+	match := extensionURN.FindStringSubmatch(id)
+	if match == nil {
+		return Schema{}, fmt.Errorf("invalid extension URN %q", id)
+	}
+	if strings.EqualFold(match[1], "ietf") {
+		return Schema{}, fmt.Errorf("invalid extension URN %q: the ietf namespace is reserved for standard schemas", id)
+	}
+
+	names := make(map[string]bool, len(attributes))
+	for _, attribute := range attributes {
+		name := strings.ToLower(attribute.Name())
+		if names[name] {
+			return Schema{}, fmt.Errorf("extension %q: duplicate attribute %q", id, attribute.Name())
+		}
+		names[name] = true
+	}
+
+	segments := strings.Split(id, ":")
+	s := Schema{
+		Attributes: attributes,
+		ID:         id,
+		Name:       optional.NewString(segments[len(segments)-1]),
+	}
+	for _, issue := range s.Lint() {
+		if issue.Severity == LintSeverityError {
+			return Schema{}, fmt.Errorf("extension %q: %s", id, issue)
+		}
+	}
+	return s.indexed(), nil
+}
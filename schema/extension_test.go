@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewExtension(t *testing.T) {
+	costCenter := SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "costCenter"}))
+	s, err := NewExtension("urn:mycorp:scim:schemas:extension:hr:1.0:User", costCenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "urn:mycorp:scim:schemas:extension:hr:1.0:User" || s.Name.Value() != "User" {
+		t.Errorf("unexpected extension %s (%s)", s.ID, s.Name.Value())
+	}
+	if _, ok := s.Attribute("costCenter"); !ok {
+		t.Error("expected the extension to define costCenter")
+	}
+
+	for _, test := range []struct {
+		id         string
+		attributes []CoreAttribute
+		expected   string
+	}{
+		{"mycorp:hr:User", nil, "invalid extension URN"},
+		{"urn:mycorp", nil, "invalid extension URN"},
+		{"urn:-mycorp:hr:User", nil, "invalid extension URN"},
+		{"urn:mycorp:hr:User:", nil, "invalid extension URN"},
+		{"urn:mycorp:hr (1.0):User", nil, "invalid extension URN"},
+		{"URN:IETF:params:scim:schemas:extension:hr:1.0:User", nil, "reserved"},
+		{"urn:mycorp:hr:User", []CoreAttribute{costCenter, costCenter}, "duplicate attribute"},
+	} {
+		_, err := NewExtension(test.id, test.attributes...)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing %q, got: %v", test.id, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package schema
+
+import "github.com/elimity-com/scim/optional"
+
+// CoreGroupMembers returns the "members" attribute of the core Group schema: a list of members of the group. The
+// identifier, reference and type of a member can not be changed once it is added, only the member as a whole can be
+// removed.
+func CoreGroupMembers() CoreAttribute {
+	return ComplexCoreAttribute(ComplexParams{
+		Description: optional.NewString("A list of members of the Group."),
+		MultiValued: true,
+		Name:        "members",
+		SubAttributes: []SimpleParams{
+			SimpleStringParams(StringParams{
+				Description: optional.NewString("Identifier of the member of this Group."),
+				Mutability:  AttributeMutabilityImmutable(),
+				Name:        "value",
+			}),
+			SimpleReferenceParams(ReferenceParams{
+				Description:    optional.NewString("The URI corresponding to a SCIM resource that is a member of this Group."),
+				Mutability:     AttributeMutabilityImmutable(),
+				Name:           "$ref",
+				ReferenceTypes: []AttributeReferenceType{"User", "Group"},
+			}),
+			SimpleStringParams(StringParams{
+				CanonicalValues: []string{"User", "Group"},
+				Description:     optional.NewString("A label indicating the type of resource, e.g., 'User' or 'Group'."),
+				Mutability:      AttributeMutabilityImmutable(),
+				Name:            "type",
+			}),
+			SimpleStringParams(StringParams{
+				Description: optional.NewString("A human-readable name, primarily used for display purposes. READ-ONLY."),
+				Mutability:  AttributeMutabilityReadOnly(),
+				Name:        "display",
+			}),
+		},
+	})
+}
+
+// CoreGroupSchema returns the complete core Group schema as defined in RFC 7643 section 4.2.
+func CoreGroupSchema() Schema {
+	return Schema{
+		ID:          "urn:ietf:params:scim:schemas:core:2.0:Group",
+		Name:        optional.NewString("Group"),
+		Description: optional.NewString("Group"),
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Description: optional.NewString("A human-readable name for the Group. REQUIRED."),
+				Name:        "displayName",
+				Required:    true,
+			})),
+			CoreGroupMembers(),
+		},
+	}.indexed()
+}
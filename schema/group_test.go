@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+func TestCoreGroupSchema(t *testing.T) {
+	s := CoreGroupSchema()
+	if issues := s.Lint(); HasErrors(issues) {
+		t.Errorf("unexpected lint issues: %v", issues)
+	}
+
+	if _, scimErr := s.Validate(map[string]interface{}{
+		"displayName": "Tour Guides",
+		"members": []interface{}{
+			map[string]interface{}{
+				"value": "2819c223-7f76-453a-919d-413861904646",
+				"$ref":  "https://example.com/v2/Users/2819c223-7f76-453a-919d-413861904646",
+				"type":  "User",
+			},
+		},
+	}); scimErr != errors.ValidationErrorNil {
+		t.Errorf("valid resource expected, got %v", scimErr)
+	}
+
+	if _, scimErr := s.Validate(map[string]interface{}{"members": []interface{}{}}); scimErr == errors.ValidationErrorNil {
+		t.Errorf("invalid resource expected: the displayName is required")
+	}
+
+	members := s.Attributes[1]
+	for _, name := range []string{"value", "$ref", "type"} {
+		for _, attribute := range members.SubAttributes() {
+			if attribute.Name() == name && attribute.Mutability() != AttributeMutabilityImmutable() {
+				t.Errorf("expected the %q sub-attribute of members to be immutable", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package idp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// azureSchema is the synchronization schema of an application in Azure AD, as exported from the provisioning
+// settings of the application or retrieved with the Microsoft Graph API.
+type azureSchema struct {
+	Directories []struct {
+		Name    string `json:"name"`
+		Objects []struct {
+			Name       string `json:"name"`
+			Attributes []struct {
+				Name        string `json:"name"`
+				Type        string `json:"type"`
+				CaseExact   bool   `json:"caseExact"`
+				Multivalued bool   `json:"multivalued"`
+				Mutability  string `json:"mutability"`
+				Required    bool   `json:"required"`
+			} `json:"attributes"`
+		} `json:"objects"`
+	} `json:"directories"`
+}
+
+// AzureAD translates the attributes of an object of a directory within the synchronization schema of an Azure AD
+// application into schema definitions. The directory is the target directory of the provisioning, e.g.
+// "customappsso", and the object is the name of the object within that directory, e.g.
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User". Attributes without a schema URI prefix are added to
+// the core schema with given identifier.
+func AzureAD(data []byte, directory, object, coreSchemaID string) ([]schema.Schema, error) {
+	var export azureSchema
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	for _, d := range export.Directories {
+		if !strings.EqualFold(d.Name, directory) {
+			continue
+		}
+		for _, o := range d.Objects {
+			if !strings.EqualFold(o.Name, object) {
+				continue
+			}
+
+			b := newBuilder(coreSchemaID)
+			for _, a := range o.Attributes {
+				typ, ok := azureTypes[strings.ToLower(a.Type)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported type %q of attribute %q", a.Type, a.Name)
+				}
+				mutability, ok := azureMutabilities[strings.ToLower(a.Mutability)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported mutability %q of attribute %q", a.Mutability, a.Name)
+				}
+				if err := b.add(attributeDefinition{
+					path:        a.Name,
+					typ:         typ,
+					multiValued: a.Multivalued,
+					required:    a.Required,
+					caseExact:   a.CaseExact,
+					mutability:  mutability,
+				}); err != nil {
+					return nil, err
+				}
+			}
+			return b.schemas()
+		}
+		return nil, fmt.Errorf("object %q not found in directory %q", object, directory)
+	}
+	return nil, fmt.Errorf("directory %q not found", directory)
+}
+
+var azureTypes = map[string]string{
+	"binary":    "binary",
+	"boolean":   "boolean",
+	"datetime":  "dateTime",
+	"integer":   "integer",
+	"reference": "reference",
+	"string":    "string",
+}
+
+var azureMutabilities = map[string]schema.AttributeMutability{
+	"":          schema.AttributeMutabilityReadWrite(),
+	"immutable": schema.AttributeMutabilityImmutable(),
+	"readonly":  schema.AttributeMutabilityReadOnly(),
+	"readwrite": schema.AttributeMutabilityReadWrite(),
+	"writeonly": schema.AttributeMutabilityWriteOnly(),
+}
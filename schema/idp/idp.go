@@ -0,0 +1,244 @@
+// Package idp translates the schema and attribute mapping exports of popular identity providers into schema
+// definitions, so that a service provider can mirror exactly what its identity provider sends.
+package idp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elimity-com/scim/optional"
+	"github.com/elimity-com/scim/schema"
+)
+
+// attributeNamePattern matches the valid attribute names, as defined in RFC 7643 section 2.1.
+var attributeNamePattern = regexp.MustCompile(`^[A-Za-z][\w$-]*$`)
+
+// attributeDefinition is an attribute as described by the export of an identity provider.
+type attributeDefinition struct {
+	// path is the attribute path as used by the identity provider, e.g. "userName", "name.givenName",
+	// `emails[type eq "work"].value` or "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber".
+	path        string
+	typ         string
+	description string
+	multiValued bool
+	required    bool
+	caseExact   bool
+	mutability  schema.AttributeMutability
+	uniqueness  schema.AttributeUniqueness
+}
+
+// builder collects the attribute definitions of an export and groups them by schema.
+type builder struct {
+	coreSchemaID string
+	schemaIDs    []string
+	attributes   map[string][]*attributeBuilder
+}
+
+// attributeBuilder is a (complex) attribute that is being built.
+type attributeBuilder struct {
+	name          string
+	definition    *attributeDefinition
+	multiValued   bool
+	subAttributes []attributeDefinition
+}
+
+func newBuilder(coreSchemaID string) *builder {
+	return &builder{
+		coreSchemaID: coreSchemaID,
+		schemaIDs:    []string{coreSchemaID},
+		attributes:   map[string][]*attributeBuilder{coreSchemaID: nil},
+	}
+}
+
+// add adds given attribute definition. Definitions of the same (sub-)attribute, e.g. the "value" of both the work and
+// home email addresses, are merged.
+func (b *builder) add(definition attributeDefinition) error {
+	schemaID, path := b.coreSchemaID, definition.path
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		schemaID, path = path[:i], path[i+1:]
+	}
+
+	// Value filters, e.g. `[type eq "work"]`, only select one of the values of a multi-valued attribute.
+	var multiValued bool
+	for {
+		i := strings.IndexByte(path, '[')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(path[i:], ']')
+		if j < 0 {
+			return fmt.Errorf("invalid attribute path %q", definition.path)
+		}
+		path = path[:i] + path[i+j+1:]
+		multiValued = true
+	}
+
+	names := strings.Split(path, ".")
+	if len(names) > 2 {
+		return fmt.Errorf("attribute path %q is nested too deep", definition.path)
+	}
+	for _, name := range names {
+		if !attributeNamePattern.MatchString(name) && name != "$ref" {
+			return fmt.Errorf("invalid attribute name %q in path %q", name, definition.path)
+		}
+	}
+
+	if _, ok := b.attributes[schemaID]; !ok {
+		b.schemaIDs = append(b.schemaIDs, schemaID)
+	}
+	var attribute *attributeBuilder
+	for _, a := range b.attributes[schemaID] {
+		if strings.EqualFold(a.name, names[0]) {
+			attribute = a
+		}
+	}
+	if attribute == nil {
+		attribute = &attributeBuilder{name: names[0]}
+		b.attributes[schemaID] = append(b.attributes[schemaID], attribute)
+	}
+	attribute.multiValued = attribute.multiValued || multiValued
+
+	if len(names) == 1 {
+		if attribute.definition == nil {
+			definition.path = names[0]
+			attribute.definition = &definition
+		}
+		return nil
+	}
+	for _, sub := range attribute.subAttributes {
+		if strings.EqualFold(sub.path, names[1]) {
+			return nil
+		}
+	}
+	definition.path = names[1]
+	attribute.subAttributes = append(attribute.subAttributes, definition)
+	return nil
+}
+
+// schemas returns the schemas of the collected attributes. The core schema is returned first, followed by the schema
+// extensions in the order in which they were encountered.
+func (b *builder) schemas() ([]schema.Schema, error) {
+	var schemas []schema.Schema
+	for _, id := range b.schemaIDs {
+		s := schema.Schema{
+			ID:   id,
+			Name: optional.NewString(id[strings.LastIndex(id, ":")+1:]),
+		}
+		for _, a := range b.attributes[id] {
+			attribute, err := a.build()
+			if err != nil {
+				return nil, err
+			}
+			s.Attributes = append(s.Attributes, attribute)
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+func (a *attributeBuilder) build() (schema.CoreAttribute, error) {
+	if len(a.subAttributes) == 0 {
+		if a.definition == nil {
+			return schema.CoreAttribute{}, fmt.Errorf("attribute %q has no definition", a.name)
+		}
+		params, err := a.definition.simpleParams()
+		if err != nil {
+			return schema.CoreAttribute{}, err
+		}
+		return schema.SimpleCoreAttribute(params), nil
+	}
+
+	params := schema.ComplexParams{
+		Name:        a.name,
+		MultiValued: a.multiValued,
+	}
+	if a.definition != nil {
+		if a.definition.description != "" {
+			params.Description = optional.NewString(a.definition.description)
+		}
+		params.MultiValued = params.MultiValued || a.definition.multiValued
+		params.Mutability = a.definition.mutability
+		params.Required = a.definition.required
+	}
+	for _, sub := range a.subAttributes {
+		// Sub-attributes of multi-valued attributes hold a single value.
+		sub.multiValued = sub.multiValued && !params.MultiValued
+		simple, err := sub.simpleParams()
+		if err != nil {
+			return schema.CoreAttribute{}, err
+		}
+		params.SubAttributes = append(params.SubAttributes, simple)
+	}
+	return schema.ComplexCoreAttribute(params), nil
+}
+
+// simpleParams returns the parameters of the (non-complex) attribute. The type is one of the data types of RFC 7643.
+func (d attributeDefinition) simpleParams() (schema.SimpleParams, error) {
+	var description optional.String
+	if d.description != "" {
+		description = optional.NewString(d.description)
+	}
+
+	switch d.typ {
+	case "string":
+		return schema.SimpleStringParams(schema.StringParams{
+			CaseExact:   d.caseExact,
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+			Uniqueness:  d.uniqueness,
+		}), nil
+	case "boolean":
+		return schema.SimpleBooleanParams(schema.BooleanParams{
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+		}), nil
+	case "integer", "decimal":
+		typ := schema.AttributeTypeDecimal()
+		if d.typ == "integer" {
+			typ = schema.AttributeTypeInteger()
+		}
+		return schema.SimpleNumberParams(schema.NumberParams{
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+			Type:        typ,
+			Uniqueness:  d.uniqueness,
+		}), nil
+	case "dateTime":
+		return schema.SimpleDateTimeParams(schema.DateTimeParams{
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+		}), nil
+	case "binary":
+		return schema.SimpleBinaryParams(schema.BinaryParams{
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+		}), nil
+	case "reference":
+		return schema.SimpleReferenceParams(schema.ReferenceParams{
+			Description: description,
+			MultiValued: d.multiValued,
+			Mutability:  d.mutability,
+			Name:        d.path,
+			Required:    d.required,
+			Uniqueness:  d.uniqueness,
+		}), nil
+	default:
+		return schema.SimpleParams{}, fmt.Errorf("unsupported type %q of attribute %q", d.typ, d.path)
+	}
+}
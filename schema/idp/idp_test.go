@@ -0,0 +1,140 @@
+package idp
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+const (
+	userSchemaID       = "urn:ietf:params:scim:schemas:core:2.0:User"
+	enterpriseSchemaID = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile("./fixtures/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// checkAttributes checks the names and data types of the (sub-)attributes of given schema.
+func checkAttributes(t *testing.T, s schema.Schema, expected map[string]string) {
+	actual := make(map[string]string)
+	for _, attribute := range s.Attributes {
+		typ := attribute.Type().String()
+		if attribute.MultiValued() {
+			typ = "[]" + typ
+		}
+		actual[attribute.Name()] = typ
+		for _, sub := range attribute.SubAttributes() {
+			actual[attribute.Name()+"."+sub.Name()] = sub.Type().String()
+		}
+	}
+
+	if len(actual) != len(expected) {
+		t.Errorf("%s: got attributes %v want %v", s.ID, actual, expected)
+	}
+	for name, typ := range expected {
+		if actual[name] != typ {
+			t.Errorf("%s: got type %q for %q, want %q", s.ID, actual[name], name, typ)
+		}
+	}
+}
+
+func TestAzureAD(t *testing.T) {
+	data := readFixture(t, "azure.json")
+	schemas, err := AzureAD(data, "customappsso", enterpriseSchemaID, userSchemaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 || schemas[0].ID != userSchemaID || schemas[1].ID != enterpriseSchemaID {
+		t.Fatalf("unexpected schemas: %v", schemas)
+	}
+
+	checkAttributes(t, schemas[0], map[string]string{
+		"userName":        "string",
+		"active":          "boolean",
+		"displayName":     "string",
+		"emails":          "[]complex",
+		"emails.value":    "string",
+		"name":            "complex",
+		"name.givenName":  "string",
+		"name.familyName": "string",
+		"externalId":      "string",
+	})
+	checkAttributes(t, schemas[1], map[string]string{
+		"employeeNumber": "string",
+		"manager":        "reference",
+	})
+
+	if _, scimErr := schemas[0].Validate(map[string]interface{}{
+		"displayName": "Babs Jensen",
+	}); scimErr != errors.ValidationErrorInvalidValue {
+		t.Error("expected the user name to be required")
+	}
+	if _, scimErr := schemas[0].Validate(map[string]interface{}{
+		"userName": "bjensen",
+		"emails":   []interface{}{map[string]interface{}{"value": "bjensen@example.com"}},
+	}); scimErr != errors.ValidationErrorNil {
+		t.Errorf("unexpected validation error: %v", scimErr)
+	}
+
+	if _, err := AzureAD(data, "customappsso", "urn:ietf:params:scim:schemas:core:2.0:Group", userSchemaID); err == nil {
+		t.Error("expected an error for an unknown object")
+	}
+	if _, err := AzureAD(data, "unknown", enterpriseSchemaID, userSchemaID); err == nil {
+		t.Error("expected an error for an unknown directory")
+	}
+}
+
+func TestOkta(t *testing.T) {
+	schemas, err := Okta(readFixture(t, "okta.json"), userSchemaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 || schemas[0].ID != userSchemaID || schemas[1].ID != enterpriseSchemaID {
+		t.Fatalf("unexpected schemas: %v", schemas)
+	}
+
+	checkAttributes(t, schemas[0], map[string]string{
+		"userName":       "string",
+		"name":           "complex",
+		"name.givenName": "string",
+		"emails":         "[]complex",
+		"emails.value":   "string",
+		"groupNames":     "[]string",
+	})
+	checkAttributes(t, schemas[1], map[string]string{
+		"costCenter": "string",
+		"seniority":  "integer",
+	})
+
+	for _, attribute := range schemas[0].Attributes {
+		switch attribute.Name() {
+		case "userName":
+			if attribute.Mutability() != schema.AttributeMutabilityReadWrite() {
+				t.Errorf("unexpected mutability of the user name")
+			}
+		case "groupNames":
+			if attribute.Mutability() != schema.AttributeMutabilityReadOnly() {
+				t.Errorf("expected the group names to be read-only")
+			}
+		}
+	}
+}
+
+func TestInvalidAttributePath(t *testing.T) {
+	for _, path := range []string{
+		"name.givenName.first",
+		"emails[type eq \"work\".value",
+		"1name",
+	} {
+		if err := newBuilder(userSchemaID).add(attributeDefinition{path: path, typ: "string"}); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
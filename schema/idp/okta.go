@@ -0,0 +1,111 @@
+package idp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// oktaSchema is the user profile schema of an application in Okta, as returned by the
+// "/api/v1/meta/schemas/apps/{appId}/default" endpoint of the Okta API.
+type oktaSchema struct {
+	Definitions map[string]struct {
+		Properties map[string]oktaProperty `json:"properties"`
+		Required   []string                `json:"required"`
+	} `json:"definitions"`
+}
+
+type oktaProperty struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Items       struct {
+		Type string `json:"type"`
+	} `json:"items"`
+	Mutability        string `json:"mutability"`
+	Unique            string `json:"unique"`
+	ExternalName      string `json:"externalName"`
+	ExternalNamespace string `json:"externalNamespace"`
+}
+
+// Okta translates the user profile schema of an Okta application into schema definitions. The "externalName" and
+// "externalNamespace" of the properties are used as the path and schema of the attributes. Properties without a
+// namespace are added to the core schema with given identifier.
+func Okta(data []byte, coreSchemaID string) ([]schema.Schema, error) {
+	var export oktaSchema
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	b := newBuilder(coreSchemaID)
+	// The base properties are defined by Okta, the custom ones by the administrator of the application.
+	for _, definition := range []string{"base", "custom"} {
+		properties := export.Definitions[definition].Properties
+		required := make(map[string]bool)
+		for _, name := range export.Definitions[definition].Required {
+			required[name] = true
+		}
+
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p := properties[name]
+			typ, multiValued := p.Type, false
+			if typ == "array" {
+				typ, multiValued = p.Items.Type, true
+			}
+			if typ == "number" {
+				typ = "decimal"
+			}
+
+			mutability, ok := oktaMutabilities[p.Mutability]
+			if !ok {
+				return nil, fmt.Errorf("unsupported mutability %q of property %q", p.Mutability, name)
+			}
+			uniqueness := schema.AttributeUniquenessNone()
+			if p.Unique == "UNIQUE_VALIDATED" {
+				uniqueness = schema.AttributeUniquenessServer()
+			}
+
+			path := name
+			if p.ExternalName != "" {
+				path = p.ExternalName
+			}
+			if p.ExternalNamespace != "" && !strings.EqualFold(p.ExternalNamespace, coreSchemaID) {
+				path = p.ExternalNamespace + ":" + path
+			}
+
+			description := p.Description
+			if description == "" {
+				description = p.Title
+			}
+			if err := b.add(attributeDefinition{
+				path:        path,
+				typ:         typ,
+				description: description,
+				multiValued: multiValued,
+				required:    required[name],
+				mutability:  mutability,
+				uniqueness:  uniqueness,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b.schemas()
+}
+
+var oktaMutabilities = map[string]schema.AttributeMutability{
+	"":           schema.AttributeMutabilityReadWrite(),
+	"IMMUTABLE":  schema.AttributeMutabilityImmutable(),
+	"READ_ONLY":  schema.AttributeMutabilityReadOnly(),
+	"READ_WRITE": schema.AttributeMutabilityReadWrite(),
+	"WRITE_ONLY": schema.AttributeMutabilityWriteOnly(),
+}
@@ -0,0 +1,57 @@
+package schema
+
+// AttributeIndex is a hint for storage layers on how the values of an attribute are filtered, so that they can create
+// appropriate indexes and reject filters that would require a full scan. It is not part of the SCIM representation of
+// the schema.
+type AttributeIndex struct {
+	i attributeIndex
+}
+
+// AttributeIndexNone indicates that the values of the attribute are not indexed. This is the default value.
+func AttributeIndexNone() AttributeIndex {
+	return AttributeIndex{i: attributeIndexNone}
+}
+
+// AttributeIndexEquality indicates that the values of the attribute are indexed for equality comparisons, e.g. with a
+// hash index. It supports the "eq", "ne" and "pr" operators.
+func AttributeIndexEquality() AttributeIndex {
+	return AttributeIndex{i: attributeIndexEquality}
+}
+
+// AttributeIndexPrefix indicates that the values of the attribute are indexed in order, e.g. with a B-tree index. Next
+// to the operators of AttributeIndexEquality, it supports the "sw", "gt", "ge", "lt" and "le" operators.
+func AttributeIndexPrefix() AttributeIndex {
+	return AttributeIndex{i: attributeIndexPrefix}
+}
+
+// String returns the name of the index hint, i.e. "none", "equality" or "prefix".
+func (i AttributeIndex) String() string {
+	switch i.i {
+	case attributeIndexEquality:
+		return "equality"
+	case attributeIndexPrefix:
+		return "prefix"
+	default:
+		return "none"
+	}
+}
+
+// Supports reports whether the index supports given (lowercase) filter operator, e.g. "eq" or "sw".
+func (i AttributeIndex) Supports(operator string) bool {
+	switch operator {
+	case "eq", "ne", "pr":
+		return i.i != attributeIndexNone
+	case "sw", "gt", "ge", "lt", "le":
+		return i.i == attributeIndexPrefix
+	default:
+		return false
+	}
+}
+
+type attributeIndex int
+
+const (
+	attributeIndexNone attributeIndex = iota
+	attributeIndexEquality
+	attributeIndexPrefix
+)
@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintSeverity indicates how severe a lint issue is.
+type LintSeverity int
+
+const (
+	// LintSeverityWarning indicates that a characteristic has no effect and is most likely a mistake.
+	LintSeverityWarning LintSeverity = iota
+	// LintSeverityError indicates that the characteristics contradict each other, which will cause identity providers
+	// to misbehave (e.g. a required attribute that can never be provided).
+	LintSeverityError
+)
+
+func (s LintSeverity) String() string {
+	if s == LintSeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// LintIssue describes a contradictory combination of characteristics of an attribute.
+type LintIssue struct {
+	// Severity is the severity of the issue.
+	Severity LintSeverity
+	// Attribute is the name of the attribute, sub-attributes are prefixed with the name of their parent attribute,
+	// e.g., "name.givenName".
+	Attribute string
+	// Message is a human-readable description of the issue.
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Attribute, i.Message)
+}
+
+// Lint checks the attributes of the schema for contradictory characteristics, so misconfigured schemas can be caught
+// before they are served. It returns an empty slice if no issues are found.
+func (s Schema) Lint() []LintIssue {
+	issues := make([]LintIssue, 0)
+	for _, attribute := range s.Attributes {
+		issues = append(issues, attribute.lint("")...)
+	}
+	for _, constraint := range s.Constraints {
+		for _, name := range constraint.names() {
+			if !s.hasAttribute(name) {
+				issues = append(issues, LintIssue{
+					Severity:  LintSeverityError,
+					Attribute: name,
+					Message:   "constraint refers to an attribute that is not defined by the schema",
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// hasAttribute reports whether the schema defines the (sub-)attribute with given name.
+func (s Schema) hasAttribute(name string) bool {
+	names := strings.SplitN(name, ".", 2)
+	for _, attribute := range s.Attributes {
+		if !strings.EqualFold(attribute.name, names[0]) {
+			continue
+		}
+		if len(names) == 1 {
+			return true
+		}
+		for _, sub := range attribute.subAttributes {
+			if strings.EqualFold(sub.name, names[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasErrors reports whether given issues contain at least one issue with an error severity.
+func HasErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (a CoreAttribute) lint(prefix string) []LintIssue {
+	name := prefix + a.name
+	var issues []LintIssue
+	add := func(severity LintSeverity, msg string) {
+		issues = append(issues, LintIssue{
+			Severity:  severity,
+			Attribute: name,
+			Message:   msg,
+		})
+	}
+
+	if a.required && a.mutability == attributeMutabilityReadOnly {
+		add(LintSeverityError, "required attribute is read-only and can therefore never be provided by clients")
+	}
+	if a.mutability == attributeMutabilityWriteOnly && a.returned != attributeReturnedNever {
+		add(LintSeverityError, "write-only attribute must have a returned characteristic of never")
+	}
+	if a.typ == attributeDataTypeBoolean && a.uniqueness != attributeUniquenessNone {
+		add(LintSeverityWarning, "uniqueness has no meaning for a boolean attribute")
+	}
+	if len(a.canonicalValues) != 0 && a.typ != attributeDataTypeString {
+		add(LintSeverityWarning, "canonical values are only supported for string attributes")
+	}
+	if a.caseExact {
+		switch a.typ {
+		case attributeDataTypeString, attributeDataTypeReference, attributeDataTypeBinary:
+		default:
+			add(LintSeverityWarning, "case exactness is only meaningful for string, reference and binary attributes")
+		}
+	}
+
+	for _, sub := range a.subAttributes {
+		issues = append(issues, sub.lint(name+".")...)
+	}
+	return issues
+}
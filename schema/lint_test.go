@@ -0,0 +1,55 @@
+package schema
+
+import "testing"
+
+func TestLint(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Mutability: AttributeMutabilityReadOnly(),
+				Name:       "userName",
+				Required:   true,
+			})),
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Mutability: AttributeMutabilityWriteOnly(),
+				Name:       "password",
+			})),
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Mutability: AttributeMutabilityWriteOnly(),
+				Name:       "pin",
+				Returned:   AttributeReturnedNever(),
+			})),
+			ComplexCoreAttribute(ComplexParams{
+				Name: "name",
+				SubAttributes: []SimpleParams{
+					{name: "flag", typ: attributeDataTypeBoolean, caseExact: true, canonicalValues: []string{"yes"}},
+				},
+			}),
+		},
+	}
+
+	issues := s.Lint()
+	if !HasErrors(issues) {
+		t.Error("expected errors")
+	}
+
+	expected := []LintIssue{
+		{Severity: LintSeverityError, Attribute: "userName"},
+		{Severity: LintSeverityError, Attribute: "password"},
+		{Severity: LintSeverityWarning, Attribute: "name.flag"},
+		{Severity: LintSeverityWarning, Attribute: "name.flag"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %v", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if issue.Severity != expected[i].Severity || issue.Attribute != expected[i].Attribute {
+			t.Errorf("unexpected issue %d: %v", i, issue)
+		}
+	}
+
+	if issues := testSchema.Lint(); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
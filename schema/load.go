@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+// schemaDefinition is the representation of a schema, as defined in RFC 7643, section 7.
+type schemaDefinition struct {
+	ID          string                `json:"id"`
+	Name        interface{}           `json:"name"`
+	Description string                `json:"description"`
+	Attributes  []attributeDefinition `json:"attributes"`
+}
+
+// attributeDefinition is the representation of an attribute within a schema.
+type attributeDefinition struct {
+	Name            string                `json:"name"`
+	Type            string                `json:"type"`
+	SubAttributes   []attributeDefinition `json:"subAttributes"`
+	MultiValued     bool                  `json:"multiValued"`
+	Description     string                `json:"description"`
+	Required        bool                  `json:"required"`
+	CanonicalValues []string              `json:"canonicalValues"`
+	CaseExact       bool                  `json:"caseExact"`
+	Mutability      string                `json:"mutability"`
+	Returned        string                `json:"returned"`
+	Uniqueness      string                `json:"uniqueness"`
+	ReferenceTypes  []string              `json:"referenceTypes"`
+	Constraints     struct {
+		MaxLength int `json:"maxLength"`
+	} `json:"urn:elimity:params:scim:schemas:extension:constraints:2.0"`
+}
+
+// FromJSON returns the schema of given JSON representation, as defined in RFC 7643, section 7, and as returned by the
+// "/Schemas" endpoint. Characteristics that are left out get their default value, e.g. the data type "string" and the
+// mutability "readWrite", and the maximum length of strings is taken from the ConstraintsExtensionID extension.
+//
+// It returns an error if the representation is invalid, e.g. because of an unknown data type or an invalid attribute
+// name, or if the schema has an issue with severity LintSeverityError (see Lint).
+func FromJSON(data []byte) (Schema, error) {
+	var definition schemaDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return Schema{}, err
+	}
+	return definition.schema()
+}
+
+// ListFromJSON returns the schemas of given JSON document, which contains a single schema, an array of schemas or a
+// list response of the "/Schemas" endpoint. See FromJSON.
+func ListFromJSON(data []byte) ([]Schema, error) {
+	var definitions []schemaDefinition
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(data, &definitions); err != nil {
+			return nil, err
+		}
+	default:
+		var list struct {
+			Resources []schemaDefinition `json:"Resources"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		definitions = list.Resources
+		if definitions == nil {
+			var definition schemaDefinition
+			if err := json.Unmarshal(data, &definition); err != nil {
+				return nil, err
+			}
+			definitions = []schemaDefinition{definition}
+		}
+	}
+
+	schemas := make([]Schema, len(definitions))
+	for i, definition := range definitions {
+		s, err := definition.schema()
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+// schema returns the schema of the definition.
+func (d schemaDefinition) schema() (s Schema, err error) {
+	if d.ID == "" {
+		return Schema{}, fmt.Errorf("invalid schema: missing id")
+	}
+
+	// The attribute constructors panic on invalid definitions, e.g. invalid names.
+	defer func() {
+		if r := recover(); r != nil {
+			s, err = Schema{}, fmt.Errorf("invalid schema %q: %v", d.ID, r)
+		}
+	}()
+
+	s = Schema{ID: d.ID}
+	// Schemas that were marshalled without a name have an empty object instead.
+	if name, ok := d.Name.(string); ok && name != "" {
+		s.Name = optional.NewString(name)
+	}
+	if d.Description != "" {
+		s.Description = optional.NewString(d.Description)
+	}
+	for _, a := range d.Attributes {
+		attribute, err := a.attribute()
+		if err != nil {
+			return Schema{}, fmt.Errorf("invalid schema %q: %v", d.ID, err)
+		}
+		s.Attributes = append(s.Attributes, attribute)
+	}
+	for _, issue := range s.Lint() {
+		if issue.Severity == LintSeverityError {
+			return Schema{}, fmt.Errorf("invalid schema %q: %s", d.ID, issue)
+		}
+	}
+	return s.indexed(), nil
+}
+
+// attribute returns the attribute of the definition.
+func (d attributeDefinition) attribute() (CoreAttribute, error) {
+	params, err := d.simpleParams()
+	if err != nil {
+		return CoreAttribute{}, err
+	}
+	if params.typ != attributeDataTypeComplex {
+		return SimpleCoreAttribute(params), nil
+	}
+
+	complexParams := ComplexParams{
+		Description: params.description,
+		MultiValued: params.multiValued,
+		Mutability:  AttributeMutability{m: params.mutability},
+		Name:        params.name,
+		Required:    params.required,
+		Returned:    AttributeReturned{r: params.returned},
+		Uniqueness:  AttributeUniqueness{u: params.uniqueness},
+	}
+	for _, sub := range d.SubAttributes {
+		subParams, err := sub.simpleParams()
+		if err != nil {
+			return CoreAttribute{}, fmt.Errorf("attribute %q: %v", d.Name, err)
+		}
+		if subParams.typ == attributeDataTypeComplex {
+			return CoreAttribute{}, fmt.Errorf("attribute %q: complex attributes cannot contain complex attributes", d.Name+"."+sub.Name)
+		}
+		complexParams.SubAttributes = append(complexParams.SubAttributes, subParams)
+	}
+	return ComplexCoreAttribute(complexParams), nil
+}
+
+// simpleParams returns the parameters of the attribute of the definition, ignoring its sub-attributes.
+func (d attributeDefinition) simpleParams() (SimpleParams, error) {
+	params := SimpleParams{
+		canonicalValues: d.CanonicalValues,
+		caseExact:       d.CaseExact,
+		maxLength:       d.Constraints.MaxLength,
+		multiValued:     d.MultiValued,
+		name:            d.Name,
+		required:        d.Required,
+		typ:             attributeDataTypeString,
+	}
+	if d.Description != "" {
+		params.description = optional.NewString(d.Description)
+	}
+	if d.Type != "" {
+		typ, ok := dataTypes[strings.ToLower(d.Type)]
+		if !ok {
+			return SimpleParams{}, fmt.Errorf("attribute %q: invalid type %q", d.Name, d.Type)
+		}
+		params.typ = typ
+	}
+	if d.Mutability != "" {
+		m, ok := mutabilities[strings.ToLower(d.Mutability)]
+		if !ok {
+			return SimpleParams{}, fmt.Errorf("attribute %q: invalid mutability %q", d.Name, d.Mutability)
+		}
+		params.mutability = m.m
+	}
+	if d.Returned != "" {
+		r, ok := returnedValues[strings.ToLower(d.Returned)]
+		if !ok {
+			return SimpleParams{}, fmt.Errorf("attribute %q: invalid returned %q", d.Name, d.Returned)
+		}
+		params.returned = r.r
+	}
+	if d.Uniqueness != "" {
+		u, ok := uniquenesses[strings.ToLower(d.Uniqueness)]
+		if !ok {
+			return SimpleParams{}, fmt.Errorf("attribute %q: invalid uniqueness %q", d.Name, d.Uniqueness)
+		}
+		params.uniqueness = u.u
+	}
+	for _, referenceType := range d.ReferenceTypes {
+		params.referenceTypes = append(params.referenceTypes, AttributeReferenceType(referenceType))
+	}
+	if len(d.SubAttributes) != 0 && params.typ != attributeDataTypeComplex {
+		return SimpleParams{}, fmt.Errorf("attribute %q: only complex attributes can have sub-attributes", d.Name)
+	}
+	return params, nil
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	for _, s := range []Schema{testSchema, CoreUserSchema(), CoreGroupSchema()} {
+		expected, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := FromJSON(expected)
+		if err != nil {
+			t.Fatalf("%s: %v", s.ID, err)
+		}
+		actual, err := json.Marshal(loaded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != string(expected) {
+			t.Errorf("%s: expected %s, got %s", s.ID, expected, actual)
+		}
+	}
+
+	s, err := FromJSON([]byte(`{
+		"id": "urn:example:2.0:Device",
+		"name": "Device",
+		"attributes": [
+			{"name": "serialNumber", "required": true, "uniqueness": "server"},
+			{"name": "ports", "type": "integer", "multiValued": true},
+			{"name": "owner", "type": "complex", "subAttributes": [
+				{"name": "value", "mutability": "immutable"},
+				{"name": "$ref", "type": "reference", "referenceTypes": ["User"]}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name.Value() != "Device" || len(s.Attributes) != 3 {
+		t.Fatalf("unexpected schema %+v", s)
+	}
+	serialNumber, _ := s.Attribute("serialNumber")
+	if serialNumber.Type().String() != "string" || !serialNumber.Required() || serialNumber.Uniqueness() != AttributeUniquenessServer() {
+		t.Errorf("unexpected attribute %+v", serialNumber)
+	}
+	if ports, _ := s.Attribute("ports"); ports.Type() != AttributeTypeInteger() || !ports.MultiValued() {
+		t.Errorf("unexpected attribute %+v", ports)
+	}
+	owner, _ := s.Attribute("owner")
+	if value, _ := owner.SubAttribute("value"); value == nil || value.Mutability() != AttributeMutabilityImmutable() {
+		t.Errorf("unexpected sub-attribute %+v", value)
+	}
+}
+
+func TestFromJSONInvalid(t *testing.T) {
+	for _, test := range []struct {
+		json     string
+		expected string
+	}{
+		{`{"attributes": []}`, "missing id"},
+		{`{"id": "urn:example:2.0:Device", "attributes": [{"name": "serial", "type": "text"}]}`, `invalid type "text"`},
+		{`{"id": "urn:example:2.0:Device", "attributes": [{"name": "serial", "returned": "sometimes"}]}`, `invalid returned "sometimes"`},
+		{`{"id": "urn:example:2.0:Device", "attributes": [{"name": "serial number"}]}`, `invalid attribute name "serial number"`},
+		{`{"id": "urn:example:2.0:Device", "attributes": [{"name": "serial", "subAttributes": [{"name": "value"}]}]}`, "only complex attributes"},
+		{`{"id": "urn:example:2.0:Device", "attributes": [{"name": "a", "type": "complex", "subAttributes": [{"name": "b", "type": "complex"}]}]}`, "cannot contain complex attributes"},
+		{`[]`, "cannot unmarshal array"},
+	} {
+		if _, err := FromJSON([]byte(test.json)); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.json, test.expected, err)
+		}
+	}
+}
+
+func TestListFromJSON(t *testing.T) {
+	user, err := json.Marshal(CoreUserSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := json.Marshal(CoreGroupSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, document := range []string{
+		`[` + string(user) + `,` + string(group) + `]`,
+		`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"], "totalResults": 2, "Resources": [` + string(user) + `,` + string(group) + `]}`,
+	} {
+		schemas, err := ListFromJSON([]byte(document))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schemas) != 2 || schemas[0].ID != CoreUserSchema().ID || schemas[1].ID != CoreGroupSchema().ID {
+			t.Errorf("unexpected schemas %v", schemas)
+		}
+	}
+
+	schemas, err := ListFromJSON(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 1 || schemas[0].ID != CoreUserSchema().ID {
+		t.Errorf("unexpected schemas %v", schemas)
+	}
+}
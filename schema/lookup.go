@@ -0,0 +1,61 @@
+package schema
+
+import "strings"
+
+// attributeLookup is the precomputed index of the attributes of a schema by their lowercase name.
+type attributeLookup struct {
+	// attributes are the attributes that are indexed. The index is only used as long as the schema holds the same
+	// slice, so schemas whose attributes are replaced fall back to scanning them.
+	attributes []CoreAttribute
+	names      map[string]int
+}
+
+// indexed returns the schema with an index of its attributes. The schemas that are constructed by this package, e.g.
+// CoreUserSchema, NewExtension, ListFromJSON, FromStruct and Override, are indexed.
+func (s Schema) indexed() Schema {
+	s.lookup = &attributeLookup{attributes: s.Attributes, names: indexAttributes(s.Attributes)}
+	return s
+}
+
+// Attribute returns the attribute of the schema with given name, compared case-insensitively. The attributes of the
+// schemas that are constructed by this package are looked up in a precomputed index, as long as the schema holds the
+// slice of attributes it was constructed with; the attributes of other schemas are scanned.
+func (s Schema) Attribute(name string) (*CoreAttribute, bool) {
+	if l := s.lookup; l != nil && len(l.attributes) == len(s.Attributes) &&
+		(len(s.Attributes) == 0 || &l.attributes[0] == &s.Attributes[0]) {
+		if i, ok := l.names[strings.ToLower(name)]; ok && strings.EqualFold(s.Attributes[i].name, name) {
+			return &s.Attributes[i], true
+		}
+	}
+	// Attributes that are not in the index, e.g. because they replaced an indexed attribute, are scanned.
+	for i := range s.Attributes {
+		if strings.EqualFold(s.Attributes[i].name, name) {
+			return &s.Attributes[i], true
+		}
+	}
+	return nil, false
+}
+
+// SubAttribute returns the sub-attribute of a complex attribute with given name, compared case-insensitively. The
+// sub-attributes are looked up in the index that is built when the complex attribute is constructed.
+func (a CoreAttribute) SubAttribute(name string) (*CoreAttribute, bool) {
+	if a.subAttributeIndex == nil {
+		// Only attributes without sub-attributes have no index.
+		return nil, false
+	}
+	i, ok := a.subAttributeIndex[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return &a.subAttributes[i], true
+}
+
+// indexAttributes returns the indexes of given attributes by their lowercase name. If names collide, the first
+// attribute wins.
+func indexAttributes(attributes []CoreAttribute) map[string]int {
+	index := make(map[string]int, len(attributes))
+	for i := len(attributes) - 1; i >= 0; i-- {
+		index[strings.ToLower(attributes[i].name)] = i
+	}
+	return index
+}
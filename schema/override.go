@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+// AttributeOverride redefines characteristics of an attribute of an existing schema, e.g. of one of the core schemas,
+// without copying its whole definition. Only the characteristics that are set are overridden.
+type AttributeOverride struct {
+	// CanonicalValues, if not nil, replaces the suggested canonical values of a string attribute.
+	CanonicalValues []string
+	// CaseExact, if set, overrides whether the values of a string attribute are case sensitive.
+	CaseExact *bool
+	// Description, if present, replaces the description of the attribute.
+	Description optional.String
+	// Mutability, if set, overrides the mutability of the attribute.
+	Mutability *AttributeMutability
+	// Required, if set, overrides whether the attribute is required. Required attributes cannot become optional.
+	Required *bool
+	// Returned, if set, overrides when the attribute is returned.
+	Returned *AttributeReturned
+	// Uniqueness, if set, overrides how the uniqueness of the values of the attribute is enforced.
+	Uniqueness *AttributeUniqueness
+}
+
+// Override returns a copy of the schema in which the characteristics of the attributes are redefined by given
+// overrides, keyed by the name of the attribute, e.g. "userName", or the name of a sub-attribute prefixed with the
+// name of its parent attribute, e.g. "emails.value". The schema itself is not modified.
+//
+// It returns an error if an attribute is not defined by the schema, or if an override breaks an invariant of RFC 7643:
+// required attributes cannot become optional, case exactness only applies to string attributes and the resulting
+// characteristics may not contradict each other (see Lint).
+func (s Schema) Override(overrides map[string]AttributeOverride) (Schema, error) {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	overridden := s
+	overridden.Attributes = append([]CoreAttribute(nil), s.Attributes...)
+	overridden = overridden.indexed()
+	for _, name := range names {
+		parts := strings.SplitN(name, ".", 2)
+		attribute, ok := overridden.Attribute(parts[0])
+		if ok && len(parts) == 2 {
+			attribute.subAttributes = append([]CoreAttribute(nil), attribute.subAttributes...)
+			attribute, ok = attribute.SubAttribute(parts[1])
+		}
+		if !ok {
+			return Schema{}, fmt.Errorf("cannot override attribute %q: it is not defined by schema %q", name, s.ID)
+		}
+		if err := attribute.override(overrides[name]); err != nil {
+			return Schema{}, fmt.Errorf("cannot override attribute %q: %v", name, err)
+		}
+		for _, issue := range attribute.lint("") {
+			if issue.Severity == LintSeverityError {
+				return Schema{}, fmt.Errorf("cannot override attribute %q: %s", name, issue.Message)
+			}
+		}
+	}
+	return overridden, nil
+}
+
+// override applies given override to the attribute.
+func (a *CoreAttribute) override(o AttributeOverride) error {
+	if o.CanonicalValues != nil {
+		if a.typ != attributeDataTypeString {
+			return fmt.Errorf("canonical values are only supported for string attributes")
+		}
+		a.canonicalValues = append([]string(nil), o.CanonicalValues...)
+	}
+	if o.CaseExact != nil {
+		if a.typ != attributeDataTypeString {
+			return fmt.Errorf("case exactness can only be overridden for string attributes")
+		}
+		a.caseExact = *o.CaseExact
+	}
+	if o.Description.Present() {
+		a.description = o.Description
+	}
+	if o.Mutability != nil {
+		a.mutability = o.Mutability.m
+	}
+	if o.Required != nil {
+		if a.required && !*o.Required {
+			return fmt.Errorf("required attributes cannot become optional")
+		}
+		a.required = *o.Required
+	}
+	if o.Returned != nil {
+		a.returned = o.Returned.r
+	}
+	if o.Uniqueness != nil {
+		a.uniqueness = o.Uniqueness.u
+	}
+	return nil
+}
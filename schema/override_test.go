@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaOverride(t *testing.T) {
+	caseExact, required := true, true
+	never := AttributeReturnedNever()
+	user := CoreUserSchema()
+	_, err := user.Override(map[string]AttributeOverride{
+		"userName":           {CaseExact: &caseExact},
+		"phoneNumbers":       {Returned: &never},
+		"emails.value":       {Required: &required},
+		"NAME.familyName":    {CanonicalValues: []string{"Jensen"}},
+		"name.honorificCase": {},
+	})
+	if err == nil || !strings.Contains(err.Error(), "honorificCase") {
+		t.Fatalf("expected an error for the undefined attribute, got %v", err)
+	}
+
+	overridden, err := user.Override(map[string]AttributeOverride{
+		"userName":     {CaseExact: &caseExact},
+		"phoneNumbers": {Returned: &never},
+		"emails.value": {Required: &required},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userName := lookupAttribute(overridden, "userName"); !userName.CaseExact() || !userName.Required() {
+		t.Errorf("expected userName to be case exact and still required")
+	}
+	if phoneNumbers := lookupAttribute(overridden, "phoneNumbers"); phoneNumbers.Returned() != never {
+		t.Errorf("expected phoneNumbers to be never returned")
+	}
+	emails := lookupAttribute(overridden, "emails")
+	if value := lookupSubAttribute(emails, "value"); !value.Required() {
+		t.Errorf("expected emails.value to be required")
+	}
+
+	// The schema itself is left untouched.
+	if lookupAttribute(user, "userName").CaseExact() {
+		t.Error("expected the original userName not to be case exact")
+	}
+	emails = lookupAttribute(user, "emails")
+	if lookupSubAttribute(emails, "value").Required() {
+		t.Error("expected the original emails.value not to be required")
+	}
+}
+
+func TestSchemaOverrideInvariants(t *testing.T) {
+	notRequired, caseExact := false, true
+	readOnly, writeOnly := AttributeMutabilityReadOnly(), AttributeMutabilityWriteOnly()
+	always := AttributeReturnedAlways()
+	for name, override := range map[string]AttributeOverride{
+		"userName": {Required: &notRequired},
+		"active":   {CaseExact: &caseExact},
+		"emails":   {CanonicalValues: []string{"work"}},
+		"password": {Returned: &always},
+		"nickName": {Mutability: &writeOnly},
+	} {
+		if _, err := CoreUserSchema().Override(map[string]AttributeOverride{name: override}); err == nil {
+			t.Errorf("%s: expected the override to be rejected", name)
+		}
+	}
+
+	if _, err := CoreUserSchema().Override(map[string]AttributeOverride{"userName": {Mutability: &readOnly}}); err == nil {
+		t.Error("expected a required read-only attribute to be rejected")
+	}
+}
+
+func lookupAttribute(s Schema, name string) *CoreAttribute {
+	attribute, _ := s.Attribute(name)
+	return attribute
+}
+
+func lookupSubAttribute(a *CoreAttribute, name string) *CoreAttribute {
+	sub, _ := a.SubAttribute(name)
+	return sub
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// PhotoParams are the parameters used to create a "photos" attribute that accepts embedded photos.
+type PhotoParams struct {
+	// MaxSize is the maximum size in bytes of a decoded embedded photo. A value of zero means that there is no limit.
+	MaxSize int
+}
+
+// CoreUserEmbeddedPhotos returns the "photos" attribute of the core User schema, which besides URLs also accepts photos
+// that are embedded in the value as a data URI (e.g. "data:image/jpeg;base64,/9j/4AAQ...") or as a (URL-safe) base64
+// encoded payload, since identity providers send photos in different encodings. Embedded photos are normalized to a
+// base64 encoded data URI and their content type is stored in the read-only "contentType" sub-attribute.
+func CoreUserEmbeddedPhotos(params PhotoParams) CoreAttribute {
+	photos := multiValuedParams(
+		"photos",
+		"URLs or embedded data URIs of photos of the User.",
+		SimpleReferenceParams(ReferenceParams{
+			Description:    optional.NewString("URL or data URI of a photo of the User."),
+			Name:           "value",
+			ReferenceTypes: []AttributeReferenceType{AttributeReferenceTypeExternal},
+		}),
+		[]string{"photo", "thumbnail"},
+	)
+	photos.SubAttributes = append(photos.SubAttributes, SimpleStringParams(StringParams{
+		Description: optional.NewString("The content type of an embedded photo, e.g. 'image/jpeg'. READ-ONLY."),
+		Mutability:  AttributeMutabilityReadOnly(),
+		Name:        "contentType",
+	}))
+
+	attribute := ComplexCoreAttribute(photos)
+	attribute.photo = &params
+	return attribute
+}
+
+// normalize normalizes the value of given (validated) photo and extracts the content type of embedded photos.
+func (p PhotoParams) normalize(photo map[string]interface{}) errors.ValidationError {
+	value, ok := photo["value"].(string)
+	if !ok {
+		return errors.ValidationErrorNil
+	}
+
+	var contentType string
+	var data []byte
+	switch {
+	case strings.HasPrefix(strings.ToLower(value), "data:"):
+		var ok bool
+		contentType, data, ok = parseDataURI(value)
+		if !ok {
+			return errors.ValidationErrorInvalidValue
+		}
+	case strings.Contains(value, "://"):
+		// A regular URL, nothing to normalize.
+		return errors.ValidationErrorNil
+	default:
+		var ok bool
+		data, ok = decodeBase64(value)
+		if !ok {
+			return errors.ValidationErrorInvalidValue
+		}
+	}
+
+	if p.MaxSize > 0 && len(data) > p.MaxSize {
+		return errors.ValidationErrorInvalidValue
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	photo["value"] = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	photo["contentType"] = contentType
+	return errors.ValidationErrorNil
+}
+
+// parseDataURI parses a data URI as defined in RFC 2397: "data:[<mediatype>][;base64],<data>".
+func parseDataURI(uri string) (string, []byte, bool) {
+	i := strings.Index(uri, ",")
+	if i < 0 {
+		return "", nil, false
+	}
+	header, payload := uri[len("data:"):i], uri[i+1:]
+
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		isBase64 = true
+		header = header[:len(header)-len(";base64")]
+	}
+
+	var contentType string
+	if header != "" {
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return "", nil, false
+		}
+		contentType = mediaType
+	}
+
+	if isBase64 {
+		data, ok := decodeBase64(payload)
+		return contentType, data, ok
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", nil, false
+	}
+	return contentType, []byte(data), true
+}
+
+// decodeBase64 decodes given standard or URL-safe base64 encoded string, with or without padding.
+func decodeBase64(s string) ([]byte, bool) {
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, false
+	}
+	if strings.ContainsAny(s, "-_") {
+		data, err := base64.RawURLEncoding.DecodeString(s)
+		return data, err == nil
+	}
+	data, err := base64.RawStdEncoding.DecodeString(s)
+	return data, err == nil
+}
@@ -8,12 +8,21 @@ import (
 	"github.com/elimity-com/scim/optional"
 )
 
+// ConstraintsExtensionID is the URI of the vendor extension under which additional constraints of an attribute (i.e.
+// its "maxLength") are represented in its definition, since RFC 7643 does not define them.
+const ConstraintsExtensionID = "urn:elimity:params:scim:schemas:extension:constraints:2.0"
+
 // Schema is a collection of attribute definitions that describe the contents of an entire or partial resource.
 type Schema struct {
-	Attributes  []CoreAttribute
+	Attributes []CoreAttribute
+	// Constraints restrict the combinations of attribute values, e.g. MutuallyExclusive("password", "federatedOnly").
+	// They are not part of the representation of the schema.
+	Constraints []Constraint
 	Description optional.String
 	ID          string
 	Name        optional.String
+
+	lookup *attributeLookup
 }
 
 // Validate validates given resource based on the schema.
@@ -46,32 +55,53 @@ func (s Schema) Validate(resource interface{}) (map[string]interface{}, errors.V
 	return attributes, errors.ValidationErrorNil
 }
 
-// ValidatePatchOperationValue validates an individual operation and its related value
+// WithoutReadOnly returns a copy of given attributes without the values of the attributes and sub-attributes that
+// are read-only, which clients can not set (RFC 7643, section 2.2). It reports whether any values were left out. Keys of
+// unknown attributes are kept.
+func (s Schema) WithoutReadOnly(attributes map[string]interface{}) (map[string]interface{}, bool) {
+	result := make(map[string]interface{}, len(attributes))
+	var removed bool
+	for k, v := range attributes {
+		attribute, _ := s.Attribute(k)
+		switch {
+		case attribute == nil:
+			result[k] = v
+		case isReadOnly(*attribute):
+			removed = removed || v != nil
+		default:
+			value, r := attribute.withoutReadOnly(v)
+			result[k] = value
+			removed = removed || r
+		}
+	}
+	return result, removed
+}
+
+// ValidatePatchOperationValue validates an individual operation and its related value. The keys of the operation value
+// are attribute names, or paths of sub-attributes (e.g. "name.givenName") whose values are validated against the
+// sub-attribute, taking the mutability of both the attribute and the sub-attribute into account.
 func (s Schema) ValidatePatchOperationValue(operation string, operationValue map[string]interface{}) errors.ValidationError {
 	for k, v := range operationValue {
-		var attr *CoreAttribute
-		scimErr := errors.ValidationErrorNil
-
-		for _, attribute := range s.Attributes {
-			if strings.EqualFold(attribute.name, k) {
-				attr = &attribute
-				break
-			}
-		}
+		names := strings.SplitN(k, ".", 2)
+		attr, _ := s.Attribute(names[0])
 
 		// Attribute does not exist in the schema, thus it is an invalid request.
 		// Immutable attrs can only be added and Readonly attrs cannot be patched
 		if attr == nil || cannotBePatched(operation, *attr) {
 			return errors.ValidationErrorInvalidValue
 		}
+		if len(names) == 2 {
+			attr, _ = attr.SubAttribute(names[1])
+			if attr == nil || cannotBePatched(operation, *attr) {
+				return errors.ValidationErrorInvalidValue
+			}
+		}
 
 		// "remove" operations simply have to exist
 		if operation != "remove" {
-			_, scimErr = attr.validate(v)
-		}
-
-		if scimErr != errors.ValidationErrorNil {
-			return scimErr
+			if _, scimErr := attr.validate(v); scimErr != errors.ValidationErrorNil {
+				return scimErr
+			}
 		}
 	}
 
@@ -90,11 +120,42 @@ func isReadOnly(attr CoreAttribute) bool {
 	return attr.mutability == attributeMutabilityReadOnly
 }
 
+// withoutReadOnly returns a copy of given value of the attribute without the values of its read-only sub-attributes.
+func (a CoreAttribute) withoutReadOnly(value interface{}) (interface{}, bool) {
+	if a.typ != attributeDataTypeComplex {
+		return value, false
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		var removed bool
+		for i, e := range v {
+			var r bool
+			result[i], r = a.withoutReadOnly(e)
+			removed = removed || r
+		}
+		return result, removed
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		var removed bool
+		for k, e := range v {
+			if sub, ok := a.SubAttribute(k); ok && isReadOnly(*sub) {
+				removed = removed || e != nil
+				continue
+			}
+			result[k] = e
+		}
+		return result, removed
+	default:
+		return value, false
+	}
+}
+
 // MarshalJSON converts the schema struct to its corresponding json representation.
 func (s Schema) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
 		"id":          s.ID,
-		"name":        s.Name,
+		"name":        s.Name.Value(),
 		"description": s.Description.Value(),
 		"attributes":  s.getRawAttributes(),
 	})
@@ -109,3 +170,20 @@ func (s Schema) getRawAttributes() []map[string]interface{} {
 
 	return attributes
 }
+
+// Coerce converts string-encoded booleans (e.g. "True") and numbers (e.g. "42") within given attributes to their native
+// types if the data type of the corresponding attribute demands it. Values that can not be converted are left untouched,
+// so they will still be rejected by the validation.
+func (s Schema) Coerce(attributes map[string]interface{}) map[string]interface{} {
+	coerced := make(map[string]interface{}, len(attributes))
+	for k, v := range attributes {
+		coerced[k] = v
+		for _, attribute := range s.Attributes {
+			if strings.EqualFold(attribute.name, k) {
+				coerced[k] = attribute.coerce(v)
+				break
+			}
+		}
+	}
+	return coerced
+}
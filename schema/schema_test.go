@@ -3,6 +3,8 @@ package schema
 import (
 	"encoding/json"
 	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/elimity-com/scim/errors"
@@ -245,3 +247,324 @@ func normalizeJSON(rawJSON []byte) (string, error) {
 
 	return string(ret), err
 }
+
+func TestCoerce(t *testing.T) {
+	coerced := testSchema.Coerce(map[string]interface{}{
+		"required": "true",
+		"booleans": []interface{}{"True", "FALSE", "maybe"},
+		"integer":  "42",
+		"decimal":  "-2.1e5",
+		"unknown":  "1",
+	})
+
+	for k, expected := range map[string]interface{}{
+		"required": "true",
+		"integer":  42,
+		"decimal":  -2.1e5,
+		"unknown":  "1",
+	} {
+		if coerced[k] != expected {
+			t.Errorf("unexpected coerced value for %q: got %v want %v", k, coerced[k], expected)
+		}
+	}
+
+	booleans := coerced["booleans"].([]interface{})
+	if booleans[0] != true || booleans[1] != false || booleans[2] != "maybe" {
+		t.Errorf("unexpected coerced booleans: %v", booleans)
+	}
+}
+
+func TestInvalidAttributeType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("did not panic")
+		}
+	}()
+
+	_ = SimpleCoreAttribute(SimpleParams{name: "unspecified"})
+}
+
+func TestNumberDefaultType(t *testing.T) {
+	attr := SimpleCoreAttribute(SimpleNumberParams(NumberParams{Name: "number"}))
+
+	raw, err := json.Marshal(attr.getRawAttributes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["type"] != "decimal" {
+		t.Errorf("expected type decimal, got %v", m["type"])
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Name:      "userName",
+				MaxLength: 5,
+			})),
+		},
+	}
+
+	for value, expected := range map[string]errors.ValidationError{
+		"bjens":  errors.ValidationErrorNil,
+		"bjëns":  errors.ValidationErrorNil,
+		"bjense": errors.ValidationErrorInvalidValue,
+	} {
+		if _, scimErr := s.Validate(map[string]interface{}{"userName": value}); scimErr != expected {
+			t.Errorf("%q: wrong validation error: got %v want %v", value, scimErr, expected)
+		}
+		if scimErr := s.ValidatePatchOperationValue("replace", map[string]interface{}{"userName": value}); scimErr != expected {
+			t.Errorf("%q: wrong patch validation error: got %v want %v", value, scimErr, expected)
+		}
+	}
+
+	raw, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var definition struct {
+		Attributes []map[string]interface{}
+	}
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		t.Fatal(err)
+	}
+	constraints, _ := definition.Attributes[0][ConstraintsExtensionID].(map[string]interface{})
+	if constraints["maxLength"] != 5.0 {
+		t.Errorf("expected the maximum length in the vendor extension, got %v", definition.Attributes[0])
+	}
+}
+
+func TestAttributeReplaced(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "userName"})),
+		},
+	}
+	if _, ok := s.Attribute("userName"); !ok {
+		t.Fatal("expected attribute userName")
+	}
+
+	s.Attributes[0] = SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "nickName"}))
+	if _, ok := s.Attribute("userName"); ok {
+		t.Error("expected the replaced attribute to be gone")
+	}
+	if a, ok := s.Attribute("NICKNAME"); !ok || a.Name() != "nickName" {
+		t.Error("expected the new attribute nickName")
+	}
+}
+
+func TestSubAttributeIndex(t *testing.T) {
+	emails := ComplexCoreAttribute(ComplexParams{
+		Name:        "emails",
+		MultiValued: true,
+		SubAttributes: []SimpleParams{
+			SimpleStringParams(StringParams{Name: "value", Index: AttributeIndexPrefix()}),
+			SimpleStringParams(StringParams{Name: "type"}),
+		},
+	})
+
+	for name, expected := range map[string]AttributeIndex{
+		"value": AttributeIndexPrefix(),
+		"type":  AttributeIndexNone(),
+	} {
+		sub, ok := emails.SubAttribute(name)
+		if !ok {
+			t.Fatalf("expected sub-attribute %q", name)
+		}
+		if index := sub.Index(); index != expected {
+			t.Errorf("%s: expected index %s, got %s", name, expected, index)
+		}
+	}
+}
+
+func TestCanonicalValues(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Name:            "lenient",
+				CanonicalValues: []string{"work", "home"},
+			})),
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Name:                  "strict",
+				CanonicalValues:       []string{"work", "home"},
+				StrictCanonicalValues: true,
+			})),
+			SimpleCoreAttribute(SimpleStringParams(StringParams{
+				Name:                  "caseExact",
+				CanonicalValues:       []string{"work", "home"},
+				CaseExact:             true,
+				StrictCanonicalValues: true,
+			})),
+		},
+	}
+
+	for _, test := range []struct {
+		name, value, expected string
+		expectedErr           errors.ValidationError
+	}{
+		{"lenient", "work", "work", errors.ValidationErrorNil},
+		{"lenient", "Work", "work", errors.ValidationErrorNil},
+		{"lenient", "other", "other", errors.ValidationErrorNil},
+		{"strict", "HOME", "home", errors.ValidationErrorNil},
+		{"strict", "other", "", errors.ValidationErrorInvalidValue},
+		{"caseExact", "home", "home", errors.ValidationErrorNil},
+		{"caseExact", "Home", "", errors.ValidationErrorInvalidValue},
+	} {
+		attributes, scimErr := s.Validate(map[string]interface{}{test.name: test.value})
+		if scimErr != test.expectedErr {
+			t.Errorf("%s %q: wrong validation error: got %v want %v", test.name, test.value, scimErr, test.expectedErr)
+			continue
+		}
+		if scimErr == errors.ValidationErrorNil && attributes[test.name] != test.expected {
+			t.Errorf("%s %q: expected %q, got %v", test.name, test.value, test.expected, attributes[test.name])
+		}
+		if scimErr := s.ValidatePatchOperationValue("replace", map[string]interface{}{test.name: test.value}); scimErr != test.expectedErr {
+			t.Errorf("%s %q: wrong patch validation error: got %v want %v", test.name, test.value, scimErr, test.expectedErr)
+		}
+	}
+}
+
+func TestValidatePatchOperationValueSubAttribute(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			ComplexCoreAttribute(ComplexParams{
+				Name: "name",
+				SubAttributes: []SimpleParams{
+					SimpleStringParams(StringParams{Name: "givenName"}),
+					SimpleStringParams(StringParams{Name: "formatted", Mutability: AttributeMutabilityReadOnly()}),
+				},
+			}),
+			ComplexCoreAttribute(ComplexParams{
+				Name:        "emails",
+				MultiValued: true,
+				SubAttributes: []SimpleParams{
+					SimpleStringParams(StringParams{Name: "value"}),
+					SimpleBooleanParams(BooleanParams{Name: "primary"}),
+				},
+			}),
+			ComplexCoreAttribute(ComplexParams{
+				Name:       "manager",
+				Mutability: AttributeMutabilityImmutable(),
+				SubAttributes: []SimpleParams{
+					SimpleStringParams(StringParams{Name: "value"}),
+				},
+			}),
+		},
+	}
+
+	for _, test := range []struct {
+		operation string
+		path      string
+		value     interface{}
+		expected  errors.ValidationError
+	}{
+		{"replace", "name.givenName", "Barbara", errors.ValidationErrorNil},
+		{"replace", "Name.GivenName", "Barbara", errors.ValidationErrorNil},
+		{"replace", "name.givenName", 42.0, errors.ValidationErrorInvalidValue},
+		{"replace", "name.unknown", "Barbara", errors.ValidationErrorInvalidValue},
+		{"replace", "name.formatted", "Barbara Jensen", errors.ValidationErrorInvalidValue},
+		{"remove", "name.formatted", nil, errors.ValidationErrorInvalidValue},
+		{"replace", "emails.primary", true, errors.ValidationErrorNil},
+		{"replace", "emails.primary", "yes", errors.ValidationErrorInvalidValue},
+		{"add", "manager.value", "26118915-6090-4610-87e4-49d8ca9f808d", errors.ValidationErrorNil},
+		{"replace", "manager.value", "26118915-6090-4610-87e4-49d8ca9f808d", errors.ValidationErrorInvalidValue},
+	} {
+		if scimErr := s.ValidatePatchOperationValue(test.operation, map[string]interface{}{test.path: test.value}); scimErr != test.expected {
+			t.Errorf("%s %s: wrong validation error: got %v want %v", test.operation, test.path, scimErr, test.expected)
+		}
+	}
+}
+
+func TestWithoutReadOnly(t *testing.T) {
+	attributes, removed := CoreGroupSchema().WithoutReadOnly(map[string]interface{}{
+		"displayName": "Tour Guides",
+		"members": []interface{}{
+			map[string]interface{}{"value": "2819c223", "Display": "Babs Jensen"},
+		},
+		"unknown": "kept",
+	})
+	if !removed {
+		t.Error("expected the read-only sub-attribute to be removed")
+	}
+	expected := map[string]interface{}{
+		"displayName": "Tour Guides",
+		"members": []interface{}{
+			map[string]interface{}{"value": "2819c223"},
+		},
+		"unknown": "kept",
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("expected %v, got %v", expected, attributes)
+	}
+
+	if _, removed := CoreGroupSchema().WithoutReadOnly(map[string]interface{}{"displayName": "Tour Guides"}); removed {
+		t.Error("expected nothing to be removed")
+	}
+}
+
+func TestSchemaAttribute(t *testing.T) {
+	user := CoreUserSchema()
+	for _, name := range []string{"userName", "USERNAME", "emails"} {
+		if attribute, ok := user.Attribute(name); !ok || !strings.EqualFold(attribute.Name(), name) {
+			t.Errorf("expected attribute %q to be found, got %v", name, attribute)
+		}
+	}
+	if _, ok := user.Attribute("unknown"); ok {
+		t.Error("expected an unknown attribute not to be found")
+	}
+	if _, ok := (Schema{}).Attribute("userName"); ok {
+		t.Error("expected no attributes in an empty schema")
+	}
+
+	// Attributes that are added after a lookup are found as well.
+	extended := user
+	extended.Attributes = append(extended.Attributes, SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "badge"})))
+	if _, ok := extended.Attribute("Badge"); !ok {
+		t.Error("expected an added attribute to be found")
+	}
+
+	emails, _ := user.Attribute("emails")
+	if sub, ok := emails.SubAttribute("VALUE"); !ok || sub.Name() != "value" {
+		t.Errorf("expected sub-attribute value to be found, got %v", sub)
+	}
+	if _, ok := emails.SubAttribute("unknown"); ok {
+		t.Error("expected an unknown sub-attribute not to be found")
+	}
+}
+
+func TestSchemaAttributeIndex(t *testing.T) {
+	user := CoreUserSchema()
+	if user.lookup == nil {
+		t.Fatal("expected the core user schema to be indexed")
+	}
+	caseExact := true
+	overridden, err := user.Override(map[string]AttributeOverride{"userName": {CaseExact: &caseExact}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overridden.lookup == nil || overridden.lookup == user.lookup {
+		t.Fatal("expected the overridden schema to be indexed again")
+	}
+	if a, ok := overridden.Attribute("USERNAME"); !ok || a != &overridden.Attributes[0] || !a.CaseExact() {
+		t.Errorf("expected the overridden attribute userName, got %v", a)
+	}
+
+	// An indexed attribute that is replaced is not found by its old name.
+	user.Attributes[0] = SimpleCoreAttribute(SimpleStringParams(StringParams{Name: "badge"}))
+	if _, ok := user.Attribute("userName"); ok {
+		t.Error("expected the replaced attribute to be gone")
+	}
+	if a, ok := user.Attribute("BADGE"); !ok || a != &user.Attributes[0] {
+		t.Error("expected the new attribute badge")
+	}
+}
@@ -7,12 +7,15 @@ type SimpleParams struct {
 	canonicalValues []string
 	caseExact       bool
 	description     optional.String
+	index           attributeIndex
+	maxLength       int
 	multiValued     bool
 	mutability      attributeMutability
 	name            string
 	referenceTypes  []AttributeReferenceType
 	required        bool
 	returned        attributeReturned
+	strictCanonical bool
 	typ             attributeType
 	uniqueness      attributeUniqueness
 }
@@ -49,6 +52,7 @@ func SimpleBooleanParams(params BooleanParams) SimpleParams {
 	return SimpleParams{
 		caseExact:   false,
 		description: params.Description,
+		index:       params.Index.i,
 		multiValued: params.MultiValued,
 		mutability:  params.Mutability.m,
 		name:        params.Name,
@@ -63,6 +67,7 @@ func SimpleBooleanParams(params BooleanParams) SimpleParams {
 // The literal "true" or "false". A boolean has no case sensitivity or uniqueness.
 type BooleanParams struct {
 	Description optional.String
+	Index       AttributeIndex
 	MultiValued bool
 	Mutability  AttributeMutability
 	Name        string
@@ -75,6 +80,7 @@ func SimpleDateTimeParams(params DateTimeParams) SimpleParams {
 	return SimpleParams{
 		caseExact:   false,
 		description: params.Description,
+		index:       params.Index.i,
 		multiValued: params.MultiValued,
 		mutability:  params.Mutability.m,
 		name:        params.Name,
@@ -89,6 +95,7 @@ func SimpleDateTimeParams(params DateTimeParams) SimpleParams {
 // A DateTime value (e.g., 2008-01-23T04:56:22Z). A date time format has no case sensitivity or uniqueness.
 type DateTimeParams struct {
 	Description optional.String
+	Index       AttributeIndex
 	MultiValued bool
 	Mutability  AttributeMutability
 	Name        string
@@ -96,17 +103,24 @@ type DateTimeParams struct {
 	Returned    AttributeReturned
 }
 
-// SimpleNumberParams converts given number parameters to their corresponding simple parameters.
+// SimpleNumberParams converts given number parameters to their corresponding simple parameters. If no type is
+// specified, the attribute will be a decimal.
 func SimpleNumberParams(params NumberParams) SimpleParams {
+	typ := params.Type.t
+	if typ == attributeDataTypeUnspecified {
+		typ = attributeDataTypeDecimal
+	}
+
 	return SimpleParams{
 		caseExact:   false,
 		description: params.Description,
+		index:       params.Index.i,
 		multiValued: params.MultiValued,
 		mutability:  params.Mutability.m,
 		name:        params.Name,
 		required:    params.Required,
 		returned:    params.Returned.r,
-		typ:         params.Type.t,
+		typ:         typ,
 		uniqueness:  params.Uniqueness.u,
 	}
 }
@@ -115,6 +129,7 @@ func SimpleNumberParams(params NumberParams) SimpleParams {
 // A number has no case sensitivity.
 type NumberParams struct {
 	Description optional.String
+	Index       AttributeIndex
 	MultiValued bool
 	Mutability  AttributeMutability
 	Name        string
@@ -129,6 +144,7 @@ func SimpleReferenceParams(params ReferenceParams) SimpleParams {
 	return SimpleParams{
 		caseExact:      true,
 		description:    params.Description,
+		index:          params.Index.i,
 		multiValued:    params.MultiValued,
 		mutability:     params.Mutability.m,
 		name:           params.Name,
@@ -145,6 +161,7 @@ func SimpleReferenceParams(params ReferenceParams) SimpleParams {
 // be linked.
 type ReferenceParams struct {
 	Description    optional.String
+	Index          AttributeIndex
 	MultiValued    bool
 	Mutability     AttributeMutability
 	Name           string
@@ -160,11 +177,14 @@ func SimpleStringParams(params StringParams) SimpleParams {
 		canonicalValues: params.CanonicalValues,
 		caseExact:       params.CaseExact,
 		description:     params.Description,
+		index:           params.Index.i,
+		maxLength:       params.MaxLength,
 		multiValued:     params.MultiValued,
 		mutability:      params.Mutability.m,
 		name:            params.Name,
 		required:        params.Required,
 		returned:        params.Returned.r,
+		strictCanonical: params.StrictCanonicalValues,
 		typ:             attributeDataTypeString,
 		uniqueness:      params.Uniqueness.u,
 	}
@@ -172,14 +192,21 @@ func SimpleStringParams(params StringParams) SimpleParams {
 
 // StringParams are the parameters used to create a simple attribute with a data type of "string".
 // A string is a sequence of zero or more Unicode characters encoded using UTF-8.
+// MaxLength optionally limits the number of characters of a value, e.g. to match the column limits of a database.
+// Values that equal one of the CanonicalValues apart from their casing are normalized to the canonical value, unless
+// the attribute is case exact. If StrictCanonicalValues is set, other values are rejected as well. The values of PATCH
+// operations are checked, but not normalized.
 type StringParams struct {
-	CanonicalValues []string
-	CaseExact       bool
-	Description     optional.String
-	MultiValued     bool
-	Mutability      AttributeMutability
-	Name            string
-	Required        bool
-	Returned        AttributeReturned
-	Uniqueness      AttributeUniqueness
+	CanonicalValues       []string
+	CaseExact             bool
+	Description           optional.String
+	Index                 AttributeIndex
+	MaxLength             int
+	MultiValued           bool
+	Mutability            AttributeMutability
+	Name                  string
+	Required              bool
+	Returned              AttributeReturned
+	StrictCanonicalValues bool
+	Uniqueness            AttributeUniqueness
 }
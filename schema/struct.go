@@ -0,0 +1,274 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	optionalStringType = reflect.TypeOf(optional.String{})
+	bytesType          = reflect.TypeOf([]byte(nil))
+)
+
+// FromStruct derives a schema with given ID and name from the fields of given struct, or pointer to a struct. Fields
+// are mapped onto attributes by their "scim" tag, the same way as by scim.MarshalAttributes, which also accepts the
+// options that describe the characteristics of the attribute:
+//
+//	type User struct {
+//		UserName string   `scim:"userName,required,uniqueness=server"`
+//		Name     *Name    `scim:"name"`
+//		Emails   []Email  `scim:"emails"`
+//		Active   bool     `scim:"active"`
+//		Password string   `scim:"password,mutability=writeOnly,returned=never"`
+//		Groups   []Group  `scim:"groups,mutability=readOnly"`
+//	}
+//
+//	type Email struct {
+//		Value string `scim:"value" description:"Email address of the user."`
+//		Type  string `scim:"type,canonicalValues=work|home|other"`
+//	}
+//
+// The options are "required", "caseExact", "mutability=...", "returned=...", "uniqueness=...",
+// "canonicalValues=a|b|c" and "referenceTypes=User|Group|external|uri", which makes a string attribute a reference.
+// The description of an attribute is taken from the "description" tag.
+//
+// The data type follows from the Go type: strings (and optional.String) are strings, booleans are booleans, integers
+// are integers, floats are decimals, time.Time is a dateTime and []byte is binary. Structs are complex attributes,
+// slices and arrays of any of these are multi-valued and pointers are followed. Fields that are tagged with the URI of
+// a schema extension belong to another schema and are left out.
+func FromStruct(id, name string, v interface{}) (s Schema, err error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return Schema{}, fmt.Errorf("cannot derive a schema from %T: not a struct", v)
+	}
+
+	// The attribute constructors panic on invalid definitions, e.g. invalid names.
+	defer func() {
+		if r := recover(); r != nil {
+			s, err = Schema{}, fmt.Errorf("cannot derive a schema from %s: %v", t, r)
+		}
+	}()
+
+	s = Schema{ID: id, Name: optional.NewString(name)}
+	for _, field := range taggedFields(t) {
+		if strings.Contains(field.name, ":") {
+			continue
+		}
+		attribute, err := field.attribute()
+		if err != nil {
+			return Schema{}, fmt.Errorf("cannot derive a schema from %s: %v", t, err)
+		}
+		s.Attributes = append(s.Attributes, attribute)
+	}
+	for _, issue := range s.Lint() {
+		if issue.Severity == LintSeverityError {
+			return Schema{}, fmt.Errorf("cannot derive a schema from %s: %s", t, issue.Message)
+		}
+	}
+	return s.indexed(), nil
+}
+
+// taggedField is a field of a struct that is mapped onto an attribute.
+type taggedField struct {
+	name        string
+	typ         reflect.Type
+	description string
+	options     map[string]string
+}
+
+// taggedFields returns the fields of given struct type that are mapped onto attributes.
+func taggedFields(t reflect.Type) []taggedField {
+	var fields []taggedField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("scim")
+		if !tagged {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				fields = append(fields, taggedFields(f.Type)...)
+			}
+			continue
+		}
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		field := taggedField{
+			name:        parts[0],
+			typ:         f.Type,
+			description: f.Tag.Get("description"),
+			options:     make(map[string]string),
+		}
+		if field.name == "" {
+			field.name = f.Name
+		}
+		for _, option := range parts[1:] {
+			kv := strings.SplitN(option, "=", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			field.options[kv[0]] = kv[1]
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// attribute returns the attribute of the field.
+func (f taggedField) attribute() (CoreAttribute, error) {
+	typ, multiValued := elementType(f.typ)
+	if typ.Kind() != reflect.Struct || typ == timeType || typ == optionalStringType {
+		params, err := f.simpleParams(typ, multiValued)
+		if err != nil {
+			return CoreAttribute{}, err
+		}
+		return SimpleCoreAttribute(params), nil
+	}
+
+	params := ComplexParams{Name: f.name, MultiValued: multiValued}
+	if err := f.characteristics(&params.Description, &params.Required, &params.Mutability, &params.Returned, &params.Uniqueness); err != nil {
+		return CoreAttribute{}, err
+	}
+	for _, sub := range taggedFields(typ) {
+		subType, subMultiValued := elementType(sub.typ)
+		if subType.Kind() == reflect.Struct && subType != timeType && subType != optionalStringType {
+			return CoreAttribute{}, fmt.Errorf("attribute %q: complex attributes cannot contain complex attributes", f.name+"."+sub.name)
+		}
+		subParams, err := sub.simpleParams(subType, subMultiValued)
+		if err != nil {
+			return CoreAttribute{}, fmt.Errorf("attribute %q: %v", f.name, err)
+		}
+		params.SubAttributes = append(params.SubAttributes, subParams)
+	}
+	return ComplexCoreAttribute(params), nil
+}
+
+// simpleParams returns the parameters of the simple attribute of the field, whose values are of given type.
+func (f taggedField) simpleParams(typ reflect.Type, multiValued bool) (SimpleParams, error) {
+	var (
+		description optional.String
+		required    bool
+		mutability  AttributeMutability
+		returned    AttributeReturned
+		uniqueness  AttributeUniqueness
+	)
+	if err := f.characteristics(&description, &required, &mutability, &returned, &uniqueness); err != nil {
+		return SimpleParams{}, err
+	}
+	_, caseExact := f.options["caseExact"]
+	canonicalValues := splitOption(f.options["canonicalValues"])
+	referenceTypes := splitOption(f.options["referenceTypes"])
+	if (caseExact || canonicalValues != nil) && typ.Kind() != reflect.String && typ != optionalStringType {
+		return SimpleParams{}, fmt.Errorf("attribute %q: caseExact and canonicalValues only apply to strings", f.name)
+	}
+
+	switch {
+	case typ == timeType:
+		return SimpleDateTimeParams(DateTimeParams{
+			Description: description, MultiValued: multiValued, Mutability: mutability, Name: f.name,
+			Required: required, Returned: returned,
+		}), nil
+	case typ == bytesType:
+		return SimpleBinaryParams(BinaryParams{
+			Description: description, MultiValued: multiValued, Mutability: mutability, Name: f.name,
+			Required: required, Returned: returned,
+		}), nil
+	case typ.Kind() == reflect.String || typ == optionalStringType:
+		if referenceTypes != nil {
+			params := ReferenceParams{
+				Description: description, MultiValued: multiValued, Mutability: mutability, Name: f.name,
+				Required: required, Returned: returned, Uniqueness: uniqueness,
+			}
+			for _, referenceType := range referenceTypes {
+				params.ReferenceTypes = append(params.ReferenceTypes, AttributeReferenceType(referenceType))
+			}
+			return SimpleReferenceParams(params), nil
+		}
+		return SimpleStringParams(StringParams{
+			CanonicalValues: canonicalValues, CaseExact: caseExact, Description: description,
+			MultiValued: multiValued, Mutability: mutability, Name: f.name, Required: required, Returned: returned,
+			Uniqueness: uniqueness,
+		}), nil
+	case typ.Kind() == reflect.Bool:
+		return SimpleBooleanParams(BooleanParams{
+			Description: description, MultiValued: multiValued, Mutability: mutability, Name: f.name,
+			Required: required, Returned: returned,
+		}), nil
+	}
+
+	params := NumberParams{
+		Description: description, MultiValued: multiValued, Mutability: mutability, Name: f.name,
+		Required: required, Returned: returned, Uniqueness: uniqueness,
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		params.Type = AttributeTypeInteger()
+	case reflect.Float32, reflect.Float64:
+		params.Type = AttributeTypeDecimal()
+	default:
+		return SimpleParams{}, fmt.Errorf("attribute %q: unsupported type %s", f.name, typ)
+	}
+	return SimpleNumberParams(params), nil
+}
+
+// characteristics sets the characteristics of the attribute of the field that all data types share.
+func (f taggedField) characteristics(description *optional.String, required *bool, mutability *AttributeMutability, returned *AttributeReturned, uniqueness *AttributeUniqueness) error {
+	if f.description != "" {
+		*description = optional.NewString(f.description)
+	}
+	_, *required = f.options["required"]
+	if value, ok := f.options["mutability"]; ok {
+		m, ok := mutabilities[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("attribute %q: invalid mutability %q", f.name, value)
+		}
+		*mutability = m
+	}
+	if value, ok := f.options["returned"]; ok {
+		r, ok := returnedValues[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("attribute %q: invalid returned %q", f.name, value)
+		}
+		*returned = r
+	}
+	if value, ok := f.options["uniqueness"]; ok {
+		u, ok := uniquenesses[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("attribute %q: invalid uniqueness %q", f.name, value)
+		}
+		*uniqueness = u
+	}
+	return nil
+}
+
+// elementType returns the type of the values of a field of given type, following pointers, and whether the field holds
+// multiple values.
+func elementType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == bytesType || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return t, false
+	}
+	t = t.Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+// splitOption splits the "|"-separated values of an option, e.g. "work|home|other". It returns nil for an empty option.
+func splitOption(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "|")
+}
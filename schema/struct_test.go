@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+type testStructEmail struct {
+	Value   string `scim:"value,required" description:"Email address of the user."`
+	Type    string `scim:"type,canonicalValues=work|home|other"`
+	Primary bool   `scim:"primary"`
+}
+
+type testStructGroup struct {
+	Value string `scim:"value"`
+	Ref   string `scim:"$ref,referenceTypes=User|Group"`
+}
+
+type testStructMeta struct {
+	LastLogin *time.Time `scim:"lastLogin,mutability=readOnly"`
+}
+
+type testStructUser struct {
+	testStructMeta
+	UserName    string            `scim:"userName,required,caseExact,uniqueness=server"`
+	DisplayName optional.String   `scim:"displayName"`
+	Age         int               `scim:"age,omitempty"`
+	Height      float64           `scim:"height"`
+	Certificate []byte            `scim:"certificate"`
+	Password    string            `scim:"password,mutability=writeOnly,returned=never"`
+	Emails      []testStructEmail `scim:"emails"`
+	Groups      []testStructGroup `scim:"groups,mutability=readOnly"`
+	Enterprise  *struct{}         `scim:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"`
+	Internal    string
+}
+
+func TestFromStruct(t *testing.T) {
+	s, err := FromStruct("urn:example:params:scim:schemas:User", "User", &testStructUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "urn:example:params:scim:schemas:User" || s.Name.Value() != "User" {
+		t.Errorf("unexpected schema %s (%s)", s.ID, s.Name.Value())
+	}
+	if len(s.Attributes) != 9 {
+		t.Errorf("expected 9 attributes, got %d", len(s.Attributes))
+	}
+
+	for _, test := range []struct {
+		path        string
+		dataType    string
+		multiValued bool
+	}{
+		{"lastLogin", "dateTime", false},
+		{"userName", "string", false},
+		{"displayName", "string", false},
+		{"age", "integer", false},
+		{"height", "decimal", false},
+		{"certificate", "binary", false},
+		{"emails", "complex", true},
+		{"emails.value", "string", false},
+		{"emails.primary", "boolean", false},
+		{"groups.$ref", "reference", false},
+	} {
+		attribute := lookupStructAttribute(t, s, test.path)
+		if attribute.Type().String() != test.dataType || attribute.MultiValued() != test.multiValued {
+			t.Errorf("%s: unexpected type %s (multi-valued: %t)", test.path, attribute.Type(), attribute.MultiValued())
+		}
+	}
+
+	userName := lookupStructAttribute(t, s, "userName")
+	if !userName.Required() || !userName.CaseExact() || userName.Uniqueness() != AttributeUniquenessServer() {
+		t.Error("expected userName to be required, case exact and unique")
+	}
+	password := lookupStructAttribute(t, s, "password")
+	if password.Mutability() != AttributeMutabilityWriteOnly() || password.Returned() != AttributeReturnedNever() {
+		t.Error("expected password to be write-only and never returned")
+	}
+	if lookupStructAttribute(t, s, "groups").Mutability() != AttributeMutabilityReadOnly() {
+		t.Error("expected groups to be read-only")
+	}
+	if values := lookupStructAttribute(t, s, "emails.type").CanonicalValues(); strings.Join(values, ",") != "work,home,other" {
+		t.Errorf("unexpected canonical values %v", values)
+	}
+	if !lookupStructAttribute(t, s, "emails.value").Required() {
+		t.Error("expected emails.value to be required")
+	}
+	if _, ok := s.Attribute("Internal"); ok {
+		t.Error("expected untagged fields to be left out")
+	}
+}
+
+func TestFromStructErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		v        interface{}
+		expected string
+	}{
+		{"not a struct", "User", "not a struct"},
+		{"mutability", struct {
+			A string `scim:"a,mutability=sometimes"`
+		}{}, "invalid mutability"},
+		{"case exact", struct {
+			A int `scim:"a,caseExact"`
+		}{}, "only apply to strings"},
+		{"unsupported", struct {
+			A map[string]string `scim:"a"`
+		}{}, "unsupported type"},
+		{"nested", struct {
+			A struct {
+				B struct {
+					C string `scim:"c"`
+				} `scim:"b"`
+			} `scim:"a"`
+		}{}, "cannot contain complex attributes"},
+		{"name", struct {
+			A string `scim:"1a"`
+		}{}, "invalid attribute name"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := FromStruct("urn:example:params:scim:schemas:Test", "Test", test.v)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error containing %q, got: %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func lookupStructAttribute(t *testing.T, s Schema, path string) CoreAttribute {
+	t.Helper()
+	parts := strings.SplitN(path, ".", 2)
+	attribute, ok := s.Attribute(parts[0])
+	if ok && len(parts) == 2 {
+		attribute, ok = attribute.SubAttribute(parts[1])
+	}
+	if !ok {
+		t.Fatalf("attribute %s not found", path)
+	}
+	return *attribute
+}
@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+func TestCoreUserMultiValuedAttributes(t *testing.T) {
+	s := Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{
+			CoreUserEmails(),
+			CoreUserPhoneNumbers(),
+			CoreUserIms(),
+			CoreUserPhotos(),
+			CoreUserAddresses(),
+			CoreUserGroups(),
+			CoreUserEntitlements(),
+			CoreUserRoles(),
+			CoreUserX509Certificates(),
+		},
+	}
+
+	if _, scimErr := s.Validate(map[string]interface{}{
+		"ims": []interface{}{
+			map[string]interface{}{"value": "someaimhandle", "type": "aim"},
+		},
+		"photos": []interface{}{
+			map[string]interface{}{"value": "https://photos.example.com/profilephoto/72930000000Ccne/F", "type": "photo"},
+		},
+		"addresses": []interface{}{
+			map[string]interface{}{
+				"type":          "work",
+				"streetAddress": "100 Universal City Plaza",
+				"locality":      "Hollywood",
+				"region":        "CA",
+				"postalCode":    "91608",
+				"country":       "USA",
+				"primary":       true,
+			},
+		},
+		"x509Certificates": []interface{}{
+			map[string]interface{}{"value": "ZXhhbXBsZQ=="},
+		},
+	}); scimErr != errors.ValidationErrorNil {
+		t.Errorf("valid resource expected")
+	}
+
+	if _, scimErr := s.Validate(map[string]interface{}{
+		"x509Certificates": []interface{}{
+			map[string]interface{}{"value": "not base64"},
+		},
+	}); scimErr == errors.ValidationErrorNil {
+		t.Errorf("invalid resource expected")
+	}
+}
+
+func TestCoreUserEmbeddedPhotos(t *testing.T) {
+	s := Schema{
+		ID:         "urn:ietf:params:scim:schemas:core:2.0:User",
+		Attributes: []CoreAttribute{CoreUserEmbeddedPhotos(PhotoParams{MaxSize: 16})},
+	}
+	png := "\x89PNG\r\n\x1a\n\xfb\xff"
+
+	for _, test := range []struct {
+		value               string
+		expectedValue       string
+		expectedContentType interface{}
+		expectedErr         errors.ValidationError
+	}{
+		{
+			value:         "https://photos.example.com/profilephoto/72930000000Ccne/F",
+			expectedValue: "https://photos.example.com/profilephoto/72930000000Ccne/F",
+		},
+		{
+			value:               "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString([]byte("jpeg")),
+			expectedValue:       "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString([]byte("jpeg")),
+			expectedContentType: "image/jpeg",
+		},
+		{
+			value:               base64.RawURLEncoding.EncodeToString([]byte(png)),
+			expectedValue:       "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte(png)),
+			expectedContentType: "image/png",
+		},
+		{
+			value:       base64.StdEncoding.EncodeToString([]byte(strings.Repeat(png, 2))),
+			expectedErr: errors.ValidationErrorInvalidValue,
+		},
+		{
+			value:       "not a photo",
+			expectedErr: errors.ValidationErrorInvalidValue,
+		},
+	} {
+		attributes, scimErr := s.Validate(map[string]interface{}{
+			"photos": []interface{}{
+				map[string]interface{}{"value": test.value},
+			},
+		})
+		if scimErr != test.expectedErr {
+			t.Errorf("%q: wrong validation error: got %v want %v", test.value, scimErr, test.expectedErr)
+			continue
+		}
+		if scimErr != errors.ValidationErrorNil {
+			continue
+		}
+
+		photo := attributes["photos"].([]interface{})[0].(map[string]interface{})
+		if photo["value"] != test.expectedValue {
+			t.Errorf("%q: wrong value: got %v want %v", test.value, photo["value"], test.expectedValue)
+		}
+		if photo["contentType"] != test.expectedContentType {
+			t.Errorf("%q: wrong content type: got %v want %v", test.value, photo["contentType"], test.expectedContentType)
+		}
+	}
+}
+
+func TestCoreUserSchema(t *testing.T) {
+	s := CoreUserSchema()
+	if issues := s.Lint(); HasErrors(issues) {
+		t.Errorf("unexpected lint issues: %v", issues)
+	}
+
+	attributes, scimErr := s.Validate(map[string]interface{}{
+		"userName": "bjensen",
+		"name": map[string]interface{}{
+			"formatted":       "Ms. Barbara J Jensen, III",
+			"familyName":      "Jensen",
+			"givenName":       "Barbara",
+			"middleName":      "Jane",
+			"honorificPrefix": "Ms.",
+			"honorificSuffix": "III",
+		},
+		"displayName":       "Babs Jensen",
+		"nickName":          "Babs",
+		"profileUrl":        "https://login.example.com/bjensen",
+		"title":             "Tour Guide",
+		"userType":          "Employee",
+		"preferredLanguage": "en-US",
+		"locale":            "en-US",
+		"timezone":          "America/Los_Angeles",
+		"active":            true,
+		"password":          "t1meMa$heen",
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work", "primary": true},
+		},
+		"groups": []interface{}{
+			map[string]interface{}{"value": "e9e30dba-f08f-4109-8486-d5c6a331660a", "display": "Tour Guides"},
+		},
+	})
+	if scimErr != errors.ValidationErrorNil {
+		t.Fatalf("valid resource expected, got %v", scimErr)
+	}
+	if _, ok := attributes["password"]; !ok {
+		t.Errorf("expected the password to be validated")
+	}
+
+	if _, scimErr := s.Validate(map[string]interface{}{"displayName": "Babs Jensen"}); scimErr == errors.ValidationErrorNil {
+		t.Errorf("invalid resource expected: the userName is required")
+	}
+}
@@ -0,0 +1,202 @@
+package scim
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// SchemaSet gives resource handlers access to the metadata of the attributes of the resource type they serve, such as
+// their type, mutability and case sensitivity. This way storage layers can build indexes and comparisons that are
+// consistent with the schema without duplicating its definitions.
+type SchemaSet struct {
+	schema     schema.Schema
+	extensions []SchemaExtension
+	hashers    map[string]UniquenessHasher
+}
+
+// SchemaSet returns the schema set of the resource type. It can be passed to the handler when the resource type is
+// registered, it is also added to the context of the requests that are passed to the callback methods.
+func (t ResourceType) SchemaSet() SchemaSet {
+	return SchemaSet{
+		schema:     t.Schema,
+		extensions: t.SchemaExtensions,
+		hashers:    t.UniquenessHashers,
+	}
+}
+
+// Schema returns the main schema of the resource type.
+func (s SchemaSet) Schema() schema.Schema {
+	return s.schema
+}
+
+// Extensions returns the schema extensions of the resource type.
+func (s SchemaSet) Extensions() []SchemaExtension {
+	return s.extensions
+}
+
+// Attribute returns the attribute with given path, e.g. "userName", "name.familyName" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber". Attribute names are case insensitive.
+// The boolean is false if the resource type has no such attribute.
+func (s SchemaSet) Attribute(path string) (schema.CoreAttribute, bool) {
+	p, err := ParseAttributePath(path)
+	if err != nil {
+		return schema.CoreAttribute{}, false
+	}
+	return s.attribute(p)
+}
+
+// ValuesEqual reports whether given values of the attribute with given path are equal, honoring its "caseExact"
+// characteristic and data type, see schema.CoreAttribute.ValuesEqual. Handlers can use it to compare values the same
+// way the server does. Values of unknown attributes are equal if they are deeply equal.
+func (s SchemaSet) ValuesEqual(path string, x, y interface{}) bool {
+	attribute, ok := s.Attribute(path)
+	if !ok {
+		return reflect.DeepEqual(x, y)
+	}
+	return attribute.ValuesEqual(x, y)
+}
+
+// Equal reports whether given attributes of two resources of the resource type are equal, see schema.Equal. The
+// attributes of schema extensions are compared according to their extension schema. The server uses it to check that
+// PUT and PATCH requests do not change immutable attributes, and handlers can use it to detect whether a request
+// changes a resource at all.
+func (s SchemaSet) Equal(a, b ResourceAttributes) bool {
+	coreA, extensionsA := s.splitExtensions(a)
+	coreB, extensionsB := s.splitExtensions(b)
+	if !schema.Equal(coreA, coreB, s.schema) {
+		return false
+	}
+	for i, extension := range s.extensions {
+		if !schema.Equal(extensionsA[i], extensionsB[i], extension.Schema) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a hash of given attributes of a resource of the resource type that is consistent with Equal, see
+// schema.Hash. The server does not generate versions itself, but handlers can use it to compute the version of a
+// resource from its attributes, so that the version only changes if the resource changes.
+func (s SchemaSet) Hash(attributes ResourceAttributes) uint64 {
+	core, extensions := s.splitExtensions(attributes)
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, schema.Hash(core, s.schema))
+	for i, extension := range s.extensions {
+		_ = binary.Write(h, binary.LittleEndian, schema.Hash(extensions[i], extension.Schema))
+	}
+	return h.Sum64()
+}
+
+// splitExtensions splits given attributes into the attributes of the main schema and the attributes of each schema
+// extension, in the order of the extensions.
+func (s SchemaSet) splitExtensions(attributes ResourceAttributes) (map[string]interface{}, []map[string]interface{}) {
+	core := make(map[string]interface{}, len(attributes))
+	extensions := make([]map[string]interface{}, len(s.extensions))
+outer:
+	for k, v := range attributes {
+		for i, extension := range s.extensions {
+			if strings.EqualFold(k, extension.Schema.ID) {
+				extensions[i], _ = toAttributeMap(v)
+				continue outer
+			}
+		}
+		core[k] = v
+	}
+	return core, extensions
+}
+
+// DiffResources returns the attributes that differ between given attributes of two resources of the resource type,
+// like DiffResources, but leaves out the changes whose values are equal according to the schemas, see ValuesEqual, e.g.
+// values of multi-valued attributes that are only reordered or strings that only differ in case if they are not case
+// exact. The server uses it for the changes of audit events.
+func (s SchemaSet) DiffResources(before, after ResourceAttributes) []AttributeChange {
+	changes := make([]AttributeChange, 0)
+	for _, change := range DiffResources(before, after) {
+		path := change.Path
+		for _, extension := range s.extensions {
+			// DiffResources separates the attributes of extensions from their URI by a dot.
+			if len(path) > len(extension.Schema.ID) && strings.EqualFold(path[:len(extension.Schema.ID)+1], extension.Schema.ID+".") {
+				path = path[:len(extension.Schema.ID)] + ":" + path[len(extension.Schema.ID)+1:]
+			}
+		}
+		if _, ok := s.Attribute(path); ok && s.ValuesEqual(path, change.Before, change.After) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// qualify returns given path prefixed with the URI of the schema that defines its attribute, e.g.
+// "urn:ietf:params:scim:schemas:core:2.0:User:userName" for "userName", so that paths can be compared regardless of
+// their prefix. Paths of attributes that are not defined by the schemas are returned as they are.
+func (s SchemaSet) qualify(path AttributePath) AttributePath {
+	schemas := []schema.Schema{s.schema}
+	for _, extension := range s.extensions {
+		schemas = append(schemas, extension.Schema)
+	}
+	for _, candidate := range schemas {
+		if path.URI != "" && !strings.EqualFold(candidate.ID, path.URI) {
+			continue
+		}
+		if _, ok := candidate.Attribute(path.AttributeName); ok {
+			path.URI = candidate.ID
+			return path
+		}
+	}
+	return path
+}
+
+// attribute returns the (sub-)attribute that is referred to by given path, ignoring its value filter.
+func (s SchemaSet) attribute(path AttributePath) (schema.CoreAttribute, bool) {
+	schemas := []schema.Schema{s.schema}
+	for _, extension := range s.extensions {
+		schemas = append(schemas, extension.Schema)
+	}
+
+	if path.URI != "" {
+		var prefixed []schema.Schema
+		for _, s := range schemas {
+			if strings.EqualFold(s.ID, path.URI) {
+				prefixed = append(prefixed, s)
+			}
+		}
+		schemas = prefixed
+	}
+
+	for _, s := range schemas {
+		attribute, ok := s.Attribute(path.AttributeName)
+		if !ok {
+			continue
+		}
+		if path.SubAttribute != "" {
+			attribute, ok = attribute.SubAttribute(path.SubAttribute)
+		}
+		if !ok {
+			return schema.CoreAttribute{}, false
+		}
+		return *attribute, true
+	}
+	return schema.CoreAttribute{}, false
+}
+
+type schemaSetContextKey struct{}
+
+// SchemaSetFromContext returns the schema set of the resource type the request is addressed to. The boolean is false
+// if the context does not originate from a request to a resource endpoint.
+func SchemaSetFromContext(ctx context.Context) (SchemaSet, bool) {
+	schemaSet, ok := ctx.Value(schemaSetContextKey{}).(SchemaSet)
+	return schemaSet, ok
+}
+
+// withSchemaSet returns a shallow copy of given request with the schema set of given resource type added to its
+// context.
+func withSchemaSet(r *http.Request, t ResourceType) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), schemaSetContextKey{}, t.SchemaSet()))
+}
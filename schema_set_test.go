@@ -0,0 +1,106 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// schemaSetRecorder records the schema set of the requests it receives.
+type schemaSetRecorder struct {
+	testResourceHandler
+	schemaSet *SchemaSet
+}
+
+func (h schemaSetRecorder) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	*h.schemaSet, _ = SchemaSetFromContext(r.Context())
+	return h.testResourceHandler.Get(r, id)
+}
+
+func TestSchemaSetAttribute(t *testing.T) {
+	schemaSet := newTestServer().ResourceTypes[1].SchemaSet()
+	for _, test := range []struct {
+		path     string
+		name     string
+		dataType string
+	}{
+		{"userName", "userName", "string"},
+		{"NAME", "name", "complex"},
+		{"name.familyName", "familyName", "string"},
+		{"urn:ietf:params:scim:schemas:core:2.0:User:userName", "userName", "string"},
+		{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", "employeeNumber", "string"},
+	} {
+		attribute, ok := schemaSet.Attribute(test.path)
+		if !ok {
+			t.Errorf("%s: attribute not found", test.path)
+			continue
+		}
+		if !strings.EqualFold(attribute.Name(), test.name) || attribute.Type().String() != test.dataType {
+			t.Errorf("%s: got %s (%s)", test.path, attribute.Name(), attribute.Type())
+		}
+	}
+
+	for _, path := range []string{
+		"unknown",
+		"name.unknown",
+		"urn:ietf:params:scim:schemas:core:2.0:User:employeeNumber",
+	} {
+		if _, ok := schemaSet.Attribute(path); ok {
+			t.Errorf("%s: expected no attribute", path)
+		}
+	}
+}
+
+func TestSchemaSetFromContext(t *testing.T) {
+	var schemaSet SchemaSet
+	server := newTestServer()
+	server.ResourceTypes[1].Handler = schemaSetRecorder{
+		testResourceHandler: newTestResourceHandler().(testResourceHandler),
+		schemaSet:           &schemaSet,
+	}
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/EnterpriseUser/0001", nil))
+	if schemaSet.Schema().ID != server.ResourceTypes[1].Schema.ID || len(schemaSet.Extensions()) != 1 {
+		t.Errorf("unexpected schema set: %v", schemaSet)
+	}
+
+	if _, ok := SchemaSetFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); ok {
+		t.Error("expected no schema set")
+	}
+}
+
+func TestSchemaSetEqualAndHash(t *testing.T) {
+	schemaSet := newTestServer().ResourceTypes[1].SchemaSet()
+	a := ResourceAttributes{
+		"userName": "bjensen",
+		"emails":   []interface{}{map[string]interface{}{"value": "a@example.com"}, map[string]interface{}{"value": "b@example.com"}},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701984"},
+	}
+	b := ResourceAttributes{
+		"userName": "BJensen",
+		"emails":   []interface{}{map[string]interface{}{"value": "B@example.com"}, map[string]interface{}{"value": "a@example.com"}},
+		"URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER": map[string]interface{}{"EmployeeNumber": "701984"},
+	}
+	if !schemaSet.Equal(a, b) || schemaSet.Hash(a) != schemaSet.Hash(b) {
+		t.Error("expected the attributes to be equal according to the schemas")
+	}
+	if changes := schemaSet.DiffResources(a, b); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	c := ResourceAttributes{
+		"userName": "bjensen",
+		"emails":   []interface{}{map[string]interface{}{"value": "a@example.com"}, map[string]interface{}{"value": "b@example.com"}},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{"employeeNumber": "701985"},
+	}
+	if schemaSet.Equal(a, c) || schemaSet.Hash(a) == schemaSet.Hash(c) {
+		t.Error("expected the attributes of the extensions to differ")
+	}
+	changes := schemaSet.DiffResources(a, c)
+	if len(changes) != 1 || changes[0].Path != "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User.employeeNumber" {
+		t.Errorf("expected a change of the employee number, got %v", changes)
+	}
+}
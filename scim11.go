@@ -0,0 +1,320 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// scim11CoreSchema is the URI of the core schema of SCIM 1.1, which covers both users and groups.
+	scim11CoreSchema = "urn:scim:schemas:core:1.0"
+	// scim11EnterpriseSchema is the URI of the enterprise user extension of SCIM 1.1.
+	scim11EnterpriseSchema = "urn:scim:schemas:extension:enterprise:1.0"
+
+	scim20CorePrefix       = "urn:ietf:params:scim:schemas:core:2.0:"
+	scim20EnterpriseSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	scim20ListResponse     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scim20PatchOp          = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+)
+
+// isSCIM11Request reports whether given request is addressed to the SCIM 1.1 endpoints under "/v1".
+func isSCIM11Request(r *http.Request) bool {
+	return r.URL.Path == "/v1" || strings.HasPrefix(r.URL.Path, "/v1/")
+}
+
+// serveSCIM11 handles an admitted request of a SCIM 1.1 client to an endpoint under "/v1", see
+// Server.SCIM11Compatibility. The request is translated to SCIM 2.0 and handled as usual, after which ServeHTTP
+// translates the response back:
+//   - the URIs of the core and enterprise user schemas of SCIM 1.1 are replaced by their SCIM 2.0 counterparts, and the
+//     other way around in responses,
+//   - the body of a PATCH request, which contains the attributes to modify and lists the attributes to remove in
+//     "meta.attributes", is converted to PATCH operations; values of multi-valued attributes that are marked with
+//     "operation": "delete" are removed, other values are added,
+//   - errors are returned in the "Errors" array of SCIM 1.1.
+func (s Server) serveSCIM11(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+	if path == "/ServiceProviderConfigs" {
+		path = "/ServiceProviderConfig"
+	}
+	resourceType, _ := s.resourceTypeByPath(path)
+
+	// Signatures cover the body that the client sent, not its translation.
+	if signatureErr := s.verifySignature(r); signatureErr != nil {
+		errorHandler(w, r, *signatureErr)
+		return
+	}
+	s.SignatureVerifier = nil
+
+	translated := r.Clone(r.Context())
+	translated.URL.Path, translated.URL.RawPath = path, ""
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		data, readErr := s.readBody(r)
+		if readErr != nil {
+			errorHandler(w, r, *readErr)
+			return
+		}
+		body, err := translateSCIM11Request(r.Method, data, resourceType)
+		if err != nil {
+			errorHandler(w, r, scimErrorInvalidSyntax)
+			return
+		}
+		translated.Body = ioutil.NopCloser(bytes.NewReader(body))
+		translated.ContentLength = int64(len(body))
+	}
+
+	// Locations refer to the SCIM 1.1 endpoints.
+	if s.BaseURL == "" {
+		s.BaseURL = s.baseURL(r) + "/v1"
+	} else {
+		s.BaseURL = strings.TrimSuffix(strings.TrimSuffix(s.BaseURL, "/"), "/v2") + "/v1"
+	}
+	s.SCIM11Compatibility, s.BasePath = false, ""
+	s.serve(w, s.withBaseURL(translated))
+}
+
+// resourceTypeByPath returns the resource type whose endpoint handles given path, e.g. "/Users/{id}".
+func (s Server) resourceTypeByPath(path string) (ResourceType, bool) {
+	for _, resourceType := range s.ResourceTypes {
+		if path == resourceType.Endpoint || strings.HasPrefix(path, resourceType.Endpoint+"/") {
+			return resourceType, true
+		}
+	}
+	return ResourceType{}, false
+}
+
+// writeSCIM11Response writes the SCIM 1.1 translation of given SCIM 2.0 response.
+func writeSCIM11Response(w http.ResponseWriter, r *http.Request, status int, header http.Header, body []byte) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	body = translateSCIM20Response(status, body)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		logf(r, "failed writing response: %v", err)
+	}
+}
+
+// translateSCIM11Request translates the body of a POST, PUT or PATCH request of a SCIM 1.1 client to SCIM 2.0.
+func translateSCIM11Request(method string, data []byte, resourceType ResourceType) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var resource map[string]interface{}
+	if err := d.Decode(&resource); err != nil {
+		return nil, err
+	}
+
+	if method != http.MethodPatch {
+		translated := make(map[string]interface{}, len(resource))
+		for k, v := range resource {
+			switch {
+			case strings.EqualFold(k, "meta"):
+			case strings.EqualFold(k, "schemas"):
+				translated[k] = translateSchemas(v, func(uri string) string { return scim20SchemaURI(uri, resourceType) })
+			default:
+				translated[scim20SchemaURI(k, resourceType)] = v
+			}
+		}
+		return json.Marshal(translated)
+	}
+
+	operations := make([]map[string]interface{}, 0)
+	// The attributes in "meta.attributes" are removed before the other attributes are modified.
+	if meta, ok := lookupFold(resource, "meta").(map[string]interface{}); ok {
+		removed, _ := lookupFold(meta, "attributes").([]interface{})
+		for _, name := range removed {
+			if name, ok := name.(string); ok {
+				operations = append(operations, map[string]interface{}{
+					"op":   PatchOperationRemove,
+					"path": scim20AttributePath(name, resourceType),
+				})
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch strings.ToLower(k) {
+		case "schemas", "meta", "id":
+			continue
+		}
+		uri := scim20SchemaURI(k, resourceType)
+		if extension, ok := resource[k].(map[string]interface{}); ok && strings.Contains(k, ":") {
+			names := make([]string, 0, len(extension))
+			for name := range extension {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				operations = append(operations, scim11Operations(uri+":"+name, extension[name])...)
+			}
+			continue
+		}
+		operations = append(operations, scim11Operations(k, resource[k])...)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"schemas":    []string{scim20PatchOp},
+		"Operations": operations,
+	})
+}
+
+// scim11Operations returns the PATCH operations that modify the attribute with given path as described by given SCIM
+// 1.1 value. Values of multi-valued attributes are added, unless they are marked with "operation": "delete".
+func scim11Operations(path string, value interface{}) []map[string]interface{} {
+	values, ok := value.([]interface{})
+	if !ok {
+		return []map[string]interface{}{{"op": PatchOperationReplace, "path": path, "value": value}}
+	}
+
+	var operations []map[string]interface{}
+	added := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		complex, ok := v.(map[string]interface{})
+		if !ok {
+			added = append(added, v)
+			continue
+		}
+		operation, _ := lookupFold(complex, "operation").(string)
+		deleteFold(complex, "operation")
+		if !strings.EqualFold(operation, "delete") {
+			added = append(added, complex)
+			continue
+		}
+		if v := lookupFold(complex, "value"); v != nil {
+			raw, _ := json.Marshal(v)
+			operations = append(operations, map[string]interface{}{
+				"op":   PatchOperationRemove,
+				"path": fmt.Sprintf("%s[value eq %s]", path, raw),
+			})
+		}
+	}
+	if len(added) != 0 {
+		operations = append(operations, map[string]interface{}{"op": PatchOperationAdd, "path": path, "value": added})
+	}
+	return operations
+}
+
+// translateSCIM20Response translates the body of a SCIM 2.0 response with given status code to SCIM 1.1. Bodies that
+// are not JSON objects are returned as is.
+func translateSCIM20Response(status int, data []byte) []byte {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var body map[string]interface{}
+	if len(data) == 0 || d.Decode(&body) != nil {
+		return data
+	}
+
+	if status >= http.StatusBadRequest {
+		description, _ := body["detail"].(string)
+		if description == "" {
+			description = http.StatusText(status)
+		}
+		return mustMarshal(map[string]interface{}{
+			"Errors": []map[string]interface{}{{
+				"description": description,
+				"code":        strconv.Itoa(status),
+			}},
+		})
+	}
+	return mustMarshal(scim11Resource(body))
+}
+
+// scim11Resource replaces the URIs of the SCIM 2.0 schemas in given resource or list response by their SCIM 1.1
+// counterparts.
+func scim11Resource(resource map[string]interface{}) map[string]interface{} {
+	translated := make(map[string]interface{}, len(resource))
+	for k, v := range resource {
+		switch {
+		case k == "schemas":
+			translated[k] = translateSchemas(v, scim11SchemaURI)
+		case k == "Resources":
+			resources, _ := v.([]interface{})
+			for i, r := range resources {
+				if r, ok := r.(map[string]interface{}); ok {
+					resources[i] = scim11Resource(r)
+				}
+			}
+			translated[k] = resources
+		default:
+			translated[scim11SchemaURI(k)] = v
+		}
+	}
+	return translated
+}
+
+// translateSchemas translates the URIs in given "schemas" value, leaving out duplicates.
+func translateSchemas(value interface{}, translate func(string) string) interface{} {
+	schemas, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	translated := make([]interface{}, 0, len(schemas))
+	var seen []string
+	for _, uri := range schemas {
+		if uri, ok := uri.(string); ok {
+			uri = translate(uri)
+			if contains(seen, uri) {
+				continue
+			}
+			seen = append(seen, uri)
+			translated = append(translated, uri)
+		}
+	}
+	return translated
+}
+
+// scim20SchemaURI returns the SCIM 2.0 counterpart of given SCIM 1.1 schema URI, or the URI itself if there is none.
+// The SCIM 1.1 core schema is replaced by the schema of given resource type.
+func scim20SchemaURI(uri string, resourceType ResourceType) string {
+	switch {
+	case strings.EqualFold(uri, scim11CoreSchema) && resourceType.Schema.ID != "":
+		return resourceType.Schema.ID
+	case strings.EqualFold(uri, scim11EnterpriseSchema):
+		return scim20EnterpriseSchema
+	default:
+		return uri
+	}
+}
+
+// scim20AttributePath replaces the SCIM 1.1 schema URI that prefixes given attribute path, if any.
+func scim20AttributePath(path string, resourceType ResourceType) string {
+	for _, uri := range []string{scim11CoreSchema, scim11EnterpriseSchema} {
+		if len(path) > len(uri) && strings.EqualFold(path[:len(uri)+1], uri+":") {
+			return scim20SchemaURI(uri, resourceType) + path[len(uri):]
+		}
+	}
+	return path
+}
+
+// scim11SchemaURI returns the SCIM 1.1 counterpart of given SCIM 2.0 schema URI, or the URI itself if there is none.
+func scim11SchemaURI(uri string) string {
+	switch {
+	case strings.HasPrefix(uri, scim20CorePrefix), uri == scim20ListResponse:
+		return scim11CoreSchema
+	case uri == scim20EnterpriseSchema:
+		return scim11EnterpriseSchema
+	default:
+		return uri
+	}
+}
+
+func mustMarshal(v interface{}) []byte {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("failed marshaling response: %v", err)
+	}
+	return raw
+}
@@ -0,0 +1,163 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServerSCIM11Create(t *testing.T) {
+	server := newTestServer()
+	server.SCIM11Compatibility = true
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/EnterpriseUser", strings.NewReader(`{
+		"schemas": ["urn:scim:schemas:core:1.0", "urn:scim:schemas:extension:enterprise:1.0"],
+		"userName": "bjensen",
+		"urn:scim:schemas:extension:enterprise:1.0": {"employeeNumber": "701984"}
+	}`))
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{scim11CoreSchema, scim11EnterpriseSchema}
+	if !reflect.DeepEqual(resource["schemas"], expected) {
+		t.Errorf("expected schemas %v, got %v", expected, resource["schemas"])
+	}
+	extension, ok := resource[scim11EnterpriseSchema].(map[string]interface{})
+	if !ok || extension["employeeNumber"] != "701984" {
+		t.Errorf("expected the enterprise extension under %s, got %v", scim11EnterpriseSchema, resource)
+	}
+	if location := rr.Header().Get("Location"); !strings.Contains(location, "/v1/EnterpriseUser/") {
+		t.Errorf("expected a location under /v1, got %q", location)
+	}
+}
+
+func TestServerSCIM11Get(t *testing.T) {
+	server := newTestServer()
+	server.SCIM11Compatibility = true
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/Users/0001", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resource["schemas"], []interface{}{scim11CoreSchema}) {
+		t.Errorf("expected the SCIM 1.1 core schema, got %v", resource["schemas"])
+	}
+	meta, _ := resource["meta"].(map[string]interface{})
+	if location, _ := meta["location"].(string); !strings.HasSuffix(location, "/v1/Users/0001") {
+		t.Errorf("expected a location under /v1, got %q", location)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/Users/9999", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	var errorResponse struct {
+		Errors []struct {
+			Description string
+			Code        string
+		}
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatal(err)
+	}
+	if len(errorResponse.Errors) != 1 || errorResponse.Errors[0].Code != "404" {
+		t.Errorf("expected a SCIM 1.1 error, got %s", rr.Body.String())
+	}
+}
+
+func TestServerSCIM11Disabled(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/Users/0001", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestServerSCIM11Admission(t *testing.T) {
+	server := newTestServer()
+	server.SCIM11Compatibility = true
+	server.Authenticator = AuthenticatorFunc(func(r *http.Request) (*http.Request, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, fmt.Errorf("no credentials")
+		}
+		return r, nil
+	})
+	server.MaxBodySize = 16
+	server.Config.Features.Bulk.MaxPayloadSize = 8
+
+	for _, test := range []struct {
+		name          string
+		authorization string
+		body          io.Reader
+		expected      int
+	}{
+		{"unauthenticated", "", strings.NewReader(`{}`), http.StatusUnauthorized},
+		{"too large", "Bearer token", strings.NewReader(`{"userName": "bjensen"}`), http.StatusRequestEntityTooLarge},
+		{"unreadable", "Bearer token", failingReader{}, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/Users", test.body)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected %d, got %d: %s", test.name, test.expected, rr.Code, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), `"Errors"`) {
+			t.Errorf("%s: expected a SCIM 1.1 error, got %s", test.name, rr.Body.String())
+		}
+	}
+}
+
+func TestTranslateSCIM11Patch(t *testing.T) {
+	raw, err := translateSCIM11Request(http.MethodPatch, []byte(`{
+		"schemas": ["urn:scim:schemas:core:1.0"],
+		"displayName": "Babs Jensen",
+		"emails": [
+			{"value": "babs@example.com", "type": "home"},
+			{"value": "bjensen@example.com", "operation": "delete"}
+		],
+		"urn:scim:schemas:extension:enterprise:1.0": {"employeeNumber": "701984"},
+		"meta": {"attributes": ["name.givenName", "urn:scim:schemas:extension:enterprise:1.0:organization"]}
+	}`), newTestServer().ResourceTypes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req PatchRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		t.Fatal(err)
+	}
+	expected := []PatchOperation{
+		{Op: PatchOperationRemove, Path: "name.givenName"},
+		{Op: PatchOperationRemove, Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:organization"},
+		{Op: PatchOperationReplace, Path: "displayName", Value: "Babs Jensen"},
+		{Op: PatchOperationRemove, Path: `emails[value eq "bjensen@example.com"]`},
+		{Op: PatchOperationAdd, Path: "emails", Value: []interface{}{
+			map[string]interface{}{"value": "babs@example.com", "type": "home"},
+		}},
+		{Op: PatchOperationReplace, Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", Value: "701984"},
+	}
+	if !reflect.DeepEqual(req.Operations, expected) {
+		t.Errorf("expected %v, got %v", expected, req.Operations)
+	}
+}
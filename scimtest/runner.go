@@ -0,0 +1,273 @@
+package scimtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Runner executes scenarios against a server, either in process through its handler or over the network through its
+// URL.
+type Runner struct {
+	// Handler is the server to test in process, e.g. a scim.Server. It takes precedence over BaseURL.
+	Handler http.Handler
+	// BaseURL is the base URL of the server to test over the network, e.g. "https://example.com/scim/v2".
+	BaseURL string
+	// HTTPClient sends the requests to BaseURL. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Header contains the headers that are added to every request, e.g. an "Authorization" header.
+	Header http.Header
+}
+
+// Run executes the steps of given scenario in order. It returns an error that describes the first step that failed.
+func (r Runner) Run(ctx context.Context, s Scenario) error {
+	variables := make(map[string]string)
+	for i, step := range s.Steps {
+		name := step.Name
+		if name == "" {
+			name = step.Method + " " + step.Path
+		}
+		if err := r.runStep(ctx, step, variables); err != nil {
+			return fmt.Errorf("step %d (%s): %v", i+1, name, err)
+		}
+	}
+	return nil
+}
+
+// Test runs every given scenario as a subtest of given test.
+func (r Runner) Test(t *testing.T, scenarios ...Scenario) {
+	t.Helper()
+	for _, s := range scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			if err := r.Run(context.Background(), s); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestFile parses the scenario in the file with given path with given unmarshaler, see ParseScenario, and runs it as a
+// subtest of given test.
+func (r Runner) TestFile(t *testing.T, path string, unmarshal Unmarshaler) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ParseScenario(data, unmarshal)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if s.Name == "" {
+		s.Name = path
+	}
+	r.Test(t, s)
+}
+
+// runStep sends the request of given step and checks its response. The variables are updated with the values that
+// the step saves.
+func (r Runner) runStep(ctx context.Context, step Step, variables map[string]string) error {
+	var body io.Reader
+	if step.Body != nil {
+		raw, err := json.Marshal(substituteValue(step.Body, variables))
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(step.Method, r.url(substitute(step.Path, variables)), body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	for k, v := range step.Headers {
+		req.Header.Set(k, substitute(v, variables))
+	}
+
+	status, raw, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	if step.Expect.Status != 0 && status != step.Expect.Status {
+		return fmt.Errorf("expected status %d, got %d: %s", step.Expect.Status, status, raw)
+	}
+
+	var response map[string]interface{}
+	if len(raw) != 0 {
+		if err := json.Unmarshal(raw, &response); err != nil && (step.Expect.Body != nil || len(step.Save) != 0) {
+			return fmt.Errorf("invalid response body: %v", err)
+		}
+	}
+	if step.Expect.Body != nil {
+		expected := substituteValue(step.Expect.Body, variables)
+		if path, ok := contains(response, expected); !ok {
+			return fmt.Errorf("unexpected value of %q in response: %s", path, raw)
+		}
+	}
+	for _, path := range step.Expect.Absent {
+		if _, ok := lookup(response, path); ok {
+			return fmt.Errorf("expected %q to be absent from response: %s", path, raw)
+		}
+	}
+	for name, path := range step.Save {
+		value, ok := lookup(response, path)
+		if !ok {
+			return fmt.Errorf("can not save %q: %q is absent from response: %s", name, path, raw)
+		}
+		variables[name] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+// url returns the URL of the request with given path.
+func (r Runner) url(path string) string {
+	if r.Handler != nil {
+		return "http://scimtest" + path
+	}
+	return strings.TrimSuffix(r.BaseURL, "/") + path
+}
+
+// do sends given request and returns the status code and body of the response.
+func (r Runner) do(req *http.Request) (int, []byte, error) {
+	if r.Handler != nil {
+		rr := httptest.NewRecorder()
+		r.Handler.ServeHTTP(rr, req)
+		return rr.Code, rr.Body.Bytes(), nil
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	raw, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, raw, err
+}
+
+// substitute replaces the references to variables in given string by their values. References to unknown variables
+// are left as is.
+func substitute(s string, variables map[string]string) string {
+	for name, value := range variables {
+		s = strings.Replace(s, "${"+name+"}", value, -1)
+	}
+	return s
+}
+
+// substituteValue replaces the references to variables in the strings within given value.
+func substituteValue(value interface{}, variables map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return substitute(v, variables)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = substituteValue(e, variables)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = substituteValue(e, variables)
+		}
+		return s
+	default:
+		return value
+	}
+}
+
+// contains reports whether given actual value contains the expected value, see Expectation.Body. If not, it returns
+// the path of the first value that does not match.
+func contains(actual, expected interface{}) (string, bool) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		for k, v := range e {
+			value, _ := lookupFold(a, k)
+			if path, ok := contains(value, v); !ok {
+				return joinPath(k, path), false
+			}
+		}
+		return "", true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return "", false
+		}
+	outer:
+		for _, v := range e {
+			for _, value := range a {
+				if _, ok := contains(value, v); ok {
+					continue outer
+				}
+			}
+			return "", false
+		}
+		return "", true
+	default:
+		return "", reflect.DeepEqual(actual, expected)
+	}
+}
+
+// lookup returns the value of the (sub-)attribute with given path, e.g. "meta.version", within given response body.
+func lookup(response map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = response
+	for _, name := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = lookupFold(m, name); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func lookupFold(m map[string]interface{}, name string) (interface{}, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(name, path string) string {
+	if path == "" {
+		return name
+	}
+	return name + "." + path
+}
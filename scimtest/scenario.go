@@ -0,0 +1,156 @@
+// Package scimtest runs declarative test scenarios, i.e. sequences of requests with their expected responses, against a
+// SCIM server. Scenarios are plain documents, so they can be written and extended without writing Go, e.g.:
+//
+//	name: provision a user
+//	steps:
+//	  - name: create
+//	    method: POST
+//	    path: /Users
+//	    body: {userName: bjensen}
+//	    expect: {status: 201, body: {userName: bjensen}}
+//	    save: {id: id}
+//	  - name: deactivate
+//	    method: PATCH
+//	    path: /Users/${id}
+//	    body:
+//	      schemas: [urn:ietf:params:scim:api:messages:2.0:PatchOp]
+//	      Operations: [{op: replace, path: active, value: false}]
+//	  - name: check
+//	    method: GET
+//	    path: /Users/${id}
+//	    expect: {status: 200, body: {active: false}}
+//	  - method: DELETE
+//	    path: /Users/${id}
+//	    expect: {status: 204}
+//
+// This package does not depend on a YAML library: pass the Unmarshal function of the YAML library of your choice to
+// ParseScenario, e.g. the one of "gopkg.in/yaml.v2". Scenarios in JSON are parsed without one.
+package scimtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Scenario is a named sequence of steps, which are executed in order. A scenario stops at the first step that fails.
+type Scenario struct {
+	// Name describes the scenario.
+	Name string `json:"name" yaml:"name"`
+	// Steps are the requests to send.
+	Steps []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is a single request of a scenario together with its expected response.
+//
+// The path, the header values and the string values within the body can refer to variables as "${name}", which are
+// replaced by the values that previous steps saved.
+type Step struct {
+	// Name describes the step. It defaults to the method and path.
+	Name string `json:"name" yaml:"name"`
+	// Method is the HTTP method of the request, e.g. "POST".
+	Method string `json:"method" yaml:"method"`
+	// Path is the path of the request relative to the base URL, including the query, e.g. "/Users?filter=...".
+	Path string `json:"path" yaml:"path"`
+	// Headers are the additional headers of the request.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	// Body is the body of the request, which is sent as JSON. The request has no body if it is nil.
+	Body interface{} `json:"body" yaml:"body"`
+	// Expect describes the expected response.
+	Expect Expectation `json:"expect" yaml:"expect"`
+	// Save maps the names of variables to the attributes of the response whose values they are set to, e.g.
+	// {"id": "id", "version": "meta.version"}.
+	Save map[string]string `json:"save" yaml:"save"`
+}
+
+// Expectation describes the expected response of a step.
+type Expectation struct {
+	// Status is the expected status code. It is not checked if zero.
+	Status int `json:"status" yaml:"status"`
+	// Body is the expected subset of the response body: every attribute it contains must be present in the response
+	// with the same value. Attribute names are compared case-insensitively and every value of a multi-valued attribute
+	// must match a value in the response, regardless of their order.
+	Body map[string]interface{} `json:"body" yaml:"body"`
+	// Absent lists the attributes that must not be present in the response body, e.g. "name.givenName".
+	Absent []string `json:"absent" yaml:"absent"`
+}
+
+// Unmarshaler parses a document into given value, e.g. the Unmarshal function of a YAML library.
+type Unmarshaler func(data []byte, v interface{}) error
+
+// ParseScenario parses the scenario in given document with given unmarshaler. If the unmarshaler is nil, the document
+// is parsed as JSON.
+func ParseScenario(data []byte, unmarshal Unmarshaler) (Scenario, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	var s Scenario
+	if err := unmarshal(data, &s); err != nil {
+		return Scenario{}, err
+	}
+
+	for i, step := range s.Steps {
+		if step.Method == "" || step.Path == "" {
+			return Scenario{}, fmt.Errorf("step %d: the method and path are required", i+1)
+		}
+		body, err := normalize(step.Body)
+		if err != nil {
+			return Scenario{}, fmt.Errorf("step %d: %v", i+1, err)
+		}
+		s.Steps[i].Body = body
+		if step.Expect.Body != nil {
+			expected, err := normalize(step.Expect.Body)
+			if err != nil {
+				return Scenario{}, fmt.Errorf("step %d: %v", i+1, err)
+			}
+			s.Steps[i].Expect.Body = expected.(map[string]interface{})
+		}
+	}
+	return s, nil
+}
+
+// normalize converts given value to the types that encoding/json produces, so that values that are parsed from YAML,
+// whose maps may have keys of any type, can be compared to the values in responses.
+func normalize(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(stringKeys(reflect.ValueOf(value)))
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// stringKeys converts the maps within given value to maps with string keys.
+func stringKeys(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return stringKeys(v.Elem())
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = stringKeys(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = stringKeys(v.Index(i))
+		}
+		return s
+	default:
+		return v.Interface()
+	}
+}
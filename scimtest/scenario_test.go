@@ -0,0 +1,136 @@
+package scimtest
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/examples/memstore"
+	"github.com/elimity-com/scim/schema"
+)
+
+const provisioningScenario = `{
+	"name": "provision a user",
+	"steps": [
+		{
+			"name": "create",
+			"method": "POST",
+			"path": "/Users",
+			"body": {"userName": "bjensen", "name": {"givenName": "Barbara"}, "emails": [{"value": "bjensen@example.com"}]},
+			"expect": {"status": 201, "body": {"userName": "bjensen", "emails": [{"value": "bjensen@example.com"}]}},
+			"save": {"id": "id"}
+		},
+		{
+			"name": "patch",
+			"method": "PATCH",
+			"path": "/Users/${id}",
+			"body": {
+				"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations": [
+					{"op": "replace", "path": "active", "value": false},
+					{"op": "remove", "path": "name.givenName"}
+				]
+			},
+			"expect": {"status": 200}
+		},
+		{
+			"name": "assert state",
+			"method": "GET",
+			"path": "/Users/${id}",
+			"expect": {"status": 200, "body": {"id": "${id}", "active": false}, "absent": ["name.givenName"]}
+		},
+		{
+			"method": "DELETE",
+			"path": "/Users/${id}",
+			"expect": {"status": 204}
+		},
+		{
+			"method": "GET",
+			"path": "/Users/${id}",
+			"expect": {"status": 404}
+		}
+	]
+}`
+
+func newTestServer() scim.Server {
+	return scim.Server{
+		Config: scim.ServiceProviderConfig{
+			Features: scim.Features{Patch: scim.PatchFeature{Supported: true}},
+		},
+		ResourceTypes: []scim.ResourceType{{
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   schema.CoreUserSchema(),
+			Handler:  scim.ContextHandler(memstore.New(schema.CoreUserSchema())),
+		}},
+	}
+}
+
+func TestRunner(t *testing.T) {
+	s, err := ParseScenario([]byte(provisioningScenario), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Runner{Handler: newTestServer()}.Test(t, s)
+
+	server := httptest.NewServer(newTestServer())
+	defer server.Close()
+	if err := (Runner{BaseURL: server.URL}).Run(context.Background(), s); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunnerFailure(t *testing.T) {
+	s, err := ParseScenario([]byte(`{"steps": [
+		{"method": "POST", "path": "/Users", "body": {"userName": "bjensen"}, "expect": {"status": 201}},
+		{"name": "get unknown", "method": "GET", "path": "/Users/unknown", "expect": {"status": 200}}
+	]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Runner{Handler: newTestServer()}.Run(context.Background(), s)
+	if err == nil || !strings.HasPrefix(err.Error(), "step 2 (get unknown): expected status 200, got 404") {
+		t.Errorf("expected the second step to fail, got %v", err)
+	}
+}
+
+func TestParseScenarioUnmarshaler(t *testing.T) {
+	// The Unmarshal functions of YAML libraries produce maps with keys of any type and integers.
+	unmarshal := func(data []byte, v interface{}) error {
+		*v.(*Scenario) = Scenario{Steps: []Step{{
+			Method: "POST",
+			Path:   "/Users",
+			Body: map[interface{}]interface{}{
+				"userName": "bjensen",
+				"emails":   []interface{}{map[interface{}]interface{}{"value": "bjensen@example.com"}},
+			},
+			Expect: Expectation{Status: 201, Body: map[string]interface{}{
+				"meta": map[interface{}]interface{}{"version": 1},
+			}},
+		}}}
+		return nil
+	}
+	s, err := ParseScenario(nil, unmarshal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := map[string]interface{}{
+		"userName": "bjensen",
+		"emails":   []interface{}{map[string]interface{}{"value": "bjensen@example.com"}},
+	}
+	if !reflect.DeepEqual(s.Steps[0].Body, body) {
+		t.Errorf("expected body %v, got %v", body, s.Steps[0].Body)
+	}
+	expected := map[string]interface{}{"meta": map[string]interface{}{"version": float64(1)}}
+	if !reflect.DeepEqual(s.Steps[0].Expect.Body, expected) {
+		t.Errorf("expected body %v, got %v", expected, s.Steps[0].Expect.Body)
+	}
+
+	if _, err := ParseScenario([]byte(`{"steps": [{"path": "/Users"}]}`), nil); err == nil {
+		t.Error("expected a step without method to be rejected")
+	}
+}
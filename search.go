@@ -0,0 +1,150 @@
+package scim
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+const searchRequestSchema = "urn:ietf:params:scim:api:messages:2.0:SearchRequest"
+
+// searchRequest is the body of a query using HTTP POST, as described in RFC 7644 section 3.4.3. It holds the same
+// parameters as the query string of a GET request to a resource endpoint.
+type searchRequest struct {
+	Schemas            []string `json:"schemas"`
+	Attributes         []string `json:"attributes"`
+	ExcludedAttributes []string `json:"excludedAttributes"`
+	Filter             string   `json:"filter"`
+	SortBy             string   `json:"sortBy"`
+	SortOrder          string   `json:"sortOrder"`
+	StartIndex         *int     `json:"startIndex"`
+	Count              *int     `json:"count"`
+}
+
+// parseSearchRequest parses the search request in the body of given request. It returns a shallow copy of the request
+// of which the query string holds the parameters of the search request, so that it can be handled as a GET request.
+func parseSearchRequest(r *http.Request) (*http.Request, *scimError) {
+	data, _ := ioutil.ReadAll(r.Body)
+
+	var req searchRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, &scimErrorInvalidSyntax
+	}
+	if len(req.Schemas) != 1 || req.Schemas[0] != searchRequestSchema {
+		return nil, &scimErrorInvalidValue
+	}
+
+	query := make(url.Values)
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("attributes", strings.Join(req.Attributes, ","))
+	set("excludedAttributes", strings.Join(req.ExcludedAttributes, ","))
+	set("filter", req.Filter)
+	set("sortBy", req.SortBy)
+	set("sortOrder", req.SortOrder)
+	if req.StartIndex != nil {
+		set("startIndex", strconv.Itoa(*req.StartIndex))
+	}
+	if req.Count != nil {
+		set("count", strconv.Itoa(*req.Count))
+	}
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	search := r.WithContext(r.Context())
+	search.URL = &u
+	return search, nil
+}
+
+// searchHandler receives an HTTP POST to the "/.search" endpoint of a resource type, e.g. "/Users/.search", to
+// retrieve the resources that match the search request in the body.
+func (s Server) searchHandler(w http.ResponseWriter, r *http.Request, resourceType ResourceType) {
+	search, scimErr := parseSearchRequest(r)
+	if scimErr != nil {
+		errorHandler(w, r, *scimErr)
+		return
+	}
+	s.resourcesGetHandler(w, search, resourceType)
+}
+
+// rootSearchHandler receives an HTTP POST to the "/.search" endpoint at the root of the server, to retrieve the
+// resources of all resource types that match the search request in the body. The results of the resource types are
+// concatenated in the order in which the resource types are configured, so the sort parameters only apply to the
+// resources of the same type.
+func (s Server) rootSearchHandler(w http.ResponseWriter, r *http.Request) {
+	search, scimErr := parseSearchRequest(r)
+	if scimErr != nil {
+		errorHandler(w, r, *scimErr)
+		return
+	}
+	params, paramsErr := s.parseRequestParams(search)
+	if paramsErr != nil {
+		errorHandler(w, r, *paramsErr)
+		return
+	}
+
+	var total int
+	exact := true
+	var resources []interface{}
+	for _, resourceType := range s.ResourceTypes {
+		typeRequest := withOperation(withSchemaSet(search, resourceType), OperationList)
+		typeParams := params
+		// The start index is relative to the resources of the previous resource types.
+		if typeParams.StartIndex = params.StartIndex - total; typeParams.StartIndex < 1 {
+			typeParams.StartIndex = 1
+		}
+		if typeParams.Count = params.Count - len(resources); typeParams.Count < 1 {
+			// The resource type is still queried to know its total number of results.
+			typeParams.Count = 1
+		}
+		if typeParams.SortBy != "" && resourceType.lookupAttribute(typeParams.SortBy) == nil {
+			typeParams.SortBy, typeParams.SortOrder = "", ""
+		}
+
+		page, getError := resourceType.Handler.GetAll(typeRequest, typeParams)
+		if getError != errors.GetErrorNil {
+			errorHandler(w, r, scimGetAllError(getError))
+			return
+		}
+		if sorter, ok := resourceType.Handler.(Sorter); ok && !sorter.SupportsSort() {
+			resourceType.SchemaSet().sortResources(page.Resources, typeParams)
+		}
+
+		pageTotal, pageExact := page.totalResults(typeParams)
+		exact = exact && pageExact
+		if params.StartIndex <= total+pageTotal {
+			projection := resourceType.parseProjection(search)
+			for _, v := range page.Resources {
+				if len(resources) == params.Count {
+					break
+				}
+				resources = append(resources, resourceType.project(v.response(r, resourceType), projection))
+			}
+		}
+		total += pageTotal
+	}
+
+	raw, err := json.Marshal(s.withTotalResults(listResponse{
+		Resources:    resources,
+		StartIndex:   params.StartIndex,
+		ItemsPerPage: params.Count,
+	}, total, exact))
+	if err != nil {
+		errorHandler(w, r, scimErrorInternalServer)
+		log.Fatalf("failed marshalling list response: %v", err)
+		return
+	}
+	_, err = w.Write(raw)
+	if err != nil {
+		logf(r, "failed writing response: %v", err)
+	}
+}
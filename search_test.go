@@ -0,0 +1,66 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerSearchHandler(t *testing.T) {
+	for _, test := range []struct {
+		target       string
+		body         string
+		expected     int
+		totalResults int
+		resources    int
+	}{
+		{"/Users/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"attributes": ["userName"],
+			"startIndex": 1,
+			"count": 5
+		}`, http.StatusOK, 20, 5},
+		{"/Users/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"filter": "userName eq \"test1\""
+		}`, http.StatusOK, 20, 20},
+		{"/Users/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"filter": "userName eq"
+		}`, http.StatusBadRequest, 0, 0},
+		{"/Users/.search", `{"filter": "userName pr"}`, http.StatusBadRequest, 0, 0},
+		{"/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"startIndex": 15,
+			"count": 10
+		}`, http.StatusOK, 40, 10},
+		{"/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"startIndex": 35
+		}`, http.StatusOK, 40, 6},
+	} {
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: handler returned wrong status code: got %v want %v", test.target, test.body, rr.Code, test.expected)
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			continue
+		}
+
+		var response struct {
+			TotalResults int
+			Resources    []map[string]interface{}
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalResults != test.totalResults || len(response.Resources) != test.resources {
+			t.Errorf("%s %s: got %d of %d resources, want %d of %d", test.target, test.body,
+				len(response.Resources), response.TotalResults, test.resources, test.totalResults)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// SelfCheckStatus is the outcome of a single check of a self-check.
+type SelfCheckStatus string
+
+const (
+	// SelfCheckPassed indicates that the check passed.
+	SelfCheckPassed SelfCheckStatus = "passed"
+	// SelfCheckFailed indicates that the check failed, see the message of the result.
+	SelfCheckFailed SelfCheckStatus = "failed"
+	// SelfCheckSkipped indicates that the check does not apply or is not enabled, e.g. because the resource type does
+	// not support creating resources.
+	SelfCheckSkipped SelfCheckStatus = "skipped"
+)
+
+// SelfCheckResult is the result of a single check of a self-check.
+type SelfCheckResult struct {
+	// Name identifies the check, e.g. "discovery /Schemas", "schema urn:ietf:params:scim:schemas:core:2.0:User" or
+	// "canary /Users".
+	Name string `json:"name"`
+	// Status is the outcome of the check.
+	Status SelfCheckStatus `json:"status"`
+	// Message explains why the check failed or was skipped.
+	Message string `json:"message,omitempty"`
+}
+
+// SelfCheckReport is the report of a self-check, see Server.SelfCheck. It can be marshaled to JSON, e.g. to be
+// published by a deployment pipeline.
+type SelfCheckReport struct {
+	// Checks are the results of the checks, in the order in which they ran.
+	Checks []SelfCheckResult `json:"checks"`
+}
+
+// Passed reports whether none of the checks failed.
+func (r SelfCheckReport) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == SelfCheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaSchemaID is the URI of the schema of the representations of schemas.
+const schemaSchemaID = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+type selfCheckContextKey struct{}
+
+// IsSelfCheck reports whether given context belongs to a request of a self-check, see Server.SelfCheck. Handlers can
+// use it to store the canary resources in a sandbox instead of their regular storage.
+func IsSelfCheck(ctx context.Context) bool {
+	selfCheck, _ := ctx.Value(selfCheckContextKey{}).(bool)
+	return selfCheck
+}
+
+// SelfCheckOption configures a self-check, see Server.SelfCheck.
+type SelfCheckOption func(c *selfCheckConfig)
+
+type selfCheckConfig struct {
+	canaries bool
+}
+
+// SelfCheckCanaries enables the canary checks of a self-check, which create, retrieve and delete a resource of every
+// resource type with the resource handlers. Only enable them if the handlers can store the canaries without side
+// effects, e.g. in a sandbox, see IsSelfCheck.
+func SelfCheckCanaries() SelfCheckOption {
+	return func(c *selfCheckConfig) {
+		c.canaries = true
+	}
+}
+
+// canariesDisabled is the reason why the canary checks are skipped if they are not enabled.
+const canariesDisabled = "canary resources are only created if enabled, see SelfCheckCanaries"
+
+// SelfCheck runs a quick battery of checks against the configuration and the resource handlers of the server, e.g.
+// when it starts or from a deployment pipeline:
+//
+//   - the endpoints of the resource types do not conflict, see Server.RouteConflicts;
+//   - the discovery endpoints respond and their documents validate against the schemas they declare, including the
+//     types, required attributes and characteristics (e.g. the mutability) of the attribute definitions;
+//   - the schemas of the resource types are served and have no lint errors, see schema.Schema.Lint;
+//   - a canary resource of every resource type, with generated values for its required attributes, can be created,
+//     retrieved and deleted. These checks write to the resource handlers, so they are skipped unless they are enabled
+//     with SelfCheckCanaries.
+//
+// The requests are handled in the same way as the requests of clients, except that access control, load shedding,
+// signature verification and authentication are skipped. Their contexts are derived from given context and marked,
+// see IsSelfCheck.
+func (s Server) SelfCheck(ctx context.Context, opts ...SelfCheckOption) SelfCheckReport {
+	var config selfCheckConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	s.AccessControl, s.LoadShedder, s.SignatureVerifier, s.Authenticator = nil, nil, nil, nil
+	// The requests are addressed to the endpoints themselves.
+	s.BasePath = ""
+	ctx = context.WithValue(ctx, selfCheckContextKey{}, true)
+
+	var report SelfCheckReport
+	add := func(name string, err error) {
+		result := SelfCheckResult{Name: name, Status: SelfCheckPassed}
+		if err != nil {
+			result.Status, result.Message = SelfCheckFailed, err.Error()
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	add("routes", checkRoutes(s.RouteConflicts()))
+	add("discovery /ServiceProviderConfig", s.checkServiceProviderConfig(ctx))
+	schemaIDs, err := s.checkSchemas(ctx)
+	add("discovery /Schemas", err)
+	add("discovery /ResourceTypes", s.checkResourceTypes(ctx, schemaIDs))
+
+	for _, definition := range s.getSchemas() {
+		add("schema "+definition.ID, checkSchemaLint(definition))
+	}
+
+	for _, resourceType := range s.ResourceTypes {
+		name := "canary " + resourceType.Endpoint
+		if !config.canaries {
+			report.Checks = append(report.Checks, SelfCheckResult{Name: name, Status: SelfCheckSkipped, Message: canariesDisabled})
+			continue
+		}
+		skipped, err := s.checkCanary(ctx, resourceType)
+		if skipped != "" {
+			report.Checks = append(report.Checks, SelfCheckResult{Name: name, Status: SelfCheckSkipped, Message: skipped})
+			continue
+		}
+		add(name, err)
+	}
+	return report
+}
+
+// selfCheckRequest serves a request with given method, path and body, and decodes the body of the response into v if
+// it is not nil.
+func (s Server) selfCheckRequest(ctx context.Context, method, path string, body interface{}, v interface{}) (int, error) {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+	r, err := http.NewRequest(method, path, bytes.NewReader(raw))
+	if err != nil {
+		return 0, err
+	}
+	r = r.WithContext(ctx)
+	r.Header.Set("Content-Type", "application/scim+json")
+
+	rw := &bulkResponseWriter{header: make(http.Header), status: http.StatusOK}
+	s.ServeHTTP(rw, r)
+	if v != nil && rw.body.Len() != 0 {
+		if err := json.Unmarshal(rw.body.Bytes(), v); err != nil {
+			return rw.status, fmt.Errorf("%s %s: invalid response body: %v", method, path, err)
+		}
+	}
+	return rw.status, nil
+}
+
+// selfCheckGet retrieves the document with given path into v, if it is not nil.
+func (s Server) selfCheckGet(ctx context.Context, path string, v interface{}) error {
+	status, err := s.selfCheckRequest(ctx, http.MethodGet, path, nil, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", path, status)
+	}
+	return nil
+}
+
+func (s Server) checkServiceProviderConfig(ctx context.Context) error {
+	var config map[string]interface{}
+	if err := s.selfCheckGet(ctx, "/ServiceProviderConfig", &config); err != nil {
+		return err
+	}
+	return checkDocument(config, serviceProviderConfigSchema())
+}
+
+// checkSchemas checks the documents of the "/Schemas" endpoint and returns the identifiers of the schemas.
+func (s Server) checkSchemas(ctx context.Context) (map[string]bool, error) {
+	var list struct{ Resources []map[string]interface{} }
+	if err := s.selfCheckGet(ctx, "/Schemas", &list); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(list.Resources))
+	for _, document := range list.Resources {
+		if err := checkDocument(document, schemaSchema()); err != nil {
+			return nil, err
+		}
+		id, _ := document["id"].(string)
+		attributes, _ := document["attributes"].([]interface{})
+		if err := checkSubAttributeDefinitions(id, attributes); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// checkResourceTypes checks the documents of the "/ResourceTypes" endpoint, including that their schemas are served
+// by the "/Schemas" endpoint, i.e. are in given identifiers.
+func (s Server) checkResourceTypes(ctx context.Context, schemaIDs map[string]bool) error {
+	var list struct{ Resources []map[string]interface{} }
+	if err := s.selfCheckGet(ctx, "/ResourceTypes", &list); err != nil {
+		return err
+	}
+	if len(list.Resources) != len(s.ResourceTypes) {
+		return fmt.Errorf("expected %d resource types, got %d", len(s.ResourceTypes), len(list.Resources))
+	}
+	for _, document := range list.Resources {
+		if err := checkDocument(document, resourceTypeSchema()); err != nil {
+			return err
+		}
+		if schemaIDs == nil {
+			continue
+		}
+		ids := []interface{}{document["schema"]}
+		extensions, _ := document["schemaExtensions"].([]interface{})
+		for _, extension := range extensions {
+			extension, _ := extension.(map[string]interface{})
+			ids = append(ids, extension["schema"])
+		}
+		for _, id := range ids {
+			if id, _ := id.(string); !schemaIDs[id] {
+				return fmt.Errorf("resource type %v refers to schema %q, which is not served", document["name"], id)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDocument checks that given discovery document declares given schema and validates against it. The declaration
+// is optional for the representations of schemas, which do not have a "schemas" attribute themselves.
+func checkDocument(document map[string]interface{}, definition schema.Schema) error {
+	schemas, _ := document["schemas"].([]interface{})
+	var declared bool
+	for _, s := range schemas {
+		declared = declared || s == definition.ID
+	}
+	if !declared && (definition.ID != schemaSchemaID || schemas != nil) {
+		return fmt.Errorf("document does not declare schema %q", definition.ID)
+	}
+	return validateDocument(document, definition)
+}
+
+// checkSubAttributeDefinitions checks the definitions of the sub-attributes within given attribute definitions of the
+// schema with given identifier.
+func checkSubAttributeDefinitions(id string, attributes []interface{}) error {
+	for _, attribute := range attributes {
+		attribute, _ := attribute.(map[string]interface{})
+		subAttributes, _ := attribute["subAttributes"].([]interface{})
+		for _, sub := range subAttributes {
+			sub, ok := sub.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("schema %q: attribute %v has an invalid sub-attribute definition", id, attribute["name"])
+			}
+			if err := validateDocument(sub, attributeDefinitionSchema()); err != nil {
+				return fmt.Errorf("schema %q: attribute %v: %v", id, attribute["name"], err)
+			}
+		}
+		if err := checkSubAttributeDefinitions(id, subAttributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDocument validates given document against given schema, one attribute at a time to report the attribute
+// that does not validate. Whole numbers are validated as integers, since the discovery schemas have no decimals.
+func validateDocument(document map[string]interface{}, definition schema.Schema) error {
+	document, _ = wholeNumbers(document).(map[string]interface{})
+	for _, attribute := range definition.Attributes {
+		single := schema.Schema{ID: definition.ID, Attributes: []schema.CoreAttribute{attribute}}
+		if _, err := single.Validate(document); err != errors.ValidationErrorNil {
+			return fmt.Errorf(
+				"document of schema %q: attribute %q does not validate: %s",
+				definition.ID, attribute.Name(), scimValidationError(err).scimType,
+			)
+		}
+	}
+	return nil
+}
+
+// wholeNumbers returns given decoded JSON value with its whole numbers converted to integers.
+func wholeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v)
+		}
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, element := range v {
+			converted[i] = wholeNumbers(element)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, element := range v {
+			converted[k] = wholeNumbers(element)
+		}
+		return converted
+	}
+	return value
+}
+
+func checkSchemaLint(s schema.Schema) error {
+	for _, issue := range s.Lint() {
+		if issue.Severity == schema.LintSeverityError {
+			return fmt.Errorf("lint: %s", issue)
+		}
+	}
+	return nil
+}
+
+// checkRoutes returns an error that describes given route conflicts, if any.
+func checkRoutes(conflicts []RouteConflict) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	messages := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		messages[i] = conflict.Error()
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "; "))
+}
+
+// checkCanary creates, retrieves and deletes a canary resource of given resource type. It returns the reason why the
+// check is skipped, if it is.
+func (s Server) checkCanary(ctx context.Context, resourceType ResourceType) (string, error) {
+	if resourceType.Handler == nil {
+		return "", fmt.Errorf("the resource type has no handler")
+	}
+
+	var created map[string]interface{}
+	status, err := s.selfCheckRequest(ctx, http.MethodPost, resourceType.Endpoint, s.canary(resourceType), &created)
+	switch {
+	case err != nil:
+		return "", err
+	case status == http.StatusNotImplemented:
+		return "the resource type does not support creating resources", nil
+	case status != http.StatusCreated:
+		return "", fmt.Errorf("POST %s: unexpected status %d: %v", resourceType.Endpoint, status, created["detail"])
+	}
+	id, _ := created["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("POST %s: the created resource has no identifier", resourceType.Endpoint)
+	}
+
+	path := resourceType.Endpoint + "/" + id
+	getErr := s.selfCheckGet(ctx, path, nil)
+	// The canary is deleted, even if it could not be retrieved.
+	status, err = s.selfCheckRequest(ctx, http.MethodDelete, path, nil, nil)
+	switch {
+	case getErr != nil:
+		return "", getErr
+	case err != nil:
+		return "", err
+	case status != http.StatusNoContent:
+		return "", fmt.Errorf("DELETE %s: unexpected status %d, the canary resource may be left behind", path, status)
+	}
+	return "", nil
+}
+
+// canary returns the attributes of a canary resource of given resource type, with generated values for the required
+// attributes of its schema and required schema extensions.
+func (s Server) canary(resourceType ResourceType) map[string]interface{} {
+	clock := s.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	g := canaryGenerator{suffix: randomIDGenerator{}.NewID(), now: clock.Now()}
+
+	attributes := g.complex(resourceType.Schema.Attributes)
+	for _, extension := range resourceType.SchemaExtensions {
+		if extension.Required {
+			attributes[extension.Schema.ID] = g.complex(extension.Schema.Attributes)
+		}
+	}
+	return attributes
+}
+
+// canaryGenerator generates the values of the required attributes of a canary resource.
+type canaryGenerator struct {
+	suffix string
+	now    time.Time
+}
+
+func (g canaryGenerator) complex(attributes []schema.CoreAttribute) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, attribute := range attributes {
+		if !attribute.Required() || attribute.Mutability() == schema.AttributeMutabilityReadOnly() {
+			continue
+		}
+		value := g.value(attribute)
+		if attribute.MultiValued() {
+			value = []interface{}{value}
+		}
+		values[attribute.Name()] = value
+	}
+	return values
+}
+
+func (g canaryGenerator) value(attribute schema.CoreAttribute) interface{} {
+	if canonical := attribute.CanonicalValues(); len(canonical) != 0 {
+		return canonical[0]
+	}
+	switch attribute.Type().String() {
+	case "boolean":
+		return false
+	case "integer", "decimal":
+		return 0
+	case "dateTime":
+		return g.now.UTC().Format(time.RFC3339)
+	case "binary":
+		return "c2VsZi1jaGVjaw=="
+	case "reference":
+		return "https://example.com/scim-self-check/" + g.suffix
+	case "complex":
+		return g.complex(attribute.SubAttributes())
+	default:
+		return "scim-self-check-" + g.suffix
+	}
+}
@@ -0,0 +1,122 @@
+package scim
+
+import (
+	"github.com/elimity-com/scim/schema"
+)
+
+// The schemas of the discovery documents, as defined in RFC 7643 sections 5 to 7, against which the self-check
+// validates the documents that the discovery endpoints serve. They only define the attributes that clients depend on.
+
+func serviceProviderConfigSchema() schema.Schema {
+	supported := schema.SimpleBooleanParams(schema.BooleanParams{Name: "supported", Required: true})
+	feature := func(name string, limits ...string) schema.CoreAttribute {
+		subAttributes := []schema.SimpleParams{supported}
+		for _, limit := range limits {
+			subAttributes = append(subAttributes, schema.SimpleNumberParams(schema.NumberParams{
+				Name:     limit,
+				Required: true,
+				Type:     schema.AttributeTypeInteger(),
+			}))
+		}
+		return schema.ComplexCoreAttribute(schema.ComplexParams{Name: name, Required: true, SubAttributes: subAttributes})
+	}
+	return schema.Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig",
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleReferenceParams(schema.ReferenceParams{Name: "documentationUri"})),
+			feature("patch"),
+			feature("bulk", "maxOperations", "maxPayloadSize"),
+			feature("filter", "maxResults"),
+			feature("changePassword"),
+			feature("sort"),
+			feature("etag"),
+			// Service providers that do not advertise their authentication schemes serve an empty list.
+			schema.ComplexCoreAttribute(schema.ComplexParams{
+				Name:        "authenticationSchemes",
+				MultiValued: true,
+				SubAttributes: []schema.SimpleParams{
+					schema.SimpleStringParams(schema.StringParams{Name: "type", Required: true}),
+					schema.SimpleStringParams(schema.StringParams{Name: "name", Required: true}),
+					schema.SimpleStringParams(schema.StringParams{Name: "description", Required: true}),
+					schema.SimpleReferenceParams(schema.ReferenceParams{Name: "specUri"}),
+					schema.SimpleReferenceParams(schema.ReferenceParams{Name: "documentationUri"}),
+					schema.SimpleBooleanParams(schema.BooleanParams{Name: "primary"}),
+				},
+			}),
+		},
+	}
+}
+
+func resourceTypeSchema() schema.Schema {
+	return schema.Schema{
+		ID: "urn:ietf:params:scim:schemas:core:2.0:ResourceType",
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "id", CaseExact: true})),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "name", Required: true})),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "description"})),
+			schema.SimpleCoreAttribute(schema.SimpleReferenceParams(schema.ReferenceParams{Name: "endpoint", Required: true})),
+			schema.SimpleCoreAttribute(schema.SimpleReferenceParams(schema.ReferenceParams{Name: "schema", Required: true})),
+			schema.ComplexCoreAttribute(schema.ComplexParams{
+				Name:        "schemaExtensions",
+				MultiValued: true,
+				SubAttributes: []schema.SimpleParams{
+					schema.SimpleReferenceParams(schema.ReferenceParams{Name: "schema", Required: true}),
+					schema.SimpleBooleanParams(schema.BooleanParams{Name: "required", Required: true}),
+				},
+			}),
+		},
+	}
+}
+
+func schemaSchema() schema.Schema {
+	return schema.Schema{
+		ID: schemaSchemaID,
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "id", Required: true, CaseExact: true})),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "name"})),
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{Name: "description"})),
+			schema.ComplexCoreAttribute(schema.ComplexParams{
+				Name:          "attributes",
+				MultiValued:   true,
+				Required:      true,
+				SubAttributes: attributeCharacteristics(),
+			}),
+		},
+	}
+}
+
+// attributeDefinitionSchema returns the schema of the definitions of sub-attributes, which have the same
+// characteristics as the definitions of attributes, see schemaSchema. The definitions of attributes can not contain
+// the definitions of their sub-attributes, since a complex attribute can not contain complex sub-attributes.
+func attributeDefinitionSchema() schema.Schema {
+	var attributes []schema.CoreAttribute
+	for _, params := range attributeCharacteristics() {
+		attributes = append(attributes, schema.SimpleCoreAttribute(params))
+	}
+	return schema.Schema{ID: schemaSchemaID, Attributes: attributes}
+}
+
+// attributeCharacteristics returns the characteristics of an attribute definition, except for its sub-attributes.
+func attributeCharacteristics() []schema.SimpleParams {
+	canonical := func(name string, values ...string) schema.SimpleParams {
+		return schema.SimpleStringParams(schema.StringParams{
+			Name:                  name,
+			Required:              true,
+			CanonicalValues:       values,
+			StrictCanonicalValues: true,
+		})
+	}
+	return []schema.SimpleParams{
+		schema.SimpleStringParams(schema.StringParams{Name: "name", Required: true, CaseExact: true}),
+		canonical("type", "string", "boolean", "decimal", "integer", "dateTime", "reference", "complex", "binary"),
+		schema.SimpleBooleanParams(schema.BooleanParams{Name: "multiValued", Required: true}),
+		schema.SimpleStringParams(schema.StringParams{Name: "description"}),
+		schema.SimpleBooleanParams(schema.BooleanParams{Name: "required", Required: true}),
+		schema.SimpleStringParams(schema.StringParams{Name: "canonicalValues", MultiValued: true}),
+		schema.SimpleBooleanParams(schema.BooleanParams{Name: "caseExact"}),
+		canonical("mutability", "readOnly", "readWrite", "immutable", "writeOnly"),
+		canonical("returned", "always", "never", "default", "request"),
+		canonical("uniqueness", "none", "server", "global"),
+		schema.SimpleStringParams(schema.StringParams{Name: "referenceTypes", MultiValued: true}),
+	}
+}
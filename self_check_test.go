@@ -0,0 +1,144 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// unretrievableResourceHandler creates resources that can not be retrieved.
+type unretrievableResourceHandler struct {
+	testResourceHandler
+}
+
+func (h unretrievableResourceHandler) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	return Resource{}, errors.GetErrorResourceNotFound
+}
+
+func TestServerSelfCheck(t *testing.T) {
+	server := newTestServer()
+	report := server.SelfCheck(context.Background(), SelfCheckCanaries())
+	if !report.Passed() {
+		t.Fatalf("expected the self-check to pass, got %+v", report)
+	}
+	expected := []string{
+		"routes",
+		"discovery /ServiceProviderConfig",
+		"discovery /Schemas",
+		"discovery /ResourceTypes",
+		"schema urn:ietf:params:scim:schemas:core:2.0:User",
+		"schema urn:ietf:params:scim:schemas:extension:enterprise:2.0:User",
+		"canary /Users",
+		"canary /EnterpriseUser",
+	}
+	if len(report.Checks) != len(expected) {
+		t.Fatalf("expected %d checks, got %+v", len(expected), report.Checks)
+	}
+	for i, check := range report.Checks {
+		if check.Name != expected[i] || check.Status != SelfCheckPassed {
+			t.Errorf("expected check %q to pass, got %+v", expected[i], check)
+		}
+	}
+
+	// The canary resources are deleted.
+	for _, resourceType := range server.ResourceTypes {
+		if n := len(resourceType.Handler.(testResourceHandler).data); n != 20 {
+			t.Errorf("%s: expected 20 resources, got %d", resourceType.Name, n)
+		}
+	}
+}
+
+func TestServerSelfCheckFailure(t *testing.T) {
+	server := newTestServer()
+	handler := server.ResourceTypes[0].Handler.(testResourceHandler)
+	server.ResourceTypes[0].Handler = unretrievableResourceHandler{testResourceHandler: handler}
+
+	report := server.SelfCheck(context.Background(), SelfCheckCanaries())
+	if report.Passed() {
+		t.Fatal("expected the self-check to fail")
+	}
+	var failed []SelfCheckResult
+	for _, check := range report.Checks {
+		if check.Status == SelfCheckFailed {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) != 1 || failed[0].Name != "canary /Users" || failed[0].Message == "" {
+		t.Errorf("expected the canary of the users to fail, got %+v", failed)
+	}
+	if len(handler.data) != 20 {
+		t.Errorf("expected the canary to be deleted, got %d resources", len(handler.data))
+	}
+}
+
+func TestServerSelfCheckWithoutCanaries(t *testing.T) {
+	server := newTestServer()
+	handler := server.ResourceTypes[0].Handler.(testResourceHandler)
+	server.ResourceTypes[0].Handler = unretrievableResourceHandler{testResourceHandler: handler}
+
+	report := server.SelfCheck(context.Background())
+	if !report.Passed() {
+		t.Fatalf("expected the self-check to pass without canaries, got %+v", report)
+	}
+	for _, check := range report.Checks[len(report.Checks)-2:] {
+		if check.Status != SelfCheckSkipped || check.Message != canariesDisabled {
+			t.Errorf("expected the canary check to be skipped, got %+v", check)
+		}
+	}
+	if len(handler.data) != 20 {
+		t.Errorf("expected no canary to be created, got %d resources", len(handler.data))
+	}
+}
+
+func TestServerSelfCheckDiscoveryDocuments(t *testing.T) {
+	server := newTestServer()
+	server.ResourceTypes[1].SchemaExtensions[0].Schema.Attributes = nil
+
+	report := server.SelfCheck(context.Background())
+	var failed []SelfCheckResult
+	for _, check := range report.Checks {
+		if check.Status == SelfCheckFailed {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) != 1 || failed[0].Name != "discovery /Schemas" || !strings.Contains(failed[0].Message, `"attributes"`) {
+		t.Errorf("expected the schema without attributes to fail, got %+v", failed)
+	}
+}
+
+func TestCheckDocument(t *testing.T) {
+	definition := func(mutability string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": "userName", "type": "string", "multiValued": false, "required": true,
+			"mutability": mutability, "returned": "default", "uniqueness": "server",
+		}
+	}
+	for _, test := range []struct {
+		name     string
+		document map[string]interface{}
+		invalid  string
+	}{
+		{"valid", map[string]interface{}{"id": "urn:example", "attributes": []interface{}{definition("readWrite")}}, ""},
+		{"type", map[string]interface{}{"id": 1, "attributes": []interface{}{definition("readWrite")}}, `"id"`},
+		{"required", map[string]interface{}{"attributes": []interface{}{definition("readWrite")}}, `"id"`},
+		{"mutability", map[string]interface{}{"id": "urn:example", "attributes": []interface{}{definition("sometimes")}}, `"attributes"`},
+	} {
+		err := checkDocument(test.document, schemaSchema())
+		switch {
+		case test.invalid == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.invalid != "" && (err == nil || !strings.Contains(err.Error(), test.invalid)):
+			t.Errorf("%s: expected attribute %s to be reported, got %v", test.name, test.invalid, err)
+		}
+	}
+
+	// The definitions of sub-attributes are checked as well.
+	attribute := definition("readWrite")
+	attribute["subAttributes"] = []interface{}{definition("sometimes")}
+	if err := checkSubAttributeDefinitions("urn:example", []interface{}{attribute}); err == nil || !strings.Contains(err.Error(), `"mutability"`) {
+		t.Errorf("expected the mutability of the sub-attribute to be reported, got %v", err)
+	}
+}
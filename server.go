@@ -1,19 +1,27 @@
 package scim
 
 import (
+	"context"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	scim "github.com/di-wu/scim-filter-parser"
 	"github.com/elimity-com/scim/schema"
 )
 
 const (
-	defaultStartIndex = 1
-	fallbackCount     = 100
+	defaultStartIndex      = 1
+	fallbackCount          = 100
+	fallbackBulkMaxOpts    = 1000
+	fallbackBulkMaxPayload = 1048576
+
+	fallbackStreamingThreshold = 1048576
+	fallbackMaxBodySize        = 1048576
 )
 
 // Server represents a SCIM server which implements the HTTP-based SCIM protocol that makes managing identities in multi-
@@ -21,6 +29,118 @@ const (
 type Server struct {
 	Config        ServiceProviderConfig
 	ResourceTypes []ResourceType
+
+	// CoercePatchValues enables the conversion of string-encoded booleans and numbers within PATCH operation values
+	// (e.g. "False" or "42") to their native types when the targeted attribute demands it, as sent by some identity
+	// providers such as Azure AD. When disabled, which is the default, these values are rejected as invalid.
+	CoercePatchValues bool
+
+	// StrictReadOnly rejects POST and PUT requests that contain values of read-only attributes with a "400 Bad Request"
+	// response. By default, these values are ignored, as described in RFC 7643 section 2.2, and never passed to the
+	// callback methods.
+	StrictReadOnly bool
+
+	// LoadShedder, if set, rejects requests with a "429 Too Many Requests" response when the service provider is
+	// overloaded.
+	LoadShedder *LoadShedder
+
+	// Auditor, if set, receives an audit event for every resource that is created, replaced, patched or deleted.
+	Auditor Auditor
+
+	// PaginationDeadlineMargin, if non-zero, shortens the deadline of the request that is passed to the "GetAll"
+	// callback method by the given margin when the request context has a deadline. Callback methods that stop listing
+	// once their context is done can return the resources they collected so far, which are then returned as a shortened
+	// page with an accurate "itemsPerPage", instead of timing out. Clients continue with the next page as usual.
+	PaginationDeadlineMargin time.Duration
+
+	// UnknownTotalResults decides how the total number of results of list responses is reported if the "GetAll"
+	// callback method returns a page with UnknownTotalResults. By default, an estimate is reported.
+	UnknownTotalResults UnknownTotalResultsPolicy
+
+	// TextValidation configures the additional validation of the text within request bodies. By default, no additional
+	// validation is done.
+	TextValidation TextValidation
+
+	// Capabilities, if set, adds a vendor extension to the service provider configuration that exposes the version of
+	// the library, the enabled compatibility profiles and the enabled features. See CapabilitiesExtensionID.
+	Capabilities *Capabilities
+
+	// GrantCatalog, if set, validates the values of the "roles" and "entitlements" attributes of the resources that are
+	// created, replaced or patched.
+	GrantCatalog GrantCatalog
+
+	// DisclosurePolicy controls whether a client that is not allowed to access an existing resource, i.e. a callback
+	// method returned a "Forbidden" error, receives a "403 Forbidden" or a "404 Not Found" response.
+	DisclosurePolicy DisclosurePolicy
+
+	// StreamingThreshold is the size in bytes above which the bodies of POST, PUT, PATCH and bulk requests are decoded
+	// while they are read, instead of being read into memory first. This reduces the peak memory of large payloads,
+	// such as bulk requests or groups with many members. Bodies of unknown length are always streamed. Zero uses a
+	// threshold of 1 MiB, a negative value disables streaming.
+	StreamingThreshold int64
+
+	// MaxResourceSize, if positive, is a soft limit in bytes on the size of a single serialized resource in a response.
+	// The largest multi-valued attributes of a resource that exceeds it, e.g. the members of a group with an enormous
+	// membership, are left out until it fits, and a "Warning" header lists the excluded attributes. By default, there
+	// is no limit.
+	MaxResourceSize int
+
+	// AccessControl, if set, restricts the clients that can reach the server by their network or client certificate.
+	AccessControl *AccessControl
+
+	// SignatureVerifier, if set, verifies the signature of every request before its body is parsed, e.g. with an
+	// HMACVerifier. Requests with an invalid signature are rejected with a "401 Unauthorized" response. Keep in mind
+	// that the body of every request is read into memory to verify it, see MaxBodySize.
+	SignatureVerifier SignatureVerifier
+
+	// MaxBodySize is the maximum size in bytes of the bodies that are read into memory before the request is handled,
+	// e.g. to verify its signature. Larger bodies are rejected with a "413 Payload Too Large" response. Zero uses a
+	// limit of 1 MiB. The maximum payload size of bulk requests applies instead if it is larger.
+	MaxBodySize int64
+
+	// Authenticator, if set, authenticates the client of every request before it is handled. Requests of clients that
+	// cannot be authenticated are rejected with a "401 Unauthorized" response.
+	Authenticator Authenticator
+
+	// BaseURL is the URL under which the endpoints of the server are reachable for clients, e.g.
+	// "https://example.com/scim/v2". It is used for the "meta.location" attribute of resources and the "Location"
+	// header. If empty, it is derived from the request, see TrustForwardedHeaders.
+	BaseURL string
+
+	// BasePath is the path under which the server serves its endpoints, e.g. "/scim" for "/scim/Users", as an
+	// alternative to http.StripPrefix. Requests outside of it are rejected with a "404 Not Found" response. It is part
+	// of the base URL that is derived from the request.
+	BasePath string
+
+	// TrustForwardedHeaders derives the base URL from the "X-Forwarded-Proto" and "X-Forwarded-Host" headers, as set by
+	// reverse proxies, instead of the address of the listener, if BaseURL is empty. Only enable this if the server is
+	// exclusively reachable through proxies that set (or strip) these headers, since clients can forge them.
+	TrustForwardedHeaders bool
+
+	// Clock, if set, replaces the system clock, e.g. to make tests deterministic. See ClockFromContext.
+	Clock Clock
+
+	// IDGenerator, if set, replaces the generator of random UUIDs that is passed to the callback methods to create the
+	// identifiers of new resources. See IDGeneratorFromContext.
+	IDGenerator IDGenerator
+
+	// Logger, if set, replaces the standard logger of the log package for the errors that cannot be reported to
+	// clients. See LoggerFromContext.
+	Logger Logger
+
+	// PatchStatistics adds a summary of the changes to the responses of PATCH requests, so that operators can confirm
+	// that large group updates landed as expected without reading the whole group. See PatchStatisticsExtensionID.
+	PatchStatistics bool
+
+	// SCIM11Compatibility enables a compatibility layer for legacy SCIM 1.1 clients, which serves the endpoints under
+	// "/v1", e.g. "/v1/Users". Their requests are translated to SCIM 2.0 before they are handled, and the responses are
+	// translated back: the SCIM 1.1 schema URIs are used, PATCH requests follow the semantics of SCIM 1.1 and errors
+	// are returned in the "Errors" array. Only the core and enterprise user schemas are translated, other schemas are
+	// left as is.
+	SCIM11Compatibility bool
+
+	// profiles are the names of the compatibility profiles that are enabled with WithCompatibilityProfile.
+	profiles []string
 }
 
 // getSchemas extracts all the schemas from the resources types defined in the server. Duplicate IDs will be ignored.
@@ -59,7 +179,74 @@ func (s Server) getSchema(id string) schema.Schema {
 
 // ServeHTTP dispatches the request to the handler whose pattern most closely matches the request URL.
 func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, ok := s.trimBasePath(withRequestURI(r))
+	if !ok {
+		w.Header().Set("Content-Type", "application/scim+json")
+		errorHandler(w, r, scimError{
+			detail: "Specified endpoint does not exist.",
+			status: http.StatusNotFound,
+		})
+		return
+	}
+	r = s.withLogger(r)
+	if s.SCIM11Compatibility && isSCIM11Request(r) {
+		// SCIM 1.1 clients are admitted like any other client. Their responses, including the errors of clients that
+		// are not admitted, are translated.
+		rw := &bulkResponseWriter{header: make(http.Header), status: http.StatusOK}
+		if admitted, r, ok := s.admit(rw, r); ok {
+			s.serveSCIM11(admitted, r)
+		}
+		writeSCIM11Response(w, r, rw.status, rw.header, rw.body.Bytes())
+		return
+	}
+	if w, r, ok := s.admit(w, r); ok {
+		s.serve(w, r)
+	}
+}
+
+// admit checks the access control, load shedding and authentication of the server for given request. If the request
+// may not be handled, an error response is written and false is returned.
+func (s Server) admit(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, bool) {
 	w.Header().Set("Content-Type", "application/scim+json")
+	r = s.withBaseURL(s.withClock(r))
+	if s.AccessControl != nil {
+		if accessErr := s.AccessControl.allow(r, s.TrustForwardedHeaders); accessErr != nil {
+			errorHandler(w, r, *accessErr)
+			return w, r, false
+		}
+	}
+	if s.LoadShedder != nil {
+		if ok, retryAfter := s.LoadShedder.allow(r); !ok {
+			errorHandler(w, r, scimErrorTooManyRequests(retryAfter))
+			return w, r, false
+		}
+		w = loadSheddingWriter{ResponseWriter: w, shedder: s.LoadShedder}
+	}
+	r, authErr := s.authenticate(r)
+	if authErr != nil {
+		errorHandler(w, r, *authErr)
+		return w, r, false
+	}
+	return w, r, true
+}
+
+// serve dispatches an admitted request to the handler of its endpoint.
+func (s Server) serve(w http.ResponseWriter, r *http.Request) {
+	s, r = s.withFeatureFlags(r)
+
+	if !acceptsJSON(r) {
+		errorHandler(w, r, scimError{
+			detail: "The service provider only supports the application/scim+json and application/json media types.",
+			status: http.StatusNotAcceptable,
+		})
+		return
+	}
+
+	if signatureErr := s.verifySignature(r); signatureErr != nil {
+		errorHandler(w, r, *signatureErr)
+		return
+	}
+
 	path := strings.TrimPrefix(r.URL.Path, "/v2")
 	switch {
 	case path == "/Schemas" && r.Method == http.MethodGet:
@@ -77,17 +264,55 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case path == "/ServiceProviderConfig":
 		s.serviceProviderConfigHandler(w, r)
 		return
+	case path == "/.search" && r.Method == http.MethodPost:
+		s.rootSearchHandler(w, r)
+		return
+	case path == "/Bulk" && r.Method == http.MethodPost && s.Config.features().Bulk.Supported:
+		s.bulkHandler(w, r)
+		return
 	}
 
+	if s.serveResource(w, r, path) {
+		return
+	}
+
+	errorHandler(w, r, scimError{
+		detail: "Specified endpoint does not exist.",
+		status: http.StatusNotFound,
+	})
+}
+
+// serveResource routes a request to the resource endpoint with given path, e.g. "/Users" or "/Users/{id}". It returns
+// false if none of the resource types handle the request.
+func (s Server) serveResource(w http.ResponseWriter, r *http.Request, path string) bool {
 	for _, resourceType := range s.ResourceTypes {
+		if operation, ok := resourceOperation(r, path, resourceType.Endpoint); ok && resourceType.ConcurrencyLimiter != nil {
+			release, ok, retryAfter := resourceType.ConcurrencyLimiter.acquire(operation)
+			if !ok {
+				errorHandler(w, r, scimErrorConcurrencyLimit(retryAfter))
+				return true
+			}
+			defer release()
+		}
+
+		if path == resourceType.Endpoint+"/.search" && r.Method == http.MethodPost {
+			s.searchHandler(w, withOperation(withSchemaSet(r, resourceType), OperationList), resourceType)
+			return true
+		}
+		if path == resourceType.Endpoint+"/.getMany" && r.Method == http.MethodPost {
+			s.getManyHandler(w, withOperation(withSchemaSet(r, resourceType), OperationList), resourceType)
+			return true
+		}
+
 		if path == resourceType.Endpoint {
+			r := withSchemaSet(r, resourceType)
 			switch r.Method {
 			case http.MethodPost:
-				s.resourcePostHandler(w, r, resourceType)
-				return
+				s.resourcePostHandler(w, withOperation(r, OperationWrite), resourceType)
+				return true
 			case http.MethodGet:
-				s.resourcesGetHandler(w, r, resourceType)
-				return
+				s.resourcesGetHandler(w, withOperation(r, OperationList), resourceType)
+				return true
 			}
 		}
 
@@ -97,27 +322,54 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 
+			r := withSchemaSet(r, resourceType)
 			switch r.Method {
 			case http.MethodGet:
-				s.resourceGetHandler(w, r, id, resourceType)
-				return
+				s.resourceGetHandler(w, withOperation(r, OperationRead), id, resourceType)
+				return true
 			case http.MethodPut:
-				s.resourcePutHandler(w, r, id, resourceType)
-				return
+				s.resourcePutHandler(w, withOperation(r, OperationWrite), id, resourceType)
+				return true
 			case http.MethodPatch:
-				s.resourcePatchHandler(w, r, id, resourceType)
-				return
+				s.resourcePatchHandler(w, withOperation(r, OperationWrite), id, resourceType)
+				return true
 			case http.MethodDelete:
-				s.resourceDeleteHandler(w, r, id, resourceType)
-				return
+				s.resourceDeleteHandler(w, withOperation(r, OperationWrite), id, resourceType)
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// acceptsJSON reports whether the client accepts a JSON response, based on the Accept header of given request. Clients
+// that do not send an Accept header accept any media type.
+func acceptsJSON(r *http.Request) bool {
+	accept := strings.Join(r.Header["Accept"], ",")
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
 
-	errorHandler(w, r, scimError{
-		detail: "Specified endpoint does not exist.",
-		status: http.StatusNotFound,
-	})
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			mediaType = strings.ToLower(strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0]))
+		}
+		if mediaType == "*" {
+			// Some clients send the invalid media range "*", which is interpreted as "*/*".
+			mediaType = "*/*"
+		}
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v <= 0 {
+				continue
+			}
+		}
+		switch mediaType {
+		case "application/scim+json", "application/json", "application/*", "*/*":
+			return true
+		}
+	}
+	return false
 }
 
 func parseIdentifier(path, endpoint string) (string, error) {
@@ -141,7 +393,7 @@ func getIntQueryParam(r *http.Request, key string, def int) (int, error) {
 func (s Server) parseRequestParams(r *http.Request) (ListRequestParams, *scimError) {
 	invalidParams := make([]string, 0)
 
-	defaultCount := s.Config.getItemsPerPage()
+	defaultCount := s.Config.features().Filter.MaxResults
 	count, countErr := getIntQueryParam(r, "count", defaultCount)
 	if countErr != nil {
 		invalidParams = append(invalidParams, "count")
@@ -165,21 +417,60 @@ func (s Server) parseRequestParams(r *http.Request) (ListRequestParams, *scimErr
 		startIndex = defaultStartIndex
 	}
 
-	filter, filterErr := getFilter(r)
+	rawFilter := strings.TrimSpace(r.URL.Query().Get("filter"))
+	if filterFeature := s.Config.features().Filter; rawFilter != "" && !filterFeature.Supported {
+		switch filterFeature.Unsupported {
+		case UnsupportedFilterIgnore:
+			rawFilter = ""
+		case UnsupportedFilterReject:
+			return ListRequestParams{}, &scimErrorFilterNotSupported
+		}
+	}
+	filter, filterErr := getFilter(rawFilter)
 	if filterErr != nil {
 		err := scimErrorBadParams([]string{"filter"})
 		return ListRequestParams{}, &err
 	}
 
+	var sortBy string
+	var sortOrder SortOrder
+	if s.Config.features().Sort.Supported {
+		sortBy = strings.TrimSpace(r.URL.Query().Get("sortBy"))
+		switch order := strings.TrimSpace(r.URL.Query().Get("sortOrder")); {
+		case sortBy == "":
+		case order == "" || strings.EqualFold(order, string(SortOrderAscending)):
+			sortOrder = SortOrderAscending
+		case strings.EqualFold(order, string(SortOrderDescending)):
+			sortOrder = SortOrderDescending
+		default:
+			err := scimErrorBadParams([]string{"sortOrder"})
+			return ListRequestParams{}, &err
+		}
+	}
+
 	return ListRequestParams{
 		Count:      count,
 		Filter:     filter,
+		RawFilter:  rawFilter,
 		StartIndex: startIndex,
+		SortBy:     sortBy,
+		SortOrder:  sortOrder,
 	}, nil
 }
 
-func getFilter(r *http.Request) (scim.Expression, error) {
-	rawFilter := strings.TrimSpace(r.URL.Query().Get("filter"))
+// withPaginationDeadline returns a shallow copy of given request of which the context deadline is shortened by the
+// pagination deadline margin. The request is returned unchanged if no margin is configured or the context of the request
+// has no deadline. The returned cancel function should be called once the request is handled.
+func (s Server) withPaginationDeadline(r *http.Request) (*http.Request, context.CancelFunc) {
+	deadline, ok := r.Context().Deadline()
+	if s.PaginationDeadlineMargin <= 0 || !ok {
+		return r, func() {}
+	}
+	ctx, cancel := context.WithDeadline(r.Context(), deadline.Add(-s.PaginationDeadlineMargin))
+	return r.WithContext(ctx), cancel
+}
+
+func getFilter(rawFilter string) (scim.Expression, error) {
 	if rawFilter != "" {
 		parser := scim.NewParser(strings.NewReader(rawFilter))
 		return parser.Parse()
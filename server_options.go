@@ -0,0 +1,128 @@
+package scim
+
+// ServerOption configures a server that is created with NewServer.
+type ServerOption func(s *Server)
+
+// NewServer returns a server that is configured with given options, which are applied in order. Options are the
+// preferred way to configure a server: unlike the fields of the Server struct, they remain stable as new settings are
+// added. The fields of the returned server can still be set directly.
+//
+// The conflicts between the routes of the resource types are logged with the logger of the server, see
+// Server.RouteConflicts.
+func NewServer(opts ...ServerOption) Server {
+	s := newServer(opts...)
+	logger := s.Logger
+	if logger == nil {
+		logger = standardLogger{}
+	}
+	for _, conflict := range s.RouteConflicts() {
+		logger.Printf("route conflict: %v", conflict)
+	}
+	return s
+}
+
+// newServer returns a server that is configured with given options, without reporting its route conflicts.
+func newServer(opts ...ServerOption) Server {
+	var s Server
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// WithServiceProviderConfig sets the service provider configuration of the server.
+func WithServiceProviderConfig(config ServiceProviderConfig) ServerOption {
+	return func(s *Server) {
+		s.Config = config
+	}
+}
+
+// WithResourceType adds given resource types to the server.
+func WithResourceType(resourceTypes ...ResourceType) ServerOption {
+	return func(s *Server) {
+		s.ResourceTypes = append(s.ResourceTypes, resourceTypes...)
+	}
+}
+
+// WithBasePath sets the path under which the server serves its endpoints, e.g. "/scim". See Server.BasePath.
+func WithBasePath(path string) ServerOption {
+	return func(s *Server) {
+		s.BasePath = path
+	}
+}
+
+// WithBaseURL sets the URL under which the endpoints of the server are reachable for clients. See Server.BaseURL.
+func WithBaseURL(url string) ServerOption {
+	return func(s *Server) {
+		s.BaseURL = url
+	}
+}
+
+// WithAuthenticator sets the authenticator of the clients of the server. See Server.Authenticator.
+func WithAuthenticator(authenticator Authenticator) ServerOption {
+	return func(s *Server) {
+		s.Authenticator = authenticator
+	}
+}
+
+// WithLogger sets the logger of the server. See Server.Logger.
+func WithLogger(logger Logger) ServerOption {
+	return func(s *Server) {
+		s.Logger = logger
+	}
+}
+
+// WithClock replaces the system clock of the server. See Server.Clock.
+func WithClock(clock Clock) ServerOption {
+	return func(s *Server) {
+		s.Clock = clock
+	}
+}
+
+// WithIDGenerator replaces the generator of the identifiers of new resources. See Server.IDGenerator.
+func WithIDGenerator(generator IDGenerator) ServerOption {
+	return func(s *Server) {
+		s.IDGenerator = generator
+	}
+}
+
+// WithCompatibilityProfile enables given compatibility profiles. Their names are reported in the capabilities
+// extension, if enabled, next to Capabilities.Profiles.
+func WithCompatibilityProfile(profiles ...CompatibilityProfile) ServerOption {
+	return func(s *Server) {
+		for _, profile := range profiles {
+			for _, opt := range profile.Options {
+				opt(s)
+			}
+			if !contains(s.profiles, profile.Name) {
+				s.profiles = append(s.profiles, profile.Name)
+			}
+		}
+	}
+}
+
+// CompatibilityProfile bundles the options that adapt a server to the SCIM client of a particular identity provider.
+type CompatibilityProfile struct {
+	// Name is the name of the profile, e.g. "azure".
+	Name string
+	// Options are the options that the profile applies.
+	Options []ServerOption
+}
+
+var (
+	// AzureADProfile accepts the string-encoded booleans and numbers that Azure Active Directory sends in PATCH
+	// requests, e.g. "False". See Server.CoercePatchValues.
+	AzureADProfile = CompatibilityProfile{
+		Name: "azure",
+		Options: []ServerOption{func(s *Server) {
+			s.CoercePatchValues = true
+		}},
+	}
+	// SCIM11Profile serves legacy SCIM 1.1 clients under "/v1". See Server.SCIM11Compatibility.
+	SCIM11Profile = CompatibilityProfile{
+		Name: "scim11",
+		Options: []ServerOption{func(s *Server) {
+			s.SCIM11Compatibility = true
+		}},
+	}
+)
@@ -0,0 +1,123 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	messages *[]string
+}
+
+func (l recordingLogger) Printf(format string, v ...interface{}) {
+	*l.messages = append(*l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestNewServer(t *testing.T) {
+	test := newTestServer()
+	var messages []string
+	server := NewServer(
+		WithServiceProviderConfig(test.Config),
+		WithResourceType(test.ResourceTypes...),
+		WithBasePath("/scim/"),
+		WithAuthenticator(AuthenticatorFunc(func(r *http.Request) (*http.Request, error) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return nil, fmt.Errorf("invalid token")
+			}
+			return r, nil
+		})),
+		WithLogger(recordingLogger{messages: &messages}),
+	)
+	if len(server.ResourceTypes) != len(test.ResourceTypes) {
+		t.Fatalf("expected %d resource types, got %d", len(test.ResourceTypes), len(server.ResourceTypes))
+	}
+
+	for _, test := range []struct {
+		target, authorization string
+		expected              int
+	}{
+		{"/scim/Users/0001", "Bearer secret", http.StatusOK},
+		{"/scim/v2/Users/0001", "Bearer secret", http.StatusOK},
+		{"/scim/Users/0001", "Bearer guess", http.StatusUnauthorized},
+		{"/Users/0001", "Bearer secret", http.StatusNotFound},
+		{"/scimUsers/0001", "Bearer secret", http.StatusNotFound},
+	} {
+		r := httptest.NewRequest(http.MethodGet, test.target, nil)
+		r.Header.Set("Authorization", test.authorization)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, r)
+		if rr.Code != test.expected {
+			t.Errorf("%s (%s): expected %d, got %d: %s", test.target, test.authorization, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/scim/Users/0001", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, r)
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if location := resource["meta"].(map[string]interface{})["location"]; location != "http://example.com/scim/Users/0001" {
+		t.Errorf("unexpected location: %v", location)
+	}
+
+	ctx := context.WithValue(r.Context(), loggerContextKey{}, server.Logger)
+	scimErrorFromError(ctx, fmt.Errorf("connection refused"))
+	if len(messages) != 1 || !strings.Contains(messages[0], "connection refused") {
+		t.Errorf("expected the failure to be logged, got %v", messages)
+	}
+}
+
+func TestNewServerRouteConflicts(t *testing.T) {
+	var messages []string
+	NewServer(
+		WithResourceType(newTestServer().ResourceTypes...),
+		WithResourceType(ResourceType{Name: "Admin", Endpoint: "/Users/Admins"}),
+		WithLogger(recordingLogger{messages: &messages}),
+	)
+	if len(messages) != 1 || !strings.Contains(messages[0], `resource type "Admin" (/Users/Admins)`) {
+		t.Errorf("expected the route conflict to be logged, got %v", messages)
+	}
+}
+
+func TestNewServerAuthenticatorError(t *testing.T) {
+	server := NewServer(WithAuthenticator(AuthenticatorFunc(func(r *http.Request) (*http.Request, error) {
+		return nil, &Error{Status: http.StatusForbidden, Detail: "The client is suspended."}
+	})))
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ServiceProviderConfig", nil))
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "suspended") {
+		t.Errorf("expected the error of the authenticator, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestNewServerCompatibilityProfile(t *testing.T) {
+	server := NewServer(
+		WithCompatibilityProfile(AzureADProfile, AzureADProfile),
+		func(s *Server) { s.Capabilities = &Capabilities{Profiles: []string{"custom"}} },
+	)
+	if !server.CoercePatchValues {
+		t.Error("expected the Azure AD profile to enable the coercion of PATCH values")
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ServiceProviderConfig", nil))
+	var config struct {
+		Capabilities struct {
+			Profiles []string `json:"profiles"`
+		} `json:"urn:elimity:params:scim:schemas:extension:capabilities:2.0"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &config); err != nil {
+		t.Fatal(err)
+	}
+	if profiles := strings.Join(config.Capabilities.Profiles, ","); profiles != "custom,azure" {
+		t.Errorf("unexpected profiles: %s", profiles)
+	}
+}
@@ -1,6 +1,9 @@
 package scim
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/elimity-com/scim/optional"
 )
 
@@ -12,12 +15,132 @@ type ServiceProviderConfig struct {
 	DocumentationURI optional.String
 	// AuthenticationSchemes is a multi-valued complex type that specifies supported authentication scheme properties.
 	AuthenticationSchemes []AuthenticationScheme
+	// Features configures the optional features of the protocol that the service provider supports. It both drives the
+	// behavior of the server and the features that are advertised in the service provider configuration.
+	Features Features
+
 	// MaxResults denotes the the integer value specifying the maximum number of resources returned in a response. It defaults to 100.
+	//
+	// Deprecated: use Features.Filter.MaxResults instead.
 	MaxResults int
 	// SupportFiltering whether you SCIM implementation will support filtering.
+	//
+	// Deprecated: use Features.Filter.Supported instead.
 	SupportFiltering bool
 	// SupportPatch whether your SCIM implementation will support patch requests.
+	//
+	// Deprecated: use Features.Patch.Supported instead.
 	SupportPatch bool
+	// SupportBulk whether your SCIM implementation will support bulk requests to the "/Bulk" endpoint.
+	//
+	// Deprecated: use Features.Bulk.Supported instead.
+	SupportBulk bool
+	// BulkMaxOpts is the maximum number of operations in a bulk request. It defaults to 1000.
+	//
+	// Deprecated: use Features.Bulk.MaxOperations instead.
+	BulkMaxOpts int
+	// BulkMaxPayload is the maximum payload size of a bulk request in bytes. It defaults to 1048576.
+	//
+	// Deprecated: use Features.Bulk.MaxPayloadSize instead.
+	BulkMaxPayload int
+	// SupportSort whether your SCIM implementation will support sorting.
+	//
+	// Deprecated: use Features.Sort.Supported instead.
+	SupportSort bool
+	// SupportETag whether your SCIM implementation will support entity tags.
+	//
+	// Deprecated: use Features.ETag.Supported instead.
+	SupportETag bool
+	// SupportChangePassword whether your SCIM implementation will support changing passwords.
+	//
+	// Deprecated: use Features.ChangePassword.Supported instead.
+	SupportChangePassword bool
+}
+
+// Features configures the optional features of the protocol that the service provider supports. The deprecated
+// Support* fields of the service provider configuration are still honored: a feature is supported if it is enabled in
+// either place, and limits that are not set here fall back to their deprecated counterparts.
+type Features struct {
+	// Patch configures the support of PATCH requests.
+	Patch PatchFeature
+	// Bulk configures the support of bulk requests to the "/Bulk" endpoint.
+	Bulk BulkFeature
+	// Filter configures the support of filtering and the size of list responses.
+	Filter FilterFeature
+	// Sort configures the support of sorting.
+	Sort SortFeature
+	// ETag configures the support of entity tags.
+	ETag ETagFeature
+	// ChangePassword configures the support of changing passwords.
+	ChangePassword ChangePasswordFeature
+}
+
+// PatchFeature configures the support of PATCH requests.
+type PatchFeature struct {
+	// Supported indicates whether PATCH requests are supported. If not, PATCH requests are answered with status code
+	// 501 (Not Implemented) instead of being forwarded to the resource handler.
+	Supported bool
+	// MaxOperations is the maximum number of operations in a PATCH request. Requests with more operations are rejected.
+	// If zero, the number of operations is not limited.
+	MaxOperations int
+}
+
+// BulkFeature configures the support of bulk requests to the "/Bulk" endpoint.
+type BulkFeature struct {
+	// Supported indicates whether bulk requests are supported.
+	Supported bool
+	// MaxOperations is the maximum number of operations in a bulk request. It defaults to 1000.
+	MaxOperations int
+	// MaxPayloadSize is the maximum payload size of a bulk request in bytes. It defaults to 1048576.
+	MaxPayloadSize int
+}
+
+// FilterFeature configures the support of filtering and the size of list responses.
+type FilterFeature struct {
+	// Supported indicates whether filtering is supported.
+	Supported bool
+	// MaxResults is the maximum number of resources returned in a list response. It defaults to 100.
+	MaxResults int
+	// Unsupported decides how a filter in a list request is handled if filtering is not supported. By default, the
+	// filter is passed to the "GetAll" callback method anyway.
+	Unsupported UnsupportedFilterPolicy
+}
+
+// UnsupportedFilterPolicy decides how the server handles a filter in a list request if the service provider does not
+// support filtering.
+type UnsupportedFilterPolicy int
+
+const (
+	// UnsupportedFilterPassThrough passes the filter to the "GetAll" callback method, as if filtering was supported.
+	UnsupportedFilterPassThrough UnsupportedFilterPolicy = iota
+	// UnsupportedFilterIgnore ignores the filter, so all resources are listed.
+	UnsupportedFilterIgnore
+	// UnsupportedFilterReject rejects the request with status code 400 (Bad Request) and the "invalidFilter" SCIM
+	// type.
+	UnsupportedFilterReject
+)
+
+// SortFeature configures the support of sorting.
+type SortFeature struct {
+	// Supported indicates whether sorting is supported. If true, the "sortBy" and "sortOrder" query parameters are
+	// passed to the "GetAll" callback method.
+	Supported bool
+}
+
+// ETagFeature configures the support of entity tags.
+type ETagFeature struct {
+	// Supported indicates whether entity tags are supported. If true, the "If-Match" and "If-None-Match" headers of
+	// PUT, PATCH and DELETE requests are evaluated against the versions of the resources, and a GET request with an
+	// "If-None-Match" header that matches the version of the resource results in a "304 Not Modified".
+	Supported bool
+}
+
+// ChangePasswordFeature configures the support of changing passwords.
+type ChangePasswordFeature struct {
+	// Supported indicates whether passwords can be changed. If not, PUT and PATCH requests that set or remove the
+	// "password" attribute are answered with status code 501 (Not Implemented) instead of being forwarded to the
+	// resource handler. Passwords can always be set when a resource is created.
+	Supported bool
 }
 
 // AuthenticationScheme specifies a supported authentication scheme property.
@@ -54,53 +177,182 @@ const (
 )
 
 func (config ServiceProviderConfig) getRaw() map[string]interface{} {
+	features := config.features()
+	patch := map[string]interface{}{
+		"supported": features.Patch.Supported,
+	}
+	if features.Patch.MaxOperations > 0 {
+		patch["maxOperations"] = features.Patch.MaxOperations
+	}
 	return map[string]interface{}{
 		"schemas":          []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
 		"documentationUri": config.DocumentationURI.Value(),
-		"patch": map[string]bool{
-			"supported": config.SupportPatch,
-		},
+		"patch":            patch,
 		"bulk": map[string]interface{}{
-			"supported":      false,
-			"maxOperations":  1000,
-			"maxPayloadSize": 1048576,
+			"supported":      features.Bulk.Supported,
+			"maxOperations":  features.Bulk.MaxOperations,
+			"maxPayloadSize": features.Bulk.MaxPayloadSize,
 		},
 		"filter": map[string]interface{}{
-			"supported":  config.SupportFiltering,
-			"maxResults": config.MaxResults,
+			"supported":  features.Filter.Supported,
+			"maxResults": features.Filter.MaxResults,
 		},
 		"changePassword": map[string]bool{
-			"supported": false,
+			"supported": features.ChangePassword.Supported,
 		},
 		"sort": map[string]bool{
-			"supported": false,
+			"supported": features.Sort.Supported,
 		},
 		"etag": map[string]bool{
-			"supported": false,
+			"supported": features.ETag.Supported,
 		},
 		"authenticationSchemes": config.getRawAuthenticationSchemes(),
 	}
 }
 
-// getItemsPerPage retrieves the configured default count. It falls back to 100 when not configured.
-func (config ServiceProviderConfig) getItemsPerPage() int {
-	if config.MaxResults < 1 {
-		return fallbackCount
+// features returns the features of the service provider, merged with the deprecated Support* fields and with the
+// defaults applied.
+func (config ServiceProviderConfig) features() Features {
+	features := config.Features
+	features.Patch.Supported = features.Patch.Supported || config.SupportPatch
+	features.Bulk.Supported = features.Bulk.Supported || config.SupportBulk
+	features.Filter.Supported = features.Filter.Supported || config.SupportFiltering
+	features.Sort.Supported = features.Sort.Supported || config.SupportSort
+	features.ETag.Supported = features.ETag.Supported || config.SupportETag
+	features.ChangePassword.Supported = features.ChangePassword.Supported || config.SupportChangePassword
+
+	features.Bulk.MaxOperations = firstPositive(features.Bulk.MaxOperations, config.BulkMaxOpts, fallbackBulkMaxOpts)
+	features.Bulk.MaxPayloadSize = firstPositive(features.Bulk.MaxPayloadSize, config.BulkMaxPayload, fallbackBulkMaxPayload)
+	features.Filter.MaxResults = firstPositive(features.Filter.MaxResults, config.MaxResults, fallbackCount)
+	return features
+}
+
+// firstPositive returns the first of given values that is positive.
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
 	}
-	return config.MaxResults
+	return 0
 }
 
 func (config ServiceProviderConfig) getRawAuthenticationSchemes() []map[string]interface{} {
 	rawAuthScheme := make([]map[string]interface{}, 0)
 	for _, auth := range config.AuthenticationSchemes {
-		rawAuthScheme = append(rawAuthScheme, map[string]interface{}{
-			"description":      auth.Description,
-			"documentationUri": auth.DocumentationURI.Value(),
-			"name":             auth.Name,
-			"primary":          auth.Primary,
-			"specUri":          auth.SpecURI.Value(),
-			"type":             auth.Type,
-		})
+		rawAuthScheme = append(rawAuthScheme, auth.getRaw())
 	}
 	return rawAuthScheme
 }
+
+func (auth AuthenticationScheme) getRaw() map[string]interface{} {
+	return map[string]interface{}{
+		"description":      auth.Description,
+		"documentationUri": auth.DocumentationURI.Value(),
+		"name":             auth.Name,
+		"primary":          auth.Primary,
+		"specUri":          auth.SpecURI.Value(),
+		"type":             auth.Type,
+	}
+}
+
+// MarshalJSON converts the service provider configuration to its JSON representation, as returned by the
+// "/ServiceProviderConfig" endpoint. The supported features and their limits are taken from both the Features and the
+// deprecated Support* fields, with the defaults applied.
+func (config ServiceProviderConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(config.getRaw())
+}
+
+// UnmarshalJSON sets the service provider configuration to the one of given JSON representation, as defined in RFC
+// 7643, section 5. The supported features and their limits are loaded into the Features of the configuration, the
+// deprecated Support* fields are left unset.
+func (config *ServiceProviderConfig) UnmarshalJSON(data []byte) error {
+	type supported struct {
+		Supported bool `json:"supported"`
+	}
+	var raw struct {
+		DocumentationURI string `json:"documentationUri"`
+		Patch            struct {
+			Supported     bool `json:"supported"`
+			MaxOperations int  `json:"maxOperations"`
+		} `json:"patch"`
+		Bulk struct {
+			Supported      bool `json:"supported"`
+			MaxOperations  int  `json:"maxOperations"`
+			MaxPayloadSize int  `json:"maxPayloadSize"`
+		} `json:"bulk"`
+		Filter struct {
+			Supported  bool `json:"supported"`
+			MaxResults int  `json:"maxResults"`
+		} `json:"filter"`
+		ChangePassword        supported              `json:"changePassword"`
+		Sort                  supported              `json:"sort"`
+		ETag                  supported              `json:"etag"`
+		AuthenticationSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*config = ServiceProviderConfig{
+		DocumentationURI:      optionalString(raw.DocumentationURI),
+		AuthenticationSchemes: raw.AuthenticationSchemes,
+		Features: Features{
+			Patch: PatchFeature{
+				Supported:     raw.Patch.Supported,
+				MaxOperations: raw.Patch.MaxOperations,
+			},
+			Bulk: BulkFeature{
+				Supported:      raw.Bulk.Supported,
+				MaxOperations:  raw.Bulk.MaxOperations,
+				MaxPayloadSize: raw.Bulk.MaxPayloadSize,
+			},
+			Filter: FilterFeature{
+				Supported:  raw.Filter.Supported,
+				MaxResults: raw.Filter.MaxResults,
+			},
+			Sort:           SortFeature{Supported: raw.Sort.Supported},
+			ETag:           ETagFeature{Supported: raw.ETag.Supported},
+			ChangePassword: ChangePasswordFeature{Supported: raw.ChangePassword.Supported},
+		},
+	}
+	return nil
+}
+
+// MarshalJSON converts the authentication scheme to its JSON representation within the service provider configuration.
+func (auth AuthenticationScheme) MarshalJSON() ([]byte, error) {
+	return json.Marshal(auth.getRaw())
+}
+
+// UnmarshalJSON sets the authentication scheme to the one of given JSON representation. The type of the scheme is
+// converted to lowercase, e.g. "OAuthBearerToken" becomes AuthenticationTypeOauthBearerToken.
+func (auth *AuthenticationScheme) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type             string `json:"type"`
+		Name             string `json:"name"`
+		Description      string `json:"description"`
+		SpecURI          string `json:"specUri"`
+		DocumentationURI string `json:"documentationUri"`
+		Primary          bool   `json:"primary"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*auth = AuthenticationScheme{
+		Type:             AuthenticationType(strings.ToLower(raw.Type)),
+		Name:             raw.Name,
+		Description:      raw.Description,
+		SpecURI:          optionalString(raw.SpecURI),
+		DocumentationURI: optionalString(raw.DocumentationURI),
+		Primary:          raw.Primary,
+	}
+	return nil
+}
+
+// optionalString returns given string as an optional string, which is absent if the string is empty.
+func optionalString(s string) optional.String {
+	if s == "" {
+		return optional.String{}
+	}
+	return optional.NewString(s)
+}
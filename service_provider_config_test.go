@@ -0,0 +1,77 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+func TestServiceProviderConfigJSON(t *testing.T) {
+	config := ServiceProviderConfig{
+		DocumentationURI: optional.NewString("https://example.com/help/scim.html"),
+		AuthenticationSchemes: []AuthenticationScheme{
+			{
+				Type:             AuthenticationTypeOauthBearerToken,
+				Name:             "OAuth Bearer Token",
+				Description:      "Authentication scheme using the OAuth Bearer Token Standard",
+				SpecURI:          optional.NewString("https://www.rfc-editor.org/info/rfc6750"),
+				DocumentationURI: optional.NewString("https://example.com/help/oauth.html"),
+				Primary:          true,
+			},
+			{
+				Type:        AuthenticationTypeHTTPBasic,
+				Name:        "HTTP Basic",
+				Description: "Authentication scheme using the HTTP Basic Standard",
+			},
+		},
+		Features: Features{
+			Patch:  PatchFeature{Supported: true, MaxOperations: 10},
+			Bulk:   BulkFeature{Supported: true, MaxOperations: 100, MaxPayloadSize: 2048},
+			Filter: FilterFeature{Supported: true, MaxResults: 200},
+			Sort:   SortFeature{Supported: true},
+			ETag:   ETagFeature{Supported: true},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual ServiceProviderConfig
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, config) {
+		t.Errorf("expected %+v, got %+v", config, actual)
+	}
+
+	// The deprecated fields are marshalled as features, with the defaults applied.
+	data, err = json.Marshal(ServiceProviderConfig{SupportFiltering: true, SupportETag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual = ServiceProviderConfig{SupportPatch: true}
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatal(err)
+	}
+	expected := Features{
+		Bulk:   BulkFeature{MaxOperations: fallbackBulkMaxOpts, MaxPayloadSize: fallbackBulkMaxPayload},
+		Filter: FilterFeature{Supported: true, MaxResults: fallbackCount},
+		ETag:   ETagFeature{Supported: true},
+	}
+	if actual.SupportPatch || !reflect.DeepEqual(actual.Features, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	var scheme AuthenticationScheme
+	if err := json.Unmarshal([]byte(`{"type": "OAuthBearerToken", "name": "OAuth", "primary": true}`), &scheme); err != nil {
+		t.Fatal(err)
+	}
+	if scheme.Type != AuthenticationTypeOauthBearerToken || scheme.Name != "OAuth" || !scheme.Primary || scheme.SpecURI.Present() {
+		t.Errorf("unexpected authentication scheme %+v", scheme)
+	}
+	if err := json.Unmarshal([]byte(`{"patch": {"supported": "yes"}}`), &actual); err == nil {
+		t.Error("expected an error for an invalid feature")
+	}
+}
@@ -0,0 +1,209 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSignatureHeader = "X-Scim-Signature"
+	defaultTimestampHeader = "X-Scim-Timestamp"
+	defaultNonceHeader     = "X-Scim-Nonce"
+	defaultMaxClockSkew    = 5 * time.Minute
+)
+
+var (
+	errSignatureMissing  = stderrors.New("the request is not signed")
+	errSignatureInvalid  = stderrors.New("the signature of the request is invalid")
+	errSignatureExpired  = stderrors.New("the timestamp of the request is missing or outside of the allowed clock skew")
+	errSignatureReplayed = stderrors.New("the nonce of the request has already been used")
+)
+
+// SignatureVerifier verifies the signature of a request, e.g. an HMAC or a JWT that an identity provider or gateway
+// adds to the requests it sends. It runs before the body of the request is parsed, so unsigned or tampered requests
+// never reach the validation or the callback methods.
+type SignatureVerifier interface {
+	// Verify returns an error if the signature of given request with given (raw) body is invalid. The request is
+	// rejected with a "401 Unauthorized" response, unless the error is (or wraps) an Error with another status code.
+	Verify(r *http.Request, body []byte) error
+}
+
+// SignatureVerifierFunc is an adapter to use an ordinary function as a signature verifier.
+type SignatureVerifierFunc func(r *http.Request, body []byte) error
+
+// Verify returns f(r, body).
+func (f SignatureVerifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// NonceStore remembers the nonces of signed requests to reject replayed requests.
+type NonceStore interface {
+	// Use records given nonce of given request until given expiry and reports whether it was not used before.
+	Use(r *http.Request, nonce string, expiry time.Time) bool
+}
+
+// HMACVerifier verifies HMAC-SHA256 request signatures. The signature is the hex encoded HMAC of the timestamp, the
+// nonce, the method, the request URI (the path and the query, e.g. "/Users?filter=userName%20eq%20%22bjensen%22") and
+// the body of the request, separated by newlines:
+//
+//	timestamp + "\n" + nonce + "\n" + method + "\n" + requestURI + "\n" + body
+//
+// The request URI is the one that the client sent, including the base path of the server, e.g.
+// "/scim/v2/Users?filter=...", see RequestURIFromContext. The query is signed as it is sent, so it can not be changed
+// to retrieve other resources with a signed request. The
+// timestamp is the number of seconds since the Unix epoch. Requests whose timestamp differs more than the allowed
+// clock skew from the clock of the server are rejected, as are requests that reuse a nonce, if a nonce store is set.
+type HMACVerifier struct {
+	// Secret is the shared secret of the HMAC.
+	Secret []byte
+	// SignatureHeader is the name of the header with the signature, optionally prefixed with "sha256=". It defaults to
+	// "X-Scim-Signature".
+	SignatureHeader string
+	// TimestampHeader is the name of the header with the timestamp. It defaults to "X-Scim-Timestamp".
+	TimestampHeader string
+	// NonceHeader is the name of the header with the nonce. It defaults to "X-Scim-Nonce".
+	NonceHeader string
+	// MaxClockSkew is the maximum difference between the timestamp of a request and the clock of the server. It
+	// defaults to five minutes.
+	MaxClockSkew time.Duration
+	// Nonces, if set, rejects requests whose nonce was already used. Nonces are remembered for twice the maximum clock
+	// skew, after which the timestamp check rejects the request anyway.
+	Nonces NonceStore
+}
+
+// Sign returns the signature of a request with given timestamp, nonce, method, request URI and body, e.g. to sign
+// requests in tests or in a client.
+func (v HMACVerifier) Sign(timestamp time.Time, nonce, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, v.Secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "\n" + nonce + "\n" + method + "\n" + requestURI + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns an error if the signature, timestamp or nonce of given request is invalid.
+func (v HMACVerifier) Verify(r *http.Request, body []byte) error {
+	signature := strings.TrimPrefix(r.Header.Get(headerOrDefault(v.SignatureHeader, defaultSignatureHeader)), "sha256=")
+	if signature == "" {
+		return errSignatureMissing
+	}
+	seconds, err := strconv.ParseInt(r.Header.Get(headerOrDefault(v.TimestampHeader, defaultTimestampHeader)), 10, 64)
+	if err != nil {
+		return errSignatureExpired
+	}
+	timestamp := time.Unix(seconds, 0)
+	nonce := r.Header.Get(headerOrDefault(v.NonceHeader, defaultNonceHeader))
+
+	expected := v.Sign(timestamp, nonce, r.Method, RequestURIFromContext(r.Context()), body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errSignatureInvalid
+	}
+
+	maxClockSkew := v.MaxClockSkew
+	if maxClockSkew <= 0 {
+		maxClockSkew = defaultMaxClockSkew
+	}
+	now := ClockFromContext(r.Context()).Now()
+	if skew := now.Sub(timestamp); skew > maxClockSkew || skew < -maxClockSkew {
+		return errSignatureExpired
+	}
+	if v.Nonces != nil && (nonce == "" || !v.Nonces.Use(r, nonce, timestamp.Add(2*maxClockSkew))) {
+		return errSignatureReplayed
+	}
+	return nil
+}
+
+func headerOrDefault(header, fallback string) string {
+	if header == "" {
+		return fallback
+	}
+	return header
+}
+
+// MemoryNonceStore is a nonce store that keeps the nonces in memory. It is safe for concurrent use, but only suits
+// servers that run as a single instance, since the nonces are not shared between instances.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+// NewMemoryNonceStore creates an empty nonce store that keeps the nonces in memory.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// Use records given nonce until given expiry and reports whether it was not used before. Expired nonces are removed,
+// according to the clock of the server.
+func (s *MemoryNonceStore) Use(r *http.Request, nonce string, expiry time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := ClockFromContext(r.Context()).Now()
+	for n, e := range s.nonces {
+		if e.Before(now) {
+			delete(s.nonces, n)
+		}
+	}
+	if _, ok := s.nonces[nonce]; ok {
+		return false
+	}
+	s.nonces[nonce] = expiry
+	return true
+}
+
+type requestURIContextKey struct{}
+
+// RequestURIFromContext returns the request URI (the path and the query) of the request as the client sent it, before
+// the server removed its base path, e.g. "/scim/v2/Users?startIndex=2". Signature verifiers use it to verify what the
+// client signed. It returns an empty string if the context does not originate from a request to the server.
+func RequestURIFromContext(ctx context.Context) string {
+	requestURI, _ := ctx.Value(requestURIContextKey{}).(string)
+	return requestURI
+}
+
+// withRequestURI returns a shallow copy of given request with its request URI added to its context, see
+// RequestURIFromContext.
+func withRequestURI(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestURIContextKey{}, r.URL.RequestURI()))
+}
+
+// verifySignature verifies the signature of given request with the signature verifier of the server, if any. The body
+// of the request is read to do so, up to the maximum body size, and replaced by a reader of the buffered body.
+func (s Server) verifySignature(r *http.Request) *scimError {
+	if s.SignatureVerifier == nil {
+		return nil
+	}
+	var body []byte
+	if r.Body != nil {
+		var readErr *scimError
+		if body, readErr = s.readBody(r); readErr != nil {
+			return readErr
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	err := s.SignatureVerifier.Verify(r, body)
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		scimErr := scimCustomError(scimErrorFromError(r.Context(), err))
+		return &scimErr
+	}
+	return &scimError{
+		detail: "The signature of the request could not be verified.",
+		status: http.StatusUnauthorized,
+	}
+}
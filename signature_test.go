@@ -0,0 +1,150 @@
+package scim
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerHMACVerifier(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	verifier := HMACVerifier{
+		Secret: []byte("secret"),
+		Nonces: NewMemoryNonceStore(),
+	}
+	server := newTestServer()
+	server.Clock = ClockFunc(func() time.Time { return now })
+	server.SignatureVerifier = verifier
+
+	body := `{"userName": "test"}`
+	signed := func(timestamp time.Time, nonce, signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(body))
+		req.Header.Set("X-Scim-Timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set("X-Scim-Nonce", nonce)
+		req.Header.Set("X-Scim-Signature", "sha256="+signature)
+		return req
+	}
+
+	for _, test := range []struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{
+		{"valid", signed(now, "1", verifier.Sign(now, "1", http.MethodPost, "/Users", []byte(body))), http.StatusCreated},
+		{"replayed", signed(now, "1", verifier.Sign(now, "1", http.MethodPost, "/Users", []byte(body))), http.StatusUnauthorized},
+		{"tampered", signed(now, "2", verifier.Sign(now, "2", http.MethodPost, "/Users", []byte(`{}`))), http.StatusUnauthorized},
+		{"expired", signed(now.Add(-time.Hour), "3", verifier.Sign(now.Add(-time.Hour), "3", http.MethodPost, "/Users", []byte(body))), http.StatusUnauthorized},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(body)), http.StatusUnauthorized},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, test.req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestServerHMACVerifierQuery(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	verifier := HMACVerifier{Secret: []byte("secret")}
+	server := newTestServer()
+	server.Clock = ClockFunc(func() time.Time { return now })
+	server.SignatureVerifier = verifier
+
+	signature := verifier.Sign(now, "1", http.MethodGet, "/Users?filter=userName%20eq%20%22test1%22", nil)
+	for target, expected := range map[string]int{
+		"/Users?filter=userName%20eq%20%22test1%22": http.StatusOK,
+		"/Users?filter=userName%20eq%20%22test2%22": http.StatusUnauthorized,
+		"/Users": http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Scim-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set("X-Scim-Nonce", "1")
+		req.Header.Set("X-Scim-Signature", signature)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != expected {
+			t.Errorf("%s: expected status %d, got %d: %s", target, expected, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestServerSignatureVerifierError(t *testing.T) {
+	server := newTestServer()
+	server.SignatureVerifier = SignatureVerifierFunc(func(r *http.Request, body []byte) error {
+		return &Error{Status: http.StatusForbidden, Detail: "Unknown signing key."}
+	})
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users/0001", nil))
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "Unknown signing key.") {
+		t.Errorf("expected the error of the verifier, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerHMACVerifierBasePath(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	verifier := HMACVerifier{Secret: []byte("secret")}
+	server := newTestServer()
+	server.BasePath = "/scim"
+	server.Clock = ClockFunc(func() time.Time { return now })
+	server.SignatureVerifier = verifier
+
+	for _, test := range []struct {
+		signed   string
+		expected int
+	}{
+		{"/scim/v2/Users?startIndex=2", http.StatusOK},
+		{"/v2/Users?startIndex=2", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users?startIndex=2", nil)
+		req.Header.Set("X-Scim-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set("X-Scim-Signature", verifier.Sign(now, "", http.MethodGet, test.signed, nil))
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test.signed, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+// failingReader fails to read.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("connection reset")
+}
+
+func TestServerSignatureVerifierBody(t *testing.T) {
+	var verified bool
+	server := newTestServer()
+	server.MaxBodySize = 16
+	server.Config.Features.Bulk.MaxPayloadSize = 8
+	server.SignatureVerifier = SignatureVerifierFunc(func(r *http.Request, body []byte) error {
+		verified = true
+		return nil
+	})
+
+	for _, test := range []struct {
+		name     string
+		body     io.Reader
+		expected int
+	}{
+		{"too large", strings.NewReader(`{"userName": "test"}`), http.StatusRequestEntityTooLarge},
+		{"unreadable", failingReader{}, http.StatusBadRequest},
+	} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", test.body))
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.expected, rr.Code, rr.Body.String())
+		}
+	}
+	if verified {
+		t.Error("expected the requests to be rejected before their signature is verified")
+	}
+}
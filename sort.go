@@ -0,0 +1,186 @@
+package scim
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+// Sorter is an optional interface that resource handlers implement to declare whether they sort the resources that
+// are returned by the "GetAll" callback method according to the "sortBy" and "sortOrder" parameters. If SupportsSort
+// returns false, the server sorts the returned page itself. Keep in mind that this only orders the resources within
+// the page, so handlers that return multiple pages should sort themselves. Handlers that do not implement this
+// interface are assumed to sort.
+type Sorter interface {
+	SupportsSort() bool
+}
+
+// PageSorter wraps a resource handler that does not support sorting. It orders the page that is returned by the
+// "GetAll" callback method of the wrapped handler according to the "sortBy" and "sortOrder" parameters, comparing the
+// values according to the type and case sensitivity of the attribute. Keep in mind that the sorting is page-local: the
+// wrapped handler still decides which resources end up on which page, so use it only for handlers that return a single
+// page or when an approximate order is acceptable.
+type PageSorter struct {
+	ResourceHandler
+}
+
+// GetAll retrieves the page of resources from the wrapped handler and sorts it.
+func (h PageSorter) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	page, err := h.ResourceHandler.GetAll(r, params)
+	if err != errors.GetErrorNil {
+		return page, err
+	}
+	if schemaSet, ok := SchemaSetFromContext(r.Context()); ok {
+		schemaSet.sortResources(page.Resources, params)
+	}
+	return page, errors.GetErrorNil
+}
+
+// SupportsSort reports that the pages are sorted, so the server does not sort them again.
+func (h PageSorter) SupportsSort() bool {
+	return true
+}
+
+// sortResources sorts given resources in place according to the sort parameters. Resources without a value for the
+// "sortBy" attribute are placed after all other resources in ascending order, and before them in descending order.
+func (s SchemaSet) sortResources(resources []Resource, params ListRequestParams) {
+	if params.SortBy == "" {
+		return
+	}
+	attribute, ok := s.Attribute(params.SortBy)
+	if !ok || attribute.Type().String() == "complex" {
+		return
+	}
+
+	type sortable struct {
+		resource Resource
+		value    interface{}
+	}
+	sorted := make([]sortable, len(resources))
+	for i, resource := range resources {
+		sorted[i] = sortable{
+			resource: resource,
+			value:    s.attributeValue(resource.Attributes, params.SortBy),
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		c := compareSortValues(&attribute, sorted[i].value, sorted[j].value)
+		if params.SortOrder == SortOrderDescending {
+			return c > 0
+		}
+		return c < 0
+	})
+	for i, s := range sorted {
+		resources[i] = s.resource
+	}
+}
+
+// attributeValue returns the value of the attribute with given path, e.g. to sort the resource. The primary (or
+// otherwise the first) value is used for multi-valued attributes.
+func (s SchemaSet) attributeValue(attributes ResourceAttributes, path string) interface{} {
+	p, err := ParseAttributePath(path)
+	if err != nil {
+		return nil
+	}
+	if strings.EqualFold(p.URI, s.schema.ID) {
+		p.URI = ""
+	}
+
+	_, value := filterMatcher{
+		attributes: s.schema.Attributes,
+		extensions: s.extensions,
+	}.lookup(p.URI, p.AttributeName, attributes)
+	value = primaryValue(value)
+	if p.SubAttribute != "" {
+		complex, _ := value.(map[string]interface{})
+		value = primaryValue(getCaseInsensitive(complex, p.SubAttribute))
+	}
+	return value
+}
+
+func primaryValue(value interface{}) interface{} {
+	values, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	for _, v := range values {
+		if complex, ok := v.(map[string]interface{}); ok && getCaseInsensitive(complex, "primary") == true {
+			return v
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// compareSortValues compares two values of given attribute. It returns a negative number if a is less than b, zero if
+// they are equal and a positive number if a is greater than b. Absent values are greater than all other values.
+func compareSortValues(attribute *schema.CoreAttribute, a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	switch attribute.Type().String() {
+	case "decimal", "integer":
+		x, _ := toFloat(a)
+		y, _ := toFloat(b)
+		return compareFloats(x, y)
+	case "boolean":
+		x, _ := a.(bool)
+		y, _ := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case y:
+			return -1
+		default:
+			return 1
+		}
+	case "dateTime":
+		x, xErr := time.Parse(time.RFC3339, toString(a))
+		y, yErr := time.Parse(time.RFC3339, toString(b))
+		if xErr == nil && yErr == nil {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	x, y := toString(a), toString(b)
+	if !attribute.CaseExact() {
+		x, y = strings.ToLower(x), strings.ToLower(y)
+	}
+	return strings.Compare(x, y)
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,110 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// unsortedResourceHandler declares that it does not sort the resources it returns.
+type unsortedResourceHandler struct {
+	testResourceHandler
+}
+
+func (h unsortedResourceHandler) SupportsSort() bool {
+	return false
+}
+
+func TestResourceTypeSortResources(t *testing.T) {
+	resourceType := newTestServer().ResourceTypes[1]
+	resourceType.Schema.Attributes = append(resourceType.Schema.Attributes,
+		schema.SimpleCoreAttribute(schema.SimpleDateTimeParams(schema.DateTimeParams{
+			Name: "lastLogin",
+		})),
+	)
+	resources := []Resource{
+		{ID: "1", Attributes: ResourceAttributes{
+			"name":      map[string]interface{}{"familyName": "jensen"},
+			"lastLogin": "2020-01-01T10:00:00+02:00",
+			"emails": []interface{}{
+				map[string]interface{}{"value": "z@example.com"},
+				map[string]interface{}{"value": "b@example.com", "primary": true},
+			},
+		}},
+		{ID: "2", Attributes: ResourceAttributes{
+			"name":      map[string]interface{}{"familyName": "Doe"},
+			"lastLogin": "2020-01-01T09:00:00Z",
+			"emails": []interface{}{
+				map[string]interface{}{"value": "a@example.com"},
+			},
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+				"employeeNumber": "2",
+			},
+		}},
+		{ID: "3", Attributes: ResourceAttributes{
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+				"employeeNumber": "1",
+			},
+		}},
+	}
+
+	for _, test := range []struct {
+		sortBy    string
+		sortOrder SortOrder
+		expected  []string
+	}{
+		{"name.familyName", SortOrderAscending, []string{"2", "1", "3"}},
+		{"name.familyName", SortOrderDescending, []string{"3", "1", "2"}},
+		{"lastLogin", SortOrderAscending, []string{"1", "2", "3"}},
+		{"emails.value", SortOrderAscending, []string{"2", "1", "3"}},
+		{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber", SortOrderAscending, []string{"3", "2", "1"}},
+	} {
+		sorted := append([]Resource(nil), resources...)
+		resourceType.SchemaSet().sortResources(sorted, ListRequestParams{SortBy: test.sortBy, SortOrder: test.sortOrder})
+
+		var ids []string
+		for _, resource := range sorted {
+			ids = append(ids, resource.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s %s: got %v want %v", test.sortBy, test.sortOrder, ids, test.expected)
+		}
+	}
+}
+
+func TestServerResourcesGetHandlerFallbackSort(t *testing.T) {
+	handler := newTestResourceHandler().(testResourceHandler)
+	for _, h := range []ResourceHandler{
+		unsortedResourceHandler{testResourceHandler: handler},
+		PageSorter{ResourceHandler: handler},
+	} {
+		server := newTestServer()
+		server.Config.SupportSort = true
+		server.ResourceTypes[0].Handler = h
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/Users?sortBy=userName&sortOrder=descending", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Resources []map[string]interface{}
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Resources) != 20 {
+			t.Fatalf("expected 20 resources, got %d", len(response.Resources))
+		}
+		for i := 1; i < len(response.Resources); i++ {
+			if response.Resources[i-1]["userName"].(string) < response.Resources[i]["userName"].(string) {
+				t.Fatalf("%T: resources are not sorted in descending order: %v", h, response.Resources)
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInvalidUTF8 is returned by a utf8Reader when the data it reads contains an invalid UTF-8 sequence.
+var errInvalidUTF8 = fmt.Errorf("invalid UTF-8 sequence")
+
+// errPayloadTooLarge is returned by a payloadReader when the data it reads exceeds its limit.
+var errPayloadTooLarge = fmt.Errorf("payload too large")
+
+// errTooManyOperations is returned when a bulk request has more operations than allowed.
+var errTooManyOperations = fmt.Errorf("too many operations")
+
+// getStreamingThreshold returns the size above which request bodies are streamed, or a negative value if streaming is
+// disabled.
+func (s Server) getStreamingThreshold() int64 {
+	if s.StreamingThreshold == 0 {
+		return fallbackStreamingThreshold
+	}
+	return s.StreamingThreshold
+}
+
+// getMaxBodySize returns the maximum size of the bodies that are read into memory before the request is handled, see
+// Server.MaxBodySize.
+func (s Server) getMaxBodySize() int64 {
+	limit := s.MaxBodySize
+	if limit <= 0 {
+		limit = fallbackMaxBodySize
+	}
+	if maxPayload := int64(s.Config.features().Bulk.MaxPayloadSize); maxPayload > limit {
+		limit = maxPayload
+	}
+	return limit
+}
+
+// readBody reads the body of given request into memory, up to the maximum body size. It returns an error if the body
+// is larger or can not be read.
+func (s Server) readBody(r *http.Request) ([]byte, *scimError) {
+	limit := s.getMaxBodySize()
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, &scimErrorInvalidSyntax
+	}
+	if int64(len(data)) > limit {
+		payloadErr := scimErrorPayloadTooLarge(fmt.Sprintf("The size of the request exceeds the maximum size (%d).", limit))
+		return nil, &payloadErr
+	}
+	return data, nil
+}
+
+// streamBody reports whether the body of given request is decoded while it is read, rather than being read into memory
+// before it is decoded. Small bodies take the latter path, which is faster.
+func (s Server) streamBody(r *http.Request) bool {
+	threshold := s.getStreamingThreshold()
+	if threshold < 0 {
+		return false
+	}
+	return r.ContentLength < 0 || r.ContentLength > threshold
+}
+
+// newBodyDecoder returns a decoder that reads the JSON body of given request. If rejectInvalidUTF8 is true, the
+// decoder fails on the first invalid UTF-8 sequence in the body.
+func newBodyDecoder(body io.Reader, rejectInvalidUTF8 bool) *json.Decoder {
+	if rejectInvalidUTF8 {
+		body = &utf8Reader{r: body}
+	}
+	return json.NewDecoder(body)
+}
+
+// utf8Reader validates the UTF-8 encoding of the data that is read from the underlying reader. A multi-byte sequence
+// that is split over two reads is validated once it is complete.
+type utf8Reader struct {
+	r io.Reader
+	// pending is the incomplete sequence at the end of the previous read.
+	pending []byte
+}
+
+func (u *utf8Reader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	data := append(u.pending, p[:n]...)
+
+	end := len(data)
+	if err != io.EOF {
+		// Hold back the start of a sequence that is not complete yet.
+		for i := len(data) - 1; i >= 0 && i > len(data)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(data[i]) {
+				if !utf8.FullRune(data[i:]) {
+					end = i
+				}
+				break
+			}
+		}
+	}
+	if !utf8.Valid(data[:end]) {
+		// Withhold the data, as decoders might not look at the error if the data completes their value.
+		return 0, errInvalidUTF8
+	}
+	u.pending = append([]byte(nil), data[end:]...)
+	return n, err
+}
+
+// payloadReader reads from the underlying reader until more than limit bytes are read, after which it fails with
+// errPayloadTooLarge.
+type payloadReader struct {
+	r     io.Reader
+	limit int64
+}
+
+func (l *payloadReader) Read(p []byte) (int, error) {
+	if l.limit < 0 {
+		return 0, errPayloadTooLarge
+	}
+	if int64(len(p)) > l.limit+1 {
+		p = p[:l.limit+1]
+	}
+	n, err := l.r.Read(p)
+	l.limit -= int64(n)
+	if l.limit < 0 {
+		return 0, errPayloadTooLarge
+	}
+	return n, err
+}
+
+// decodeBulkRequest decodes a bulk request token by token, so that the body is never held in memory as a whole and the
+// number of operations is checked while they are read. It returns errTooManyOperations if the request has more than
+// maxOperations operations, or the error of the decoder.
+func decodeBulkRequest(d *json.Decoder, maxOperations int) (bulkRequest, error) {
+	var req bulkRequest
+	if err := expectDelim(d, '{'); err != nil {
+		return req, err
+	}
+	for d.More() {
+		token, err := d.Token()
+		if err != nil {
+			return req, err
+		}
+		key, _ := token.(string)
+		switch strings.ToLower(key) {
+		case "schemas":
+			err = d.Decode(&req.Schemas)
+		case "failonerrors":
+			err = d.Decode(&req.FailOnErrors)
+		case "operations":
+			err = decodeBulkOperations(d, &req, maxOperations)
+		default:
+			var skip json.RawMessage
+			err = d.Decode(&skip)
+		}
+		if err != nil {
+			return req, err
+		}
+	}
+	if err := expectDelim(d, '}'); err != nil {
+		return req, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("unexpected data after the bulk request")
+		}
+		return req, err
+	}
+	return req, nil
+}
+
+// decodeBulkOperations decodes the operations of a bulk request one by one.
+func decodeBulkOperations(d *json.Decoder, req *bulkRequest, maxOperations int) error {
+	token, err := d.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("unexpected token: %v", token)
+	}
+	for d.More() {
+		if len(req.Operations) == maxOperations {
+			return errTooManyOperations
+		}
+		var op bulkOperation
+		if err := d.Decode(&op); err != nil {
+			return err
+		}
+		req.Operations = append(req.Operations, op)
+	}
+	return expectDelim(d, ']')
+}
+
+// expectDelim reads the next token of given decoder, which must be the given delimiter.
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("unexpected token: %v", token)
+	}
+	return nil
+}
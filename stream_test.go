@@ -0,0 +1,116 @@
+package scim
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestServerStreaming(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{http.MethodPost, "/Users", `{"userName": "test"}`, http.StatusCreated},
+		{http.MethodPost, "/Users", `{"userName": "test\u0007"}`, http.StatusBadRequest},
+		{http.MethodPost, "/Users", "{\"userName\": \"test\xff\"}", http.StatusBadRequest},
+		{http.MethodPost, "/Users", `{"userName": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/Users", `{"userName": "test"`, http.StatusBadRequest},
+		{http.MethodPut, "/Users/0001", `{"userName": "test"}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "userName", "value": "test"}]
+		}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "userName", "value": "test\u0000"}]
+		}`, http.StatusBadRequest},
+		{http.MethodPatch, "/Users/0001", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": []
+		}`, http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.StreamingThreshold = 1
+		server.TextValidation = TextValidation{
+			RejectInvalidUTF8:       true,
+			RejectControlCharacters: true,
+		}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s %s: handler returned wrong status code: got %v want %v", test.method, test.body, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestServerBulkHandlerStreaming(t *testing.T) {
+	for _, test := range []struct {
+		config   ServiceProviderConfig
+		body     string
+		expected int
+	}{
+		{ServiceProviderConfig{SupportBulk: true}, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"failOnErrors": 1,
+			"Operations": [
+				{"method": "DELETE", "path": "/Users/0001"},
+				{"method": "DELETE", "path": "/Users/0002"}
+			]
+		}`, http.StatusOK},
+		{ServiceProviderConfig{SupportBulk: true, BulkMaxOpts: 1}, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": [
+				{"method": "DELETE", "path": "/Users/0001"},
+				{"method": "DELETE", "path": "/Users/0002"}
+			]
+		}`, http.StatusRequestEntityTooLarge},
+		{ServiceProviderConfig{SupportBulk: true, BulkMaxPayload: 64}, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": [
+				{"method": "DELETE", "path": "/Users/0001"}
+			]
+		}`, http.StatusRequestEntityTooLarge},
+		{ServiceProviderConfig{SupportBulk: true}, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": {}
+		}`, http.StatusBadRequest},
+		{ServiceProviderConfig{SupportBulk: true}, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": []
+		} {}`, http.StatusBadRequest},
+	} {
+		server := newTestServer()
+		server.Config = test.config
+		server.StreamingThreshold = 1
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Bulk", strings.NewReader(test.body)))
+		if rr.Code != test.expected {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", test.body, rr.Code, test.expected)
+		}
+	}
+}
+
+func TestUTF8Reader(t *testing.T) {
+	for _, test := range []struct {
+		data  string
+		valid bool
+	}{
+		{"ascii", true},
+		{"héllo € \U0001F600", true},
+		{"invalid \xff", false},
+		{"truncated \xe2\x82", false},
+	} {
+		_, err := ioutil.ReadAll(&utf8Reader{r: iotest.OneByteReader(strings.NewReader(test.data))})
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%q: expected valid to be %v, got error %v", test.data, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package scim
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	optionalStringType = reflect.TypeOf(optional.String{})
+	bytesType          = reflect.TypeOf([]byte(nil))
+)
+
+// MarshalAttributes encodes given struct, or pointer to a struct, into resource attributes. The fields of the struct
+// are mapped onto attributes by their "scim" tag, e.g. `scim:"userName"`:
+//
+//	type User struct {
+//		UserName string   `scim:"userName"`
+//		Name     *Name    `scim:"name"`
+//		Emails   []Email  `scim:"emails"`
+//		Active   bool     `scim:"active,omitempty"`
+//		Groups   []string `scim:"-"`
+//	}
+//
+// Fields without a tag, or with tag "-", are ignored, except for embedded structs whose fields are mapped as if they
+// were fields of the outer struct. The attributes of a schema extension are mapped by a field of a struct type that
+// is tagged with the URI of the extension. Options other than "omitempty", e.g. the characteristics of the attribute
+// that schema.FromStruct derives a schema from, are ignored.
+//
+// Structs and maps with string keys are encoded as complex attributes, slices and arrays as multi-valued attributes,
+// time.Time as dateTime, []byte as base64-encoded binary and optional.String as a string if it is present. Nil
+// pointers, slices and maps are absent, as are structs without values and zero values of fields with the "omitempty"
+// option.
+func MarshalAttributes(v interface{}) (ResourceAttributes, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal attributes from %T: not a struct", v)
+	}
+	attributes, err := encodeStruct(rv, "")
+	if err != nil {
+		return nil, err
+	}
+	return ResourceAttributes(attributes), nil
+}
+
+// UnmarshalAttributes decodes given resource attributes into the struct to which given pointer points. It is the
+// inverse of MarshalAttributes: fields are mapped onto attributes by their "scim" tag. Attribute names are compared
+// case-insensitively and attributes without a corresponding field are ignored. Fields of absent attributes are left
+// untouched.
+//
+// Numbers may be given as json.Number, as decoded by the server, or as any Go numeric type. An error is returned if a
+// value cannot be converted to the type of its field.
+func UnmarshalAttributes(attributes ResourceAttributes, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal attributes into %T: not a pointer to a struct", v)
+	}
+	return decodeStruct(attributes, rv.Elem(), "")
+}
+
+// structField is a field of a struct that is mapped onto an attribute.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the fields of given struct type that are mapped onto attributes.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("scim")
+		if !tagged {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				for _, embedded := range structFields(f.Type) {
+					embedded.index = append([]int{i}, embedded.index...)
+					fields = append(fields, embedded)
+				}
+			}
+			continue
+		}
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		// Schema URIs contain colons, but never commas, so the options can be split off safely.
+		parts := strings.Split(tag, ",")
+		field := structField{name: parts[0], index: []int{i}}
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+		if field.name == "" {
+			field.name = f.Name
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// encodeStruct encodes the mapped fields of given struct value into a complex value. The path of the value is used
+// in errors.
+func encodeStruct(v reflect.Value, path string) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{})
+	for _, field := range structFields(v.Type()) {
+		fv := v.FieldByIndex(field.index)
+		if field.omitEmpty && isZeroValue(fv) {
+			continue
+		}
+		value, err := encodeValue(fv, joinPath(path, field.name))
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			attributes[field.name] = value
+		}
+	}
+	return attributes, nil
+}
+
+// encodeValue encodes given value into an attribute value. It returns nil if the value is absent.
+func encodeValue(v reflect.Value, path string) (interface{}, error) {
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	case optionalStringType:
+		s := v.Interface().(optional.String)
+		if !s.Present() {
+			return nil, nil
+		}
+		return s.Value(), nil
+	case bytesType:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem(), path)
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := int64(v.Uint())
+		if i < 0 {
+			return nil, fmt.Errorf("cannot marshal attribute %q: %d overflows an integer", path, v.Uint())
+		}
+		return i, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Struct:
+		complex, err := encodeStruct(v, path)
+		if err != nil || len(complex) == 0 {
+			return nil, err
+		}
+		return complex, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot marshal attribute %q: map keys must be strings", path)
+		}
+		complex := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := iter.Key().String()
+			value, err := encodeValue(iter.Value(), joinPath(path, name))
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				complex[name] = value
+			}
+		}
+		return complex, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := encodeValue(v.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("cannot marshal attribute %q: unsupported type %s", path, v.Type())
+	}
+}
+
+// decodeStruct decodes given complex value into the mapped fields of given struct value.
+func decodeStruct(attributes map[string]interface{}, v reflect.Value, path string) error {
+	for _, field := range structFields(v.Type()) {
+		value, ok := attributes[field.name]
+		if !ok {
+			value = lookupFold(attributes, field.name)
+		}
+		if value == nil {
+			continue
+		}
+		if err := decodeValue(value, v.FieldByIndex(field.index), joinPath(path, field.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes given attribute value into given settable value.
+func decodeValue(value interface{}, v reflect.Value, path string) error {
+	mismatch := func() error {
+		return fmt.Errorf("cannot unmarshal attribute %q: cannot convert %T into %s", path, value, v.Type())
+	}
+
+	switch v.Type() {
+	case timeType:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal attribute %q: %v", path, err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case optionalStringType:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(optional.NewString(s)))
+		return nil
+	case bytesType:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal attribute %q: %v", path, err)
+		}
+		v.SetBytes(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(value, elem.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := ResourceAttributes{"value": value}.GetInt("value")
+		if !ok || v.OverflowInt(int64(i)) {
+			return mismatch()
+		}
+		v.SetInt(int64(i))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := ResourceAttributes{"value": value}.GetInt("value")
+		if !ok || i < 0 || v.OverflowUint(uint64(i)) {
+			return mismatch()
+		}
+		v.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(value)
+		if !ok || v.OverflowFloat(f) {
+			return mismatch()
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Struct:
+		complex, ok := ResourceAttributes{"value": value}.GetComplex("value")
+		if !ok {
+			return mismatch()
+		}
+		return decodeStruct(complex, v, path)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot unmarshal attribute %q: map keys must be strings", path)
+		}
+		complex, ok := ResourceAttributes{"value": value}.GetComplex("value")
+		if !ok {
+			return mismatch()
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(complex))
+		for name, subValue := range complex {
+			if subValue == nil {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(subValue, elem, joinPath(path, name)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Slice:
+		values, ok := ResourceAttributes{"value": value}.GetMulti("value")
+		if !ok {
+			return mismatch()
+		}
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, e := range values {
+			if err := decodeValue(e, s.Index(i), path); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	default:
+		return fmt.Errorf("cannot unmarshal attribute %q: unsupported type %s", path, v.Type())
+	}
+}
+
+// isZeroValue reports whether given value is the zero value of its type.
+func isZeroValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// joinPath returns the path of the attribute with given name within the attribute with given path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
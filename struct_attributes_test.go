@@ -0,0 +1,162 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elimity-com/scim/optional"
+)
+
+type testStructName struct {
+	GivenName  string `scim:"givenName"`
+	FamilyName string `scim:"familyName,omitempty"`
+}
+
+type testStructEmail struct {
+	Value   string `scim:"value"`
+	Primary bool   `scim:"primary,omitempty"`
+}
+
+type testStructEnterprise struct {
+	EmployeeNumber string `scim:"employeeNumber"`
+}
+
+type testStructMeta struct {
+	Created time.Time `scim:"created"`
+}
+
+type testStructUser struct {
+	testStructMeta
+	UserName   string                `scim:"userName"`
+	NickName   optional.String       `scim:"nickName"`
+	Active     bool                  `scim:"active"`
+	Age        int                   `scim:"age,omitempty"`
+	Height     float64               `scim:"height,omitempty"`
+	Name       *testStructName       `scim:"name"`
+	Emails     []testStructEmail     `scim:"emails"`
+	Roles      []string              `scim:"roles"`
+	Enterprise *testStructEnterprise `scim:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"`
+	Password   string                `scim:"-"`
+	Internal   string
+}
+
+func TestMarshalAttributes(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	attributes, err := MarshalAttributes(&testStructUser{
+		testStructMeta: testStructMeta{Created: created},
+		UserName:       "bjensen",
+		Active:         true,
+		Name:           &testStructName{GivenName: "Barbara"},
+		Emails:         []testStructEmail{{Value: "bjensen@example.com", Primary: true}},
+		Enterprise:     &testStructEnterprise{EmployeeNumber: "701984"},
+		Password:       "secret",
+		Internal:       "internal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ResourceAttributes{
+		"created":  "2020-01-02T03:04:05Z",
+		"userName": "bjensen",
+		"active":   true,
+		"name":     map[string]interface{}{"givenName": "Barbara"},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "primary": true},
+		},
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "701984",
+		},
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("unexpected attributes: %v", attributes)
+	}
+
+	if _, err := MarshalAttributes("bjensen"); err == nil {
+		t.Error("expected an error for a value that is not a struct")
+	}
+}
+
+func TestUnmarshalAttributes(t *testing.T) {
+	var attributes ResourceAttributes
+	d := json.NewDecoder(strings.NewReader(`{
+		"created": "2020-01-02T03:04:05Z",
+		"USERNAME": "bjensen",
+		"nickName": "Babs",
+		"active": true,
+		"age": 42,
+		"height": 1.75,
+		"name": {"givenName": "Barbara", "familyName": "Jensen"},
+		"emails": [{"value": "bjensen@example.com", "primary": true}],
+		"roles": ["admin"],
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"},
+		"password": "secret",
+		"unknown": "ignored"
+	}`))
+	d.UseNumber()
+	if err := d.Decode(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	var user testStructUser
+	if err := UnmarshalAttributes(attributes, &user); err != nil {
+		t.Fatal(err)
+	}
+	expected := testStructUser{
+		testStructMeta: testStructMeta{Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		UserName:       "bjensen",
+		NickName:       optional.NewString("Babs"),
+		Active:         true,
+		Age:            42,
+		Height:         1.75,
+		Name:           &testStructName{GivenName: "Barbara", FamilyName: "Jensen"},
+		Emails:         []testStructEmail{{Value: "bjensen@example.com", Primary: true}},
+		Roles:          []string{"admin"},
+		Enterprise:     &testStructEnterprise{EmployeeNumber: "701984"},
+	}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	roundTrip, err := MarshalAttributes(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again testStructUser
+	if err := UnmarshalAttributes(roundTrip, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, expected) {
+		t.Errorf("unexpected user after a round trip: %+v", again)
+	}
+}
+
+func TestUnmarshalAttributesErrors(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		attributes ResourceAttributes
+		expected   string
+	}{
+		{"string", ResourceAttributes{"userName": 42}, `"userName"`},
+		{"fraction", ResourceAttributes{"age": json.Number("4.2")}, `"age"`},
+		{"complex", ResourceAttributes{"name": "Barbara"}, `"name"`},
+		{"sub-attribute", ResourceAttributes{"emails": []interface{}{map[string]interface{}{"value": true}}}, `"emails.value"`},
+		{"dateTime", ResourceAttributes{"created": "yesterday"}, `"created"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var user testStructUser
+			err := UnmarshalAttributes(test.attributes, &user)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error about %s, got: %v", test.expected, err)
+			}
+		})
+	}
+
+	var user testStructUser
+	if err := UnmarshalAttributes(ResourceAttributes{}, user); err == nil {
+		t.Error("expected an error for a value that is not a pointer")
+	}
+}
@@ -0,0 +1,367 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+// TenantGuard wraps the resource handler of a multi-tenant store as a defense-in-depth layer against resources that
+// leak between tenants, e.g. through a query that lacks a condition on the tenant. Every resource carries the label of
+// the tenant (or owner) it belongs to, either in Resource.Tenant or in an attribute, which the guard asserts to match
+// the tenant of the client before a resource is returned or modified:
+//
+//   - the tenant of the client is passed to the wrapped handler in the context of the request, see
+//     TenantFromContext, so that it can scope its queries, e.g. the pages of the GetAll callback method;
+//   - if the label is held by an attribute, it is set to the tenant of the client in the attributes of created and
+//     replaced resources before they reach the wrapped handler. Requests that assign a resource to another tenant,
+//     including PATCH requests that change or remove the label, are rejected as forbidden;
+//   - a resource of another tenant is reported as forbidden, which the server returns as a "403 Forbidden" or
+//     "404 Not Found" response depending on its disclosure policy. Resources are retrieved with the Get callback
+//     method before they are replaced, patched or deleted;
+//   - a page of the GetAll callback method that contains resources of other tenants, or a resource that is labeled
+//     with another tenant after it is created, replaced or patched, results in a "500 Internal Server Error" response.
+//
+// Resources without a label are treated as resources of another tenant. Every violation is logged.
+type TenantGuard struct {
+	ResourceHandler
+	// Tenant returns the tenant of the client that sent given request, e.g. based on its credentials. Requests of
+	// clients without a tenant, i.e. for which it returns an empty string, are rejected as forbidden.
+	Tenant func(r *http.Request) string
+	// Attribute is the path of the attribute that holds the tenant label of resources whose Tenant field is empty, e.g.
+	// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:organization". It is optional.
+	Attribute string
+}
+
+type tenantContextKey struct{}
+
+// TenantFromContext returns the tenant of the client that sent the request, as determined by a TenantGuard. The
+// boolean is false if the request did not pass through a tenant guard.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok
+}
+
+// withTenant returns a shallow copy of given request with given tenant added to its context.
+func withTenant(r *http.Request, tenant string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenant))
+}
+
+// Create labels the resource with the tenant of the client, creates it with the wrapped handler and asserts that it
+// belongs to the tenant of the client.
+func (g TenantGuard) Create(r *http.Request, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PostError) {
+	tenant := g.Tenant(r)
+	if tenant == "" {
+		return Resource{}, errors.PostErrorForbidden.WithDetail(unknownTenantDetail)
+	}
+	attributes, ok := g.assign(r, tenant, attributes)
+	if !ok {
+		return Resource{}, errors.PostErrorForbidden.WithDetail(otherTenantDetail)
+	}
+	r = withTenant(r, tenant)
+	resource, postErr := g.ResourceHandler.Create(r, attributes, externalID)
+	if postErr != errors.PostErrorNil {
+		return resource, postErr
+	}
+	if !g.owns(r, tenant, resource) {
+		return Resource{}, errors.PostError(tenantViolation)
+	}
+	return resource, errors.PostErrorNil
+}
+
+// Get retrieves the resource with the wrapped handler, and returns a forbidden error if it belongs to another tenant.
+func (g TenantGuard) Get(r *http.Request, id string) (Resource, errors.GetError) {
+	tenant := g.Tenant(r)
+	if tenant == "" {
+		return Resource{}, errors.GetErrorForbidden.WithDetail(unknownTenantDetail)
+	}
+	r = withTenant(r, tenant)
+	resource, getErr := g.ResourceHandler.Get(r, id)
+	if getErr != errors.GetErrorNil {
+		return resource, getErr
+	}
+	if !g.owns(r, tenant, resource) {
+		return Resource{}, errors.GetErrorForbidden
+	}
+	return resource, errors.GetErrorNil
+}
+
+// GetAll retrieves the page of resources of the tenant of the client with the wrapped handler, which is expected to
+// scope its query with TenantFromContext. Resources of other tenants can not be left out of the page without breaking
+// the pagination, so a page that contains them is rejected.
+func (g TenantGuard) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	tenant := g.Tenant(r)
+	if tenant == "" {
+		return Page{}, errors.GetErrorForbidden.WithDetail(unknownTenantDetail)
+	}
+	r = withTenant(r, tenant)
+	page, getErr := g.ResourceHandler.GetAll(r, params)
+	if getErr != errors.GetErrorNil {
+		return page, getErr
+	}
+	for _, resource := range page.Resources {
+		if !g.owns(r, tenant, resource) {
+			return Page{}, errors.GetError(tenantViolation)
+		}
+	}
+	return page, errors.GetErrorNil
+}
+
+// Replace labels the resource with the tenant of the client and replaces it with the wrapped handler if it belongs to
+// the tenant of the client.
+func (g TenantGuard) Replace(r *http.Request, id string, attributes ResourceAttributes, externalID optional.String) (Resource, errors.PutError) {
+	tenant, getErr := g.check(r, id)
+	if getErr != errors.GetErrorNil {
+		return Resource{}, errors.PutError(getErr)
+	}
+	attributes, ok := g.assign(r, tenant, attributes)
+	if !ok {
+		return Resource{}, errors.PutErrorForbidden.WithDetail(otherTenantDetail)
+	}
+	r = withTenant(r, tenant)
+	resource, putErr := g.ResourceHandler.Replace(r, id, attributes, externalID)
+	if putErr != errors.PutErrorNil {
+		return resource, putErr
+	}
+	if !g.owns(r, tenant, resource) {
+		return Resource{}, errors.PutError(tenantViolation)
+	}
+	return resource, errors.PutErrorNil
+}
+
+// Delete deletes the resource with the wrapped handler if it belongs to the tenant of the client.
+func (g TenantGuard) Delete(r *http.Request, id string) errors.DeleteError {
+	tenant, getErr := g.check(r, id)
+	if getErr != errors.GetErrorNil {
+		return errors.DeleteError(getErr)
+	}
+	return g.ResourceHandler.Delete(withTenant(r, tenant), id)
+}
+
+// Patch patches the resource with the wrapped handler if it belongs to the tenant of the client and the operations do
+// not change its tenant label.
+func (g TenantGuard) Patch(r *http.Request, id string, request PatchRequest) (Resource, errors.PatchError) {
+	tenant, getErr := g.check(r, id)
+	if getErr != errors.GetErrorNil {
+		return Resource{}, errors.PatchError(getErr)
+	}
+	if !g.keepsLabel(r, tenant, request) {
+		return Resource{}, errors.PatchErrorForbidden.WithDetail(otherTenantDetail)
+	}
+	r = withTenant(r, tenant)
+	resource, patchErr := g.ResourceHandler.Patch(r, id, request)
+	if patchErr != errors.PatchErrorNil {
+		return resource, patchErr
+	}
+	if !g.owns(r, tenant, resource) {
+		return Resource{}, errors.PatchError(tenantViolation)
+	}
+	return resource, errors.PatchErrorNil
+}
+
+// SupportsSort forwards to the wrapped handler. Handlers that do not implement Sorter are assumed to sort.
+func (g TenantGuard) SupportsSort() bool {
+	if sorter, ok := g.ResourceHandler.(Sorter); ok {
+		return sorter.SupportsSort()
+	}
+	return true
+}
+
+const (
+	unknownTenantDetail = "The tenant of the client is unknown."
+	otherTenantDetail   = "The resource can not be assigned to another tenant."
+)
+
+// tenantViolation is returned when the wrapped handler returns a resource of another tenant.
+var tenantViolation = errors.ScimError{
+	Detail: "The resource does not belong to the tenant of the client.",
+	Status: http.StatusInternalServerError,
+}
+
+// check retrieves the resource with given identifier to assert that it belongs to the tenant of the client, which it
+// returns.
+func (g TenantGuard) check(r *http.Request, id string) (string, errors.GetError) {
+	tenant := g.Tenant(r)
+	if tenant == "" {
+		return "", errors.GetErrorForbidden.WithDetail(unknownTenantDetail)
+	}
+	resource, getErr := g.ResourceHandler.Get(withTenant(r, tenant), id)
+	if getErr != errors.GetErrorNil {
+		return "", getErr
+	}
+	if !g.owns(r, tenant, resource) {
+		return "", errors.GetErrorForbidden
+	}
+	return tenant, errors.GetErrorNil
+}
+
+// owns reports whether given resource belongs to given tenant, and logs a violation if it does not.
+func (g TenantGuard) owns(r *http.Request, tenant string, resource Resource) bool {
+	label := g.label(r, resource)
+	if label == tenant {
+		return true
+	}
+	logf(r, "tenant violation: resource %q of tenant %q returned to a client of tenant %q", resource.ID, label, tenant)
+	return false
+}
+
+// label returns the tenant label of given resource.
+func (g TenantGuard) label(r *http.Request, resource Resource) string {
+	if resource.Tenant != "" || g.Attribute == "" {
+		return resource.Tenant
+	}
+	var value interface{}
+	if schemaSet, ok := SchemaSetFromContext(r.Context()); ok {
+		value = schemaSet.attributeValue(resource.Attributes, g.Attribute)
+	} else {
+		value = lookupFold(resource.Attributes, g.Attribute)
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// labelPath returns the qualified path of the attribute that holds the tenant label, see SchemaSet.qualify, and the
+// main schema of the resource type. It returns false if the guard has no (valid) attribute.
+func (g TenantGuard) labelPath(r *http.Request) (AttributePath, string, bool) {
+	if g.Attribute == "" {
+		return AttributePath{}, "", false
+	}
+	path, err := ParseAttributePath(g.Attribute)
+	if err != nil {
+		return AttributePath{}, "", false
+	}
+	schemaSet, ok := SchemaSetFromContext(r.Context())
+	if !ok {
+		return path, "", true
+	}
+	return schemaSet.qualify(path), schemaSet.Schema().ID, true
+}
+
+// assign returns a copy of given attributes whose tenant label is set to given tenant. It returns false if the
+// attributes are labeled with another tenant.
+func (g TenantGuard) assign(r *http.Request, tenant string, attributes ResourceAttributes) (ResourceAttributes, bool) {
+	path, mainSchema, ok := g.labelPath(r)
+	if !ok {
+		return attributes, true
+	}
+	if label := g.label(r, Resource{Attributes: attributes}); label != "" && label != tenant {
+		return nil, false
+	}
+
+	keys := []string{path.AttributeName}
+	// The attributes of schema extensions are nested in the value of the URI of the extension.
+	if path.URI != "" && !strings.EqualFold(path.URI, mainSchema) {
+		keys = append([]string{path.URI}, keys...)
+	}
+	if path.SubAttribute != "" {
+		keys = append(keys, path.SubAttribute)
+	}
+	return ResourceAttributes(setValue(attributes, keys, tenant)), true
+}
+
+// setValue returns a copy of given attributes with given value set at the (case-insensitive) keys. The nested maps
+// along the keys are copied as well, so given attributes are left unchanged.
+func setValue(attributes map[string]interface{}, keys []string, value interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(attributes)+1)
+	key := keys[0]
+	for k, v := range attributes {
+		if strings.EqualFold(k, key) {
+			key = k
+		}
+		result[k] = v
+	}
+	if len(keys) == 1 {
+		result[key] = value
+		return result
+	}
+	nested, _ := result[key].(map[string]interface{})
+	result[key] = setValue(nested, keys[1:], value)
+	return result
+}
+
+// keepsLabel reports whether the operations of given patch request leave the tenant label of the resource unchanged,
+// i.e. they do not remove it and only set it to given tenant.
+func (g TenantGuard) keepsLabel(r *http.Request, tenant string, request PatchRequest) bool {
+	labelPath, _, ok := g.labelPath(r)
+	if !ok {
+		return true
+	}
+	schemaSet, hasSchemaSet := SchemaSetFromContext(r.Context())
+	qualify := func(path AttributePath) AttributePath {
+		if hasSchemaSet {
+			return schemaSet.qualify(path)
+		}
+		return path
+	}
+
+	for _, op := range request.Operations {
+		type target struct {
+			path  AttributePath
+			value interface{}
+		}
+		var targets []target
+		if op.Path != "" {
+			path, err := ParseAttributePath(op.Path)
+			if err != nil {
+				continue
+			}
+			targets = append(targets, target{path, op.Value})
+		} else {
+			values, _ := op.Value.(map[string]interface{})
+			for k, v := range values {
+				if nested, ok := v.(map[string]interface{}); ok && g.isExtension(r, k) {
+					for nestedKey, nestedValue := range nested {
+						targets = append(targets, target{AttributePath{URI: k, AttributeName: nestedKey}, nestedValue})
+					}
+					continue
+				}
+				if path, err := ParseAttributePath(k); err == nil {
+					targets = append(targets, target{path, v})
+				}
+			}
+		}
+
+		for _, t := range targets {
+			path := qualify(t.path)
+			if !strings.EqualFold(path.URI, labelPath.URI) || !strings.EqualFold(path.AttributeName, labelPath.AttributeName) {
+				continue
+			}
+			if path.SubAttribute != "" && !strings.EqualFold(path.SubAttribute, labelPath.SubAttribute) {
+				continue
+			}
+			if strings.EqualFold(op.Op, PatchOperationRemove) {
+				return false
+			}
+			value := t.value
+			// The operation sets the complex attribute that holds the label in its sub-attribute.
+			if path.SubAttribute == "" && labelPath.SubAttribute != "" {
+				complex, _ := value.(map[string]interface{})
+				value = getCaseInsensitive(complex, labelPath.SubAttribute)
+			}
+			if value == nil || fmt.Sprint(value) != tenant {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isExtension reports whether given key is the URI of one of the schema extensions of the resource type.
+func (g TenantGuard) isExtension(r *http.Request, key string) bool {
+	schemaSet, ok := SchemaSetFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	for _, extension := range schemaSet.Extensions() {
+		if strings.EqualFold(extension.Schema.ID, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+func newTenantTestServer() (Server, testResourceHandler) {
+	handler := newTestResourceHandler().(testResourceHandler)
+	for id, attributes := range handler.data {
+		attributes["displayName"] = "globex"
+		if id == "0001" {
+			attributes["displayName"] = "acme"
+		}
+	}
+
+	server := newTestServer()
+	server.ResourceTypes[0].Handler = TenantGuard{
+		ResourceHandler: handler,
+		Tenant: func(r *http.Request) string {
+			return r.Header.Get("X-Tenant")
+		},
+		Attribute: "displayName",
+	}
+	return server, handler
+}
+
+func TestTenantGuard(t *testing.T) {
+	for _, test := range []struct {
+		method, target, tenant, body string
+		expectedStatus               int
+	}{
+		{http.MethodGet, "/Users/0001", "acme", "", http.StatusOK},
+		{http.MethodGet, "/Users/0002", "acme", "", http.StatusForbidden},
+		{http.MethodGet, "/Users/0001", "", "", http.StatusForbidden},
+		{http.MethodPut, "/Users/0001", "acme", `{"userName": "test1", "displayName": "acme"}`, http.StatusOK},
+		{http.MethodPut, "/Users/0002", "acme", `{"userName": "test2", "displayName": "acme"}`, http.StatusForbidden},
+		{http.MethodPut, "/Users/0001", "acme", `{"userName": "test1", "displayName": "globex"}`, http.StatusForbidden},
+		{http.MethodPut, "/Users/0001", "acme", `{"userName": "test1"}`, http.StatusOK},
+		{http.MethodPatch, "/Users/0002", "acme", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "active", "value": false}]
+		}`, http.StatusForbidden},
+		{http.MethodPatch, "/Users/0001", "acme", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "urn:ietf:params:scim:schemas:core:2.0:User:displayName", "value": "globex"}]
+		}`, http.StatusForbidden},
+		{http.MethodPatch, "/Users/0001", "acme", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "value": {"displayName": "globex"}}]
+		}`, http.StatusForbidden},
+		{http.MethodPatch, "/Users/0001", "acme", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "remove", "path": "displayName"}]
+		}`, http.StatusForbidden},
+		{http.MethodDelete, "/Users/0002", "acme", "", http.StatusForbidden},
+		{http.MethodPost, "/Users", "acme", `{"userName": "new", "displayName": "globex"}`, http.StatusForbidden},
+		{http.MethodPost, "/Users", "acme", `{"userName": "new", "displayName": "acme"}`, http.StatusCreated},
+	} {
+		server, handler := newTenantTestServer()
+		before := handler.data["0001"]["displayName"]
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		req.Header.Set("X-Tenant", test.tenant)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		if rr.Code != test.expectedStatus {
+			t.Errorf("%s %s: got status %d, want %d: %s", test.method, test.target, rr.Code, test.expectedStatus, rr.Body.String())
+		}
+		if after := handler.data["0001"]["displayName"]; after != before {
+			t.Errorf("%s %s: the tenant of the resource changed from %v to %v", test.method, test.target, before, after)
+		}
+	}
+}
+
+func TestTenantGuardAssign(t *testing.T) {
+	server, handler := newTenantTestServer()
+	req := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{"userName": "new"}`))
+	req.Header.Set("X-Tenant", "acme")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	for id, attributes := range handler.data {
+		if attributes["userName"] == "new" && attributes["displayName"] != "acme" {
+			t.Errorf("expected resource %s to be assigned to the tenant of the client, got %v", id, attributes["displayName"])
+		}
+	}
+}
+
+// tenantScopedHandler returns the resources of the tenant of the request only.
+type tenantScopedHandler struct {
+	testResourceHandler
+}
+
+func (h tenantScopedHandler) GetAll(r *http.Request, params ListRequestParams) (Page, errors.GetError) {
+	tenant, _ := TenantFromContext(r.Context())
+	var resources []Resource
+	for id, attributes := range h.data {
+		if attributes["displayName"] == tenant {
+			resources = append(resources, Resource{ID: id, Attributes: attributes})
+		}
+	}
+	start, end := params.Window(len(resources))
+	return Page{TotalResults: len(resources), Resources: resources[start:end]}, errors.GetErrorNil
+}
+
+func TestTenantGuardGetAll(t *testing.T) {
+	server, handler := newTenantTestServer()
+	guard := server.ResourceTypes[0].Handler.(TenantGuard)
+	guard.ResourceHandler = tenantScopedHandler{handler}
+	server.ResourceTypes[0].Handler = guard
+
+	req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"totalResults":1`) {
+		t.Errorf("expected only the resource of the tenant, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// A handler that does not scope its query leaks the resources of other tenants.
+	server, _ = newTenantTestServer()
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d: %s", http.StatusInternalServerError, rr.Code, rr.Body.String())
+	}
+}
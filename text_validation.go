@@ -0,0 +1,68 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// TextValidation configures the additional validation of the text within the bodies of POST, PUT and PATCH requests,
+// which prevents corrupted data from reaching the resource handlers and causing encoding failures further downstream.
+type TextValidation struct {
+	// RejectInvalidUTF8 rejects request bodies that contain invalid UTF-8 sequences. Otherwise, these sequences are
+	// silently replaced by the Unicode replacement character (U+FFFD) when decoding the body.
+	RejectInvalidUTF8 bool
+	// RejectControlCharacters rejects string values that contain control characters (U+0000-U+001F and
+	// U+007F-U+009F), except for the ones in AllowedControlCharacters.
+	RejectControlCharacters bool
+	// AllowedControlCharacters are the control characters that are allowed when rejecting control characters, e.g.
+	// "\n" to allow multi-line values such as the formatted address of a user.
+	AllowedControlCharacters string
+}
+
+// validate validates the text within given request body.
+func (v TextValidation) validate(data []byte) errors.ValidationError {
+	if v.RejectInvalidUTF8 && !utf8.Valid(data) {
+		return errors.ValidationErrorInvalidSyntax
+	}
+	if !v.RejectControlCharacters {
+		return errors.ValidationErrorNil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		// Syntax errors are reported by the validation of the body itself.
+		return errors.ValidationErrorNil
+	}
+	if v.containsControlCharacter(body) {
+		return errors.ValidationErrorInvalidValue
+	}
+	return errors.ValidationErrorNil
+}
+
+// containsControlCharacter reports whether any of the strings within given (JSON) value contains a disallowed control
+// character.
+func (v TextValidation) containsControlCharacter(value interface{}) bool {
+	switch value := value.(type) {
+	case string:
+		return strings.IndexFunc(value, func(r rune) bool {
+			return unicode.IsControl(r) && !strings.ContainsRune(v.AllowedControlCharacters, r)
+		}) >= 0
+	case map[string]interface{}:
+		for _, e := range value {
+			if v.containsControlCharacter(e) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range value {
+			if v.containsControlCharacter(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
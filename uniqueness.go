@@ -0,0 +1,69 @@
+package scim
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// UniquenessHasher transforms the value of a sensitive attribute, e.g. a national identification number, into the key
+// that is used to check its uniqueness. This way clear values never reach the uniqueness store, while duplicates are
+// still detected.
+type UniquenessHasher func(value string) string
+
+// HMACUniquenessHasher returns a uniqueness hasher that computes the (hex encoded) HMAC-SHA256 of the values with given
+// secret key. Unlike a plain hash, the values can not be recovered by hashing all possible values without the key.
+func HMACUniquenessHasher(key []byte) UniquenessHasher {
+	return func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+}
+
+// UniquenessKeys returns the keys to check the uniqueness of the values of given attributes with, by attribute path.
+// Only the (non-complex, single-valued) attributes with a "server" or "global" uniqueness are included. Values of
+// attributes that are not case exact are lowercased, values of attributes with a uniqueness hasher are hashed.
+func (s SchemaSet) UniquenessKeys(attributes ResourceAttributes) map[string]string {
+	keys := make(map[string]string)
+	s.addUniquenessKeys(keys, "", s.schema.Attributes, attributes)
+	for _, extension := range s.extensions {
+		values, _ := getCaseInsensitive(attributes, extension.Schema.ID).(map[string]interface{})
+		s.addUniquenessKeys(keys, extension.Schema.ID+":", extension.Schema.Attributes, values)
+	}
+	return keys
+}
+
+func (s SchemaSet) addUniquenessKeys(keys map[string]string, prefix string, attributes []schema.CoreAttribute, values map[string]interface{}) {
+	for _, attribute := range attributes {
+		if attribute.Uniqueness() == schema.AttributeUniquenessNone() || attribute.MultiValued() ||
+			attribute.Type().String() == "complex" {
+			continue
+		}
+
+		var value string
+		switch v := getCaseInsensitive(values, attribute.Name()).(type) {
+		case nil:
+			continue
+		case string:
+			value = v
+		default:
+			value = fmt.Sprint(v)
+		}
+		if !attribute.CaseExact() {
+			value = strings.ToLower(value)
+		}
+
+		path := prefix + attribute.Name()
+		for p, hash := range s.hashers {
+			if strings.EqualFold(p, path) || prefix == "" && strings.EqualFold(p, s.schema.ID+":"+path) {
+				value = hash(value)
+			}
+		}
+		keys[path] = value
+	}
+}
@@ -0,0 +1,44 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+func TestSchemaSetUniquenessKeys(t *testing.T) {
+	resourceType := newTestServer().ResourceTypes[1]
+	resourceType.SchemaExtensions[0].Schema.Attributes = append(resourceType.SchemaExtensions[0].Schema.Attributes,
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:       "nationalId",
+			CaseExact:  true,
+			Uniqueness: schema.AttributeUniquenessGlobal(),
+		})),
+	)
+	hasher := HMACUniquenessHasher([]byte("secret"))
+	resourceType.UniquenessHashers = map[string]UniquenessHasher{
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:nationalId": hasher,
+	}
+
+	keys := resourceType.SchemaSet().UniquenessKeys(ResourceAttributes{
+		"userName":    "BJensen",
+		"displayName": "Babs Jensen",
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "701984",
+			"nationalId":     "85.01.01-123.45",
+		},
+	})
+	if len(keys) != 2 {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if keys["userName"] != "bjensen" {
+		t.Errorf("expected the lowercase user name, got %q", keys["userName"])
+	}
+	key := keys["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:nationalId"]
+	if key != hasher("85.01.01-123.45") || key == "85.01.01-123.45" || len(key) != 64 {
+		t.Errorf("expected the hashed national identification number, got %q", key)
+	}
+	if hasher("a") == HMACUniquenessHasher([]byte("other"))("a") {
+		t.Error("expected the hash to depend on the key")
+	}
+}
@@ -0,0 +1,149 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+type skipValidationContextKey struct{}
+
+// WithoutValidation returns a copy of given context that marks the request as coming from a trusted internal caller
+// that already validated its payload upstream. The server then skips the (relatively expensive) text and schema
+// validation of the bodies of POST, PUT and PATCH requests, and passes the decoded attributes to the callback methods
+// as is. Only set this in middleware that authenticates the caller as a trusted service, never based on anything an
+// external client controls.
+func WithoutValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipValidationContextKey{}, true)
+}
+
+// validationSkipped reports whether the validation of the request body is skipped for given request.
+func validationSkipped(r *http.Request) bool {
+	skip, _ := r.Context().Value(skipValidationContextKey{}).(bool)
+	return skip
+}
+
+// validateResource validates the resource in the body of a POST or PUT request and returns its attributes and its
+// external identifier.
+func (s Server) validateResource(r *http.Request, resourceType ResourceType) (ResourceAttributes, optional.String, errors.ValidationError) {
+	if s.streamBody(r) {
+		return s.streamResource(r, resourceType)
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	if validationSkipped(r) {
+		var attributes ResourceAttributes
+		if err := json.Unmarshal(data, &attributes); err != nil || attributes == nil {
+			return ResourceAttributes{}, optional.String{}, errors.ValidationErrorInvalidSyntax
+		}
+		externalID, scimErr := popExternalID(attributes)
+		return attributes, externalID, scimErr
+	}
+
+	if scimErr := s.TextValidation.validate(data); scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), textValidationCause)
+		return ResourceAttributes{}, optional.String{}, scimErr
+	}
+	attributes, externalID, scimErr := resourceType.validate(data, s.StrictReadOnly)
+	if scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), func() string {
+			return resourceType.resourceBodyValidationCause(data, s.StrictReadOnly)
+		})
+	}
+	return attributes, externalID, scimErr
+}
+
+// streamResource is the counterpart of validateResource for large bodies, which are decoded while they are read.
+func (s Server) streamResource(r *http.Request, resourceType ResourceType) (ResourceAttributes, optional.String, errors.ValidationError) {
+	skip := validationSkipped(r)
+	d := newBodyDecoder(r.Body, !skip && s.TextValidation.RejectInvalidUTF8)
+	if !skip {
+		d.UseNumber()
+	}
+
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil || m == nil {
+		return ResourceAttributes{}, optional.String{}, errors.ValidationErrorInvalidSyntax
+	}
+	if skip {
+		externalID, scimErr := popExternalID(m)
+		return m, externalID, scimErr
+	}
+
+	if s.TextValidation.RejectControlCharacters && s.TextValidation.containsControlCharacter(m) {
+		recordErrorCause(r.Context(), textValidationCause)
+		return ResourceAttributes{}, optional.String{}, errors.ValidationErrorInvalidValue
+	}
+	attributes, externalID, scimErr := resourceType.validateAttributes(m, s.StrictReadOnly)
+	if scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), func() string {
+			return resourceType.resourceValidationCause(m, s.StrictReadOnly)
+		})
+	}
+	return attributes, externalID, scimErr
+}
+
+// validatePatchRequest validates the body of a PATCH request and returns the parsed request.
+func (s Server) validatePatchRequest(r *http.Request, resourceType ResourceType) (PatchRequest, errors.ValidationError) {
+	if s.streamBody(r) {
+		return s.streamPatchRequest(r, resourceType)
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	if validationSkipped(r) {
+		var req PatchRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, errors.ValidationErrorInvalidSyntax
+		}
+		return req, errors.ValidationErrorNil
+	}
+
+	if scimErr := s.TextValidation.validate(data); scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), textValidationCause)
+		return PatchRequest{}, scimErr
+	}
+	req, scimErr := resourceType.validatePatch(data, s.CoercePatchValues)
+	if scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), func() string {
+			return resourceType.patchValidationCause(data, s.CoercePatchValues)
+		})
+	}
+	return req, scimErr
+}
+
+// streamPatchRequest is the counterpart of validatePatchRequest for large bodies, which are decoded while they are
+// read.
+func (s Server) streamPatchRequest(r *http.Request, resourceType ResourceType) (PatchRequest, errors.ValidationError) {
+	skip := validationSkipped(r)
+	d := newBodyDecoder(r.Body, !skip && s.TextValidation.RejectInvalidUTF8)
+
+	var req PatchRequest
+	if err := d.Decode(&req); err != nil {
+		return req, errors.ValidationErrorInvalidSyntax
+	}
+	if skip {
+		return req, errors.ValidationErrorNil
+	}
+
+	if s.TextValidation.RejectControlCharacters {
+		for _, op := range req.Operations {
+			if s.TextValidation.containsControlCharacter(op.Op) ||
+				s.TextValidation.containsControlCharacter(op.Path) ||
+				s.TextValidation.containsControlCharacter(op.Value) {
+				recordErrorCause(r.Context(), textValidationCause)
+				return PatchRequest{}, errors.ValidationErrorInvalidValue
+			}
+		}
+	}
+	validated, scimErr := resourceType.validatePatchOperations(req, s.CoercePatchValues)
+	if scimErr != errors.ValidationErrorNil {
+		recordErrorCause(r.Context(), func() string {
+			return resourceType.patchOperationsValidationCause(req, s.CoercePatchValues)
+		})
+	}
+	return validated, scimErr
+}
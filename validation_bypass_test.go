@@ -0,0 +1,68 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const benchmarkUser = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"userName": "bjensen",
+	"displayName": "Babs Jensen",
+	"name": {"familyName": "Jensen", "givenName": "Barbara"},
+	"emails": [
+		{"value": "bjensen@example.com", "type": "work", "primary": true},
+		{"value": "babs@jensen.org", "type": "home"}
+	]
+}`
+
+func TestWithoutValidation(t *testing.T) {
+	for _, test := range []struct {
+		trusted  bool
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{false, http.MethodPost, "/Users", `{"userName": 1}`, http.StatusBadRequest},
+		{true, http.MethodPost, "/Users", `{"userName": 1}`, http.StatusCreated},
+		{true, http.MethodPost, "/Users", `{"userName": `, http.StatusBadRequest},
+		{false, http.MethodPut, "/Users/0001", `{"unknown": "test"}`, http.StatusBadRequest},
+		{true, http.MethodPut, "/Users/0001", `{"unknown": "test"}`, http.StatusOK},
+		{false, http.MethodPatch, "/Users/0001", `{"Operations": [{"op": "unknown"}]}`, http.StatusBadRequest},
+		{true, http.MethodPatch, "/Users/0001", `{"Operations": [{"op": "unknown"}]}`, http.StatusOK},
+	} {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		if test.trusted {
+			req = req.WithContext(WithoutValidation(req.Context()))
+		}
+
+		rr := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s %s (trusted: %t): handler returned wrong status code: got %v want %v", test.method, test.body, test.trusted, rr.Code, test.expected)
+		}
+	}
+}
+
+func BenchmarkServerResourcePostHandler(b *testing.B) {
+	server := newTestServer()
+	for _, trusted := range []bool{false, true} {
+		name := "validated"
+		if trusted {
+			name = "trusted"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(benchmarkUser))
+				if trusted {
+					req = req.WithContext(WithoutValidation(req.Context()))
+				}
+				server.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package scim
+
+import (
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// ValueTransform transforms the value of an attribute before it reaches the resource handler, e.g. to hash a password
+// so that clear values are never stored. An error rejects the request with status code 400 (Bad Request) and the
+// "invalidValue" SCIM type.
+type ValueTransform func(value interface{}) (interface{}, error)
+
+// transformAttributes applies the value transforms of the resource type to given attributes of a POST or PUT request.
+func (t ResourceType) transformAttributes(attributes ResourceAttributes) (ResourceAttributes, errors.ValidationError) {
+	if len(t.ValueTransforms) == 0 {
+		return attributes, errors.ValidationErrorNil
+	}
+	for k, v := range attributes {
+		if v == nil {
+			continue
+		}
+		extension, ok := t.extension(k)
+		if !ok {
+			value, err := t.transformValue(AttributePath{AttributeName: k}, v)
+			if err != nil {
+				return ResourceAttributes{}, errors.ValidationErrorInvalidValue
+			}
+			attributes[k] = value
+			continue
+		}
+		values, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, value := range values {
+			if value == nil {
+				continue
+			}
+			value, err := t.transformValue(AttributePath{URI: extension, AttributeName: name}, value)
+			if err != nil {
+				return ResourceAttributes{}, errors.ValidationErrorInvalidValue
+			}
+			values[name] = value
+		}
+	}
+	return attributes, errors.ValidationErrorNil
+}
+
+// transformOperations applies the value transforms of the resource type to the values of given PATCH operations.
+func (t ResourceType) transformOperations(req PatchRequest) (PatchRequest, errors.ValidationError) {
+	if len(t.ValueTransforms) == 0 {
+		return req, errors.ValidationErrorNil
+	}
+	for i, op := range req.Operations {
+		if op.Op == PatchOperationRemove || op.Value == nil {
+			continue
+		}
+
+		if op.Path != "" {
+			path, err := ParseAttributePath(op.Path)
+			if err != nil {
+				continue
+			}
+			if req.Operations[i].Value, err = t.transformValue(path, op.Value); err != nil {
+				return PatchRequest{}, errors.ValidationErrorInvalidValue
+			}
+			continue
+		}
+
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		transformed := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			transformed[k] = v
+			if extension, ok := t.extension(k); ok {
+				complex, _ := v.(map[string]interface{})
+				for name, value := range complex {
+					value, err := t.transformValue(AttributePath{URI: extension, AttributeName: name}, value)
+					if err != nil {
+						return PatchRequest{}, errors.ValidationErrorInvalidValue
+					}
+					complex[name] = value
+				}
+				continue
+			}
+			path, err := ParseAttributePath(k)
+			if err != nil {
+				continue
+			}
+			if transformed[k], err = t.transformValue(path, v); err != nil {
+				return PatchRequest{}, errors.ValidationErrorInvalidValue
+			}
+		}
+		req.Operations[i].Value = transformed
+	}
+	return req, errors.ValidationErrorNil
+}
+
+// transformValue applies the value transforms of the resource type to given value of the attribute with given path.
+// Transforms of sub-attributes are applied to the sub-attributes of complex values.
+func (t ResourceType) transformValue(path AttributePath, value interface{}) (interface{}, error) {
+	for p, transform := range t.ValueTransforms {
+		target, err := ParseAttributePath(p)
+		if err != nil || !t.samePath(target, path) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(target.SubAttribute, path.SubAttribute):
+			if value, err = applyTransform(transform, value); err != nil {
+				return nil, err
+			}
+		case path.SubAttribute == "":
+			if value, err = transformSubAttribute(transform, target.SubAttribute, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// samePath reports whether given attribute paths refer to the same attribute, ignoring their sub-attributes. Paths
+// without URI refer to the attributes of the schema of the resource type.
+func (t ResourceType) samePath(x, y AttributePath) bool {
+	uri := func(p AttributePath) string {
+		if p.URI == "" {
+			return t.Schema.ID
+		}
+		return p.URI
+	}
+	return strings.EqualFold(uri(x), uri(y)) && strings.EqualFold(x.AttributeName, y.AttributeName)
+}
+
+// extension returns the URI of the schema extension of the resource type with given (case insensitive) URI.
+func (t ResourceType) extension(uri string) (string, bool) {
+	for _, extension := range t.SchemaExtensions {
+		if strings.EqualFold(extension.Schema.ID, uri) {
+			return extension.Schema.ID, true
+		}
+	}
+	return "", false
+}
+
+// applyTransform applies given transform to given value, or to each of its values if it is multi-valued.
+func applyTransform(transform ValueTransform, value interface{}) (interface{}, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return transform(value)
+	}
+	transformed := make([]interface{}, len(values))
+	for i, v := range values {
+		var err error
+		if transformed[i], err = transform(v); err != nil {
+			return nil, err
+		}
+	}
+	return transformed, nil
+}
+
+// transformSubAttribute applies given transform to the sub-attribute with given name of given complex value, or of
+// each of its values if it is multi-valued.
+func transformSubAttribute(transform ValueTransform, name string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		key := keyFold(v, name)
+		sub, ok := v[key]
+		if !ok || sub == nil {
+			return v, nil
+		}
+		transformed := copyValue(v).(map[string]interface{})
+		var err error
+		if transformed[key], err = transform(sub); err != nil {
+			return nil, err
+		}
+		return transformed, nil
+	case []interface{}:
+		transformed := make([]interface{}, len(v))
+		for i, e := range v {
+			var err error
+			if transformed[i], err = transformSubAttribute(transform, name, e); err != nil {
+				return nil, err
+			}
+		}
+		return transformed, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,113 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// newPasswordTestServer returns a test server whose users have a write-only password that is "hashed" before it
+// reaches the handler.
+func newPasswordTestServer() Server {
+	server := newTestServer()
+	userType := &server.ResourceTypes[0]
+	userType.Schema.Attributes = append(append([]schema.CoreAttribute(nil), userType.Schema.Attributes...),
+		schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+			Name:       "password",
+			Mutability: schema.AttributeMutabilityWriteOnly(),
+		})),
+	)
+	userType.ValueTransforms = map[string]ValueTransform{
+		"password": func(value interface{}) (interface{}, error) {
+			password, ok := value.(string)
+			if !ok || len(password) < 4 {
+				return nil, fmt.Errorf("invalid password")
+			}
+			return "hashed:" + password, nil
+		},
+	}
+	return server
+}
+
+func TestServerWriteOnlyPassword(t *testing.T) {
+	server := newPasswordTestServer()
+	handler := server.ResourceTypes[0].Handler.(testResourceHandler)
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{
+		"userName": "bjensen",
+		"PASSWORD": "t1meMa$heen"
+	}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var resource map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resource); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resource["password"]; ok {
+		t.Errorf("expected the write-only password not to be returned, got %v", resource)
+	}
+	if stored := handler.data[resource["id"].(string)]["password"]; stored != "hashed:t1meMa$heen" {
+		t.Errorf("expected the handler to receive the hashed password, got %v", stored)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(`{
+		"userName": "jsmith",
+		"password": "abc"
+	}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for a rejected password, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestServerChangePassword(t *testing.T) {
+	put := `{"userName": "test", "password": "t1meMa$heen"}`
+	patch := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "Password", "value": "t1meMa$heen"}]
+	}`
+	patchWithoutPath := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "value": {"password": "t1meMa$heen"}}]
+	}`
+
+	for _, supported := range []bool{false, true} {
+		expected := http.StatusNotImplemented
+		if supported {
+			expected = http.StatusOK
+		}
+		for _, test := range []struct {
+			method, body string
+		}{
+			{http.MethodPut, put},
+			{http.MethodPatch, patch},
+			{http.MethodPatch, patchWithoutPath},
+		} {
+			server := newPasswordTestServer()
+			server.Config.Features.ChangePassword.Supported = supported
+			handler := server.ResourceTypes[0].Handler.(testResourceHandler)
+
+			rr := httptest.NewRecorder()
+			server.ServeHTTP(rr, httptest.NewRequest(test.method, "/Users/0001", strings.NewReader(test.body)))
+			if rr.Code != expected {
+				t.Fatalf("%s (supported: %t): expected %d, got %d: %s", test.method, supported, expected, rr.Code, rr.Body.String())
+			}
+			if !supported {
+				continue
+			}
+			if strings.Contains(rr.Body.String(), "password") {
+				t.Errorf("%s: expected the write-only password not to be returned, got %s", test.method, rr.Body.String())
+			}
+			if stored := handler.data["0001"]["password"]; stored != "hashed:t1meMa$heen" {
+				t.Errorf("%s: expected the handler to receive the hashed password, got %v", test.method, stored)
+			}
+		}
+	}
+}